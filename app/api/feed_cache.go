@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// FeedCache keeps generated feed documents in memory so concurrent and
+// repeated requests for the same feed share one items query and one XML
+// build. Entries are keyed by feed ID and format (cacheKey) and dropped
+// when the feed's items are written (Invalidate), when the feed row changes
+// (its updated_at no longer matches), or after the TTL, which bounds
+// staleness from writes that don't report their feed (filter, extraction
+// and media status updates).
+type FeedCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	clock    types.Clock
+	entries  map[string]*feedCacheEntry
+	inflight map[string]*feedCacheCall
+}
+
+type feedCacheEntry struct {
+	version   time.Time // feed updated_at the document was built from
+	body      string
+	meta      feedMeta
+	expiresAt time.Time
+}
+
+// feedCacheCall is a build in progress; requests arriving meanwhile wait
+// for its result instead of starting their own.
+type feedCacheCall struct {
+	done        chan struct{}
+	entry       *feedCacheEntry
+	err         error
+	invalidated bool
+}
+
+// NewFeedCache creates a feed cache. A zero TTL disables caching. The TTL
+// runs on clock, the one feed contents are decided by, so an item whose
+// delay expired shows up at most a TTL late.
+func NewFeedCache(ttl time.Duration, clock types.Clock) *FeedCache {
+	return &FeedCache{
+		ttl:      ttl,
+		clock:    clock,
+		entries:  make(map[string]*feedCacheEntry),
+		inflight: make(map[string]*feedCacheCall),
+	}
+}
+
+// Enabled reports whether documents are kept at all.
+func (c *FeedCache) Enabled() bool {
+	return c.ttl > 0
+}
+
+// get returns the cached document for feedID built from version, or runs
+// build to produce it. Only one build per feed runs at a time; errors are
+// handed to the waiting callers but not cached. A build that panics fails
+// its waiters and releases the feed before the panic continues, and waiters
+// give up when ctx ends, since the build itself doesn't follow a client.
+func (c *FeedCache) get(ctx context.Context, feedID string, version time.Time, build func() (string, feedMeta, error)) (string, feedMeta, error) {
+	if c.ttl <= 0 {
+		return build()
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[feedID]; ok && e.version.Equal(version) && c.clock.Now().Before(e.expiresAt) {
+		c.mu.Unlock()
+		return e.body, e.meta, nil
+	}
+	if call, ok := c.inflight[feedID]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return "", feedMeta{}, ctx.Err()
+		}
+		if call.err != nil {
+			return "", feedMeta{}, call.err
+		}
+		return call.entry.body, call.entry.meta, nil
+	}
+	call := &feedCacheCall{done: make(chan struct{})}
+	c.inflight[feedID] = call
+	c.mu.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			c.finish(feedID, version, call, "", feedMeta{}, fmt.Errorf("feed build panicked: %v", r))
+			panic(r)
+		}
+	}()
+	body, meta, err := build()
+	c.finish(feedID, version, call, body, meta, err)
+
+	return body, meta, err
+}
+
+// finish publishes the result of call's build to its waiters, caches it
+// unless the build failed or was invalidated, and releases the feed.
+func (c *FeedCache) finish(feedID string, version time.Time, call *feedCacheCall, body string, meta feedMeta, err error) {
+	c.mu.Lock()
+	delete(c.inflight, feedID)
+	call.err = err
+	if err == nil {
+		call.entry = &feedCacheEntry{
+			version:   version,
+			body:      body,
+			meta:      meta,
+			expiresAt: c.clock.Now().Add(c.ttl),
+		}
+		// A write during the build may not be reflected in it; serve the
+		// result to the waiting requests but don't keep it.
+		if !call.invalidated {
+			c.entries[feedID] = call.entry
+		}
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// peek returns the metadata of the cached document for feedID built from
+// version without building one, for HEAD requests.
+func (c *FeedCache) peek(feedID string, version time.Time) (feedMeta, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[feedID]; ok && e.version.Equal(version) && c.clock.Now().Before(e.expiresAt) {
+		return e.meta, true
+	}
+	return feedMeta{}, false
+}
+
+// Invalidate drops the cached documents for a feed in every format. It is
+// safe to call from any goroutine and is a no-op for feeds that aren't
+// cached.
+func (c *FeedCache) Invalidate(feedID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, format := range feed.Formats {
+		key := cacheKey(feedID, format)
+		delete(c.entries, key)
+		if call, ok := c.inflight[key]; ok {
+			call.invalidated = true
+		}
+	}
+}
+
+// cacheKey identifies a feed's document in one format; RSS documents are
+// keyed by the feed ID alone.
+func cacheKey(feedID string, format feed.Format) string {
+	if format == feed.FormatRSS {
+		return feedID
+	}
+	return feedID + "." + string(format)
+}
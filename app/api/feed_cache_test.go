@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestFeedCache_SharesConcurrentBuilds(t *testing.T) {
+	cache := NewFeedCache(time.Minute, types.SystemClock{})
+	version := time.Now()
+
+	var builds atomic.Int32
+	release := make(chan struct{})
+	build := func() (string, feedMeta, error) {
+		builds.Add(1)
+		<-release
+		return "<rss/>", feedMeta{ItemCount: 3}, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, meta, err := cache.get(context.Background(), "feed-1", version, build)
+			if err != nil || body != "<rss/>" || meta.ItemCount != 3 {
+				t.Errorf("got %q, %d, %v", body, meta.ItemCount, err)
+			}
+		}()
+	}
+
+	// Let the goroutines pile up behind the first build.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := builds.Load(); n != 1 {
+		t.Errorf("expected 1 build, got %d", n)
+	}
+
+	cache.get(context.Background(), "feed-1", version, build)
+	if n := builds.Load(); n != 1 {
+		t.Errorf("expected cached document, got %d builds", n)
+	}
+}
+
+func TestFeedCache_Invalidation(t *testing.T) {
+	cache := NewFeedCache(time.Minute, types.SystemClock{})
+	version := time.Now()
+
+	var builds int
+	build := func() (string, feedMeta, error) {
+		builds++
+		return "<rss/>", feedMeta{}, nil
+	}
+
+	cache.get(context.Background(), "feed-1", version, build)
+	cache.Invalidate("feed-1")
+	cache.get(context.Background(), "feed-1", version, build)
+	if builds != 2 {
+		t.Errorf("expected rebuild after Invalidate, got %d builds", builds)
+	}
+
+	cache.get(context.Background(), "feed-1", version.Add(time.Second), build)
+	if builds != 3 {
+		t.Errorf("expected rebuild after feed update, got %d builds", builds)
+	}
+
+	// A write during a build must not leave the stale result cached.
+	cache.Invalidate("feed-1")
+	cache.get(context.Background(), "feed-1", version, func() (string, feedMeta, error) {
+		cache.Invalidate("feed-1")
+		return build()
+	})
+	cache.get(context.Background(), "feed-1", version, build)
+	if builds != 5 {
+		t.Errorf("expected rebuild after invalidation during build, got %d builds", builds)
+	}
+
+	// Every format's document is dropped.
+	atomKey := cacheKey("feed-1", feed.FormatAtom)
+	cache.get(context.Background(), atomKey, version, build)
+	cache.Invalidate("feed-1")
+	cache.get(context.Background(), atomKey, version, build)
+	if builds != 7 {
+		t.Errorf("expected rebuild of the Atom document after Invalidate, got %d builds", builds)
+	}
+}
+
+func TestFeedCache_ErrorsNotCached(t *testing.T) {
+	cache := NewFeedCache(time.Minute, types.SystemClock{})
+	version := time.Now()
+
+	if _, _, err := cache.get(context.Background(), "feed-1", version, func() (string, feedMeta, error) {
+		return "", feedMeta{}, errors.New("db down")
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	body, _, err := cache.get(context.Background(), "feed-1", version, func() (string, feedMeta, error) {
+		return "<rss/>", feedMeta{}, nil
+	})
+	if err != nil || body != "<rss/>" {
+		t.Errorf("expected fresh build after error, got %q, %v", body, err)
+	}
+}
+
+func TestFeedCache_Peek(t *testing.T) {
+	cache := NewFeedCache(time.Minute, types.SystemClock{})
+	version := time.Now()
+
+	if _, ok := cache.peek("feed-1", version); ok {
+		t.Error("expected nothing cached yet")
+	}
+	cache.get(context.Background(), "feed-1", version, func() (string, feedMeta, error) {
+		return "<rss/>", feedMeta{ItemCount: 2, ETag: `W/"x"`}, nil
+	})
+	if meta, ok := cache.peek("feed-1", version); !ok || meta.ItemCount != 2 {
+		t.Errorf("expected the cached meta, got %+v, %v", meta, ok)
+	}
+	if _, ok := cache.peek("feed-1", version.Add(time.Second)); ok {
+		t.Error("expected no meta for a newer feed version")
+	}
+}
+
+func TestFeedCache_PanicReleasesFeed(t *testing.T) {
+	cache := NewFeedCache(time.Minute, types.SystemClock{})
+	version := time.Now()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer func() { recover() }()
+		cache.get(context.Background(), "feed-1", version, func() (string, feedMeta, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	waited := make(chan error)
+	go func() {
+		_, _, err := cache.get(context.Background(), "feed-1", version, nil)
+		waited <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	if err := <-waited; err == nil {
+		t.Error("expected the waiting request to get the build's panic as an error")
+	}
+
+	body, _, err := cache.get(context.Background(), "feed-1", version, func() (string, feedMeta, error) {
+		return "<rss/>", feedMeta{}, nil
+	})
+	if err != nil || body != "<rss/>" {
+		t.Errorf("expected a new build after the panic, got %q, %v", body, err)
+	}
+}
+
+func TestFeedCache_WaiterCancel(t *testing.T) {
+	cache := NewFeedCache(time.Minute, types.SystemClock{})
+	version := time.Now()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	go cache.get(context.Background(), "feed-1", version, func() (string, feedMeta, error) {
+		close(started)
+		<-release
+		return "<rss/>", feedMeta{}, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := cache.get(ctx, "feed-1", version, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a waiter to give up with its context, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// feedMeta describes a feed document without its body: what GET sends as
+// headers and HEAD and /feeds/:name/meta report on their own.
+type feedMeta struct {
+	ItemCount int
+	ETag      string
+}
+
+// newFeedMeta derives the validator of the document built from items. The
+// ETag is weak, as the document also carries its build time: two builds of
+// the same items are equivalent, not byte for byte identical. Formats other
+// than RSS get their own ETag, as they are different representations.
+//
+// There is no Last-Modified: a document changes when an item is released,
+// its delay expires, its extraction or media download completes or it drops
+// out after being filtered, and the rows don't record all of those. A date
+// that misses one would get readers a 304 for a changed document.
+func newFeedMeta(dbFeed *database.Feed, items []database.Item, format feed.Format) feedMeta {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s|%d|%s|%s|%s|%s|%s\n", dbFeed.ID, dbFeed.ConfigVersion,
+		dbFeed.SourceTitle, dbFeed.Link, dbFeed.Description, dbFeed.ImageURL, dbFeed.IconURL)
+	if format != feed.FormatRSS {
+		fmt.Fprintf(hash, "%s\n", format)
+	}
+
+	for _, item := range items {
+		fmt.Fprintf(hash, "%s|%s|%s|%s|%s\n", item.ID, item.ContentHash, item.Title,
+			deref(item.ContentExtractionStatus), deref(item.MediaStatus))
+	}
+
+	return feedMeta{
+		ItemCount: len(items),
+		ETag:      fmt.Sprintf(`W/"%x"`, hash.Sum(nil)[:16]),
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// currentFeedMeta returns the metadata of a feed's current document: from
+// the cache when it holds the document, else from the items query alone,
+// without rendering.
+func (h *Handler) currentFeedMeta(ctx context.Context, dbFeed *database.Feed, settings *types.Settings, format feed.Format) (feedMeta, error) {
+	if meta, ok := h.feedCache.peek(cacheKey(dbFeed.ID, format), dbFeed.UpdatedAt); ok {
+		return meta, nil
+	}
+	items, err := h.feedItems(ctx, dbFeed, settings, nil)
+	if err != nil {
+		return feedMeta{}, err
+	}
+	return newFeedMeta(dbFeed, items, format), nil
+}
+
+// headFeed answers HEAD /feeds/:name with the headers GET would send.
+func (h *Handler) headFeed(c *gin.Context, dbFeed *database.Feed, settings *types.Settings, format feed.Format) {
+	meta, err := h.currentFeedMeta(c.Request.Context(), dbFeed, settings, format)
+	if err != nil {
+		h.feedUnavailable(c, dbFeed.Name, format)
+		return
+	}
+	if h.setFeedHeaders(c, dbFeed, settings, format, meta) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// GetFeedMeta reports what a feed currently serves as JSON, for monitoring
+// probes: item count, validators and fetch status, without the document.
+func (h *Handler) GetFeedMeta(c *gin.Context) {
+	name := c.Param("name")
+
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	if dbFeed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	settings, err := dbFeed.GetSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed settings", "details": err.Error()})
+		return
+	}
+
+	status := "ok"
+	switch {
+	case dbFeed.LastSuccessAt == nil:
+		status = "pending"
+	case dbFeed.FailingSince != nil:
+		status = "failing"
+	}
+
+	meta := gin.H{
+		"name":            dbFeed.Name,
+		"title":           dbFeed.DisplayTitle(),
+		"status":          status,
+		"mirror":          settings.Mirror,
+		"items":           nil,
+		"etag":            nil,
+		"last_modified":   nil,
+		"last_fetched_at": h.formatTime(dbFeed.LastFetchedAt),
+		"last_success_at": h.formatTime(dbFeed.LastSuccessAt),
+		"next_fetch_at":   h.formatTime(dbFeed.NextFetchAt),
+		"failing_since":   h.formatTime(dbFeed.FailingSince),
+	}
+
+	switch {
+	case dbFeed.LastSuccessAt == nil:
+	case settings.Mirror:
+		mirror, err := h.feedRepo.GetMirror(c.Request.Context(), dbFeed.ID)
+		if err != nil {
+			slog.Error("Database error", "operation", "get_mirror", "feed", name, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get mirror"})
+			return
+		}
+		if mirror != nil {
+			lastModified := mirror.ChangedAt.UTC().Truncate(time.Second)
+			meta["etag"] = mirror.ETag
+			meta["last_modified"] = h.formatTime(&lastModified)
+		}
+	default:
+		fm, err := h.currentFeedMeta(c.Request.Context(), dbFeed, settings, feed.FormatRSS)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get items"})
+			return
+		}
+		meta["items"] = fm.ItemCount
+		meta["etag"] = fm.ETag
+	}
+
+	c.JSON(http.StatusOK, meta)
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestNewFeedMeta(t *testing.T) {
+	created := time.Date(2024, 7, 1, 10, 0, 0, 500, time.UTC)
+	changed := created.Add(time.Hour)
+	dbFeed := &database.Feed{ID: "feed-1", ConfigVersion: 1}
+	items := []database.Item{
+		{ID: "a", CreatedAt: created, Item: types.Item{ContentHash: "1"}},
+		{ID: "b", CreatedAt: created, ChangedAt: &changed, Item: types.Item{ContentHash: "2"}},
+	}
+
+	meta := newFeedMeta(dbFeed, items, feed.FormatRSS)
+	if meta.ItemCount != 2 {
+		t.Errorf("unexpected meta %+v", meta)
+	}
+	if again := newFeedMeta(dbFeed, items, feed.FormatRSS); again.ETag != meta.ETag {
+		t.Error("expected a stable ETag for the same items")
+	}
+	if newFeedMeta(dbFeed, items, feed.FormatAtom).ETag == meta.ETag {
+		t.Error("expected a separate ETag per format")
+	}
+
+	status := "ready"
+	items[0].ContentExtractionStatus = &status
+	if newFeedMeta(dbFeed, items, feed.FormatRSS).ETag == meta.ETag {
+		t.Error("expected a new ETag after extraction")
+	}
+	dbFeed.ConfigVersion = 2
+	if newFeedMeta(dbFeed, items[:1], feed.FormatRSS).ETag == meta.ETag {
+		t.Error("expected a new ETag after a config change")
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	lastModified := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header, value string
+		want          bool
+	}{
+		{"If-None-Match", `W/"abc"`, true},
+		{"If-None-Match", `"abc"`, true},
+		{"If-None-Match", `"other", W/"abc"`, true},
+		{"If-None-Match", `"other"`, false},
+		{"If-Modified-Since", lastModified.Format(http.TimeFormat), true},
+		{"If-Modified-Since", lastModified.Add(-time.Second).Format(http.TimeFormat), false},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest(http.MethodGet, "/feeds/test", nil)
+		r.Header.Set(tt.header, tt.value)
+		if got := notModified(r, `W/"abc"`, lastModified); got != tt.want {
+			t.Errorf("%s: %s = %v, want %v", tt.header, tt.value, got, tt.want)
+		}
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/feeds/test", nil)
+	r.Header.Set("If-Modified-Since", lastModified.Format(http.TimeFormat))
+	if notModified(r, `W/"abc"`, time.Time{}) {
+		t.Error("expected If-Modified-Since to be ignored without a Last-Modified")
+	}
+}
+
+func TestHeadFeed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dbFeed := &database.Feed{ID: "feed-1", Name: "test", UpdatedAt: time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)}
+	cache := NewFeedCache(time.Minute, types.SystemClock{})
+	h := NewHandler(&cfg.Cfg{Location: time.UTC}, nil, nil, nil, nil, nil, cache, nil, nil, nil, nil, nil, nil, nil, new(slog.LevelVar))
+
+	meta := feedMeta{ItemCount: 3, ETag: `W/"abc"`}
+	cache.get(context.Background(), cacheKey(dbFeed.ID, feed.FormatRSS), dbFeed.UpdatedAt, func() (string, feedMeta, error) {
+		return "<rss/>", meta, nil
+	})
+
+	head := func(headers ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodHead, "/feeds/test", nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			c.Request.Header.Set(headers[i], headers[i+1])
+		}
+		h.headFeed(c, dbFeed, &types.Settings{}, feed.FormatRSS)
+		c.Writer.WriteHeaderNow()
+		return w
+	}
+
+	w := head()
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Fatalf("expected an empty 200, got %d: %s", w.Code, w.Body)
+	}
+	if w.Header().Get("ETag") != meta.ETag || w.Header().Get("X-Feed-Items") != "3" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+	if w.Header().Get("Last-Modified") != "" {
+		t.Errorf("expected no Last-Modified, got %s", w.Header().Get("Last-Modified"))
+	}
+
+	if w := head("If-None-Match", meta.ETag); w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", w.Code)
+	}
+	if w := head("If-Modified-Since", time.Now().Format(http.TimeFormat)); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for If-Modified-Since alone, got %d", w.Code)
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// feedStats records how a request's feed document was produced, for the
+// X-Feed-Debug-* headers.
+type feedStats struct {
+	cache      string // hit, miss or off
+	total      time.Duration
+	dbTime     time.Duration
+	considered int
+	queried    bool // false when the document came from the cache
+}
+
+// wantsDebugHeaders reports whether a feed response gets debug headers:
+// always with FEED_DEBUG_HEADERS, else when the request asks for them.
+func (h *Handler) wantsDebugHeaders(c *gin.Context) bool {
+	return h.cfg.FeedDebugHeaders || c.Query("debug") == "1"
+}
+
+// setDebugHeaders adds the generation timing, cache outcome and item counts
+// of a feed document. Database time and considered items are only known
+// when this request ran the query.
+func setDebugHeaders(c *gin.Context, stats *feedStats) {
+	c.Header("X-Feed-Debug-Cache", stats.cache)
+	c.Header("X-Feed-Debug-Generation-Ms", formatMs(stats.total))
+	if stats.queried {
+		c.Header("X-Feed-Debug-DB-Ms", formatMs(stats.dbTime))
+		c.Header("X-Feed-Debug-Items-Considered", strconv.Itoa(stats.considered))
+	}
+}
+
+func formatMs(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', 1, 64)
+}
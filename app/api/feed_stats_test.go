@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSetDebugHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	setDebugHeaders(c, &feedStats{cache: "hit", total: 1500 * time.Microsecond})
+	if got := w.Header().Get("X-Feed-Debug-Generation-Ms"); got != "1.5" {
+		t.Errorf("generation time = %q, want 1.5", got)
+	}
+	if w.Header().Get("X-Feed-Debug-DB-Ms") != "" {
+		t.Error("expected no query headers for a cached document")
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	setDebugHeaders(c, &feedStats{cache: "miss", queried: true, dbTime: 2 * time.Millisecond, considered: 7})
+	if w.Header().Get("X-Feed-Debug-Cache") != "miss" || w.Header().Get("X-Feed-Debug-DB-Ms") != "2.0" ||
+		w.Header().Get("X-Feed-Debug-Items-Considered") != "7" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+}
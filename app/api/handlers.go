@@ -1,52 +1,186 @@
 package api
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lysyi3m/rss-comb/app/cfg"
 	"github.com/lysyi3m/rss-comb/app/database"
 	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/jobs"
+	"github.com/lysyi3m/rss-comb/app/types"
 )
 
+var itemIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
 type Handler struct {
-	cfg      *cfg.Cfg
-	feedRepo *database.FeedRepository
-	itemRepo *database.ItemRepository
-	jobRepo  *database.JobRepository
+	cfg         *cfg.Cfg
+	db          *database.DB
+	feedRepo    *database.FeedRepository
+	itemRepo    *database.ItemRepository
+	jobRepo     *database.JobRepository
+	clickRepo   *database.ClickRepository
+	feedCache   *FeedCache
+	blocklist   *feed.Blocklist
+	extractor   *jobs.Extractor
+	dryRunner   *jobs.DryRunner
+	pool        *jobs.WorkerPool
+	scheduler   *jobs.Scheduler
+	autoscaler  *jobs.Autoscaler
+	maintenance *jobs.Maintenance
+	logLevel    *slog.LevelVar
+
+	slowRequests *slowRequestLog
 }
 
 func NewHandler(
 	cfg *cfg.Cfg,
+	db *database.DB,
 	feedRepo *database.FeedRepository,
 	itemRepo *database.ItemRepository,
 	jobRepo *database.JobRepository,
+	clickRepo *database.ClickRepository,
+	feedCache *FeedCache,
+	blocklist *feed.Blocklist,
+	extractor *jobs.Extractor,
+	dryRunner *jobs.DryRunner,
+	pool *jobs.WorkerPool,
+	scheduler *jobs.Scheduler,
+	autoscaler *jobs.Autoscaler,
+	maintenance *jobs.Maintenance,
+	logLevel *slog.LevelVar,
 ) *Handler {
 	return &Handler{
-		cfg:      cfg,
-		feedRepo: feedRepo,
-		itemRepo: itemRepo,
-		jobRepo:  jobRepo,
+		cfg:         cfg,
+		db:          db,
+		feedRepo:    feedRepo,
+		itemRepo:    itemRepo,
+		jobRepo:     jobRepo,
+		clickRepo:   clickRepo,
+		feedCache:   feedCache,
+		blocklist:   blocklist,
+		extractor:   extractor,
+		dryRunner:   dryRunner,
+		pool:        pool,
+		scheduler:   scheduler,
+		autoscaler:  autoscaler,
+		maintenance: maintenance,
+		logLevel:    logLevel,
+
+		slowRequests: newSlowRequestLog(time.Duration(cfg.SlowRequestMs) * time.Millisecond),
 	}
 }
 
 func (h *Handler) GetFeed(c *gin.Context) {
-	name := c.Param("name")
+	name, format, err := feedFormat(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
 	if name == "" {
 		c.Status(http.StatusBadRequest)
 		return
 	}
 
-	dbFeed, err := h.feedRepo.GetFeed(name)
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
 	if err != nil {
 		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
-		c.Status(http.StatusInternalServerError)
+		h.feedUnavailable(c, name, format)
+		return
+	}
+
+	if dbFeed == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	if dbFeed.LastSuccessAt == nil {
+		h.servePlaceholder(c, dbFeed, format)
+		return
+	}
+
+	settings, err := dbFeed.GetSettings()
+	if err != nil {
+		slog.Error("Failed to get feed settings", "feed", name, "error", err)
+		h.feedUnavailable(c, name, format)
+		return
+	}
+
+	// The upstream document is served as is, whatever format was asked for.
+	if settings.Mirror {
+		h.serveMirror(c, dbFeed, settings)
+		return
+	}
+
+	if c.Request.Method == http.MethodHead {
+		h.headFeed(c, dbFeed, settings, format)
+		return
+	}
+
+	// Without the cache there is nothing to keep the document for, so it is
+	// streamed to the client instead of being built in memory first, unless
+	// documents are validated or timed, which needs the whole document.
+	debug := h.wantsDebugHeaders(c)
+	stats := &feedStats{cache: "off"}
+	start := time.Now()
+	var rss string
+	var meta feedMeta
+	switch {
+	case h.feedCache.Enabled():
+		stats.cache = "hit"
+		rss, meta, err = h.feedCache.get(c.Request.Context(), cacheKey(dbFeed.ID, format), dbFeed.UpdatedAt, func() (string, feedMeta, error) {
+			stats.cache = "miss"
+			// Other requests wait on this build, so it must outlive this client.
+			return h.buildFeed(context.WithoutCancel(c.Request.Context()), dbFeed, settings, format, stats)
+		})
+	case h.cfg.ValidateFeeds || debug:
+		rss, meta, err = h.buildFeed(c.Request.Context(), dbFeed, settings, format, stats)
+	default:
+		h.streamFeed(c, dbFeed, settings, format)
 		return
 	}
+	if err != nil {
+		h.feedUnavailable(c, name, format)
+		return
+	}
+	stats.total = time.Since(start)
+
+	if debug {
+		setDebugHeaders(c, stats)
+	}
+	if h.setFeedHeaders(c, dbFeed, settings, format, meta) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.String(http.StatusOK, rss)
+}
+
+// GetFeedPreview renders the items a feed currently serves as a simple HTML
+// page, to look at a feed in a browser without a reader. Mirrored feeds
+// have no stored items, so only their channel details are shown.
+func (h *Handler) GetFeedPreview(c *gin.Context) {
+	name := c.Param("name")
 
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
 	if dbFeed == nil {
 		c.Status(http.StatusNotFound)
 		return
@@ -59,38 +193,442 @@ func (h *Handler) GetFeed(c *gin.Context) {
 		return
 	}
 
-	items, err := h.itemRepo.GetVisibleItems(name, settings.MaxItems)
+	var items []database.Item
+	if dbFeed.LastSuccessAt != nil && !settings.Mirror {
+		if items, err = h.feedItems(c.Request.Context(), dbFeed, settings, nil); err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	doc, err := feed.NewDocument(*dbFeed, items, h.cfg)
 	if err != nil {
-		slog.Error("Database error", "operation", "get_items", "feed", name, "error", err)
+		slog.Error("Preview generation error", "feed", name, "error", err)
 		c.Status(http.StatusInternalServerError)
 		return
 	}
 
-	ft := feed.ForType(dbFeed.FeedType)
-	rss, err := ft.Build(*dbFeed, items, h.cfg)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Status(http.StatusOK)
+	if err := feed.WritePreview(c.Writer, doc); err != nil {
+		slog.Error("Preview generation error", "feed", name, "error", err)
+	}
+}
+
+// GetFeedSubscribe renders a page for subscribing to a feed from another
+// device: its URL, a QR code of it and one-click links for popular readers.
+func (h *Handler) GetFeedSubscribe(c *gin.Context) {
+	name := c.Param("name")
+
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
 	if err != nil {
-		slog.Error("RSS generation error", "feed", name, "error", err)
+		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
 		c.Status(http.StatusInternalServerError)
 		return
 	}
+	if dbFeed == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
 
-	c.Header("Content-Type", "application/xml; charset=utf-8")
-	c.Header("X-Feed-Items", strconv.FormatInt(int64(len(items)), 10))
-	c.Header("X-Feed-Name", name)
+	doc, err := feed.NewDocument(*dbFeed, nil, h.cfg)
+	if err != nil {
+		slog.Error("Subscribe page generation error", "feed", name, "error", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	// Without BASE_URL the self link points at localhost, which is useless
+	// on the phone scanning the code; use the address the page was opened on.
+	// X-Forwarded-* headers can be sent by anyone, so they only count when
+	// the request came through a TRUSTED_PROXIES proxy.
+	feedURL := doc.SelfURL
+	if h.cfg.BaseUrl == "" {
+		scheme, host := "http", c.Request.Host
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		if h.cfg.TrustedProxy(c.Request.RemoteAddr) {
+			if proto := c.GetHeader("X-Forwarded-Proto"); proto == "https" || proto == "http" {
+				scheme = proto
+			}
+			host = cmp.Or(c.GetHeader("X-Forwarded-Host"), host)
+		}
+		feedURL = (&url.URL{Scheme: scheme, Host: host, Path: "/feeds/" + name}).String()
+	}
+
+	var page bytes.Buffer
+	if err := feed.WriteSubscribePage(&page, doc, feedURL); err != nil {
+		slog.Error("Subscribe page generation error", "feed", name, "error", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// servePlaceholder answers for a feed that wasn't fetched yet with a
+// short-lived placeholder, so readers retry soon.
+func (h *Handler) servePlaceholder(c *gin.Context, dbFeed *database.Feed, format feed.Format) {
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Cache-Control", "public, max-age=60")
+	c.Header("X-Feed-Items", "0")
+	c.Header("X-Feed-Name", dbFeed.Name)
+	c.String(http.StatusOK, feed.BuildPlaceholder(*dbFeed, format, h.cfg))
+}
+
+// feedFormat splits the name parameter of /feeds/:name into the feed name
+// and the requested output format, given by a format suffix such as .atom,
+// the format query parameter or, failing both, an Accept header asking for
+// JSON Feed. RSS is the default.
+func feedFormat(c *gin.Context) (string, feed.Format, error) {
+	name := c.Param("name")
+	for _, format := range feed.Formats {
+		if trimmed, ok := strings.CutSuffix(name, "."+string(format)); ok && format != feed.FormatRSS {
+			return trimmed, format, nil
+		}
+	}
+	if query := c.Query("format"); query != "" {
+		format, err := feed.ParseFormat(query)
+		return name, format, err
+	}
+	// Which document this URL returns depends on Accept from here on.
+	c.Header("Vary", "Accept")
+	if strings.Contains(c.GetHeader("Accept"), "application/feed+json") {
+		return name, feed.FormatJSON, nil
+	}
+	return name, feed.FormatRSS, nil
+}
+
+// serveMirror answers with the stored upstream document of a feed in mirror
+// mode, byte for byte. Readers revalidate it with If-None-Match or
+// If-Modified-Since and get a 304 while the source hasn't changed.
+func (h *Handler) serveMirror(c *gin.Context, dbFeed *database.Feed, settings *types.Settings) {
+	mirror, err := h.feedRepo.GetMirror(c.Request.Context(), dbFeed.ID)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_mirror", "feed", dbFeed.Name, "error", err)
+		h.feedUnavailable(c, dbFeed.Name, feed.FormatRSS)
+		return
+	}
+	// Mirror mode was just turned on and the next fetch hasn't run yet.
+	if mirror == nil {
+		h.servePlaceholder(c, dbFeed, feed.FormatRSS)
+		return
+	}
+
+	lastModified := mirror.ChangedAt.UTC().Truncate(time.Second)
+	c.Header("ETag", mirror.ETag)
+	c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	c.Header("X-Feed-Name", dbFeed.Name)
+	c.Header("X-Feed-Mirror", "true")
+	setResponseHeaders(c, settings)
+
+	if notModified(c.Request, mirror.ETag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, cmp.Or(mirror.ContentType, "application/xml; charset=utf-8"), mirror.Body)
+}
+
+// notModified evaluates the request's validators; If-None-Match takes
+// precedence over If-Modified-Since as in RFC 9110 and uses the weak
+// comparison. A zero lastModified means none was sent, so If-Modified-Since
+// never matches.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		etag = strings.TrimPrefix(etag, "W/")
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !lastModified.IsZero() {
+		return !lastModified.After(since)
+	}
+	return false
+}
+
+// setFeedHeaders sets the headers of a feed document and reports whether
+// the request's validators match it, so a 304 can be sent instead.
+func (h *Handler) setFeedHeaders(c *gin.Context, dbFeed *database.Feed, settings *types.Settings, format feed.Format, meta feedMeta) bool {
+	c.Header("Content-Type", format.ContentType())
+	c.Header("ETag", meta.ETag)
+	c.Header("X-Feed-Items", strconv.Itoa(meta.ItemCount))
+	c.Header("X-Feed-Name", dbFeed.Name)
 	c.Header("X-Last-Updated", dbFeed.UpdatedAt.In(h.cfg.Location).Format(time.RFC3339))
+	if settings.Robots != "" {
+		c.Header("X-Robots-Tag", settings.Robots)
+	}
+	setResponseHeaders(c, settings)
+	return notModified(c.Request, meta.ETag, time.Time{})
+}
 
-	c.String(http.StatusOK, rss)
+// setResponseHeaders adds a feed's response_headers, which may override
+// defaults such as Cache-Control for a CDN or caching proxy in front.
+func setResponseHeaders(c *gin.Context, settings *types.Settings) {
+	for name, value := range settings.ResponseHeaders {
+		c.Header(name, value)
+	}
+}
+
+// feedItems loads the items served in a feed document (feed.OutputItems),
+// logging database errors. The query time and number of visible items are
+// recorded in stats when it isn't nil.
+func (h *Handler) feedItems(ctx context.Context, dbFeed *database.Feed, settings *types.Settings, stats *feedStats) ([]database.Item, error) {
+	now := h.cfg.Now()
+	start := time.Now()
+	items, err := h.itemRepo.GetVisibleItems(ctx, dbFeed.ID, settings, now)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_items", "feed", dbFeed.Name, "error", err)
+		return nil, err
+	}
+	if stats != nil {
+		stats.queried = true
+		stats.dbTime = time.Since(start)
+		stats.considered = len(items)
+	}
+	return feed.PrepareOutputItems(items, dbFeed, settings, h.cfg.Location, now), nil
+}
+
+// buildFeed generates the document for a feed in format and returns it with
+// its metadata. Validation only applies to RSS.
+func (h *Handler) buildFeed(ctx context.Context, dbFeed *database.Feed, settings *types.Settings, format feed.Format, stats *feedStats) (string, feedMeta, error) {
+	items, err := h.feedItems(ctx, dbFeed, settings, stats)
+	if err != nil {
+		return "", feedMeta{}, err
+	}
+
+	rss, err := feed.BuildFormat(feed.ForType(dbFeed.FeedType), format, *dbFeed, items, h.cfg)
+	if err != nil {
+		slog.Error("Feed generation error", "feed", dbFeed.Name, "format", format, "error", err)
+		return "", feedMeta{}, err
+	}
+
+	if h.cfg.ValidateFeeds && format == feed.FormatRSS {
+		if violations := feed.Validate([]byte(rss)); len(violations) > 0 {
+			slog.Warn("Generated feed has validation violations",
+				"feed", dbFeed.Name, "count", len(violations), "violations", violations)
+		}
+	}
+
+	return rss, newFeedMeta(dbFeed, items, format), nil
+}
+
+// streamFeed writes the document for a feed straight to the response.
+// Once the body has started an error can only be logged; the client sees a
+// truncated document.
+func (h *Handler) streamFeed(c *gin.Context, dbFeed *database.Feed, settings *types.Settings, format feed.Format) {
+	items, err := h.feedItems(c.Request.Context(), dbFeed, settings, nil)
+	if err != nil {
+		h.feedUnavailable(c, dbFeed.Name, format)
+		return
+	}
+
+	if h.setFeedHeaders(c, dbFeed, settings, format, newFeedMeta(dbFeed, items, format)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Status(http.StatusOK)
+	if err := feed.WriteFormat(feed.ForType(dbFeed.FeedType), format, c.Writer, *dbFeed, items, h.cfg); err != nil {
+		slog.Error("Feed generation error", "feed", dbFeed.Name, "format", format, "error", err)
+	}
+}
+
+// feedUnavailable answers a feed request that failed on the server side:
+// a 500, or with ERROR_FEEDS a document explaining the outage.
+func (h *Handler) feedUnavailable(c *gin.Context, name string, format feed.Format) {
+	if !h.cfg.ErrorFeeds {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Feed-Name", name)
+	c.String(http.StatusOK, feed.BuildError(name, format, h.cfg))
+}
+
+// RedirectItem sends a short item link (/r/:id) to the item's original URL
+// and counts the click. 302 rather than 301 so browsers don't cache the
+// redirect and skip counting repeat visits.
+func (h *Handler) RedirectItem(c *gin.Context) {
+	id := c.Param("id")
+	if !itemIDPattern.MatchString(id) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	link, err := h.clickRepo.GetItemLink(c.Request.Context(), id)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_item_link", "item_id", id, "error", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if link == "" {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	if err := h.clickRepo.RecordClick(c.Request.Context(), id, h.cfg.Now().In(h.cfg.Location)); err != nil {
+		slog.Error("Failed to record click", "item_id", id, "error", err)
+	}
+
+	c.Redirect(http.StatusFound, link)
+}
+
+// maxInboundEmailSize caps the body of an inbound email, attachments
+// included.
+const maxInboundEmailSize = 10 << 20
+
+// PostInboundEmail stores an email posted to a newsletter feed as an item.
+// The body is either the raw message or the form/JSON fields that
+// inbound-email services post. Requests authenticate with the feed's
+// inbound_token in X-Inbound-Token rather than with the API key, so a
+// leaked token exposes one feed. It isn't accepted in the query, where it
+// would end up in access logs.
+func (h *Handler) PostInboundEmail(c *gin.Context) {
+	name := c.Param("name")
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	if dbFeed == nil || dbFeed.FeedType != "newsletter" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	settings, err := dbFeed.GetSettings()
+	if err != nil {
+		slog.Error("Failed to get feed settings", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed settings"})
+		return
+	}
+	token := c.GetHeader("X-Inbound-Token")
+	if settings.InboundToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(settings.InboundToken)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid inbound token"})
+		return
+	}
+	if !dbFeed.IsEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Feed is disabled"})
+		return
+	}
+
+	email, err := readInboundEmail(c)
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, gin.H{"error": "Invalid email", "details": err.Error()})
+		return
+	}
+
+	now := h.cfg.Now().UTC()
+	id, created, err := jobs.IngestItem(c.Request.Context(), dbFeed, email.Item(now), h.blocklist, h.itemRepo, h.jobRepo, h.cfg.ReadLaterService != "", now)
+	if err != nil {
+		slog.Error("Failed to ingest email", "feed", name, "error", err)
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": "Failed to store email", "details": err.Error()})
+		return
+	}
+	if id == "" {
+		c.JSON(http.StatusOK, gin.H{"success": true, "created": false, "details": "older than the feed's ingest cutoff"})
+		return
+	}
+	h.feedCache.Invalidate(dbFeed.ID)
+
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+	c.JSON(status, gin.H{"success": true, "id": id, "created": created})
+}
+
+// readInboundEmail reads the email from a raw message, from a form (Mailgun
+// and SendGrid, whose "email" field may hold the raw message) or from JSON
+// (Postmark). Bodies over maxInboundEmailSize fail with an
+// *http.MaxBytesError.
+func readInboundEmail(c *gin.Context) (feed.Email, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxInboundEmailSize)
+	switch c.ContentType() {
+	case "application/json":
+		var fields map[string]any
+		if err := c.ShouldBindJSON(&fields); err != nil {
+			return feed.Email{}, err
+		}
+		values := make(map[string]string, len(fields))
+		for key, value := range fields {
+			if s, ok := value.(string); ok {
+				values[key] = s
+			}
+		}
+		return feed.EmailFromFields(values), nil
+	case "multipart/form-data", "application/x-www-form-urlencoded":
+		// ParseMultipartForm hides ParseForm's errors, an oversized body
+		// included, behind ErrNotMultipart for urlencoded forms.
+		parse := c.Request.ParseForm
+		if c.ContentType() == "multipart/form-data" {
+			parse = func() error { return c.Request.ParseMultipartForm(maxInboundEmailSize) }
+		}
+		if err := parse(); err != nil {
+			return feed.Email{}, err
+		}
+		values := make(map[string]string, len(c.Request.PostForm))
+		for key := range c.Request.PostForm {
+			values[key] = c.Request.PostForm.Get(key)
+		}
+		for _, key := range []string{"email", "body-mime"} {
+			if raw := values[key]; raw != "" {
+				return feed.ParseEmail([]byte(raw))
+			}
+		}
+		return feed.EmailFromFields(values), nil
+	default:
+		raw, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return feed.Email{}, err
+		}
+		return feed.ParseEmail(raw)
+	}
 }
 
 func (h *Handler) GetHealth(c *gin.Context) {
 	health := map[string]interface{}{
-		"timestamp": time.Now().In(h.cfg.Location).Format(time.RFC3339),
+		"timestamp":   h.cfg.Now().In(h.cfg.Location).Format(time.RFC3339),
+		"maintenance": h.maintenance.Enabled(),
 	}
 
-	if feedCount, err := h.feedRepo.GetFeedCount(); err == nil {
+	if feedCount, err := h.feedRepo.GetFeedCount(c.Request.Context()); err == nil {
 		health["feeds"] = feedCount
 	}
 
+	if deadLetterCount, err := h.jobRepo.GetDeadLetterCount(c.Request.Context()); err == nil {
+		health["dead_letter_jobs"] = deadLetterCount
+	}
+
+	scaling := h.autoscaler.Stats()
+	health["workers"] = map[string]interface{}{
+		"size":        h.pool.Size(),
+		"busy":        h.pool.Busy(),
+		"queue_depth": scaling.QueueDepth,
+		"autoscaling": scaling.Enabled,
+		"min":         scaling.Min,
+		"max":         scaling.Max,
+		"scale_ups":   scaling.ScaleUps,
+		"scale_downs": scaling.ScaleDowns,
+		"timeouts":    h.pool.Timeouts(),
+	}
+
 	c.JSON(http.StatusOK, health)
 }
 
@@ -103,9 +641,19 @@ func (h *Handler) APIReloadFeed(c *gin.Context) {
 
 	feed.ClearRegexCache()
 
-	config, err := feed.ConfigSync(c.Request.Context(), h.cfg.FeedsDir, name, h.feedRepo)
+	config, err := feed.ConfigSync(c.Request.Context(), h.cfg.FeedsDir, name, &h.cfg.FeedDefaults, h.feedRepo, h.cfg.Location, h.cfg.Now())
 	if err != nil {
 		slog.Error("Failed to sync feed config", "feed", name, "error", err)
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   "Failed to reload configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// An explicit reload hands control back to the config file.
+	if _, err := h.feedRepo.SetFeedPaused(c.Request.Context(), name, false); err != nil {
+		slog.Error("Failed to clear feed pause", "feed", name, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to reload configuration",
 			"details": err.Error(),
@@ -113,7 +661,7 @@ func (h *Handler) APIReloadFeed(c *gin.Context) {
 		return
 	}
 
-	err = feed.Refilter(c.Request.Context(), name, h.feedRepo, h.itemRepo)
+	err = feed.Refilter(c.Request.Context(), name, h.blocklist, h.feedRepo, h.itemRepo, h.cfg.Now())
 	if err != nil {
 		slog.Error("Error refiltering feed", "feed", name, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -134,3 +682,1480 @@ func (h *Handler) APIReloadFeed(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// APIListFeeds lists feeds, all of them ordered by name unless the query
+// filters, sorts or pages the list.
+func (h *Handler) APIListFeeds(c *gin.Context) {
+	var opts database.FeedListOptions
+	var err error
+
+	if limit := c.Query("limit"); limit != "" {
+		if opts.Limit, err = strconv.Atoi(limit); err != nil || opts.Limit <= 0 || opts.Limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+	}
+	if opts.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0")); err != nil || opts.Offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative number"})
+		return
+	}
+
+	if enabled := c.Query("enabled"); enabled != "" {
+		value, err := strconv.ParseBool(enabled)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "enabled must be true or false"})
+			return
+		}
+		opts.Enabled = &value
+	}
+	opts.Type = c.Query("type")
+
+	switch opts.Status = c.Query("status"); opts.Status {
+	case "", "pending", "failing", "ok":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, failing or ok"})
+		return
+	}
+
+	if stale := c.Query("stale"); stale != "" {
+		if opts.StaleFor, err = types.ParseDuration(stale); err != nil || opts.StaleFor <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "stale must be a duration such as 6h or 2d"})
+			return
+		}
+	}
+
+	// Names read best A-Z; times and error counts are most useful newest or
+	// highest first.
+	opts.Sort = c.DefaultQuery("sort", "name")
+	if _, ok := database.FeedListSorts[opts.Sort]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of name, last_fetched_at, last_success_at, failing_since, error_count"})
+		return
+	}
+	switch c.Query("order") {
+	case "":
+		opts.Desc = opts.Sort != "name"
+	case "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return
+	}
+
+	opts.Now = h.cfg.Now()
+	feeds, total, err := h.feedRepo.ListFeeds(c.Request.Context(), opts)
+	if err != nil {
+		slog.Error("Database error", "operation", "list_feeds", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list feeds"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(feeds))
+	for _, f := range feeds {
+		result = append(result, h.feedSummary(f))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feeds":  result,
+		"total":  total,
+		"limit":  opts.Limit,
+		"offset": opts.Offset,
+	})
+}
+
+func (h *Handler) APIGetFeedDetails(c *gin.Context) {
+	name := c.Param("name")
+
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	if dbFeed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	settings, err := dbFeed.GetSettings()
+	if err != nil {
+		slog.Error("Failed to get feed settings", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read feed settings"})
+		return
+	}
+
+	filters, err := dbFeed.GetFilters()
+	if err != nil {
+		slog.Error("Failed to get feed filters", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read feed filters"})
+		return
+	}
+
+	counts, err := h.itemRepo.GetItemCounts(c.Request.Context(), dbFeed.ID)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_item_counts", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count feed items"})
+		return
+	}
+
+	history, err := h.feedRepo.GetConfigHistory(c.Request.Context(), dbFeed.ID)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_config_history", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get config history"})
+		return
+	}
+
+	configHistory := make([]gin.H, 0, len(history))
+	for _, rev := range history {
+		configHistory = append(configHistory, gin.H{
+			"version":    rev.Version,
+			"hash":       rev.ConfigHash,
+			"created_at": h.formatTime(&rev.CreatedAt),
+		})
+	}
+
+	details := h.feedSummary(*dbFeed)
+	details["source_title"] = dbFeed.SourceTitle
+	details["link"] = dbFeed.Link
+	details["description"] = dbFeed.DisplayDescription()
+	details["source_description"] = dbFeed.Description
+	details["source_links"] = gin.H{
+		"alternate": dbFeed.Link,
+		"self":      dbFeed.SourceSelfURL,
+		"hub":       dbFeed.SourceHubURL,
+	}
+	details["image_url"] = cmp.Or(dbFeed.ImageURL, dbFeed.IconURL)
+	details["icon_url"] = dbFeed.IconURL
+	details["settings"] = settings
+	details["filters"] = filters
+	details["items"] = gin.H{
+		"total":    counts.Total,
+		"filtered": counts.Filtered,
+		"extraction": gin.H{
+			"pending":  counts.Extraction.Pending,
+			"ready":    counts.Extraction.Ready,
+			"fallback": counts.Extraction.Fallback,
+			"failed":   counts.Extraction.Failed,
+		},
+	}
+	details["config"] = gin.H{
+		"version":            dbFeed.ConfigVersion,
+		"changed_at":         h.formatTime(dbFeed.ConfigChangedAt),
+		"refiltered_version": dbFeed.RefilteredConfigVersion,
+		"refiltered_at":      h.formatTime(dbFeed.RefilteredAt),
+		"history":            configHistory,
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+func (h *Handler) APIRefreshFeed(c *gin.Context) {
+	name := c.Param("name")
+
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	if dbFeed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+	if c.Query("dry_run") == "true" {
+		h.dryRunFeed(c, dbFeed)
+		return
+	}
+	if dbFeed.IsPaused {
+		c.JSON(http.StatusConflict, gin.H{"error": "Feed is paused", "details": "resume the feed before refreshing it"})
+		return
+	}
+
+	created, err := h.jobRepo.CreateJob(c.Request.Context(), "fetch_feed", dbFeed.ID, nil, 0)
+	if err != nil {
+		slog.Error("Failed to create fetch_feed job", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue refresh"})
+		return
+	}
+
+	message := "Feed refresh enqueued"
+	if !created {
+		message = "Feed refresh already pending"
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": message,
+		"feed":    gin.H{"name": name},
+	})
+}
+
+// dryRunFeed fetches, parses and filters the feed without storing anything
+// and returns what a refresh would do. It works for paused feeds too.
+func (h *Handler) dryRunFeed(c *gin.Context, dbFeed *database.Feed) {
+	result, err := h.dryRunner.Run(c.Request.Context(), dbFeed)
+	if err != nil {
+		slog.Warn("Dry run failed", "feed", dbFeed.Name, "error", err)
+		c.JSON(errorStatus(err, http.StatusBadGateway), gin.H{"error": "Dry run failed", "details": err.Error()})
+		return
+	}
+
+	samples := make([]gin.H, 0, len(result.Samples))
+	for _, item := range result.Samples {
+		samples = append(samples, gin.H{
+			"guid":         item.GUID,
+			"title":        item.Title,
+			"link":         item.Link,
+			"published_at": h.formatTime(&item.PublishedAt),
+			"is_filtered":  item.IsFiltered,
+			"series_id":    item.SeriesID,
+			"score":        item.Score,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run": true,
+		"feed":    gin.H{"name": dbFeed.Name, "source_title": result.Title},
+		"counts": gin.H{
+			"total":              result.Total,
+			"limited":            result.Limited,
+			"too_old":            result.TooOld,
+			"duplicates":         result.Duplicates,
+			"filtered":           result.Filtered,
+			"new":                result.New,
+			"extraction_queued":  result.ExtractionQueued,
+			"extraction_skipped": result.ExtractionSkipped,
+		},
+		"samples": samples,
+	})
+}
+
+func (h *Handler) APIPauseFeed(c *gin.Context) {
+	h.setFeedPaused(c, true)
+}
+
+func (h *Handler) APIResumeFeed(c *gin.Context) {
+	h.setFeedPaused(c, false)
+}
+
+// setFeedPaused toggles fetching of a feed without touching its YAML. The
+// override lasts until resumed or until the feed's configuration is reloaded.
+func (h *Handler) setFeedPaused(c *gin.Context, paused bool) {
+	name := c.Param("name")
+
+	found, err := h.feedRepo.SetFeedPaused(c.Request.Context(), name, paused)
+	if err != nil {
+		slog.Error("Failed to update feed pause state", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update feed", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	message := "Feed resumed"
+	if paused {
+		message = "Feed paused"
+	}
+	slog.Info(message+" via API", "feed", name)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"feed":    gin.H{"name": name, "paused": paused},
+	})
+}
+
+var extractionStatuses = map[string]bool{"none": true, "pending": true, "ready": true, "fallback": true, "failed": true}
+
+func (h *Handler) APIListFeedItems(c *gin.Context) {
+	name := c.Param("name")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	extractionStatus := c.Query("extraction_status")
+	if extractionStatus != "" && !extractionStatuses[extractionStatus] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "extraction_status must be one of: none, pending, ready, fallback, failed"})
+		return
+	}
+
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	if dbFeed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	items, err := h.itemRepo.GetLatestItems(c.Request.Context(), dbFeed.ID, limit, extractionStatus)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_latest_items", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list feed items"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(items))
+	for _, item := range items {
+		result = append(result, h.itemSummary(item))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feed": name, "items": result})
+}
+
+func (h *Handler) APIListDeadLetterJobs(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	deadJobs, err := h.jobRepo.GetDeadLetterJobs(c.Request.Context(), limit)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_dead_letter_jobs", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list dead letter jobs"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(deadJobs))
+	for _, job := range deadJobs {
+		history := make([]gin.H, 0, len(job.ErrorHistory))
+		for _, e := range job.ErrorHistory {
+			history = append(history, gin.H{"error": e.Error, "at": h.formatTime(&e.At)})
+		}
+		result = append(result, gin.H{
+			"id":            job.ID,
+			"job_type":      job.JobType,
+			"feed":          job.FeedName,
+			"item_id":       job.ItemID,
+			"retries":       job.Retries,
+			"max_retries":   job.MaxRetries,
+			"error_history": history,
+			"created_at":    h.formatTime(&job.CreatedAt),
+			"failed_at":     h.formatTime(&job.FailedAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": result})
+}
+
+func (h *Handler) APIRetryDeadLetterJob(c *gin.Context) {
+	id := c.Param("id")
+	if !itemIDPattern.MatchString(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter job not found"})
+		return
+	}
+
+	found, err := h.jobRepo.RetryDeadLetterJob(c.Request.Context(), id)
+	if err != nil {
+		slog.Error("Failed to retry dead letter job", "job_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry job", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter job not found"})
+		return
+	}
+
+	slog.Info("Dead letter job re-queued", "job_id", id)
+	c.JSON(http.StatusAccepted, gin.H{"success": true, "message": "Job re-queued"})
+}
+
+func (h *Handler) APIDeleteDeadLetterJob(c *gin.Context) {
+	id := c.Param("id")
+	if !itemIDPattern.MatchString(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter job not found"})
+		return
+	}
+
+	found, err := h.jobRepo.DeleteDeadLetterJob(c.Request.Context(), id)
+	if err != nil {
+		slog.Error("Failed to delete dead letter job", "job_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete job", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Job discarded"})
+}
+
+func (h *Handler) APIListFeedSeries(c *gin.Context) {
+	name := c.Param("name")
+
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	if dbFeed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	series, err := h.itemRepo.GetSeries(c.Request.Context(), dbFeed.ID)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_series", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list series"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(series))
+	for _, s := range series {
+		result = append(result, gin.H{
+			"series_id":    s.SeriesID,
+			"items":        s.ItemCount,
+			"latest_title": s.LatestTitle,
+			"latest_at":    h.formatTime(&s.LatestAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feed": name, "series": result})
+}
+
+// APIGetFeedTimeline counts the items a feed stored per day over the last
+// ?days=30 days, oldest first and with zero days included, for activity
+// sparklines. quiet_days counts the days without items up to today, for
+// alerting on feeds that stopped publishing.
+func (h *Handler) APIGetFeedTimeline(c *gin.Context) {
+	name := c.Param("name")
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+		return
+	}
+
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	if dbFeed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	timeline, err := h.itemRepo.GetFeedTimeline(c.Request.Context(), dbFeed.ID, days, h.cfg.Location.String(), h.cfg.Now())
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed_timeline", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get timeline"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(timeline))
+	total, quietDays := 0, 0
+	for _, d := range timeline {
+		result = append(result, gin.H{
+			"day":      d.Day.Format(time.DateOnly),
+			"new":      d.Stored,
+			"filtered": d.Filtered,
+		})
+		total += d.Stored
+		if d.Stored == 0 {
+			quietDays++
+		} else {
+			quietDays = 0
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feed":       name,
+		"days":       days,
+		"total":      total,
+		"quiet_days": quietDays,
+		"timeline":   result,
+	})
+}
+
+// APIGetDuplicateFeeds reports feeds that are probably redundant: groups
+// fetching the same upstream address (feed.SameSourceFeeds) and pairs whose
+// stored items overlap by at least ?min_overlap=0.5.
+func (h *Handler) APIGetDuplicateFeeds(c *gin.Context) {
+	minOverlap, err := strconv.ParseFloat(c.DefaultQuery("min_overlap", "0.5"), 64)
+	if err != nil || minOverlap <= 0 || minOverlap > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_overlap must be greater than 0 and at most 1"})
+		return
+	}
+
+	feeds, err := h.feedRepo.GetAllFeeds(c.Request.Context())
+	if err != nil {
+		slog.Error("Database error", "operation", "list_feeds", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list feeds"})
+		return
+	}
+
+	sources := make([]feed.FeedSource, 0, len(feeds))
+	for _, f := range feeds {
+		sources = append(sources, feed.FeedSource{Name: f.Name, FeedURL: f.FeedURL, SelfURL: f.SourceSelfURL})
+	}
+	sameSource := feed.SameSourceFeeds(sources)
+	if sameSource == nil {
+		sameSource = []feed.SameSourceGroup{}
+	}
+
+	overlaps, err := h.itemRepo.GetFeedOverlaps(c.Request.Context(), minOverlap)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed_overlaps", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare feed items"})
+		return
+	}
+
+	overlapping := make([]gin.H, 0, len(overlaps))
+	for _, o := range overlaps {
+		overlapping = append(overlapping, gin.H{
+			"feeds":   []string{o.FeedA, o.FeedB},
+			"items":   []int{o.ItemsA, o.ItemsB},
+			"shared":  o.Shared,
+			"overlap": math.Round(o.Overlap*1000) / 1000,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"same_source": sameSource,
+		"overlapping": overlapping,
+	})
+}
+
+// APIGetItem returns everything stored for one item. Item IDs don't change,
+// so the URL can be kept by external tools; list endpoints link to it.
+func (h *Handler) APIGetItem(c *gin.Context) {
+	id := c.Param("id")
+	if !itemIDPattern.MatchString(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	item, err := h.itemRepo.GetItemByID(c.Request.Context(), id)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_item", "item_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get item"})
+		return
+	}
+	if item == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	dbFeed, err := h.feedRepo.GetFeedByID(c.Request.Context(), item.FeedID)
+	if err != nil || dbFeed == nil {
+		slog.Error("Database error", "operation", "get_feed", "item_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.itemDetails(*item, dbFeed.Name))
+}
+
+// APISaveItem enqueues saving an item to the read-later service, e.g. to
+// star items of feeds that don't save everything with read_later.
+func (h *Handler) APISaveItem(c *gin.Context) {
+	id := c.Param("id")
+	if !itemIDPattern.MatchString(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+	if h.cfg.ReadLaterService == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "No read-later service configured", "details": "set READ_LATER_SERVICE"})
+		return
+	}
+
+	item, err := h.itemRepo.GetItemByID(c.Request.Context(), id)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_item", "item_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get item"})
+		return
+	}
+	if item == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+	if item.Link == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Item has no link to save"})
+		return
+	}
+	if item.ReadLaterSavedAt != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success":             true,
+			"message":             "Item already saved",
+			"read_later_saved_at": h.formatTime(item.ReadLaterSavedAt),
+		})
+		return
+	}
+
+	created, err := h.jobRepo.CreateJob(c.Request.Context(), "save_item", item.FeedID, &item.ID, 3)
+	if err != nil {
+		slog.Error("Failed to create save_item job", "item_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue save"})
+		return
+	}
+
+	message := "Item save enqueued"
+	if !created {
+		message = "Item save already pending"
+	}
+	c.JSON(http.StatusAccepted, gin.H{"success": true, "message": message})
+}
+
+// APIGetSimilarItems returns stored items of any feed with titles similar
+// to the item's, for "related reading" links.
+func (h *Handler) APIGetSimilarItems(c *gin.Context) {
+	id := c.Param("id")
+	if !itemIDPattern.MatchString(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	item, err := h.itemRepo.GetItemByID(c.Request.Context(), id)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_item", "item_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get item"})
+		return
+	}
+	if item == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	similar, err := h.itemRepo.GetSimilarItems(c.Request.Context(), id, limit)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_similar_items", "item_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find similar items"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(similar))
+	for _, s := range similar {
+		result = append(result, gin.H{
+			"id":           s.ID,
+			"feed":         s.FeedName,
+			"title":        s.Title,
+			"link":         s.Link,
+			"published_at": h.formatTime(&s.PublishedAt),
+			"score":        s.Score,
+			"url":          "/api/items/" + s.ID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"item_id": id, "title": item.Title, "similar": result})
+}
+
+// moderationRequest is the body of the moderation endpoints. Filtered
+// defaults to true; false pins an item as visible despite the filters.
+type moderationRequest struct {
+	IDs      []string `json:"ids"`
+	Filtered *bool    `json:"filtered"`
+	Clear    bool     `json:"clear"` // Bulk only: remove the overrides instead
+	Reason   string   `json:"reason"`
+}
+
+// APIFilterItem filters (or, with "filtered": false, unfilters) one item by
+// hand. The override survives refetches and refilter runs until it's
+// removed with DELETE.
+func (h *Handler) APIFilterItem(c *gin.Context) {
+	var req moderationRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+	}
+	filtered := req.Filtered == nil || *req.Filtered
+	h.moderateItem(c, c.Param("id"), &filtered, req.Reason)
+}
+
+// APIClearItemFilter removes an item's moderation override; the feed's
+// filters decide again.
+func (h *Handler) APIClearItemFilter(c *gin.Context) {
+	h.moderateItem(c, c.Param("id"), nil, "")
+}
+
+func (h *Handler) moderateItem(c *gin.Context, id string, filtered *bool, reason string) {
+	if !itemIDPattern.MatchString(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	item, err := h.itemRepo.GetItemByID(c.Request.Context(), id)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_item", "item_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get item"})
+		return
+	}
+	if item == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	dbFeed, err := h.feedRepo.GetFeedByID(c.Request.Context(), item.FeedID)
+	if err != nil || dbFeed == nil {
+		slog.Error("Database error", "operation", "get_feed", "item_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+
+	if err := h.applyModeration(c.Request.Context(), dbFeed, item, filtered, reason); err != nil {
+		slog.Error("Failed to moderate item", "item_id", id, "error", err)
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": "Failed to moderate item", "details": err.Error()})
+		return
+	}
+
+	item, err = h.itemRepo.GetItemByID(c.Request.Context(), id)
+	if err != nil || item == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get item"})
+		return
+	}
+	c.JSON(http.StatusOK, h.itemSummary(*item))
+}
+
+// APIModerateFeedItems sets or clears the override of several items of a
+// feed at once, for cleaning up a feed's output in one request.
+func (h *Handler) APIModerateFeedItems(c *gin.Context) {
+	name := c.Param("name")
+
+	var req moderationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if len(req.IDs) == 0 || len(req.IDs) > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids must list between 1 and 500 items"})
+		return
+	}
+	if req.Clear && req.Filtered != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "filtered and clear can't be combined"})
+		return
+	}
+
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	if dbFeed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	var filtered *bool
+	if !req.Clear {
+		value := req.Filtered == nil || *req.Filtered
+		filtered = &value
+	}
+
+	updated := 0
+	var notFound []string
+	for _, id := range req.IDs {
+		var item *database.Item
+		if itemIDPattern.MatchString(id) {
+			item, err = h.itemRepo.GetItemByID(c.Request.Context(), id)
+			if err != nil {
+				slog.Error("Database error", "operation", "get_item", "item_id", id, "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get item", "updated": updated})
+				return
+			}
+		}
+		if item == nil || item.FeedID != dbFeed.ID {
+			notFound = append(notFound, id)
+			continue
+		}
+
+		if err := h.applyModeration(c.Request.Context(), dbFeed, item, filtered, req.Reason); err != nil {
+			slog.Error("Failed to moderate item", "item_id", id, "error", err)
+			c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": "Failed to moderate item", "details": err.Error(), "updated": updated})
+			return
+		}
+		updated++
+	}
+
+	slog.Info("Items moderated via API", "feed", name, "updated", updated, "clear", req.Clear)
+	c.JSON(http.StatusOK, gin.H{"feed": name, "updated": updated, "not_found": notFound})
+}
+
+// applyModeration pins an item's filter status, or with filtered nil
+// clears the override and applies the feed's filters to the item again.
+func (h *Handler) applyModeration(ctx context.Context, dbFeed *database.Feed, item *database.Item, filtered *bool, reason string) error {
+	if filtered != nil {
+		return h.itemRepo.SetManualFilter(ctx, item.ID, *filtered, reason)
+	}
+
+	settings, err := dbFeed.GetSettings()
+	if err != nil {
+		return err
+	}
+	filters, err := feed.FeedFilters(dbFeed, h.blocklist)
+	if err != nil {
+		return err
+	}
+	automatic := feed.Filter([]types.Item{item.Item}, filters, settings.MinScore, h.cfg.Now())[0]
+	return h.itemRepo.ClearManualFilter(ctx, item.ID, automatic.IsFiltered, automatic.Score)
+}
+
+// APIExtractItem runs content extraction for one item right away instead of
+// through the job queue, stores the result like an extract_content job
+// would and returns it, to debug extraction of specific articles.
+// ?dry_run=true only returns the result.
+func (h *Handler) APIExtractItem(c *gin.Context) {
+	id := c.Param("id")
+	if !itemIDPattern.MatchString(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	item, err := h.itemRepo.GetItemByID(c.Request.Context(), id)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_item", "item_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get item"})
+		return
+	}
+	if item == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	dbFeed, err := h.feedRepo.GetFeedByID(c.Request.Context(), item.FeedID)
+	if err != nil || dbFeed == nil {
+		slog.Error("Database error", "operation", "get_feed", "item_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	settings, err := dbFeed.GetSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read feed settings"})
+		return
+	}
+
+	content, quality, err := h.extractor.Extract(c.Request.Context(), item, settings)
+	var rescheduleErr *jobs.RescheduleError
+	if errors.As(err, &rescheduleErr) {
+		c.Header("Retry-After", strconv.Itoa(int(rescheduleErr.RunAfter.Sub(h.cfg.Now()).Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Site is being crawled", "details": rescheduleErr.Reason})
+		return
+	}
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadGateway), gin.H{"error": "Extraction failed", "details": err.Error()})
+		return
+	}
+
+	status := ""
+	if c.Query("dry_run") != "true" {
+		status, err = jobs.SaveExtraction(c.Request.Context(), h.itemRepo, id, content, quality)
+		if err != nil {
+			slog.Error("Failed to store extraction", "item_id", id, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store extraction"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"item_id": id,
+		"feed":    dbFeed.Name,
+		"link":    item.Link,
+		"status":  status,
+		"quality": gin.H{
+			"score":        quality.Score,
+			"text_length":  quality.TextLength,
+			"link_density": quality.LinkDensity,
+			"accepted":     quality.Accepted(),
+			"reason":       quality.Reason,
+		},
+		"content": content,
+	})
+}
+
+// APIValidateFeed builds the feed document as it would be served and reports
+// any validation violations in it.
+func (h *Handler) APIValidateFeed(c *gin.Context) {
+	name := c.Param("name")
+
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), name)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed", "feed", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	if dbFeed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	var rss string
+	var meta feedMeta
+	if dbFeed.LastSuccessAt == nil {
+		rss = feed.BuildPlaceholder(*dbFeed, feed.FormatRSS, h.cfg)
+	} else {
+		settings, err := dbFeed.GetSettings()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed settings", "details": err.Error()})
+			return
+		}
+		rss, meta, err = h.buildFeed(c.Request.Context(), dbFeed, settings, feed.FormatRSS, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate feed", "details": err.Error()})
+			return
+		}
+	}
+
+	violations := feed.Validate([]byte(rss))
+	if violations == nil {
+		violations = []feed.Violation{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feed":       name,
+		"items":      meta.ItemCount,
+		"valid":      len(violations) == 0,
+		"violations": violations,
+	})
+}
+
+// APIGetAnalytics reports short link clicks per feed over time and the most
+// clicked items. Clicks are only stored as daily counts per item; min_clicks
+// additionally hides buckets and items with fewer clicks, folding them into
+// a "suppressed" total so that small numbers can't single out a reader.
+func (h *Handler) APIGetAnalytics(c *gin.Context) {
+	feedName := c.Query("feed")
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 || days > 366 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 366"})
+		return
+	}
+
+	granularity := c.DefaultQuery("granularity", "day")
+	if granularity != "day" && granularity != "week" && granularity != "month" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "granularity must be day, week or month"})
+		return
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if err != nil || top < 0 || top > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "top must be between 0 and 100"})
+		return
+	}
+
+	minClicks, err := strconv.Atoi(c.DefaultQuery("min_clicks", "0"))
+	if err != nil || minClicks < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_clicks must be a non-negative integer"})
+		return
+	}
+
+	if feedName != "" {
+		dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), feedName)
+		if err != nil {
+			slog.Error("Database error", "operation", "get_feed", "feed", feedName, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+			return
+		}
+		if dbFeed == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+			return
+		}
+	}
+
+	// Clicks are counted under the local day at the configured clock.
+	now := h.cfg.Now().In(h.cfg.Location)
+	since := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, time.UTC)
+
+	buckets, err := h.clickRepo.GetClickSeries(c.Request.Context(), feedName, since, granularity)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_click_series", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get analytics"})
+		return
+	}
+
+	feeds, suppressed := clickSeries(buckets, minClicks)
+	result := gin.H{
+		"since":       since.Format(time.DateOnly),
+		"granularity": granularity,
+		"feeds":       feeds,
+		"suppressed":  suppressed,
+	}
+
+	if top > 0 {
+		items, err := h.clickRepo.GetTopItems(c.Request.Context(), feedName, since, top)
+		if err != nil {
+			slog.Error("Database error", "operation", "get_top_items", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get analytics"})
+			return
+		}
+
+		topItems := make([]gin.H, 0, len(items))
+		for _, item := range items {
+			if item.Clicks < minClicks {
+				break
+			}
+			topItems = append(topItems, gin.H{
+				"id":     item.ItemID,
+				"feed":   item.FeedName,
+				"title":  item.Title,
+				"clicks": item.Clicks,
+			})
+		}
+		result["items"] = topItems
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// clickSeries groups click buckets, ordered by feed, into one series per
+// feed. Periods with fewer than minClicks clicks are left out of the series
+// and the feed's total and only counted in suppressed; feeds left without
+// periods are omitted.
+func clickSeries(buckets []database.ClickBucket, minClicks int) (feeds []gin.H, suppressed int) {
+	feeds = make([]gin.H, 0)
+	var current gin.H
+	for _, b := range buckets {
+		if b.Clicks < minClicks {
+			suppressed += b.Clicks
+			continue
+		}
+		if current == nil || current["feed"] != b.FeedName {
+			current = gin.H{"feed": b.FeedName, "clicks": 0, "series": []gin.H{}}
+			feeds = append(feeds, current)
+		}
+		current["clicks"] = current["clicks"].(int) + b.Clicks
+		current["series"] = append(current["series"].([]gin.H), gin.H{
+			"period": b.Period.Format(time.DateOnly),
+			"clicks": b.Clicks,
+		})
+	}
+	return feeds, suppressed
+}
+
+func (h *Handler) APIGetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": h.maintenance.Enabled()})
+}
+
+// APISetMaintenance turns maintenance mode on or off. While on, feeds keep
+// being served from the database but nothing is fetched or processed.
+func (h *Handler) APISetMaintenance(c *gin.Context) {
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Enabled == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body must be {\"enabled\": true|false}"})
+		return
+	}
+
+	if err := h.maintenance.Set(c.Request.Context(), *req.Enabled); err != nil {
+		slog.Error("Failed to update maintenance mode", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update maintenance mode", "details": err.Error()})
+		return
+	}
+
+	slog.Warn("Maintenance mode changed via API", "enabled", *req.Enabled)
+	c.JSON(http.StatusOK, gin.H{"enabled": *req.Enabled})
+}
+
+// APIGetStats reports slow queries, requests and jobs recorded since
+// startup, to show where optimization would pay off.
+func (h *Handler) APIGetStats(c *gin.Context) {
+	queries := []gin.H{}
+	for _, q := range h.db.SlowQueries() {
+		queries = append(queries, gin.H{
+			"fingerprint": q.Fingerprint,
+			"statement":   q.Statement,
+			"count":       q.Count,
+			"total_ms":    q.Total.Milliseconds(),
+			"max_ms":      q.Max.Milliseconds(),
+			"avg_ms":      (q.Total / time.Duration(q.Count)).Milliseconds(),
+			"last_at":     h.formatTime(&q.LastAt),
+		})
+	}
+
+	requests := gin.H{}
+	for route, r := range h.slowRequests.snapshot() {
+		requests[route] = gin.H{"count": r.Count, "max_ms": r.Max.Milliseconds()}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thresholds": gin.H{
+			"query_ms":    h.cfg.SlowQueryMs,
+			"request_ms":  h.cfg.SlowRequestMs,
+			"job_seconds": h.cfg.SlowJobSeconds,
+		},
+		"slow_queries":  queries,
+		"slow_requests": requests,
+		"slow_jobs":     h.pool.SlowJobs(),
+		"job_timeouts":  h.pool.Timeouts(),
+	})
+}
+
+// APIGetInstanceStats summarizes the whole instance: feeds by state, items
+// stored in total and per day (?days=14), filter, cross-feed duplicate and
+// extraction success rates, and the size of the database.
+func (h *Handler) APIGetInstanceStats(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "14"))
+	if err != nil || days <= 0 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+		return
+	}
+
+	feeds, err := h.feedRepo.GetFeedStatusCounts(c.Request.Context())
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed_status_counts", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count feeds"})
+		return
+	}
+
+	items, err := h.itemRepo.GetInstanceItemCounts(c.Request.Context())
+	if err != nil {
+		slog.Error("Database error", "operation", "get_instance_item_counts", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count items"})
+		return
+	}
+
+	since := h.cfg.Now().In(h.cfg.Location).AddDate(0, 0, -(days - 1))
+	perDay, err := h.itemRepo.GetItemsPerDay(c.Request.Context(), since, h.cfg.Location.String())
+	if err != nil {
+		slog.Error("Database error", "operation", "get_items_per_day", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count items per day"})
+		return
+	}
+
+	byDay := make([]gin.H, 0, len(perDay))
+	for _, d := range perDay {
+		byDay = append(byDay, gin.H{
+			"day":      d.Day.Format(time.DateOnly),
+			"stored":   d.Stored,
+			"filtered": d.Filtered,
+		})
+	}
+
+	stats := gin.H{
+		"feeds": gin.H{
+			"total":    feeds.Total,
+			"enabled":  feeds.Enabled,
+			"paused":   feeds.Paused,
+			"mirrored": feeds.Mirrored,
+			"pending":  feeds.Pending,
+			"ok":       feeds.OK,
+			"failing":  feeds.Failing,
+		},
+		"items": gin.H{
+			"total":                 items.Total,
+			"filtered":              items.Filtered,
+			"cross_feed_duplicates": items.CrossFeedDuplicates,
+			"by_day":                byDay,
+		},
+		"rates": gin.H{
+			"filtered":           ratio(items.Filtered, items.Total),
+			"duplicate":          ratio(items.CrossFeedDuplicates, items.Total),
+			"extraction_success": ratio(items.Extraction.Ready, items.Extraction.Ready+items.Extraction.Fallback+items.Extraction.Failed),
+		},
+		"extraction": gin.H{
+			"pending":  items.Extraction.Pending,
+			"ready":    items.Extraction.Ready,
+			"fallback": items.Extraction.Fallback,
+			"failed":   items.Extraction.Failed,
+		},
+	}
+
+	// The size is an estimate for capacity planning; without the
+	// privileges to read it the rest of the statistics still help.
+	if dbSize, itemsSize, err := h.db.Sizes(c.Request.Context()); err != nil {
+		slog.Warn("Failed to get database size", "error", err)
+	} else {
+		stats["database"] = gin.H{"size_bytes": dbSize, "items_bytes": itemsSize}
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ratio returns part/total rounded to three decimals, or nil without a total.
+func ratio(part, total int) any {
+	if total == 0 {
+		return nil
+	}
+	return math.Round(float64(part)/float64(total)*1000) / 1000
+}
+
+// keywordStatsItemLimit caps how many items keyword statistics read, so a
+// long window on a busy feed stays cheap.
+const keywordStatsItemLimit = 10000
+
+// APIGetKeywordStats counts the most frequent title words and categories of
+// a feed's items in one or more time windows (?windows=1d,7d,30d), to help
+// write filters for what actually comes through.
+func (h *Handler) APIGetKeywordStats(c *gin.Context) {
+	feedName := c.Query("feed")
+	if feedName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "feed is required"})
+		return
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", "20"))
+	if err != nil || top <= 0 || top > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "top must be between 1 and 100"})
+		return
+	}
+
+	var windows []time.Duration
+	for _, w := range strings.Split(c.DefaultQuery("windows", "1d,7d,30d"), ",") {
+		window, err := types.ParseDuration(strings.TrimSpace(w))
+		if err != nil || window <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "windows must be a comma-separated list of durations such as 1d,7d,30d"})
+			return
+		}
+		windows = append(windows, window)
+	}
+
+	dbFeed, err := h.feedRepo.GetFeed(c.Request.Context(), feedName)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_feed", "feed", feedName, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	if dbFeed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	now := h.cfg.Now()
+	items, err := h.itemRepo.GetTitlesSince(c.Request.Context(), dbFeed.ID, now.Add(-slices.Max(windows)),
+		c.Query("include_filtered") == "true", keywordStatsItemLimit)
+	if err != nil {
+		slog.Error("Database error", "operation", "get_titles_since", "feed", feedName, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get items"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(windows))
+	for _, window := range windows {
+		since := now.Add(-window)
+		// Items are newest first, so each window is a prefix.
+		n := 0
+		for n < len(items) && !items[n].PublishedAt.Before(since) {
+			n++
+		}
+		stats := feed.Keywords(items[:n], top)
+		result = append(result, gin.H{
+			"window":     types.Duration(window).String(),
+			"since":      h.formatTime(&since),
+			"items":      stats.Items,
+			"keywords":   stats.Keywords,
+			"categories": stats.Categories,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feed":      feedName,
+		"truncated": len(items) == keywordStatsItemLimit,
+		"windows":   result,
+	})
+}
+
+func (h *Handler) APIGetBlocklist(c *gin.Context) {
+	c.JSON(http.StatusOK, h.blocklist.List())
+}
+
+// APIUpdateBlocklist replaces the global blocklist, writes it to the
+// blocklist file and schedules a refilter of every feed.
+func (h *Handler) APIUpdateBlocklist(c *gin.Context) {
+	var req types.Blocklist
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if err := h.blocklist.Save(req); err != nil {
+		slog.Error("Failed to save blocklist", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save blocklist", "details": err.Error()})
+		return
+	}
+	if err := h.blocklist.Sync(c.Request.Context(), h.cfg.Now()); err != nil {
+		slog.Error("Failed to schedule refilter after blocklist change", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Blocklist saved, but feeds could not be scheduled for refiltering", "details": err.Error()})
+		return
+	}
+
+	slog.Info("Blocklist updated via API")
+	c.JSON(http.StatusOK, h.blocklist.List())
+}
+
+func (h *Handler) APIGetSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, h.runtimeSettings())
+}
+
+// maxWorkerCount caps worker_count in APIUpdateSettings.
+const maxWorkerCount = 100
+
+// APIUpdateSettings adjusts runtime settings without a restart. Omitted
+// fields are left unchanged.
+func (h *Handler) APIUpdateSettings(c *gin.Context) {
+	var req struct {
+		WorkerCount       *int    `json:"worker_count"`
+		SchedulerInterval *int    `json:"scheduler_interval"`
+		LogLevel          *string `json:"log_level"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if req.WorkerCount != nil {
+		if *req.WorkerCount < 1 || *req.WorkerCount > maxWorkerCount {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "worker_count must be between 1 and " + strconv.Itoa(maxWorkerCount)})
+			return
+		}
+		// Above the maximum the autoscaler would silently switch off.
+		if stats := h.autoscaler.Stats(); stats.Enabled && *req.WorkerCount > stats.Max {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "worker_count must not exceed WORKER_MAX (" + strconv.Itoa(stats.Max) + ") while autoscaling is enabled"})
+			return
+		}
+	}
+	if req.SchedulerInterval != nil && *req.SchedulerInterval < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scheduler_interval must be at least 1 second"})
+		return
+	}
+	var level slog.Level
+	if req.LogLevel != nil {
+		var err error
+		if level, err = cfg.ParseLogLevel(*req.LogLevel); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid log_level", "details": err.Error()})
+			return
+		}
+	}
+
+	if req.WorkerCount != nil {
+		// worker_count is the autoscaler's minimum too; otherwise its next
+		// check would scale the pool back into the old range.
+		h.pool.Resize(*req.WorkerCount)
+		h.autoscaler.SetBounds(*req.WorkerCount, h.autoscaler.Stats().Max)
+	}
+	if req.SchedulerInterval != nil {
+		h.scheduler.SetInterval(time.Duration(*req.SchedulerInterval) * time.Second)
+	}
+	if req.LogLevel != nil {
+		h.logLevel.Set(level)
+	}
+
+	slog.Info("Runtime settings updated via API", "settings", h.runtimeSettings())
+	c.JSON(http.StatusOK, h.runtimeSettings())
+}
+
+func (h *Handler) runtimeSettings() gin.H {
+	return gin.H{
+		"worker_count":       h.pool.Size(),
+		"scheduler_interval": int(h.scheduler.Interval() / time.Second),
+		"log_level":          strings.ToLower(h.logLevel.Level().String()),
+	}
+}
+
+func (h *Handler) feedSummary(f database.Feed) gin.H {
+	return gin.H{
+		"name":            f.Name,
+		"title":           f.DisplayTitle(),
+		"url":             f.FeedURL,
+		"type":            cmp.Or(f.FeedType, "basic"),
+		"enabled":         f.IsEnabled,
+		"paused":          f.IsPaused,
+		"last_fetched_at": h.formatTime(f.LastFetchedAt),
+		"last_success_at": h.formatTime(f.LastSuccessAt),
+		"last_error_at":   h.formatTime(f.LastErrorAt),
+		"next_fetch_at":   h.formatTime(f.NextFetchAt),
+		"updated_at":      h.formatTime(&f.UpdatedAt),
+		"failing_since":   h.formatTime(f.FailingSince),
+		"error_count":     f.ErrorCount,
+		"last_error":      f.LastError,
+	}
+}
+
+func (h *Handler) itemSummary(item database.Item) gin.H {
+	return gin.H{
+		"id":                        item.ID,
+		"guid":                      item.GUID,
+		"title":                     item.Title,
+		"link":                      item.Link,
+		"published_at":              h.formatTime(&item.PublishedAt),
+		"created_at":                h.formatTime(&item.CreatedAt),
+		"is_filtered":               item.IsFiltered,
+		"series_id":                 item.SeriesID,
+		"score":                     item.Score,
+		"content_extraction_status": item.ContentExtractionStatus,
+		"content_extraction_note":   item.ExtractionNote,
+		"media_status":              item.MediaStatus,
+		"manual_filter":             item.ManualFilter,
+		"manual_filter_reason":      item.ManualFilterReason,
+		"manual_filter_at":          h.formatTime(item.ManualFilterAt),
+		"read_later_saved_at":       h.formatTime(item.ReadLaterSavedAt),
+		"url":                       "/api/items/" + item.ID,
+	}
+}
+
+// itemDetails is an item's summary plus everything stored for it.
+func (h *Handler) itemDetails(item database.Item, feedName string) gin.H {
+	details := h.itemSummary(item)
+	details["feed"] = feedName
+	details["description"] = item.Description
+	details["content"] = item.Content
+	details["content_ref"] = item.ContentRef
+	details["content_hash"] = item.ContentHash
+	details["updated_at"] = h.formatTime(item.UpdatedAt)
+	details["released_at"] = h.formatTime(item.ReleasedAt)
+	details["authors"] = item.Authors
+	details["categories"] = item.Categories
+	details["enclosure"] = gin.H{
+		"url":    item.EnclosureURL,
+		"length": item.EnclosureLength,
+		"type":   item.EnclosureType,
+	}
+	details["magnet_uri"] = item.MagnetURI
+	details["itunes"] = gin.H{
+		"duration":     item.ITunesDuration,
+		"episode":      item.ITunesEpisode,
+		"season":       item.ITunesSeason,
+		"episode_type": item.ITunesEpisodeType,
+		"image":        item.ITunesImage,
+	}
+	details["media_path"] = item.MediaPath
+	details["media_size"] = item.MediaSize
+	details["previous_title"] = item.PreviousTitle
+	details["previous_description"] = item.PreviousDescription
+	details["changed_at"] = h.formatTime(item.ChangedAt)
+	return details
+}
+
+// errorStatus maps the shared error kinds to an HTTP status, or fallback
+// for errors of no particular kind.
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, types.ErrFeedNotFound), errors.Is(err, types.ErrItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, types.ErrFilteredConfigInvalid):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, types.ErrNotFetched):
+		return http.StatusConflict
+	case errors.Is(err, types.ErrUpstreamTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, types.ErrParse):
+		return http.StatusBadGateway
+	}
+	return fallback
+}
+
+func (h *Handler) formatTime(t *time.Time) any {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.In(h.cfg.Location).Format(time.RFC3339)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/jobs"
+)
+
+func TestAPIUpdateSettings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pool := jobs.NewWorkerPool(nil, nil, 2, 0, nil)
+	scheduler := jobs.NewScheduler(time.Minute, nil, 0, nil, nil, nil, nil, nil)
+	autoscaler := jobs.NewAutoscaler(pool, nil, 2, 8)
+	h := NewHandler(&cfg.Cfg{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, pool, scheduler, autoscaler, nil, new(slog.LevelVar))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(`{"worker_count": 4, "scheduler_interval": 30, "log_level": "debug"}`))
+	h.APIUpdateSettings(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if pool.Size() != 4 || scheduler.Interval() != 30*time.Second || h.logLevel.Level() != slog.LevelDebug {
+		t.Errorf("settings not applied: %s", w.Body)
+	}
+	if stats := autoscaler.Stats(); stats.Min != 4 || stats.Max != 8 {
+		t.Errorf("expected the autoscaler range 4-8, got %d-%d", stats.Min, stats.Max)
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(`{"worker_count": 0}`))
+	h.APIUpdateSettings(c)
+	if w.Code != http.StatusBadRequest || pool.Size() != 4 {
+		t.Errorf("expected an invalid worker_count to be rejected, got %d", w.Code)
+	}
+
+	for _, body := range []string{`{"worker_count": 12}`, `{"worker_count": 1000}`} {
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(body))
+		h.APIUpdateSettings(c)
+		if w.Code != http.StatusBadRequest || pool.Size() != 4 {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+	if stats := autoscaler.Stats(); !stats.Enabled || stats.Min != 4 {
+		t.Errorf("expected autoscaling to stay enabled from 4, got %+v", stats)
+	}
+}
+
+func TestAPIGetAnalytics_InvalidQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(&cfg.Cfg{Location: time.UTC}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	for _, query := range []string{"days=0", "days=400", "granularity=year", "top=101", "min_clicks=-1", "min_clicks=x"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/analytics?"+query, nil)
+		h.APIGetAnalytics(c)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestClickSeries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC) }
+	buckets := []database.ClickBucket{
+		{FeedName: "a", Period: day(1), Clicks: 5},
+		{FeedName: "a", Period: day(2), Clicks: 1},
+		{FeedName: "b", Period: day(1), Clicks: 2},
+		{FeedName: "c", Period: day(1), Clicks: 4},
+	}
+
+	feeds, suppressed := clickSeries(buckets, 3)
+	if suppressed != 3 {
+		t.Errorf("expected 3 suppressed clicks, got %d", suppressed)
+	}
+	if len(feeds) != 2 || feeds[0]["feed"] != "a" || feeds[1]["feed"] != "c" {
+		t.Fatalf("expected feeds a and c, got %v", feeds)
+	}
+	if feeds[0]["clicks"] != 5 || len(feeds[0]["series"].([]gin.H)) != 1 {
+		t.Errorf("expected only the shown periods in a's total, got %v", feeds[0])
+	}
+
+	feeds, suppressed = clickSeries(buckets, 0)
+	if suppressed != 0 || len(feeds) != 3 || feeds[0]["clicks"] != 6 {
+		t.Errorf("expected every period without min_clicks, got %v and %d suppressed", feeds, suppressed)
+	}
+}
+
+func TestDeadLetterJob_MalformedID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(&cfg.Cfg{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	for method, handle := range map[string]gin.HandlerFunc{
+		http.MethodPost:   h.APIRetryDeadLetterJob,
+		http.MethodDelete: h.APIDeleteDeadLetterJob,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(method, "/api/dead-letter/abc", nil)
+		c.Params = gin.Params{{Key: "id", Value: "abc"}}
+		handle(c)
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "Dead letter job not found") {
+			t.Errorf("%s: expected 404 without querying the repository, got %d: %s", method, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+const idempotencyTTL = 24 * time.Hour
+
+// idempotencyStore remembers responses of requests carrying an
+// Idempotency-Key header so retried submissions don't enqueue work twice.
+// Entries live in memory only; a restart forgets them.
+type idempotencyStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	clock   types.Clock
+	entries map[string]*idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	route       string   // method, path and query the key was first used with
+	bodyHash    [32]byte // SHA-256 of the request body the key was first used with
+	done        bool
+	status      int
+	contentType string
+	body        []byte
+	expiresAt   time.Time
+}
+
+// newIdempotencyStore keeps responses for ttl. A nil clock is the system
+// clock.
+func newIdempotencyStore(ttl time.Duration, clock types.Clock) *idempotencyStore {
+	return &idempotencyStore{
+		ttl:     ttl,
+		clock:   types.ClockOrSystem(clock),
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+// begin returns the existing entry for key, or registers a new in-flight
+// entry and returns nil.
+func (s *idempotencyStore) begin(key, route string, bodyHash [32]byte) *idempotencyEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	for k, e := range s.entries {
+		if e.done && now.After(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+
+	if existing, ok := s.entries[key]; ok {
+		copied := *existing
+		return &copied
+	}
+
+	s.entries[key] = &idempotencyEntry{route: route, bodyHash: bodyHash}
+	return nil
+}
+
+// finish stores the response for key. Server errors are not remembered so
+// the client can retry with the same key.
+func (s *idempotencyStore) finish(key string, status int, contentType string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status >= http.StatusInternalServerError {
+		delete(s.entries, key)
+		return
+	}
+
+	entry := s.entries[key]
+	entry.done = true
+	entry.status = status
+	entry.contentType = contentType
+	entry.body = body
+	entry.expiresAt = s.clock.Now().Add(s.ttl)
+}
+
+// recordingWriter keeps a copy of the response body for the store.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyMiddleware replays the stored response when a request repeats
+// an Idempotency-Key. A key reused for another route or body is refused
+// with 422 rather than replayed, since the new request would be dropped.
+// Requests without the header pass through unchanged.
+func idempotencyMiddleware(store *idempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		// The query is part of the request: ?dry_run=true must not replay
+		// for a real refresh.
+		route := c.Request.Method + " " + c.Request.URL.Path
+		if c.Request.URL.RawQuery != "" {
+			route += "?" + c.Request.URL.RawQuery
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		bodyHash := sha256.Sum256(body)
+		existing := store.begin(key, route, bodyHash)
+		if existing != nil {
+			switch {
+			case existing.route != route:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error":   "Idempotency-Key reused for a different request",
+					"details": "key was first used with " + existing.route,
+				})
+			case existing.bodyHash != bodyHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error":   "Idempotency-Key reused for a different request",
+					"details": "key was first used with a different body",
+				})
+			case !existing.done:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error": "A request with this Idempotency-Key is still in progress",
+				})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.status, existing.contentType, existing.body)
+				c.Abort()
+			}
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer func() {
+			// Release the key if the handler panics; gin.Recovery answers with 500.
+			if r := recover(); r != nil {
+				store.finish(key, http.StatusInternalServerError, "", nil)
+				panic(r)
+			}
+		}()
+		c.Next()
+
+		store.finish(key, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes())
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// idempotentServer routes POST /run and /other through the middleware to
+// handler.
+func idempotentServer(store *idempotencyStore, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.RecoveryWithWriter(io.Discard))
+	r.POST("/run", idempotencyMiddleware(store), handler)
+	r.POST("/other", idempotencyMiddleware(store), handler)
+	return r
+}
+
+func post(r *gin.Engine, path, key string) *httptest.ResponseRecorder {
+	return postBody(r, path, key, "")
+}
+
+func postBody(r *gin.Engine, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// countingHandler counts its calls and answers 202, or 500 for ?status=500.
+func countingHandler(calls *int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		*calls++
+		status := http.StatusAccepted
+		if c.Query("status") == "500" {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"call": *calls, "dry_run": c.Query("dry_run")})
+	}
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	calls := 0
+	r := idempotentServer(newIdempotencyStore(time.Hour, nil), countingHandler(&calls))
+
+	first := post(r, "/run", "k1")
+	second := post(r, "/run", "k1")
+	if calls != 1 {
+		t.Errorf("expected one call, got %d", calls)
+	}
+	if second.Code != http.StatusAccepted || second.Body.String() != first.Body.String() || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected the first response replayed, got %d %s", second.Code, second.Body)
+	}
+
+	post(r, "/run", "")
+	post(r, "/run", "")
+	if calls != 3 {
+		t.Errorf("expected requests without a key to pass through, got %d calls", calls)
+	}
+}
+
+func TestIdempotency_RouteMismatch(t *testing.T) {
+	calls := 0
+	r := idempotentServer(newIdempotencyStore(time.Hour, nil), countingHandler(&calls))
+
+	post(r, "/run?dry_run=true", "k1")
+	for _, path := range []string{"/run", "/other?dry_run=true"} {
+		if w := post(r, path, "k1"); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d", path, w.Code)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected only the first request to run, got %d calls", calls)
+	}
+}
+
+func TestIdempotency_BodyMismatch(t *testing.T) {
+	var bodies []string
+	r := idempotentServer(newIdempotencyStore(time.Hour, nil), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		bodies = append(bodies, string(body))
+		c.Status(http.StatusAccepted)
+	})
+
+	postBody(r, "/run", "k1", `{"ids":["a"]}`)
+	if w := postBody(r, "/run", "k1", `{"ids":["a"]}`); w.Code != http.StatusAccepted || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected the same body to be replayed, got %d", w.Code)
+	}
+	if w := postBody(r, "/run", "k1", `{"ids":["b"]}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a different body, got %d", w.Code)
+	}
+	if len(bodies) != 1 || bodies[0] != `{"ids":["a"]}` {
+		t.Errorf("expected the handler to see the first body once, got %q", bodies)
+	}
+}
+
+func TestIdempotency_InFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	r := idempotentServer(newIdempotencyStore(time.Hour, nil), func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusAccepted)
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		post(r, "/run", "k1")
+	}()
+	<-started
+
+	if w := post(r, "/run", "k1"); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 while the first request runs, got %d", w.Code)
+	}
+	close(release)
+	wg.Wait()
+}
+
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	calls := 0
+	r := idempotentServer(newIdempotencyStore(time.Hour, nil), countingHandler(&calls))
+
+	if w := post(r, "/run?status=500", "k1"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	if w := post(r, "/run?status=500", "k1"); w.Code != http.StatusInternalServerError || calls != 2 {
+		t.Errorf("expected the retry to run again, got %d after %d calls", w.Code, calls)
+	}
+
+	panics := idempotentServer(newIdempotencyStore(time.Hour, nil), func(c *gin.Context) {
+		calls++
+		panic("boom")
+	})
+	post(panics, "/run", "k2")
+	post(panics, "/run", "k2")
+	if calls != 4 {
+		t.Errorf("expected a panic to release the key, got %d calls", calls)
+	}
+}
+
+func TestIdempotency_Expiry(t *testing.T) {
+	calls := 0
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := newIdempotencyStore(time.Hour, types.FixedClock(start))
+	r := idempotentServer(store, countingHandler(&calls))
+
+	post(r, "/run", "k1")
+	store.clock = types.FixedClock(start.Add(59 * time.Minute))
+	post(r, "/run", "k1")
+	if calls != 1 {
+		t.Errorf("expected a replay within the TTL, got %d calls", calls)
+	}
+
+	store.clock = types.FixedClock(start.Add(61 * time.Minute))
+	if w := post(r, "/run", "k1"); w.Header().Get("Idempotent-Replayed") != "" || calls != 2 {
+		t.Errorf("expected the key to be usable again after the TTL, got %d calls", calls)
+	}
+}
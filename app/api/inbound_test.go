@@ -0,0 +1,64 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadInboundEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	raw := "Message-ID: <1@example.com>\r\nSubject: Weekly digest\r\nFrom: news@example.com\r\n\r\nHello readers.\r\n"
+	tests := []struct {
+		contentType, body string
+	}{
+		{"message/rfc822", raw},
+		{"", raw},
+		{"application/json", `{"Subject": "Weekly digest", "MessageID": "1@example.com", "TextBody": "Hello readers."}`},
+		{"application/x-www-form-urlencoded", "subject=Weekly+digest&body-plain=Hello+readers."},
+		{"application/x-www-form-urlencoded", "email=" + strings.NewReplacer("\r\n", "%0D%0A", " ", "+", ":", "%3A").Replace(raw)},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/inbound/letters", strings.NewReader(tt.body))
+		c.Request.Header.Set("Content-Type", tt.contentType)
+
+		email, err := readInboundEmail(c)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.contentType, err)
+			continue
+		}
+		if email.Subject != "Weekly digest" || !strings.Contains(email.Item(time.Now()).Content, "Hello readers.") {
+			t.Errorf("%s: unexpected email %+v", tt.contentType, email)
+		}
+	}
+}
+
+func TestReadInboundEmail_TooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	padding := strings.Repeat("x", maxInboundEmailSize)
+	for contentType, body := range map[string]string{
+		"message/rfc822":                    "Subject: Big\r\n\r\n" + padding,
+		"application/json":                  `{"Subject": "Big", "TextBody": "` + padding + `"}`,
+		"application/x-www-form-urlencoded": "subject=Big&body-plain=" + padding,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/inbound/letters", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", contentType)
+
+		_, err := readInboundEmail(c)
+		var tooLarge *http.MaxBytesError
+		if !errors.As(err, &tooLarge) {
+			t.Errorf("%s: expected a MaxBytesError, got %v", contentType, err)
+		}
+	}
+}
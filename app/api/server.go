@@ -33,11 +33,12 @@ func NewServer(handler *Handler, cfg *cfg.Cfg) *gin.Engine {
 	}))
 
 	r.Use(gin.Recovery())
+	r.Use(slowRequestMiddleware(handler.slowRequests))
 
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept")
+		c.Header("Access-Control-Allow-Methods", "GET, HEAD, POST, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Idempotency-Key")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -54,25 +55,88 @@ func NewServer(handler *Handler, cfg *cfg.Cfg) *gin.Engine {
 
 func setupRoutes(r *gin.Engine, handler *Handler, cfg *cfg.Cfg) {
 	r.GET("/feeds/:name", handler.GetFeed)
+	r.HEAD("/feeds/:name", handler.GetFeed)
+	r.GET("/feeds/:name/meta", handler.GetFeedMeta)
+	r.GET("/feeds/:name/preview", handler.GetFeedPreview)
+	r.GET("/feeds/:name/subscribe", handler.GetFeedSubscribe)
 	r.GET("/health", handler.GetHealth)
+	r.GET("/r/:id", handler.RedirectItem)
+	r.POST("/inbound/:name", handler.PostInboundEmail)
 	r.Static("/media", cfg.MediaDir)
 
 	if cfg.APIAccessKey != "" {
+		idempotent := idempotencyMiddleware(newIdempotencyStore(idempotencyTTL, cfg.Clock))
+
 		api := r.Group("/api")
 		api.Use(authMiddleware(cfg.APIAccessKey))
 		{
-			api.POST("/feeds/:name/reload", handler.APIReloadFeed)
+			api.GET("/feeds", handler.APIListFeeds)
+			api.GET("/feeds/:name", handler.APIGetFeedDetails)
+			api.GET("/feeds/:name/items", handler.APIListFeedItems)
+			api.POST("/feeds/:name/items/filter", handler.APIModerateFeedItems)
+			api.GET("/feeds/:name/series", handler.APIListFeedSeries)
+			api.GET("/feeds/:name/timeline", handler.APIGetFeedTimeline)
+			api.GET("/feeds/:name/validate", handler.APIValidateFeed)
+			api.POST("/feeds/:name/refresh", idempotent, handler.APIRefreshFeed)
+			api.POST("/feeds/:name/reload", idempotent, handler.APIReloadFeed)
+			api.POST("/feeds/:name/pause", handler.APIPauseFeed)
+			api.POST("/feeds/:name/resume", handler.APIResumeFeed)
+			api.GET("/items/:id", handler.APIGetItem)
+			api.GET("/items/:id/similar", handler.APIGetSimilarItems)
+			api.POST("/items/:id/filter", handler.APIFilterItem)
+			api.DELETE("/items/:id/filter", handler.APIClearItemFilter)
+			api.POST("/items/:id/save", idempotent, handler.APISaveItem)
+			api.POST("/items/:id/extract", idempotent, handler.APIExtractItem)
+			api.GET("/dead-letter", handler.APIListDeadLetterJobs)
+			api.POST("/dead-letter/:id/retry", idempotent, handler.APIRetryDeadLetterJob)
+			api.DELETE("/dead-letter/:id", handler.APIDeleteDeadLetterJob)
+			api.GET("/analytics", handler.APIGetAnalytics)
+			api.GET("/maintenance", handler.APIGetMaintenance)
+			api.POST("/maintenance", handler.APISetMaintenance)
+			api.GET("/stats", handler.APIGetStats)
+			api.GET("/stats/keywords", handler.APIGetKeywordStats)
+			api.GET("/stats/instance", handler.APIGetInstanceStats)
+			api.GET("/duplicates", handler.APIGetDuplicateFeeds)
+			api.GET("/blocklist", handler.APIGetBlocklist)
+			api.POST("/blocklist", handler.APIUpdateBlocklist)
+			api.GET("/settings", handler.APIGetSettings)
+			api.POST("/settings", handler.APIUpdateSettings)
 		}
 	}
 
 	r.GET("/", func(c *gin.Context) {
 		endpoints := map[string]string{
-			"feed":   "/feeds/<name>",
-			"health": "/health",
+			"feed":      "/feeds/<name> (RSS 2.0; /feeds/<name>.atom or ?format=atom for Atom 1.0, /feeds/<name>.json or Accept: application/feed+json for JSON Feed 1.1)",
+			"preview":   "/feeds/<name>/preview (HTML page listing the feed's items)",
+			"subscribe": "/feeds/<name>/subscribe (HTML page with the feed URL, a QR code and reader links)",
+			"health":    "/health",
+			"redirect":  "/r/<item_id> (short item links, feeds with short_links: true)",
 		}
 
 		if cfg.APIAccessKey != "" {
+			endpoints["feeds_list"] = "/api/feeds (requires X-API-Key header)"
+			endpoints["feed_details"] = "/api/feeds/<name> (requires X-API-Key header)"
+			endpoints["feed_items"] = "/api/feeds/<name>/items (requires X-API-Key header)"
+			endpoints["feed_series"] = "/api/feeds/<name>/series (requires X-API-Key header)"
+			endpoints["feed_timeline"] = "/api/feeds/<name>/timeline (?days=30, requires X-API-Key header)"
+			endpoints["feed_validate"] = "/api/feeds/<name>/validate (requires X-API-Key header)"
+			endpoints["refresh"] = "/api/feeds/<name>/refresh (POST, ?dry_run=true; requires X-API-Key header)"
 			endpoints["reload"] = "/api/feeds/<name>/reload (POST, requires X-API-Key header)"
+			endpoints["pause"] = "/api/feeds/<name>/pause (POST, requires X-API-Key header)"
+			endpoints["resume"] = "/api/feeds/<name>/resume (POST, requires X-API-Key header)"
+			endpoints["item"] = "/api/items/<id> (requires X-API-Key header)"
+			endpoints["item_similar"] = "/api/items/<id>/similar (?limit=10, requires X-API-Key header)"
+			endpoints["item_filter"] = "/api/items/<id>/filter (POST {reason, filtered}; DELETE to clear; requires X-API-Key header)"
+			endpoints["feed_items_filter"] = "/api/feeds/<name>/items/filter (POST {ids, filtered|clear, reason}; requires X-API-Key header)"
+			endpoints["item_save"] = "/api/items/<id>/save (POST, saves to the read-later service; requires X-API-Key header)"
+			endpoints["item_extract"] = "/api/items/<id>/extract (POST, ?dry_run=true; requires X-API-Key header)"
+			endpoints["dead_letter"] = "/api/dead-letter (GET; POST <id>/retry; DELETE <id>; requires X-API-Key header)"
+			endpoints["keyword_stats"] = "/api/stats/keywords (?feed=<name>&windows=1d,7d,30d&top=20&include_filtered=false, requires X-API-Key header)"
+			endpoints["instance_stats"] = "/api/stats/instance (?days=14, requires X-API-Key header)"
+			endpoints["duplicates"] = "/api/duplicates (?min_overlap=0.5, requires X-API-Key header)"
+			endpoints["analytics"] = "/api/analytics (?feed=&days=30&granularity=day|week|month&top=10&min_clicks=0, requires X-API-Key header)"
+			endpoints["maintenance"] = "/api/maintenance (GET/POST, requires X-API-Key header)"
+			endpoints["settings"] = "/api/settings (GET/POST, requires X-API-Key header)"
 		}
 
 		c.JSON(200, gin.H{
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type slowRoute struct {
+	Count int
+	Max   time.Duration
+}
+
+// slowRequestLog counts requests slower than threshold per route pattern.
+type slowRequestLog struct {
+	threshold time.Duration
+	mu        sync.Mutex
+	routes    map[string]slowRoute
+}
+
+func newSlowRequestLog(threshold time.Duration) *slowRequestLog {
+	return &slowRequestLog{threshold: threshold, routes: make(map[string]slowRoute)}
+}
+
+func (l *slowRequestLog) snapshot() map[string]slowRoute {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return maps.Clone(l.routes)
+}
+
+// slowRequestMiddleware logs and counts requests that take longer than the
+// threshold. A zero threshold disables it.
+func slowRequestMiddleware(l *slowRequestLog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l.threshold <= 0 {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+		elapsed := time.Since(start)
+		if elapsed < l.threshold {
+			return
+		}
+
+		route := c.Request.Method + " " + c.FullPath()
+		slog.Warn("Slow request", "route", route, "path", c.Request.URL.Path, "status", c.Writer.Status(), "duration", elapsed)
+
+		l.mu.Lock()
+		r := l.routes[route]
+		r.Count++
+		r.Max = max(r.Max, elapsed)
+		l.routes[route] = r
+		l.mu.Unlock()
+	}
+}
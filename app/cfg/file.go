@@ -0,0 +1,72 @@
+package cfg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/lysyi3m/rss-comb/app/types"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the layout of the optional application config file. Option
+// keys are the long flag names (e.g. "db-host", "worker-count"); feed-defaults
+// provides fallbacks for feed settings omitted from individual feed files.
+type fileConfig struct {
+	Options      map[string]any `yaml:",inline"`
+	FeedDefaults types.Settings `yaml:"feed-defaults"`
+}
+
+// configFilePath finds --config/CONFIG_FILE ahead of the main parse, since the
+// file has to be loaded before go-flags resolves defaults.
+func configFilePath(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--config="); ok {
+			return value
+		}
+		if arg == "--config" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return os.Getenv("CONFIG_FILE")
+}
+
+func loadConfigFile(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var fc fileConfig
+	if err := yaml.Unmarshal(data, &fc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return &fc, nil
+}
+
+// applyFileDefaults installs config file values as option defaults, which
+// gives the precedence flags > env > file > built-in default. Empty keys
+// keep the built-in default.
+func applyFileDefaults(parser *flags.Parser, fc *fileConfig) error {
+	for key, value := range fc.Options {
+		opt := parser.FindOptionByLongName(key)
+		if opt == nil || key == "config" {
+			return fmt.Errorf("unknown option %q in config file", key)
+		}
+		switch value.(type) {
+		case nil:
+			continue
+		case string, int, int64, uint64, float64, bool:
+			opt.Default = []string{fmt.Sprint(value)}
+		default:
+			return fmt.Errorf("option %q must be a scalar", key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package cfg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jessevdk/go-flags"
+)
+
+func loadTestFile(t *testing.T, content string) (*flags.Parser, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fc, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := flags.NewParser(&Cfg{}, flags.None)
+	return parser, applyFileDefaults(parser, fc)
+}
+
+func TestApplyFileDefaults(t *testing.T) {
+	parser, err := loadTestFile(t, "worker-count: 8\nerror-feeds: true\nuser-agent: Comb\nbase-url:\ndb-host: ~\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"worker-count": "8",
+		"error-feeds":  "true",
+		"user-agent":   "Comb",
+		"db-host":      "localhost",
+		"base-url":     "",
+	} {
+		if got := strings.Join(parser.FindOptionByLongName(name).Default, ","); got != want {
+			t.Errorf("default of %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestApplyFileDefaults_Invalid(t *testing.T) {
+	for _, content := range []string{
+		"feeds-dir: [a, b]\n",
+		"db-host:\n  primary: a\n",
+		"no-such-option: 1\n",
+		"config: other.yml\n",
+	} {
+		if _, err := loadTestFile(t, content); err == nil {
+			t.Errorf("expected an error for %q", content)
+		}
+	}
+
+	if _, err := loadTestFile(t, "feeds-dir: [a, b]\n"); err == nil || !strings.Contains(err.Error(), `option "feeds-dir" must be a scalar`) {
+		t.Errorf("expected a scalar error, got %v", err)
+	}
+}
@@ -3,9 +3,14 @@ package cfg
 import (
 	"cmp"
 	"fmt"
+	"log/slog"
+	"net/netip"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/jessevdk/go-flags"
+	"github.com/lysyi3m/rss-comb/app/types"
 )
 
 // Version is set at build time via -ldflags
@@ -16,6 +21,17 @@ func Load() (*Cfg, error) {
 
 	parser := flags.NewParser(cfg, flags.Default)
 
+	if path := configFilePath(os.Args[1:]); path != "" {
+		fc, err := loadConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyFileDefaults(parser, fc); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		cfg.FeedDefaults = fc.FeedDefaults
+	}
+
 	if _, err := parser.Parse(); err != nil {
 		if flagsErr, ok := err.(*flags.Error); ok {
 			if flagsErr.Type == flags.ErrHelp {
@@ -25,6 +41,16 @@ func Load() (*Cfg, error) {
 		return nil, fmt.Errorf("failed to parse configuration: %w", err)
 	}
 
+	level, err := ParseLogLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	proxies, err := ParseProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
 	loc, err := loadTimezone(cfg.Timezone)
 	if err != nil {
 		fmt.Printf("Warning: Invalid timezone '%s', using UTC: %v\n", cfg.Timezone, err)
@@ -33,6 +59,9 @@ func Load() (*Cfg, error) {
 
 	cfg.Version = cmp.Or(Version, "unknown")
 	cfg.Location = loc
+	cfg.Level = level
+	cfg.Clock = types.SystemClock{}
+	cfg.Proxies = proxies
 
 	return cfg, nil
 }
@@ -50,3 +79,33 @@ func loadTimezone(timezone string) (*time.Location, error) {
 	fmt.Printf("Timezone configured: %s\n", timezone)
 	return loc, nil
 }
+
+// ParseProxies parses a comma-separated list of IP addresses and CIDRs.
+func ParseProxies(s string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: must be an IP address or CIDR", entry)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// ParseLogLevel converts a level name (debug, info, warn, error) to a slog level.
+func ParseLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: %w", name, err)
+	}
+	return level, nil
+}
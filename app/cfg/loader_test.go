@@ -0,0 +1,32 @@
+package cfg
+
+import "testing"
+
+func TestTrustedProxy(t *testing.T) {
+	proxies, err := ParseProxies("10.0.0.0/8, 127.0.0.1 ,::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Cfg{Proxies: proxies}
+
+	for addr, want := range map[string]bool{
+		"10.1.2.3:4567":        true,
+		"127.0.0.1:80":         true,
+		"[::1]:80":             true,
+		"[::ffff:10.0.0.1]:80": true,
+		"192.168.1.10:4567":    false,
+		"127.0.0.2:80":         false,
+		"not an address":       false,
+	} {
+		if got := c.TrustedProxy(addr); got != want {
+			t.Errorf("TrustedProxy(%q) = %v, want %v", addr, got, want)
+		}
+	}
+
+	if (&Cfg{}).TrustedProxy("127.0.0.1:80") {
+		t.Error("expected no proxy to be trusted by default")
+	}
+	if _, err := ParseProxies("10.0.0.0/8,proxy.local"); err == nil {
+		t.Error("expected an error for a host name")
+	}
+}
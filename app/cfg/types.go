@@ -1,30 +1,113 @@
 package cfg
 
-import "time"
+import (
+	"log/slog"
+	"net/netip"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
 
 type Cfg struct {
+	ConfigFile string `long:"config" env:"CONFIG_FILE" description:"Path to a YAML application config file (flags and env take precedence)"`
+
 	// Database configuration
 	DBHost     string `long:"db-host" env:"DB_HOST" default:"localhost" description:"Database host"`
 	DBPort     string `long:"db-port" env:"DB_PORT" default:"5432" description:"Database port"`
 	DBUser     string `long:"db-user" env:"DB_USER" default:"rss_user" description:"Database user"`
 	DBPassword string `long:"db-password" env:"DB_PASSWORD" default:"rss_password" description:"Database password (required)" required:"true"`
 	DBName     string `long:"db-name" env:"DB_NAME" default:"rss_comb" description:"Database name"`
+	DBReadHost string `long:"db-read-host" env:"DB_READ_HOST" description:"Read replica host for serving feeds and item listings (empty = use the primary)"`
+	DBReadPort string `long:"db-read-port" env:"DB_READ_PORT" description:"Read replica port (empty = DB_PORT)"`
 
 	// Application configuration
-	FeedsDir          string `long:"feeds-dir" env:"FEEDS_DIR" default:"./feeds" description:"Directory containing feed configuration files"`
-	Port              string `long:"port" env:"PORT" default:"8080" description:"HTTP server port"`
-	BaseUrl           string `long:"base-url" env:"BASE_URL" description:"Public base URL for the service (e.g., https://feeds.example.com)"`
-	WorkerCount       int    `long:"worker-count" env:"WORKER_COUNT" default:"5" description:"Number of background workers for feed processing"`
-	SchedulerInterval int    `long:"scheduler-interval" env:"SCHEDULER_INTERVAL" default:"30" description:"Scheduler interval in seconds"`
-	APIAccessKey      string `long:"api-key" env:"API_ACCESS_KEY" description:"API access key for authentication (optional)"`
-	MediaDir          string `long:"media-dir" env:"MEDIA_DIR" default:"./media" description:"Directory for downloaded media files"`
-	YTDLPCmd          string `long:"yt-dlp-cmd" env:"YT_DLP_CMD" default:"yt-dlp" description:"yt-dlp command (supports multi-word for docker, e.g. 'docker compose run --rm yt-dlp')"`
-	YTDLPArgs         string `long:"yt-dlp-args" env:"YT_DLP_ARGS" description:"Extra arguments for yt-dlp (e.g. '--cookies /app/cookies.txt')"`
-	YTDLPUpdate       bool   `long:"yt-dlp-update" env:"YT_DLP_UPDATE" description:"Auto-update yt-dlp on startup"`
+	FeedsDir               string `long:"feeds-dir" env:"FEEDS_DIR" default:"./feeds" description:"Directory containing feed configuration files"`
+	Port                   string `long:"port" env:"PORT" default:"8080" description:"HTTP server port"`
+	BaseUrl                string `long:"base-url" env:"BASE_URL" description:"Public base URL for the service (e.g., https://feeds.example.com)"`
+	TrustedProxies         string `long:"trusted-proxies" env:"TRUSTED_PROXIES" description:"Comma-separated addresses or CIDRs of reverse proxies whose X-Forwarded-Proto/Host are honoured (empty = none)"`
+	WorkerCount            int    `long:"worker-count" env:"WORKER_COUNT" default:"5" description:"Number of background workers for feed processing"`
+	WorkerMax              int    `long:"worker-max" env:"WORKER_MAX" default:"0" description:"Upper bound for queue-based worker autoscaling; WORKER_COUNT is the lower bound (0 disables autoscaling)"`
+	SchedulerInterval      int    `long:"scheduler-interval" env:"SCHEDULER_INTERVAL" default:"30" description:"Scheduler interval in seconds"`
+	StartupWarmup          int    `long:"startup-warmup" env:"STARTUP_WARMUP" default:"0" description:"Spread fetches of feeds due at startup over this many seconds (0 = all at once)"`
+	FetchJobTimeout        int    `long:"fetch-job-timeout" env:"FETCH_JOB_TIMEOUT" default:"120" description:"Time limit in seconds for a fetch_feed job (0 = no limit)"`
+	ExtractJobTimeout      int    `long:"extract-job-timeout" env:"EXTRACT_JOB_TIMEOUT" default:"300" description:"Time limit in seconds for an extract_content job (0 = no limit)"`
+	MaxRedirects           int    `long:"max-redirects" env:"MAX_REDIRECTS" default:"5" description:"Redirects followed when fetching feeds, site pages and icons (0 = none)"`
+	AllowInsecureRedirects bool   `long:"allow-insecure-redirects" env:"ALLOW_INSECURE_REDIRECTS" description:"Follow redirects from https to plain http (refused by default)"`
+	DNSCacheTTL            int    `long:"dns-cache-ttl" env:"DNS_CACHE_TTL" default:"0" description:"Cache DNS answers for fetches for this many seconds (0 = disabled, use the resolver for every connection)"`
+	DNSServer              string `long:"dns-server" env:"DNS_SERVER" description:"DNS server (host:port) used for fetches instead of the system resolver"`
+	ArticleMaxRedirects    int    `long:"article-max-redirects" env:"ARTICLE_MAX_REDIRECTS" default:"10" description:"Redirects (HTTP and meta refresh) followed when fetching an article for extraction"`
+	CrawlDelayMs           int    `long:"crawl-delay-ms" env:"CRAWL_DELAY_MS" default:"1500" description:"Minimum time in milliseconds between article fetches from the same site, shared by all workers (0 = disabled)"`
+	CrawlDelays            string `long:"crawl-delays" env:"CRAWL_DELAYS" description:"Per-site crawl delay overrides, e.g. example.com=5s,other.org=0s (subdomains share their site's delay)"`
+	MediaJobTimeout        int    `long:"media-job-timeout" env:"MEDIA_JOB_TIMEOUT" default:"1800" description:"Time limit in seconds for a download_media job (0 = no limit)"`
+	SlowQueryMs            int    `long:"slow-query-ms" env:"SLOW_QUERY_MS" default:"500" description:"Log and count database queries slower than this many milliseconds (0 = disabled)"`
+	SlowRequestMs          int    `long:"slow-request-ms" env:"SLOW_REQUEST_MS" default:"2000" description:"Log and count HTTP requests slower than this many milliseconds (0 = disabled)"`
+	SlowJobSeconds         int    `long:"slow-job-seconds" env:"SLOW_JOB_SECONDS" default:"60" description:"Log and count jobs running longer than this many seconds (0 = disabled)"`
+	FeedCacheTTL           int    `long:"feed-cache-ttl" env:"FEED_CACHE_TTL" default:"30" description:"Keep generated feeds in memory for up to this many seconds; item writes invalidate them sooner (0 = disabled)"`
+	ErrorFeeds             bool   `long:"error-feeds" env:"ERROR_FEEDS" description:"Serve a valid RSS document with an explanatory item instead of HTTP 500 when a feed can't be generated"`
+	ValidateFeeds          bool   `long:"validate-feeds" env:"VALIDATE_FEEDS" description:"Check every generated feed document and log validation violations (debugging aid; disables streaming)"`
+	FeedDebugHeaders       bool   `long:"feed-debug-headers" env:"FEED_DEBUG_HEADERS" description:"Add X-Feed-Debug-* headers (generation and query time, cache hit/miss, items considered) to every feed response, not only to requests with ?debug=1 (disables streaming)"`
+	BlocklistFile          string `long:"blocklist-file" env:"BLOCKLIST_FILE" default:"./blocklist.yml" description:"YAML file with authors, domains and keywords muted in every feed (managed via /api/blocklist)"`
+	APIAccessKey           string `long:"api-key" env:"API_ACCESS_KEY" description:"API access key for authentication (optional)"`
+	ContentDir             string `long:"content-dir" env:"CONTENT_DIR" description:"Store extracted article content as files in this directory instead of the database (empty = database)"`
+	ContentCacheSize       int    `long:"content-cache-size" env:"CONTENT_CACHE_SIZE" default:"64" description:"Memory cache for content read from CONTENT_DIR, in MB"`
+	PublishDir             string `long:"publish-dir" env:"PUBLISH_DIR" description:"Write every feed's RSS document to <name>.xml in this directory after each successful fetch"`
+	PublishS3Endpoint      string `long:"publish-s3-endpoint" env:"PUBLISH_S3_ENDPOINT" description:"S3-compatible endpoint to upload feed documents to after each successful fetch, e.g. https://s3.eu-west-1.amazonaws.com"`
+	PublishS3Bucket        string `long:"publish-s3-bucket" env:"PUBLISH_S3_BUCKET" description:"Bucket for published feed documents"`
+	PublishS3Region        string `long:"publish-s3-region" env:"PUBLISH_S3_REGION" default:"us-east-1" description:"Region used to sign S3 uploads"`
+	PublishS3Prefix        string `long:"publish-s3-prefix" env:"PUBLISH_S3_PREFIX" description:"Key prefix for published feed documents, e.g. feeds"`
+	PublishS3AccessKey     string `long:"publish-s3-access-key" env:"PUBLISH_S3_ACCESS_KEY" description:"Access key ID for S3 uploads"`
+	PublishS3SecretKey     string `long:"publish-s3-secret-key" env:"PUBLISH_S3_SECRET_KEY" description:"Secret access key for S3 uploads"`
+	ArchiveDir             string `long:"archive-dir" env:"ARCHIVE_DIR" description:"Render the items of feeds with the archive setting into a static HTML site in this directory"`
+	ArchiveInterval        int    `long:"archive-interval" env:"ARCHIVE_INTERVAL" default:"3600" description:"Seconds between static archive exports"`
+	GitHubToken            string `long:"github-token" env:"GITHUB_TOKEN" description:"GitHub API token for feeds with release_notes (raises the rate limit; private repositories)"`
+	GitLabToken            string `long:"gitlab-token" env:"GITLAB_TOKEN" description:"GitLab API token for feeds with release_notes"`
+	GitLabURL              string `long:"gitlab-url" env:"GITLAB_URL" description:"Self-hosted GitLab instance recognized by release_notes in addition to gitlab.com, e.g. https://gitlab.example.com"`
+	ReadLaterService       string `long:"read-later-service" env:"READ_LATER_SERVICE" description:"Read-later service for feeds with the read_later setting and POST /api/items/:id/save: wallabag or readeck"`
+	ReadLaterURL           string `long:"read-later-url" env:"READ_LATER_URL" description:"Base URL of the read-later instance, e.g. https://wallabag.example.com"`
+	ReadLaterToken         string `long:"read-later-token" env:"READ_LATER_TOKEN" description:"Readeck API token"`
+	ReadLaterClientID      string `long:"read-later-client-id" env:"READ_LATER_CLIENT_ID" description:"Wallabag API client ID"`
+	ReadLaterClientSecret  string `long:"read-later-client-secret" env:"READ_LATER_CLIENT_SECRET" description:"Wallabag API client secret"`
+	ReadLaterUsername      string `long:"read-later-username" env:"READ_LATER_USERNAME" description:"Wallabag username"`
+	ReadLaterPassword      string `long:"read-later-password" env:"READ_LATER_PASSWORD" description:"Wallabag password"`
+	MediaDir               string `long:"media-dir" env:"MEDIA_DIR" default:"./media" description:"Directory for downloaded media files"`
+	YTDLPCmd               string `long:"yt-dlp-cmd" env:"YT_DLP_CMD" default:"yt-dlp" description:"yt-dlp command (supports multi-word for docker, e.g. 'docker compose run --rm yt-dlp')"`
+	YTDLPArgs              string `long:"yt-dlp-args" env:"YT_DLP_ARGS" description:"Extra arguments for yt-dlp (e.g. '--cookies /app/cookies.txt')"`
+	YTDLPUpdate            bool   `long:"yt-dlp-update" env:"YT_DLP_UPDATE" description:"Auto-update yt-dlp on startup"`
+	LogLevel               string `long:"log-level" env:"LOG_LEVEL" default:"info" description:"Log level (debug, info, warn, error)"`
 
 	// Application metadata
 	UserAgent string         `long:"user-agent" env:"USER_AGENT" default:"RSS Comb/1.0" description:"User agent string for HTTP requests"`
 	Timezone  string         `long:"timezone" env:"TZ" default:"UTC" description:"Timezone for timestamps (e.g., UTC, America/New_York)"`
 	Version   string         // Set at runtime from build version
 	Location  *time.Location // Parsed timezone location
+	Level     slog.Level     // Parsed log level
+	Clock     types.Clock    // Time source; nil is the system clock
+	Proxies   []netip.Prefix // Parsed TrustedProxies
+
+	// Fallbacks for refresh_interval, max_items and timeout when a feed file omits them (config file only)
+	FeedDefaults types.Settings
+}
+
+// TrustedProxy reports whether a request from remoteAddr (host:port) came
+// through one of the configured reverse proxies.
+func (c *Cfg) TrustedProxy(remoteAddr string) bool {
+	addrPort, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range c.Proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Now returns the current time of the configured clock.
+func (c *Cfg) Now() time.Time {
+	if c.Clock == nil {
+		return time.Now()
+	}
+	return c.Clock.Now()
 }
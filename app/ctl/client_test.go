@@ -0,0 +1,110 @@
+package ctl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/details":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"Invalid feed configuration","details":"url is required"}`))
+		case "/plain":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error":"Feed is disabled"}`))
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`<html>Bad Gateway</html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c := newClient(srv.URL+"/", "")
+
+	for path, want := range map[string]string{
+		"/details": "Invalid feed configuration: url is required (HTTP 400)",
+		"/plain":   "Feed is disabled (HTTP 409)",
+		"/broken":  "HTTP error: 502 Bad Gateway",
+	} {
+		if err := c.get(path, nil, nil); err == nil || err.Error() != want {
+			t.Errorf("%s: expected %q, got %v", path, want, err)
+		}
+	}
+
+	// Without a key the server does not register the API routes at all.
+	if err := c.post("/api/feeds/news/refresh", nil); err == nil || !strings.Contains(err.Error(), "is API_ACCESS_KEY configured") {
+		t.Errorf("expected a hint about API_ACCESS_KEY for a 404, got %v", err)
+	}
+}
+
+func TestClient_SendsAPIKey(t *testing.T) {
+	var key, accept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, accept = r.Header.Get("X-API-Key"), r.Header.Get("Accept")
+		w.Write([]byte(`{"message":"ok"}`))
+	}))
+	defer srv.Close()
+
+	var resp map[string]any
+	if err := newClient(srv.URL, "secret").get("/api/feeds", nil, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if key != "secret" || accept != "application/json" {
+		t.Errorf("expected the API key and Accept headers, got %q / %q", key, accept)
+	}
+	if resp["message"] != "ok" {
+		t.Errorf("expected the response decoded, got %v", resp)
+	}
+
+	if err := newClient(srv.URL, "").get("/api/feeds", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if key != "" {
+		t.Errorf("expected no API key header without a key, got %q", key)
+	}
+}
+
+func TestTailer_PrintsNewItemsInOrder(t *testing.T) {
+	// Each poll answers with the next page, newest first like the API.
+	pages := []string{
+		`{"items":[{"id":"3","title":"Three","link":"https://example.com/3","published_at":"3"},
+			{"id":"2","title":"Two","link":"https://example.com/2","published_at":"2"},
+			{"id":"1","title":"One","link":"https://example.com/1","published_at":"1"}]}`,
+		`{"items":[{"id":"5","title":"Five","link":"https://example.com/5","published_at":"5","is_filtered":true},
+			{"id":"4","title":"Four","link":"https://example.com/4","published_at":"4"},
+			{"id":"3","title":"Three","link":"https://example.com/3","published_at":"3"}]}`,
+		`{"items":[{"id":"5","title":"Five","link":"https://example.com/5","published_at":"5","is_filtered":true}]}`,
+	}
+	var limit string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit = r.URL.Query().Get("limit")
+		w.Write([]byte(pages[0]))
+		pages = pages[1:]
+	}))
+	defer srv.Close()
+
+	tl := &tailer{client: newClient(srv.URL, ""), path: "/api/feeds/news/items", lines: 2, seen: make(map[string]bool)}
+	var out strings.Builder
+	for range 3 {
+		if err := tl.poll(&out); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := "2  Two\n    https://example.com/2\n" +
+		"3  Three\n    https://example.com/3\n" +
+		"4  Four\n    https://example.com/4\n" +
+		"5  Five [filtered]\n    https://example.com/5\n"
+	if out.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if limit != "50" {
+		t.Errorf("expected at least 50 items requested, got %q", limit)
+	}
+}
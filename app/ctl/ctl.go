@@ -0,0 +1,257 @@
+// Package ctl implements the `rss-comb ctl` command line client, a thin
+// wrapper around the authenticated HTTP API.
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+)
+
+type options struct {
+	URL    string `long:"url" env:"RSS_COMB_URL" default:"http://localhost:8080" description:"Base URL of the RSS Comb server"`
+	APIKey string `long:"api-key" env:"API_ACCESS_KEY" description:"API access key"`
+}
+
+// Run parses ctl arguments and executes the selected subcommand. It returns
+// the process exit code.
+func Run(args []string) int {
+	opts := &options{}
+	parser := flags.NewNamedParser("rss-comb ctl", flags.Default)
+	if _, err := parser.AddGroup("Connection Options", "", opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	commands := []struct {
+		name, short string
+		data        any
+	}{
+		{"list", "List configured feeds", &listCommand{opts: opts}},
+		{"show", "Show feed details", &showCommand{opts: opts}},
+		{"refresh", "Enqueue an immediate fetch of a feed", &refreshCommand{opts: opts}},
+		{"reload", "Reload feed configuration and re-apply filters", &reloadCommand{opts: opts}},
+		{"pause", "Stop fetching a feed until resumed or reloaded", &pauseCommand{opts: opts}},
+		{"resume", "Resume fetching a paused feed", &pauseCommand{opts: opts, resume: true}},
+		{"tail", "Print newly stored items of a feed as they arrive", &tailCommand{opts: opts}},
+	}
+	for _, cmd := range commands {
+		if _, err := parser.AddCommand(cmd.name, cmd.short, "", cmd.data); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	// Parse errors and command failures are printed by the parser itself.
+	if _, err := parser.ParseArgs(args); err != nil {
+		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	return 0
+}
+
+type feedArg struct {
+	Name string `positional-arg-name:"feed" required:"true"`
+}
+
+type feedSummary struct {
+	Name          string  `json:"name"`
+	Title         string  `json:"title"`
+	URL           string  `json:"url"`
+	Type          string  `json:"type"`
+	Enabled       bool    `json:"enabled"`
+	Paused        bool    `json:"paused"`
+	LastFetchedAt *string `json:"last_fetched_at"`
+	LastSuccessAt *string `json:"last_success_at"`
+	NextFetchAt   *string `json:"next_fetch_at"`
+}
+
+type listCommand struct {
+	opts *options
+}
+
+func (cmd *listCommand) Execute(args []string) error {
+	var resp struct {
+		Feeds []feedSummary `json:"feeds"`
+	}
+	if err := newClient(cmd.opts.URL, cmd.opts.APIKey).get("/api/feeds", nil, &resp); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tENABLED\tPAUSED\tLAST FETCHED\tLAST SUCCESS\tNEXT FETCH\tTITLE")
+	for _, f := range resp.Feeds {
+		fmt.Fprintf(w, "%s\t%s\t%t\t%t\t%s\t%s\t%s\t%s\n",
+			f.Name, f.Type, f.Enabled, f.Paused, orDash(f.LastFetchedAt), orDash(f.LastSuccessAt), orDash(f.NextFetchAt), f.Title)
+	}
+	return w.Flush()
+}
+
+type showCommand struct {
+	opts *options
+	Args feedArg `positional-args:"yes"`
+}
+
+func (cmd *showCommand) Execute(args []string) error {
+	var details map[string]any
+	if err := newClient(cmd.opts.URL, cmd.opts.APIKey).get("/api/feeds/"+url.PathEscape(cmd.Args.Name), nil, &details); err != nil {
+		return err
+	}
+	return printJSON(details)
+}
+
+type refreshCommand struct {
+	opts *options
+	Args feedArg `positional-args:"yes"`
+}
+
+func (cmd *refreshCommand) Execute(args []string) error {
+	var resp map[string]any
+	if err := newClient(cmd.opts.URL, cmd.opts.APIKey).post("/api/feeds/"+url.PathEscape(cmd.Args.Name)+"/refresh", &resp); err != nil {
+		return err
+	}
+	fmt.Println(resp["message"])
+	return nil
+}
+
+type reloadCommand struct {
+	opts *options
+	Args feedArg `positional-args:"yes"`
+}
+
+func (cmd *reloadCommand) Execute(args []string) error {
+	var resp map[string]any
+	if err := newClient(cmd.opts.URL, cmd.opts.APIKey).post("/api/feeds/"+url.PathEscape(cmd.Args.Name)+"/reload", &resp); err != nil {
+		return err
+	}
+	fmt.Println(resp["message"])
+	return nil
+}
+
+type pauseCommand struct {
+	opts   *options
+	resume bool
+	Args   feedArg `positional-args:"yes"`
+}
+
+func (cmd *pauseCommand) Execute(args []string) error {
+	action := "/pause"
+	if cmd.resume {
+		action = "/resume"
+	}
+
+	var resp map[string]any
+	if err := newClient(cmd.opts.URL, cmd.opts.APIKey).post("/api/feeds/"+url.PathEscape(cmd.Args.Name)+action, &resp); err != nil {
+		return err
+	}
+	fmt.Println(resp["message"])
+	return nil
+}
+
+type tailItem struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Link        string `json:"link"`
+	PublishedAt string `json:"published_at"`
+	IsFiltered  bool   `json:"is_filtered"`
+}
+
+type tailCommand struct {
+	opts     *options
+	Lines    int           `short:"n" long:"lines" default:"10" description:"Number of existing items to print before following"`
+	Interval time.Duration `long:"interval" default:"30s" description:"Polling interval"`
+	Args     feedArg       `positional-args:"yes"`
+}
+
+func (cmd *tailCommand) Execute(args []string) error {
+	t := &tailer{
+		client: newClient(cmd.opts.URL, cmd.opts.APIKey),
+		path:   "/api/feeds/" + url.PathEscape(cmd.Args.Name) + "/items",
+		lines:  cmd.Lines,
+		seen:   make(map[string]bool),
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	for {
+		if err := t.poll(os.Stdout); err != nil {
+			return err
+		}
+
+		select {
+		case <-sigChan:
+			return nil
+		case <-time.After(cmd.Interval):
+		}
+	}
+}
+
+// tailer remembers the items it has printed across polls.
+type tailer struct {
+	client *client
+	path   string
+	lines  int
+	seen   map[string]bool
+	polled bool
+}
+
+// poll fetches the feed's latest items and prints the ones not seen before,
+// oldest first. The first poll prints at most lines items.
+func (t *tailer) poll(w io.Writer) error {
+	var resp struct {
+		Items []tailItem `json:"items"`
+	}
+	query := url.Values{"limit": {strconv.Itoa(max(t.lines, 50))}}
+	if err := t.client.get(t.path, query, &resp); err != nil {
+		return err
+	}
+
+	// Items arrive newest first; print oldest first like tail does.
+	var fresh []tailItem
+	for _, item := range resp.Items {
+		if !t.seen[item.ID] {
+			t.seen[item.ID] = true
+			fresh = append(fresh, item)
+		}
+	}
+	if !t.polled && len(fresh) > t.lines {
+		fresh = fresh[:t.lines]
+	}
+	t.polled = true
+
+	for i := len(fresh) - 1; i >= 0; i-- {
+		item := fresh[i]
+		marker := ""
+		if item.IsFiltered {
+			marker = " [filtered]"
+		}
+		fmt.Fprintf(w, "%s  %s%s\n    %s\n", item.PublishedAt, item.Title, marker, item.Link)
+	}
+	return nil
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func orDash(s *string) string {
+	if s == nil || *s == "" {
+		return "-"
+	}
+	return *s
+}
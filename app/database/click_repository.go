@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ClickRepository records visits to short item links as daily counts.
+type ClickRepository struct {
+	db *DB
+}
+
+func NewClickRepository(db *DB) *ClickRepository {
+	return &ClickRepository{db: db}
+}
+
+// GetItemLink returns the link a short URL redirects to, or "" if the item
+// doesn't exist, has no link, is filtered or belongs to a feed without
+// short_links, so /r/ can't redirect to arbitrary stored links.
+func (r *ClickRepository) GetItemLink(ctx context.Context, itemID string) (string, error) {
+	var link sql.NullString
+	err := r.db.QueryRowContext(ctx, `
+		SELECT fi.link
+		FROM feed_items fi
+		JOIN feeds f ON f.id = fi.feed_id
+		WHERE fi.id = $1 AND fi.is_filtered = false
+		  AND COALESCE((f.settings->>'short_links')::boolean, false)
+	`, itemID).Scan(&link)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get item link: %w", err)
+	}
+	return link.String, nil
+}
+
+// RecordClick counts a click on the item under the given (local) day.
+func (r *ClickRepository) RecordClick(ctx context.Context, itemID string, day time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO item_clicks (item_id, day, clicks) VALUES ($1, $2::date, 1)
+		ON CONFLICT (item_id, day) DO UPDATE SET clicks = item_clicks.clicks + 1
+	`, itemID, day.Format(time.DateOnly))
+	if err != nil {
+		return fmt.Errorf("failed to record click: %w", err)
+	}
+	return nil
+}
+
+type ClickBucket struct {
+	FeedName string
+	Period   time.Time // Start of the day, week or month
+	Clicks   int
+}
+
+type ItemClicks struct {
+	ItemID   string
+	FeedName string
+	Title    string
+	Clicks   int
+}
+
+// GetClickSeries sums clicks per feed and period since the given day.
+// granularity is a date_trunc unit ("day", "week" or "month"); an empty
+// feedName covers all feeds.
+func (r *ClickRepository) GetClickSeries(ctx context.Context, feedName string, since time.Time, granularity string) ([]ClickBucket, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT f.name, date_trunc($3, c.day)::date AS period, SUM(c.clicks)
+		FROM item_clicks c
+		JOIN feed_items fi ON c.item_id = fi.id
+		JOIN feeds f ON fi.feed_id = f.id
+		WHERE c.day >= $2::date AND ($1 = '' OR f.name = $1)
+		GROUP BY f.name, period
+		ORDER BY f.name, period
+	`, feedName, since.Format(time.DateOnly), granularity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get click series: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []ClickBucket
+	for rows.Next() {
+		var b ClickBucket
+		if err := rows.Scan(&b.FeedName, &b.Period, &b.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan click bucket: %w", err)
+		}
+		buckets = append(buckets, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating click buckets: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// GetTopItems returns the most clicked items since the given day.
+func (r *ClickRepository) GetTopItems(ctx context.Context, feedName string, since time.Time, limit int) ([]ItemClicks, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT fi.id, f.name, COALESCE(fi.title, ''), SUM(c.clicks) AS clicks
+		FROM item_clicks c
+		JOIN feed_items fi ON c.item_id = fi.id
+		JOIN feeds f ON fi.feed_id = f.id
+		WHERE c.day >= $2::date AND ($1 = '' OR f.name = $1)
+		GROUP BY fi.id, f.name
+		ORDER BY clicks DESC, fi.published_at DESC
+		LIMIT $3
+	`, feedName, since.Format(time.DateOnly), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top clicked items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []ItemClicks
+	for rows.Next() {
+		var item ItemClicks
+		if err := rows.Scan(&item.ItemID, &item.FeedName, &item.Title, &item.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan item clicks: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating item clicks: %w", err)
+	}
+
+	return items, nil
+}
@@ -1,15 +1,25 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
 	_ "github.com/lib/pq"
 )
 
+// DB wraps the connection pool with a prepared statement cache. Repository
+// queries are fixed strings, so each one is parsed and planned once per
+// connection instead of on every call. Only the Context variants go through
+// the cache, and repositories use nothing else so cancellation reaches every
+// query.
 type DB struct {
 	*sql.DB
+	stmts sync.Map // query text -> *sql.Stmt
+	slow  *slowQueryLog
 }
 
 func NewConnection(host, port, user, password, dbname string) (*DB, error) {
@@ -29,5 +39,70 @@ func NewConnection(host, port, user, password, dbname string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db}, nil
+}
+
+// stmt returns the cached prepared statement for query, preparing it on
+// first use. A nil statement means preparation failed and the caller should
+// run the query unprepared.
+func (db *DB) stmt(ctx context.Context, query string) *sql.Stmt {
+	if s, ok := db.stmts.Load(query); ok {
+		return s.(*sql.Stmt)
+	}
+
+	s, err := db.DB.PrepareContext(ctx, query)
+	if err != nil {
+		slog.Debug("Failed to prepare statement, running unprepared", "error", err)
+		return nil
+	}
+	if existing, loaded := db.stmts.LoadOrStore(query, s); loaded {
+		s.Close()
+		return existing.(*sql.Stmt)
+	}
+	return s
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	defer db.observe(query, time.Now())
+	if s := db.stmt(ctx, query); s != nil {
+		return s.ExecContext(ctx, args...)
+	}
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	defer db.observe(query, time.Now())
+	if s := db.stmt(ctx, query); s != nil {
+		return s.QueryContext(ctx, args...)
+	}
+	return db.DB.QueryContext(ctx, query, args...)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	defer db.observe(query, time.Now())
+	if s := db.stmt(ctx, query); s != nil {
+		return s.QueryRowContext(ctx, args...)
+	}
+	return db.DB.QueryRowContext(ctx, query, args...)
+}
+
+// Sizes returns the disk space used by the database and by the items table
+// (with its indexes and TOAST data), in bytes.
+func (db *DB) Sizes(ctx context.Context) (database, items int64, err error) {
+	err = db.QueryRowContext(ctx,
+		`SELECT pg_database_size(current_database()), pg_total_relation_size('feed_items')`).Scan(&database, &items)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get database size: %w", err)
+	}
+	return database, items, nil
+}
+
+// Close releases the cached statements and closes the pool.
+func (db *DB) Close() error {
+	db.stmts.Range(func(key, value any) bool {
+		value.(*sql.Stmt).Close()
+		db.stmts.Delete(key)
+		return true
+	})
+	return db.DB.Close()
 }
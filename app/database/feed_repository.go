@@ -1,6 +1,8 @@
 package database
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -18,23 +20,39 @@ func NewFeedRepository(db *DB) *FeedRepository {
 	return &FeedRepository{db: db}
 }
 
-func (r *FeedRepository) GetFeed(feedName string) (*Feed, error) {
+const feedColumns = `
+	id, name, feed_url, COALESCE(link, ''), COALESCE(title, ''), COALESCE(source_title, ''), COALESCE(description, ''), COALESCE(description_override, ''), COALESCE(image_url, ''), COALESCE(language, ''),
+	COALESCE(source_self_url, ''), COALESCE(source_hub_url, ''), COALESCE(http_etag, ''), COALESCE(http_last_modified, ''),
+	last_fetched_at, next_fetch_at, feed_published_at, feed_updated_at, created_at, updated_at,
+	feed_type, is_enabled, is_paused, settings, filters, config_hash,
+	config_version, config_changed_at, refiltered_config_version, refiltered_at,
+	last_success_at, last_error_at, COALESCE(last_error, ''), failing_since, error_count, ingest_cutoff_at, COALESCE(icon_url, ''), icon_checked_at,
+	COALESCE(itunes_author, ''), COALESCE(itunes_image, ''), COALESCE(itunes_explicit, ''), COALESCE(itunes_owner_name, ''), COALESCE(itunes_owner_email, '')`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFeed(row rowScanner) (*Feed, error) {
 	var feed Feed
-	err := r.db.QueryRow(`
-		SELECT id, name, feed_url, COALESCE(link, ''), COALESCE(title, ''), COALESCE(source_title, ''), COALESCE(description, ''), COALESCE(image_url, ''), COALESCE(language, ''),
-		       last_fetched_at, next_fetch_at, feed_published_at, feed_updated_at, created_at, updated_at,
-		       feed_type, is_enabled, settings, filters, config_hash,
-		       COALESCE(itunes_author, ''), COALESCE(itunes_image, ''), COALESCE(itunes_explicit, ''), COALESCE(itunes_owner_name, ''), COALESCE(itunes_owner_email, '')
-		FROM feeds
-		WHERE name = $1
-	`, feedName).Scan(
-		&feed.ID, &feed.Name, &feed.FeedURL, &feed.Link, &feed.Title, &feed.SourceTitle, &feed.Description, &feed.ImageURL, &feed.Language,
+	err := row.Scan(
+		&feed.ID, &feed.Name, &feed.FeedURL, &feed.Link, &feed.Title, &feed.SourceTitle, &feed.Description, &feed.DescriptionOverride, &feed.ImageURL, &feed.Language,
+		&feed.SourceSelfURL, &feed.SourceHubURL, &feed.HTTPETag, &feed.HTTPLastModified,
 		&feed.LastFetchedAt, &feed.NextFetchAt, &feed.FeedPublishedAt, &feed.FeedUpdatedAt,
 		&feed.CreatedAt, &feed.UpdatedAt,
-		&feed.FeedType, &feed.IsEnabled, &feed.Settings, &feed.Filters, &feed.ConfigHash,
+		&feed.FeedType, &feed.IsEnabled, &feed.IsPaused, &feed.Settings, &feed.Filters, &feed.ConfigHash,
+		&feed.ConfigVersion, &feed.ConfigChangedAt, &feed.RefilteredConfigVersion, &feed.RefilteredAt,
+		&feed.LastSuccessAt, &feed.LastErrorAt, &feed.LastError, &feed.FailingSince, &feed.ErrorCount, &feed.IngestCutoffAt, &feed.IconURL, &feed.IconCheckedAt,
 		&feed.ITunesAuthor, &feed.ITunesImage, &feed.ITunesExplicit, &feed.ITunesOwnerName, &feed.ITunesOwnerEmail,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
 
+func (r *FeedRepository) GetFeed(ctx context.Context, feedName string) (*Feed, error) {
+	feed, err := scanFeed(r.db.QueryRowContext(ctx, `SELECT `+feedColumns+` FROM feeds WHERE name = $1`, feedName))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -42,38 +60,320 @@ func (r *FeedRepository) GetFeed(feedName string) (*Feed, error) {
 		return nil, fmt.Errorf("failed to get feed by name: %w", err)
 	}
 
-	return &feed, nil
+	return feed, nil
+}
+
+// GetAllFeeds returns every configured feed ordered by name, regardless of enabled state.
+func (r *FeedRepository) GetAllFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+feedColumns+` FROM feeds ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feeds: %w", err)
+	}
+	defer rows.Close()
+
+	var feeds []Feed
+	for rows.Next() {
+		feed, err := scanFeed(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feed row: %w", err)
+		}
+		feeds = append(feeds, *feed)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feeds: %w", err)
+	}
+
+	return feeds, nil
+}
+
+// FeedListOptions selects, orders and pages the feeds returned by ListFeeds.
+// Zero values don't filter.
+type FeedListOptions struct {
+	Enabled  *bool
+	Type     string        // "basic" matches feeds without a type
+	Status   string        // "pending" (never fetched successfully), "failing" or "ok"
+	StaleFor time.Duration // Only feeds without a successful fetch for this long
+	Now      time.Time     // When StaleFor is measured back from
+	Sort     string        // A key of FeedListSorts; "" sorts by name
+	Desc     bool
+	Limit    int // 0 = all
+	Offset   int
+}
+
+// FeedListSorts maps the sort keys of ListFeeds to their columns.
+var FeedListSorts = map[string]string{
+	"name":            "name",
+	"last_fetched_at": "last_fetched_at",
+	"last_success_at": "last_success_at",
+	"failing_since":   "failing_since",
+	"error_count":     "error_count",
+}
+
+// ListFeeds returns a page of the feeds matching opts and the number of
+// matching feeds. Feeds without a value for the sort column come last;
+// ties are ordered by name.
+func (r *FeedRepository) ListFeeds(ctx context.Context, opts FeedListOptions) ([]Feed, int, error) {
+	column, ok := FeedListSorts[cmp.Or(opts.Sort, "name")]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown sort key %q", opts.Sort)
+	}
+	direction := "ASC"
+	if opts.Desc {
+		direction = "DESC"
+	}
+
+	where := `
+		WHERE ($1::boolean IS NULL OR is_enabled = $1)
+		  AND ($2 = '' OR COALESCE(NULLIF(feed_type, ''), 'basic') = $2)
+		  AND ($3 = ''
+		       OR ($3 = 'pending' AND last_success_at IS NULL)
+		       OR ($3 = 'failing' AND last_success_at IS NOT NULL AND failing_since IS NOT NULL)
+		       OR ($3 = 'ok' AND last_success_at IS NOT NULL AND failing_since IS NULL))
+		  AND ($4::float8 = 0 OR last_success_at IS NULL OR last_success_at < $5::timestamptz - make_interval(secs => $4::float8))`
+	args := []any{opts.Enabled, opts.Type, opts.Status, opts.StaleFor.Seconds(), opts.Now}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM feeds`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count feeds: %w", err)
+	}
+
+	query := `SELECT ` + feedColumns + ` FROM feeds` + where +
+		` ORDER BY ` + column + ` ` + direction + ` NULLS LAST, name OFFSET $6`
+	args = append(args, opts.Offset)
+	if opts.Limit > 0 {
+		query += ` LIMIT $7`
+		args = append(args, opts.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list feeds: %w", err)
+	}
+	defer rows.Close()
+
+	var feeds []Feed
+	for rows.Next() {
+		feed, err := scanFeed(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan feed row: %w", err)
+		}
+		feeds = append(feeds, *feed)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating feeds: %w", err)
+	}
+
+	return feeds, total, nil
 }
 
-func (r *FeedRepository) GetFeedCount() (int, error) {
+func (r *FeedRepository) GetFeedCount(ctx context.Context) (int, error) {
 	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM feeds").Scan(&count)
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM feeds").Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get feed count: %w", err)
 	}
 	return count, nil
 }
 
-func (r *FeedRepository) UpdateFeedMetadata(feedName string, metadata *types.Metadata, nextFetchAt time.Time) error {
-	_, err := r.db.Exec(`
+// FeedStatusCounts counts feeds by configuration and fetch state.
+type FeedStatusCounts struct {
+	Total    int
+	Enabled  int
+	Paused   int
+	Pending  int // Never fetched successfully
+	OK       int
+	Failing  int
+	Mirrored int
+}
+
+func (r *FeedRepository) GetFeedStatusCounts(ctx context.Context) (*FeedStatusCounts, error) {
+	var counts FeedStatusCounts
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE is_enabled), COUNT(*) FILTER (WHERE is_paused),
+		       COUNT(*) FILTER (WHERE last_success_at IS NULL),
+		       COUNT(*) FILTER (WHERE last_success_at IS NOT NULL AND failing_since IS NULL),
+		       COUNT(*) FILTER (WHERE last_success_at IS NOT NULL AND failing_since IS NOT NULL),
+		       COUNT(*) FILTER (WHERE (settings->>'mirror')::boolean)
+		FROM feeds
+	`).Scan(&counts.Total, &counts.Enabled, &counts.Paused, &counts.Pending, &counts.OK, &counts.Failing, &counts.Mirrored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed status counts: %w", err)
+	}
+	return &counts, nil
+}
+
+// UpdateFeedMetadata stores the metadata of a successful fetch made at now.
+// It returns types.ErrFeedNotFound if the feed was removed meanwhile.
+func (r *FeedRepository) UpdateFeedMetadata(ctx context.Context, feedName string, metadata *types.Metadata, nextFetchAt, now time.Time) error {
+	result, err := r.db.ExecContext(ctx, `
 		UPDATE feeds
 		SET source_title = $2, link = $3, description = $4, image_url = $5, language = $6, feed_published_at = $7, feed_updated_at = $8,
-		    next_fetch_at = $9, last_fetched_at = NOW(), updated_at = NOW(),
-		    itunes_author = $10, itunes_image = $11, itunes_explicit = $12, itunes_owner_name = $13, itunes_owner_email = $14
+		    next_fetch_at = $9, last_fetched_at = $17, last_success_at = $17, updated_at = NOW(), failing_since = NULL, error_count = 0,
+		    itunes_author = $10, itunes_image = $11, itunes_explicit = $12, itunes_owner_name = $13, itunes_owner_email = $14,
+		    source_self_url = NULLIF($15, ''), source_hub_url = NULLIF($16, '')
 		WHERE name = $1
 	`, feedName, metadata.Title, metadata.Link, metadata.Description, metadata.ImageURL, metadata.Language, metadata.FeedPublishedAt, metadata.FeedUpdatedAt, nextFetchAt,
-		metadata.ITunesAuthor, metadata.ITunesImage, metadata.ITunesExplicit, metadata.ITunesOwnerName, metadata.ITunesOwnerEmail)
+		metadata.ITunesAuthor, metadata.ITunesImage, metadata.ITunesExplicit, metadata.ITunesOwnerName, metadata.ITunesOwnerEmail,
+		metadata.SelfURL, metadata.HubURL, now)
 
 	if err != nil {
 		return fmt.Errorf("failed to update feed metadata: %w", err)
 	}
 
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return types.ErrFeedNotFound
+	}
+
+	return nil
+}
+
+// MarkFetched records a successful fetch made at now that didn't parse the
+// source, such as a mirrored feed's, leaving the stored metadata as it is.
+func (r *FeedRepository) MarkFetched(ctx context.Context, feedName string, nextFetchAt, now time.Time) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE feeds
+		SET next_fetch_at = $2, last_fetched_at = $3, last_success_at = $3, updated_at = NOW(), failing_since = NULL, error_count = 0
+		WHERE name = $1
+	`, feedName, nextFetchAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to mark feed fetched: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return types.ErrFeedNotFound
+	}
+
+	return nil
+}
+
+// SaveMirror stores the upstream document of a mirrored feed. changed_at
+// only moves when the document's ETag does, so it can serve as
+// Last-Modified.
+func (r *FeedRepository) SaveMirror(ctx context.Context, feedID string, body []byte, contentType, etag string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO feed_mirrors (feed_id, body, content_type, etag)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (feed_id) DO UPDATE
+		SET body = EXCLUDED.body, content_type = EXCLUDED.content_type, etag = EXCLUDED.etag, changed_at = NOW()
+		WHERE feed_mirrors.etag <> EXCLUDED.etag
+	`, feedID, body, contentType, etag)
+	if err != nil {
+		return fmt.Errorf("failed to save feed mirror: %w", err)
+	}
+	return nil
+}
+
+// GetMirror returns the stored upstream document of a mirrored feed, or nil
+// if none was fetched yet.
+func (r *FeedRepository) GetMirror(ctx context.Context, feedID string) (*Mirror, error) {
+	var m Mirror
+	err := r.db.QueryRowContext(ctx, `
+		SELECT body, content_type, etag, changed_at FROM feed_mirrors WHERE feed_id = $1
+	`, feedID).Scan(&m.Body, &m.ContentType, &m.ETag, &m.ChangedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed mirror: %w", err)
+	}
+	return &m, nil
+}
+
+// MarkNotModified records a fetch made at now that the source answered with
+// 304 Not Modified: a success that leaves the feed's items, and so
+// updated_at and the cached documents, as they are.
+func (r *FeedRepository) MarkNotModified(ctx context.Context, feedName string, nextFetchAt, now time.Time) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE feeds
+		SET next_fetch_at = $2, last_fetched_at = $3, last_success_at = $3, failing_since = NULL, error_count = 0
+		WHERE name = $1
+	`, feedName, nextFetchAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to mark feed not modified: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return types.ErrFeedNotFound
+	}
+
 	return nil
 }
 
-func (r *FeedRepository) UpsertFeedConfig(feedName string, feedURL string, title string, feedType string, isEnabled bool, settings interface{}, filters interface{}, configHash string) error {
+// SetHTTPValidators stores the ETag and Last-Modified of the source response
+// whose items were processed; empty values clear them.
+func (r *FeedRepository) SetHTTPValidators(ctx context.Context, feedName string, etag, lastModified string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE feeds SET http_etag = NULLIF($2, ''), http_last_modified = NULLIF($3, '')
+		WHERE name = $1
+	`, feedName, etag, lastModified)
+	if err != nil {
+		return fmt.Errorf("failed to set HTTP validators: %w", err)
+	}
+	return nil
+}
+
+// RecordFetchError stores the latest fetch error. failing_since keeps the
+// time of the first failure and error_count the failures in a row until a
+// fetch succeeds again; last_error and last_error_at are kept after that,
+// so past failures stay visible.
+func (r *FeedRepository) RecordFetchError(ctx context.Context, feedName string, fetchErr string, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE feeds
+		SET last_error = $2, last_error_at = $3, last_fetched_at = $3, failing_since = COALESCE(failing_since, $3),
+		    error_count = error_count + 1
+		WHERE name = $1
+	`, feedName, fetchErr, now)
+	if err != nil {
+		return fmt.Errorf("failed to record fetch error: %w", err)
+	}
+	return nil
+}
+
+// SetIngestCutoff stops items published before cutoff from being ingested.
+func (r *FeedRepository) SetIngestCutoff(ctx context.Context, feedName string, cutoff time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE feeds SET ingest_cutoff_at = $2 WHERE name = $1`, feedName, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to set ingest cutoff: %w", err)
+	}
+	return nil
+}
+
+// SetIcon stores the site icon found for a feed; an empty iconURL records
+// that none was found. Either way the lookup isn't repeated until the
+// check expires.
+func (r *FeedRepository) SetIcon(ctx context.Context, feedName string, iconURL string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE feeds SET icon_url = NULLIF($2, ''), icon_checked_at = NOW()
+		WHERE name = $1
+	`, feedName, iconURL)
+	if err != nil {
+		return fmt.Errorf("failed to set feed icon: %w", err)
+	}
+	return nil
+}
+
+// configHistoryLimit is how many configuration versions are kept per feed.
+const configHistoryLimit = 10
+
+// UpsertFeedConfig stores a feed's configuration. A changed config hash bumps
+// the feed's config version and adds the new config to its history; changed
+// filters or settings also make the feed due for a refilter at now.
+func (r *FeedRepository) UpsertFeedConfig(ctx context.Context, feedName string, feedURL string, title string, description string, feedType string, isEnabled bool, settings interface{}, filters interface{}, configHash string, now time.Time) error {
 	var existingHash *string
-	err := r.db.QueryRow("SELECT config_hash FROM feeds WHERE name = $1", feedName).Scan(&existingHash)
+	err := r.db.QueryRowContext(ctx, "SELECT config_hash FROM feeds WHERE name = $1", feedName).Scan(&existingHash)
 	if err != nil && err != sql.ErrNoRows {
 		return fmt.Errorf("failed to check existing config hash: %w", err)
 	}
@@ -98,46 +398,193 @@ func (r *FeedRepository) UpsertFeedConfig(feedName string, feedURL string, title
 		return fmt.Errorf("failed to marshal filters: %w", err)
 	}
 
-	_, err = r.db.Exec(`
-		INSERT INTO feeds (name, feed_url, title, feed_type, is_enabled, settings, filters, config_hash)
-		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8)
-		ON CONFLICT (name) DO UPDATE SET
-			feed_url = EXCLUDED.feed_url,
-			title = NULLIF($3, ''),
-			feed_type = EXCLUDED.feed_type,
-			is_enabled = EXCLUDED.is_enabled,
-			settings = EXCLUDED.settings,
-			filters = EXCLUDED.filters,
-			config_hash = EXCLUDED.config_hash,
-			next_fetch_at = CASE
-				WHEN feeds.feed_url != EXCLUDED.feed_url OR feeds.config_hash != EXCLUDED.config_hash
-				THEN NULL
-				ELSE feeds.next_fetch_at
-			END,
-			updated_at = NOW()
-	`, feedName, feedURL, title, feedType, isEnabled, settingsJSON, filtersJSON, configHash)
+	_, err = r.db.ExecContext(ctx, `
+		WITH upserted AS (
+			INSERT INTO feeds (name, feed_url, title, feed_type, is_enabled, settings, filters, config_hash, config_version, config_changed_at, description_override)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, 1, NOW(), NULLIF($9, ''))
+			ON CONFLICT (name) DO UPDATE SET
+				feed_url = EXCLUDED.feed_url,
+				title = NULLIF($3, ''),
+				description_override = NULLIF($9, ''),
+				feed_type = EXCLUDED.feed_type,
+				is_enabled = EXCLUDED.is_enabled,
+				settings = EXCLUDED.settings,
+				filters = EXCLUDED.filters,
+				config_hash = EXCLUDED.config_hash,
+				config_version = feeds.config_version + 1,
+				config_changed_at = NOW(),
+				http_etag = NULL,
+				http_last_modified = NULL,
+				next_fetch_at = CASE
+					WHEN feeds.feed_url != EXCLUDED.feed_url OR feeds.config_hash != EXCLUDED.config_hash
+					THEN NULL
+					ELSE feeds.next_fetch_at
+				END,
+				refilter_at = CASE
+					WHEN feeds.filters IS DISTINCT FROM EXCLUDED.filters OR feeds.settings IS DISTINCT FROM EXCLUDED.settings
+					THEN $10::timestamptz
+					ELSE feeds.refilter_at
+				END,
+				updated_at = NOW()
+			RETURNING id, config_version, config_hash, settings, filters
+		)
+		INSERT INTO feed_config_history (feed_id, version, config_hash, settings, filters)
+		SELECT id, config_version, config_hash, settings, filters FROM upserted
+	`, feedName, feedURL, title, feedType, isEnabled, settingsJSON, filtersJSON, configHash, description, now)
 
 	if err != nil {
 		return fmt.Errorf("failed to upsert feed config: %w", err)
 	}
 
+	_, err = r.db.ExecContext(ctx, `
+		DELETE FROM feed_config_history h
+		USING feeds f
+		WHERE h.feed_id = f.id AND f.name = $1 AND h.version <= f.config_version - $2
+	`, feedName, configHistoryLimit)
+	if err != nil {
+		return fmt.Errorf("failed to prune config history: %w", err)
+	}
+
+	return nil
+}
+
+// GetConfigHistory returns the stored configuration versions of a feed,
+// newest first.
+func (r *FeedRepository) GetConfigHistory(ctx context.Context, feedID string) ([]ConfigRevision, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT version, config_hash, settings, filters, created_at
+		FROM feed_config_history
+		WHERE feed_id = $1
+		ORDER BY version DESC
+	`, feedID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []ConfigRevision
+	for rows.Next() {
+		var rev ConfigRevision
+		if err := rows.Scan(&rev.Version, &rev.ConfigHash, &rev.Settings, &rev.Filters, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan config history row: %w", err)
+		}
+		history = append(history, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating config history: %w", err)
+	}
+
+	return history, nil
+}
+
+// MarkRefiltered records which config version the feed's items were last
+// refiltered with.
+func (r *FeedRepository) MarkRefiltered(ctx context.Context, feedName string, configVersion int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE feeds SET refiltered_config_version = $2, refiltered_at = NOW() WHERE name = $1
+	`, feedName, configVersion)
+	if err != nil {
+		return fmt.Errorf("failed to mark feed refiltered: %w", err)
+	}
+	return nil
+}
+
+// SetFeedPaused pauses or resumes fetching of a feed. Returns false if the
+// feed does not exist.
+func (r *FeedRepository) SetFeedPaused(ctx context.Context, feedName string, paused bool) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE feeds SET is_paused = $2 WHERE name = $1`, feedName, paused)
+	if err != nil {
+		return false, fmt.Errorf("failed to update feed pause state: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// SetRefilterAt records when the feed's items next need refiltering because
+// a timed filter rule expires (nil = never).
+func (r *FeedRepository) SetRefilterAt(ctx context.Context, feedName string, at *time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE feeds SET refilter_at = $2 WHERE name = $1`, feedName, at)
+	if err != nil {
+		return fmt.Errorf("failed to set refilter time: %w", err)
+	}
+	return nil
+}
+
+// ScheduleRefilter is SetRefilterAt that keeps an already due refilter, so a
+// rule that expired while the server was down is still applied.
+func (r *FeedRepository) ScheduleRefilter(ctx context.Context, feedName string, at *time.Time, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE feeds SET refilter_at = CASE WHEN refilter_at <= $3 THEN refilter_at ELSE $2 END
+		WHERE name = $1
+	`, feedName, at, now)
+	if err != nil {
+		return fmt.Errorf("failed to schedule refilter: %w", err)
+	}
+	return nil
+}
+
+// ScheduleRefilterAll makes every feed due for a refilter, e.g. after the
+// global blocklist changed.
+func (r *FeedRepository) ScheduleRefilterAll(ctx context.Context, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE feeds SET refilter_at = $1`, now)
+	if err != nil {
+		return fmt.Errorf("failed to schedule refilter: %w", err)
+	}
 	return nil
 }
 
+// GetFeedsDueRefilter returns feeds whose timed filter rules have expired
+// since their items were last filtered.
+func (r *FeedRepository) GetFeedsDueRefilter(ctx context.Context, now time.Time) ([]FeedScheduleInfo, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, next_fetch_at
+		FROM feeds
+		WHERE refilter_at <= $1
+		ORDER BY refilter_at
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feeds due refilter: %w", err)
+	}
+	defer rows.Close()
+
+	var feeds []FeedScheduleInfo
+	for rows.Next() {
+		var feed FeedScheduleInfo
+		if err := rows.Scan(&feed.ID, &feed.Name, &feed.NextFetchAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feed schedule info: %w", err)
+		}
+		feeds = append(feeds, feed)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feeds: %w", err)
+	}
+
+	return feeds, nil
+}
+
 type FeedScheduleInfo struct {
 	ID          string
 	Name        string
 	NextFetchAt *time.Time
 }
 
-func (r *FeedRepository) GetDueFeeds() ([]FeedScheduleInfo, error) {
-	rows, err := r.db.Query(`
+// GetDueFeeds returns enabled feeds that are due for fetching at now:
+// high-priority feeds first, then never-fetched and most overdue first.
+func (r *FeedRepository) GetDueFeeds(ctx context.Context, now time.Time) ([]FeedScheduleInfo, error) {
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT id, name, next_fetch_at
 		FROM feeds
-		WHERE is_enabled = true
-		  AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
-		ORDER BY name
-	`)
+		WHERE is_enabled = true AND is_paused = false
+		  AND (next_fetch_at IS NULL OR next_fetch_at <= $1)
+		ORDER BY COALESCE(settings->>'priority', '') = 'high' DESC, next_fetch_at NULLS FIRST, name
+	`, now)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get due feeds: %w", err)
 	}
@@ -160,23 +607,8 @@ func (r *FeedRepository) GetDueFeeds() ([]FeedScheduleInfo, error) {
 	return feeds, nil
 }
 
-func (r *FeedRepository) GetFeedByID(feedID string) (*Feed, error) {
-	var feed Feed
-	err := r.db.QueryRow(`
-		SELECT id, name, feed_url, COALESCE(link, ''), COALESCE(title, ''), COALESCE(source_title, ''), COALESCE(description, ''), COALESCE(image_url, ''), COALESCE(language, ''),
-		       last_fetched_at, next_fetch_at, feed_published_at, feed_updated_at, created_at, updated_at,
-		       feed_type, is_enabled, settings, filters, config_hash,
-		       COALESCE(itunes_author, ''), COALESCE(itunes_image, ''), COALESCE(itunes_explicit, ''), COALESCE(itunes_owner_name, ''), COALESCE(itunes_owner_email, '')
-		FROM feeds
-		WHERE id = $1
-	`, feedID).Scan(
-		&feed.ID, &feed.Name, &feed.FeedURL, &feed.Link, &feed.Title, &feed.SourceTitle, &feed.Description, &feed.ImageURL, &feed.Language,
-		&feed.LastFetchedAt, &feed.NextFetchAt, &feed.FeedPublishedAt, &feed.FeedUpdatedAt,
-		&feed.CreatedAt, &feed.UpdatedAt,
-		&feed.FeedType, &feed.IsEnabled, &feed.Settings, &feed.Filters, &feed.ConfigHash,
-		&feed.ITunesAuthor, &feed.ITunesImage, &feed.ITunesExplicit, &feed.ITunesOwnerName, &feed.ITunesOwnerEmail,
-	)
-
+func (r *FeedRepository) GetFeedByID(ctx context.Context, feedID string) (*Feed, error) {
+	feed, err := scanFeed(r.db.QueryRowContext(ctx, `SELECT `+feedColumns+` FROM feeds WHERE id = $1`, feedID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -184,5 +616,5 @@ func (r *FeedRepository) GetFeedByID(feedID string) (*Feed, error) {
 		return nil, fmt.Errorf("failed to get feed by ID: %w", err)
 	}
 
-	return &feed, nil
+	return feed, nil
 }
@@ -1,48 +1,362 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/lysyi3m/rss-comb/app/storage"
 	"github.com/lysyi3m/rss-comb/app/types"
 )
 
 type ItemRepository struct {
-	db *DB
+	db           *DB
+	reader       *DB
+	contentStore storage.ContentStore
+	onChange     []func(feedID string)
 }
 
-func NewItemRepository(db *DB) *ItemRepository {
-	return &ItemRepository{db: db}
+// NewItemRepository creates an item repository. Listings served to readers
+// (GetVisibleItems, GetLatestItems, GetItemCounts, GetSeries,
+// GetFeedTimeline, GetFeedOverlaps) go to reader
+// when it is non-nil; everything else, including reads that feed back into
+// writes, uses the primary. With a non-nil contentStore, extracted content
+// is written to the store and only its key is kept in the database.
+func NewItemRepository(db, reader *DB, contentStore storage.ContentStore) *ItemRepository {
+	if reader == nil {
+		reader = db
+	}
+	return &ItemRepository{db: db, reader: reader, contentStore: contentStore}
+}
+
+// OnChange registers fn to be called with the feed ID after any write to
+// that feed's items, after the functions registered before it. Register
+// before the repository is shared between goroutines.
+func (r *ItemRepository) OnChange(fn func(feedID string)) {
+	r.onChange = append(r.onChange, fn)
+}
+
+func (r *ItemRepository) changed(feedID string) {
+	for _, fn := range r.onChange {
+		fn(feedID)
+	}
+}
+
+// updateItem runs an UPDATE of a single item (matched by id) and reports the
+// change for the item's feed. A missing item is not an error.
+func (r *ItemRepository) updateItem(ctx context.Context, query string, args ...any) error {
+	var feedID string
+	err := r.db.QueryRowContext(ctx, query+` RETURNING feed_id`, args...).Scan(&feedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.changed(feedID)
+	return nil
+}
+
+const itemColumns = `
+	fi.id, fi.feed_id, fi.guid, COALESCE(fi.link, ''), COALESCE(fi.title, ''),
+	COALESCE(fi.description, ''), COALESCE(fi.content, ''),
+	fi.published_at, fi.updated_at, COALESCE(fi.authors, '{}'),
+	COALESCE(fi.categories, '{}'),
+	fi.is_filtered,
+	fi.content_hash, fi.created_at,
+	COALESCE(fi.enclosure_url, ''), COALESCE(fi.enclosure_length, 0), COALESCE(fi.enclosure_type, ''),
+	COALESCE(fi.itunes_duration, 0), COALESCE(fi.itunes_episode, 0), COALESCE(fi.itunes_season, 0), COALESCE(fi.itunes_episode_type, ''), COALESCE(fi.itunes_image, ''),
+	fi.content_extraction_status,
+	fi.media_status, COALESCE(fi.media_path, ''), COALESCE(fi.media_size, 0),
+	COALESCE(fi.series_id, ''), fi.score, fi.released_at,
+	COALESCE(fi.previous_title, ''), COALESCE(fi.previous_description, ''), fi.changed_at,
+	COALESCE(fi.content_ref, ''), COALESCE(fi.content_extraction_note, ''),
+	fi.manual_filter, COALESCE(fi.manual_filter_reason, ''), fi.manual_filter_at,
+	fi.read_later_saved_at, COALESCE(fi.magnet_uri, '')`
+
+func scanItem(row rowScanner) (*Item, error) {
+	var item Item
+	err := row.Scan(
+		&item.ID, &item.FeedID, &item.GUID, &item.Link, &item.Title,
+		&item.Description, &item.Content, &item.PublishedAt, &item.UpdatedAt,
+		pq.Array(&item.Authors), pq.Array(&item.Categories),
+		&item.IsFiltered,
+		&item.ContentHash, &item.CreatedAt,
+		&item.EnclosureURL, &item.EnclosureLength, &item.EnclosureType,
+		&item.ITunesDuration, &item.ITunesEpisode, &item.ITunesSeason, &item.ITunesEpisodeType, &item.ITunesImage,
+		&item.ContentExtractionStatus,
+		&item.MediaStatus, &item.MediaPath, &item.MediaSize,
+		&item.SeriesID, &item.Score, &item.ReleasedAt,
+		&item.PreviousTitle, &item.PreviousDescription, &item.ChangedAt,
+		&item.ContentRef, &item.ExtractionNote,
+		&item.ManualFilter, &item.ManualFilterReason, &item.ManualFilterAt,
+		&item.ReadLaterSavedAt, &item.MagnetURI,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
 }
 
-func (r *ItemRepository) GetAllItems(feedName string) ([]Item, error) {
-	rows, err := r.db.Query(`
-		SELECT fi.id, fi.guid, COALESCE(fi.link, ''), COALESCE(fi.title, ''),
-		       COALESCE(fi.description, ''), COALESCE(fi.content, ''),
-		       fi.published_at, fi.updated_at, COALESCE(fi.authors, '{}'),
-		       COALESCE(fi.categories, '{}'),
-		       fi.is_filtered,
-		       fi.content_hash, fi.created_at,
-		       COALESCE(fi.enclosure_url, ''), COALESCE(fi.enclosure_length, 0), COALESCE(fi.enclosure_type, ''),
-		       COALESCE(fi.itunes_duration, 0), COALESCE(fi.itunes_episode, 0), COALESCE(fi.itunes_season, 0), COALESCE(fi.itunes_episode_type, ''), COALESCE(fi.itunes_image, ''),
-		       fi.content_extraction_status,
-		       fi.media_status, COALESCE(fi.media_path, ''), COALESCE(fi.media_size, 0)
+func (r *ItemRepository) GetAllItems(ctx context.Context, feedID string) ([]Item, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+itemColumns+`
 		FROM feed_items fi
-		JOIN feeds f ON fi.feed_id = f.id
-		WHERE f.name = $1
+		WHERE fi.feed_id = $1
 		ORDER BY fi.published_at DESC
-	`, feedName)
+	`, feedID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all items: %w", err)
 	}
 	defer rows.Close()
 
+	items, err := r.scanItemRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	r.resolveContent(items)
+	return items, nil
+}
+
+// GetLatestItems returns the most recently stored items of a feed, including
+// filtered and not-yet-ready ones, newest insertion first. A non-empty
+// extractionStatus keeps only items with that content extraction status,
+// "none" meaning items that were never queued for extraction.
+func (r *ItemRepository) GetLatestItems(ctx context.Context, feedID string, limit int, extractionStatus string) ([]Item, error) {
+	rows, err := r.reader.QueryContext(ctx, `
+		SELECT `+itemColumns+`
+		FROM feed_items fi
+		WHERE fi.feed_id = $1
+		  AND ($3 = '' OR COALESCE(fi.content_extraction_status, 'none') = $3)
+		ORDER BY fi.created_at DESC, fi.published_at DESC
+		LIMIT $2
+	`, feedID, limit, extractionStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest items: %w", err)
+	}
+	defer rows.Close()
+
 	return r.scanItemRows(rows)
 }
 
-func (r *ItemRepository) UpsertItem(feedName string, item types.Item) (string, error) {
+type ItemCounts struct {
+	Total      int
+	Filtered   int
+	Extraction ExtractionCounts
+}
+
+// ExtractionCounts counts a feed's items by content extraction status.
+type ExtractionCounts struct {
+	Pending  int
+	Ready    int
+	Fallback int // Extracted but rejected as boilerplate
+	Failed   int
+}
+
+// InstanceItemCounts counts the items of all feeds. CrossFeedDuplicates are
+// items whose content hash is also stored for another feed.
+type InstanceItemCounts struct {
+	ItemCounts
+	CrossFeedDuplicates int
+}
+
+func (r *ItemRepository) GetInstanceItemCounts(ctx context.Context) (*InstanceItemCounts, error) {
+	var counts InstanceItemCounts
+	err := r.reader.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE fi.is_filtered),
+		       COUNT(*) FILTER (WHERE fi.content_extraction_status = 'pending'),
+		       COUNT(*) FILTER (WHERE fi.content_extraction_status = 'ready'),
+		       COUNT(*) FILTER (WHERE fi.content_extraction_status = 'fallback'),
+		       COUNT(*) FILTER (WHERE fi.content_extraction_status = 'failed'),
+		       (SELECT COALESCE(SUM(n), 0) FROM (
+		           SELECT COUNT(*) AS n FROM feed_items
+		           GROUP BY content_hash HAVING COUNT(DISTINCT feed_id) > 1) shared)
+		FROM feed_items fi
+	`).Scan(&counts.Total, &counts.Filtered,
+		&counts.Extraction.Pending, &counts.Extraction.Ready, &counts.Extraction.Fallback, &counts.Extraction.Failed,
+		&counts.CrossFeedDuplicates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance item counts: %w", err)
+	}
+	return &counts, nil
+}
+
+type DayCount struct {
+	Day      time.Time
+	Stored   int
+	Filtered int
+}
+
+// GetItemsPerDay counts the items stored per day across all feeds since the
+// given day, with days in the given time zone as in GetFeedTimeline. Days
+// without items are left out.
+func (r *ItemRepository) GetItemsPerDay(ctx context.Context, since time.Time, timezone string) ([]DayCount, error) {
+	rows, err := r.reader.QueryContext(ctx, `
+		SELECT `+localDay(2)+`::date AS day, COUNT(*), COUNT(*) FILTER (WHERE fi.is_filtered)
+		FROM feed_items fi
+		WHERE fi.created_at >= $1::date - interval '1 day'
+		  AND `+localDay(2)+` >= $1::date
+		GROUP BY day
+		ORDER BY day
+	`, since.Format(time.DateOnly), timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items per day: %w", err)
+	}
+	defer rows.Close()
+
+	var days []DayCount
+	for rows.Next() {
+		var d DayCount
+		if err := rows.Scan(&d.Day, &d.Stored, &d.Filtered); err != nil {
+			return nil, fmt.Errorf("failed to scan day count: %w", err)
+		}
+		days = append(days, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating day counts: %w", err)
+	}
+
+	return days, nil
+}
+
+// localDay is the SQL for the local midnight of the day an item was stored,
+// in the time zone of query parameter tz. created_at holds UTC wall time, so
+// it is read as UTC before the conversion.
+func localDay(tz int) string {
+	return fmt.Sprintf(`date_trunc('day', (fi.created_at AT TIME ZONE 'UTC') AT TIME ZONE $%d)`, tz)
+}
+
+// GetFeedTimeline counts the items a feed stored on each of the last days
+// days up to now (today included) in the given time zone, with zero for
+// days without items.
+func (r *ItemRepository) GetFeedTimeline(ctx context.Context, feedID string, days int, timezone string, now time.Time) ([]DayCount, error) {
+	rows, err := r.reader.QueryContext(ctx, `
+		SELECT d.day::date, COUNT(fi.id), COUNT(fi.id) FILTER (WHERE fi.is_filtered)
+		FROM generate_series(
+		         date_trunc('day', $4::timestamptz AT TIME ZONE $3) - make_interval(days => $2 - 1),
+		         date_trunc('day', $4::timestamptz AT TIME ZONE $3),
+		         interval '1 day') AS d(day)
+		LEFT JOIN feed_items fi
+		       ON fi.feed_id = $1
+		      AND fi.created_at >= ($4::timestamptz AT TIME ZONE 'UTC') - make_interval(days => $2 + 1)
+		      AND `+localDay(3)+` = d.day
+		GROUP BY d.day
+		ORDER BY d.day
+	`, feedID, days, timezone, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed timeline: %w", err)
+	}
+	defer rows.Close()
+
+	var timeline []DayCount
+	for rows.Next() {
+		var d DayCount
+		if err := rows.Scan(&d.Day, &d.Stored, &d.Filtered); err != nil {
+			return nil, fmt.Errorf("failed to scan day count: %w", err)
+		}
+		timeline = append(timeline, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating day counts: %w", err)
+	}
+
+	return timeline, nil
+}
+
+// FeedOverlap is a pair of feeds storing some of the same items (by content
+// hash). Overlap is Shared divided by the smaller feed's item count.
+type FeedOverlap struct {
+	FeedA, FeedB   string
+	Shared         int
+	ItemsA, ItemsB int
+	Overlap        float64
+}
+
+// GetFeedOverlaps returns the pairs of feeds whose overlap is at least
+// minOverlap, largest overlap first.
+func (r *ItemRepository) GetFeedOverlaps(ctx context.Context, minOverlap float64) ([]FeedOverlap, error) {
+	rows, err := r.reader.QueryContext(ctx, `
+		WITH hashes AS (
+			SELECT DISTINCT feed_id, content_hash FROM feed_items
+		), sizes AS (
+			SELECT feed_id, COUNT(*) AS n FROM hashes GROUP BY feed_id
+		), pairs AS (
+			SELECT a.feed_id AS a, b.feed_id AS b, COUNT(*) AS shared
+			FROM hashes a
+			JOIN hashes b ON a.content_hash = b.content_hash AND a.feed_id < b.feed_id
+			GROUP BY a.feed_id, b.feed_id
+		)
+		SELECT fa.name, fb.name, p.shared, sa.n, sb.n, p.shared::float8 / LEAST(sa.n, sb.n) AS overlap
+		FROM pairs p
+		JOIN feeds fa ON fa.id = p.a
+		JOIN feeds fb ON fb.id = p.b
+		JOIN sizes sa ON sa.feed_id = p.a
+		JOIN sizes sb ON sb.feed_id = p.b
+		WHERE p.shared::float8 / LEAST(sa.n, sb.n) >= $1
+		ORDER BY overlap DESC, p.shared DESC, fa.name, fb.name
+	`, minOverlap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed overlaps: %w", err)
+	}
+	defer rows.Close()
+
+	var overlaps []FeedOverlap
+	for rows.Next() {
+		var o FeedOverlap
+		if err := rows.Scan(&o.FeedA, &o.FeedB, &o.Shared, &o.ItemsA, &o.ItemsB, &o.Overlap); err != nil {
+			return nil, fmt.Errorf("failed to scan feed overlap: %w", err)
+		}
+		overlaps = append(overlaps, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feed overlaps: %w", err)
+	}
+
+	return overlaps, nil
+}
+
+func (r *ItemRepository) GetItemCounts(ctx context.Context, feedID string) (*ItemCounts, error) {
+	var counts ItemCounts
+	err := r.reader.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE fi.is_filtered),
+		       COUNT(*) FILTER (WHERE fi.content_extraction_status = 'pending'),
+		       COUNT(*) FILTER (WHERE fi.content_extraction_status = 'ready'),
+		       COUNT(*) FILTER (WHERE fi.content_extraction_status = 'fallback'),
+		       COUNT(*) FILTER (WHERE fi.content_extraction_status = 'failed')
+		FROM feed_items fi
+		WHERE fi.feed_id = $1
+	`, feedID).Scan(&counts.Total, &counts.Filtered,
+		&counts.Extraction.Pending, &counts.Extraction.Ready, &counts.Extraction.Fallback, &counts.Extraction.Failed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item counts: %w", err)
+	}
+
+	return &counts, nil
+}
+
+// upsertTitle is the title UpsertItem's conflict clause stores: the
+// incoming one, unless extraction replaced the stored one with the page's
+// (see UpdateItemTitle), which beats the URL-derived stand-in a sitemap
+// lists again when the page's lastmod changes.
+const upsertTitle = `CASE WHEN feed_items.title_extracted THEN feed_items.title ELSE EXCLUDED.title END`
+
+// itemChanged is true in UpsertItem's conflict clause when the incoming
+// version of an item differs in title or description from the stored one.
+const itemChanged = `(feed_items.title IS DISTINCT FROM ` + upsertTitle + ` OR feed_items.description IS DISTINCT FROM EXCLUDED.description)`
+
+// UpsertItem stores an item seen at now: inserted as created then, or
+// updated in place, with changed_at set to now when its title or
+// description differ from the stored ones. An extracted title is kept;
+// extracted content is dropped, from the content store too.
+func (r *ItemRepository) UpsertItem(ctx context.Context, feedID string, item types.Item, now time.Time) (string, error) {
 	authors := item.Authors
 	if authors == nil {
 		authors = []string{}
@@ -53,8 +367,9 @@ func (r *ItemRepository) UpsertItem(feedName string, item types.Item) (string, e
 		categories = []string{}
 	}
 
-	var itemID string
-	err := r.db.QueryRow(`
+	var itemID, oldContentRef string
+	err := r.db.QueryRowContext(ctx, `
+		WITH old AS (SELECT content_ref FROM feed_items WHERE feed_id = $1 AND guid = $2)
 		INSERT INTO feed_items (
 			feed_id, guid, link, title, description, content,
 			published_at, updated_at, authors,
@@ -62,19 +377,25 @@ func (r *ItemRepository) UpsertItem(feedName string, item types.Item) (string, e
 			enclosure_url, enclosure_length, enclosure_type,
 			itunes_duration, itunes_episode, itunes_season, itunes_episode_type, itunes_image,
 			content_extraction_status,
-			media_status, media_path, media_size
+			media_status, media_path, media_size,
+			series_id, score, magnet_uri, created_at
 		) VALUES (
-			(SELECT id FROM feeds WHERE name = $1),
-			$2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24
+			$1,
+			$2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24,
+			NULLIF($25, ''), $26, NULLIF($27, ''), $28
 		)
 		ON CONFLICT (feed_id, guid) DO UPDATE SET
-			title = EXCLUDED.title,
+			previous_title = CASE WHEN `+itemChanged+` THEN feed_items.title ELSE feed_items.previous_title END,
+			previous_description = CASE WHEN `+itemChanged+` THEN feed_items.description ELSE feed_items.previous_description END,
+			changed_at = CASE WHEN `+itemChanged+` THEN $28::timestamptz ELSE feed_items.changed_at END,
+			title = `+upsertTitle+`,
+			content_ref = NULL,
 			description = EXCLUDED.description,
 			content = EXCLUDED.content,
 			updated_at = EXCLUDED.updated_at,
 			authors = EXCLUDED.authors,
 			categories = EXCLUDED.categories,
-			is_filtered = EXCLUDED.is_filtered,
+			is_filtered = COALESCE(feed_items.manual_filter, EXCLUDED.is_filtered),
 			content_hash = EXCLUDED.content_hash,
 			enclosure_url = EXCLUDED.enclosure_url,
 			enclosure_length = EXCLUDED.enclosure_length,
@@ -87,25 +408,35 @@ func (r *ItemRepository) UpsertItem(feedName string, item types.Item) (string, e
 			content_extraction_status = EXCLUDED.content_extraction_status,
 			media_status = EXCLUDED.media_status,
 			media_path = EXCLUDED.media_path,
-			media_size = EXCLUDED.media_size
-		RETURNING id
-	`, feedName, item.GUID, item.Link, item.Title, item.Description, item.Content,
+			media_size = EXCLUDED.media_size,
+			series_id = EXCLUDED.series_id,
+			score = EXCLUDED.score,
+			magnet_uri = EXCLUDED.magnet_uri
+		RETURNING id, COALESCE((SELECT content_ref FROM old), '')
+	`, feedID, item.GUID, item.Link, item.Title, item.Description, item.Content,
 		item.PublishedAt, item.UpdatedAt, pq.Array(authors),
 		pq.Array(categories), item.IsFiltered,
 		item.ContentHash, item.EnclosureURL, item.EnclosureLength, item.EnclosureType,
 		item.ITunesDuration, item.ITunesEpisode, item.ITunesSeason, item.ITunesEpisodeType, item.ITunesImage,
 		item.ContentExtractionStatus,
-		item.MediaStatus, item.MediaPath, item.MediaSize).Scan(&itemID)
+		item.MediaStatus, item.MediaPath, item.MediaSize,
+		item.SeriesID, item.Score, item.MagnetURI, now).Scan(&itemID, &oldContentRef)
 
 	if err != nil {
 		return "", fmt.Errorf("failed to upsert item: %w", err)
 	}
+	if oldContentRef != "" && r.contentStore != nil {
+		if err := r.contentStore.Delete(oldContentRef); err != nil {
+			slog.Warn("Failed to delete replaced content", "item_id", itemID, "error", err)
+		}
+	}
 
+	r.changed(feedID)
 	return itemID, nil
 }
 
-func (r *ItemRepository) UpdateItemFilterStatus(itemID string, isFiltered bool) error {
-	_, err := r.db.Exec(`
+func (r *ItemRepository) UpdateItemFilterStatus(ctx context.Context, itemID string, isFiltered bool) error {
+	err := r.updateItem(ctx, `
 		UPDATE feed_items 
 		SET is_filtered = $2
 		WHERE id = $1
@@ -118,16 +449,138 @@ func (r *ItemRepository) UpdateItemFilterStatus(itemID string, isFiltered bool)
 	return nil
 }
 
-func (r *ItemRepository) CheckDuplicate(feedName, contentHash string) (bool, *string, error) {
+// SetManualFilter pins an item's filter status, overriding the feed's
+// filters until ClearManualFilter. reason is kept for the API.
+func (r *ItemRepository) SetManualFilter(ctx context.Context, itemID string, filtered bool, reason string) error {
+	err := r.updateItem(ctx, `
+		UPDATE feed_items
+		SET is_filtered = $2, manual_filter = $2, manual_filter_reason = NULLIF($3, ''), manual_filter_at = NOW()
+		WHERE id = $1
+	`, itemID, filtered, reason)
+	if err != nil {
+		return fmt.Errorf("failed to set manual filter: %w", err)
+	}
+	return nil
+}
+
+// ClearManualFilter removes an item's override, setting is_filtered and
+// score to what the filters decide (the caller evaluates them).
+func (r *ItemRepository) ClearManualFilter(ctx context.Context, itemID string, isFiltered bool, score int) error {
+	err := r.updateItem(ctx, `
+		UPDATE feed_items
+		SET is_filtered = $2, score = $3, manual_filter = NULL, manual_filter_reason = NULL, manual_filter_at = NULL
+		WHERE id = $1
+	`, itemID, isFiltered, score)
+	if err != nil {
+		return fmt.Errorf("failed to clear manual filter: %w", err)
+	}
+	return nil
+}
+
+// UpdateItemTitle replaces an item's title with the page title found by
+// extraction for items of sitemap feeds. UpsertItem keeps it from then on.
+func (r *ItemRepository) UpdateItemTitle(ctx context.Context, itemID, title string) error {
+	err := r.updateItem(ctx, `UPDATE feed_items SET title = $2, title_extracted = TRUE WHERE id = $1`, itemID, title)
+	if err != nil {
+		return fmt.Errorf("failed to update item title: %w", err)
+	}
+	return nil
+}
+
+// MarkReadLaterSaved records that an item was saved to the read-later
+// service.
+func (r *ItemRepository) MarkReadLaterSaved(ctx context.Context, itemID string) error {
+	err := r.updateItem(ctx, `UPDATE feed_items SET read_later_saved_at = NOW() WHERE id = $1`, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to mark item saved: %w", err)
+	}
+	return nil
+}
+
+func (r *ItemRepository) UpdateItemScore(ctx context.Context, itemID string, score int) error {
+	err := r.updateItem(ctx, `UPDATE feed_items SET score = $2 WHERE id = $1`, itemID, score)
+	if err != nil {
+		return fmt.Errorf("failed to update item score: %w", err)
+	}
+	return nil
+}
+
+func (r *ItemRepository) UpdateItemSeriesID(ctx context.Context, itemID, seriesID string) error {
+	err := r.updateItem(ctx, `UPDATE feed_items SET series_id = NULLIF($2, '') WHERE id = $1`, itemID, seriesID)
+	if err != nil {
+		return fmt.Errorf("failed to update item series: %w", err)
+	}
+	return nil
+}
+
+// GetTitlesSince returns the title, categories and publish date of a feed's
+// items published after since, newest first, for keyword statistics.
+// Filtered items are left out unless includeFiltered.
+func (r *ItemRepository) GetTitlesSince(ctx context.Context, feedID string, since time.Time, includeFiltered bool, limit int) ([]types.Item, error) {
+	rows, err := r.reader.QueryContext(ctx, `
+		SELECT COALESCE(fi.title, ''), COALESCE(fi.categories, '{}'), fi.published_at
+		FROM feed_items fi
+		WHERE fi.feed_id = $1 AND fi.published_at >= $2
+		  AND ($3 OR fi.is_filtered = false)
+		ORDER BY fi.published_at DESC
+		LIMIT $4
+	`, feedID, since, includeFiltered, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item titles: %w", err)
+	}
+	defer rows.Close()
+
+	var items []types.Item
+	for rows.Next() {
+		var item types.Item
+		if err := rows.Scan(&item.Title, pq.Array(&item.Categories), &item.PublishedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan item title: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating item titles: %w", err)
+	}
+	return items, nil
+}
+
+// GetManualFilters returns the moderation overrides of a feed's items by
+// GUID, so a fetch that updates a moderated item decides its jobs by the
+// pinned status rather than the filters'.
+func (r *ItemRepository) GetManualFilters(ctx context.Context, feedID string) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT guid, manual_filter FROM feed_items
+		WHERE feed_id = $1 AND manual_filter IS NOT NULL
+	`, feedID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manual filters: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make(map[string]bool)
+	for rows.Next() {
+		var guid string
+		var filtered bool
+		if err := rows.Scan(&guid, &filtered); err != nil {
+			return nil, fmt.Errorf("failed to scan manual filter: %w", err)
+		}
+		overrides[guid] = filtered
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating manual filters: %w", err)
+	}
+	return overrides, nil
+}
+
+func (r *ItemRepository) CheckDuplicate(ctx context.Context, feedID, contentHash string) (bool, *string, error) {
 	var duplicateID sql.NullString
 
 	query := `
-		SELECT fi.id 
-		FROM feed_items fi 
-		JOIN feeds f ON fi.feed_id = f.id 
-		WHERE f.name = $1 AND fi.content_hash = $2 
+		SELECT fi.id
+		FROM feed_items fi
+		WHERE fi.feed_id = $1 AND fi.content_hash = $2
 		LIMIT 1`
-	err := r.db.QueryRow(query, feedName, contentHash).Scan(&duplicateID)
+	err := r.db.QueryRowContext(ctx, query, feedID, contentHash).Scan(&duplicateID)
 	if err == sql.ErrNoRows {
 		return false, nil, nil
 	}
@@ -139,53 +592,243 @@ func (r *ItemRepository) CheckDuplicate(feedName, contentHash string) (bool, *st
 	return true, &id, nil
 }
 
-func (r *ItemRepository) GetVisibleItems(feedName string, limit int) ([]Item, error) {
-	rows, err := r.db.Query(`
-		SELECT fi.id, fi.guid, COALESCE(fi.link, ''), COALESCE(fi.title, ''),
-		       COALESCE(fi.description, ''), COALESCE(fi.content, ''),
-		       fi.published_at, fi.updated_at, fi.authors, fi.categories, fi.is_filtered,
-		       fi.content_hash, fi.created_at,
-		       COALESCE(fi.enclosure_url, ''), fi.enclosure_length, COALESCE(fi.enclosure_type, ''),
-		       COALESCE(fi.itunes_duration, 0), COALESCE(fi.itunes_episode, 0), COALESCE(fi.itunes_season, 0), COALESCE(fi.itunes_episode_type, ''), COALESCE(fi.itunes_image, ''),
-		       fi.content_extraction_status,
-		       fi.media_status, COALESCE(fi.media_path, ''), COALESCE(fi.media_size, 0)
-		FROM feed_items fi
-		JOIN feeds f ON fi.feed_id = f.id
-		WHERE f.name = $1
-		  AND fi.is_filtered = false
-		  AND (fi.content_extraction_status IS NULL OR fi.content_extraction_status IN ('ready', 'failed'))
-		  AND (CASE WHEN f.feed_type = 'youtube' THEN fi.media_status = 'ready'
-		            ELSE fi.media_status IS NULL OR fi.media_status = 'ready' END)
-		ORDER BY fi.published_at DESC
+// itemReady is true for items of feed f whose extraction and media download
+// have finished, so they can be shown; youtube items need their audio.
+const itemReady = `(fi.content_extraction_status IS NULL OR fi.content_extraction_status IN ('ready', 'fallback', 'failed'))
+	AND (CASE WHEN f.feed_type = 'youtube' THEN fi.media_status = 'ready'
+	          ELSE fi.media_status IS NULL OR fi.media_status = 'ready' END)`
+
+// GetVisibleItems returns up to settings.MaxItems items shown in the feed
+// output at now, newest first or highest score first (order_by: score).
+// With collapse_series only the newest visible item of each series is kept.
+// A delay withholds items until they are that old; without one, items dated
+// in the future are shown right away.
+func (r *ItemRepository) GetVisibleItems(ctx context.Context, feedID string, settings *types.Settings, now time.Time) ([]Item, error) {
+	order := "fi.published_at DESC"
+	if settings.OrderBy == "score" {
+		order = "fi.score DESC, fi.published_at DESC"
+	}
+	drip := settings.DripInterval > 0
+	if drip {
+		order = "fi.released_at DESC, fi.published_at DESC"
+	}
+
+	rows, err := r.reader.QueryContext(ctx, `
+		SELECT `+itemColumns+` FROM (
+			SELECT fi.*,
+			       ROW_NUMBER() OVER (PARTITION BY COALESCE(fi.series_id, fi.id::text) ORDER BY fi.published_at DESC) AS series_rank
+			FROM feed_items fi
+			JOIN feeds f ON fi.feed_id = f.id
+			WHERE fi.feed_id = $1
+			  AND fi.is_filtered = false
+			  AND `+itemReady+`
+			  AND ($4::float8 = 0 OR fi.published_at <= $6::timestamptz - make_interval(secs => $4))
+			  AND (NOT $5 OR fi.released_at IS NOT NULL)
+		) fi
+		WHERE NOT $3 OR fi.series_rank = 1
+		ORDER BY `+order+`
 		LIMIT $2
-	`, feedName, limit)
+	`, feedID, settings.MaxItems, settings.CollapseSeries, time.Duration(settings.Delay).Seconds(), drip, now)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get visible items: %w", err)
 	}
 	defer rows.Close()
 
-	return r.scanItemRows(rows)
+	items, err := r.scanItemRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	r.resolveContent(items)
+	return items, nil
+}
+
+// ReleaseNextItems releases the next count unreleased visible items of a
+// drip-feed, oldest first, at now unless the previous release happened less
+// than interval before. Items still being extracted or downloaded wait for
+// a later release. It returns the number of items released.
+func (r *ItemRepository) ReleaseNextItems(ctx context.Context, feedID string, count int, interval time.Duration, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		WITH last AS (
+			SELECT MAX(released_at) AS at FROM feed_items WHERE feed_id = $1
+		), next AS (
+			SELECT fi.id FROM feed_items fi
+			JOIN feeds f ON fi.feed_id = f.id
+			WHERE fi.feed_id = $1 AND fi.released_at IS NULL AND fi.is_filtered = false
+			  AND `+itemReady+`
+			ORDER BY fi.published_at ASC
+			LIMIT $2
+		)
+		UPDATE feed_items SET released_at = $4
+		WHERE id IN (SELECT id FROM next)
+		  AND COALESCE((SELECT at FROM last) <= $4::timestamptz - make_interval(secs => $3), true)
+	`, feedID, count, interval.Seconds(), now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release items: %w", err)
+	}
+
+	released, err := result.RowsAffected()
+	if released > 0 {
+		r.changed(feedID)
+	}
+	return released, err
+}
+
+type SimilarItem struct {
+	ID          string
+	FeedName    string
+	Title       string
+	Link        string
+	PublishedAt time.Time
+	Score       float64 // ts_rank of the title against the source item's words
+}
+
+// GetSimilarItems returns visible items of any feed whose titles share
+// words with the given item's title, best match first. Titles are matched
+// with English full-text search, so stop words are ignored and word forms
+// are stemmed ("release" matches "released").
+func (r *ItemRepository) GetSimilarItems(ctx context.Context, itemID string, limit int) ([]SimilarItem, error) {
+	rows, err := r.reader.QueryContext(ctx, `
+		WITH query AS (
+			SELECT to_tsquery('simple', string_agg(quote_literal(lexeme), ' | ')) AS q
+			FROM feed_items src, unnest(to_tsvector('english', COALESCE(src.title, '')))
+			WHERE src.id = $1
+		)
+		SELECT fi.id, f.name, COALESCE(fi.title, ''), COALESCE(fi.link, ''), fi.published_at,
+		       ts_rank(to_tsvector('english', COALESCE(fi.title, '')), query.q) AS score
+		FROM query, feed_items fi
+		JOIN feeds f ON f.id = fi.feed_id
+		WHERE to_tsvector('english', COALESCE(fi.title, '')) @@ query.q
+		  AND fi.id <> $1
+		  AND fi.is_filtered = false
+		ORDER BY score DESC, fi.published_at DESC
+		LIMIT $2
+	`, itemID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get similar items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []SimilarItem
+	for rows.Next() {
+		var item SimilarItem
+		if err := rows.Scan(&item.ID, &item.FeedName, &item.Title, &item.Link, &item.PublishedAt, &item.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan similar item: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating similar items: %w", err)
+	}
+	return items, nil
+}
+
+type SeriesSummary struct {
+	SeriesID    string
+	ItemCount   int
+	LatestTitle string
+	LatestAt    time.Time
+}
+
+// GetSeries lists the series detected in a feed, most recently updated first.
+func (r *ItemRepository) GetSeries(ctx context.Context, feedID string) ([]SeriesSummary, error) {
+	rows, err := r.reader.QueryContext(ctx, `
+		SELECT DISTINCT ON (fi.series_id)
+		       fi.series_id, COUNT(*) OVER (PARTITION BY fi.series_id), COALESCE(fi.title, ''), fi.published_at
+		FROM feed_items fi
+		WHERE fi.feed_id = $1 AND fi.series_id IS NOT NULL
+		ORDER BY fi.series_id, fi.published_at DESC
+	`, feedID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series: %w", err)
+	}
+	defer rows.Close()
+
+	var series []SeriesSummary
+	for rows.Next() {
+		var s SeriesSummary
+		if err := rows.Scan(&s.SeriesID, &s.ItemCount, &s.LatestTitle, &s.LatestAt); err != nil {
+			return nil, fmt.Errorf("failed to scan series row: %w", err)
+		}
+		series = append(series, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating series rows: %w", err)
+	}
+
+	slices.SortFunc(series, func(a, b SeriesSummary) int { return b.LatestAt.Compare(a.LatestAt) })
+	return series, nil
+}
+
+// CleanupContent deletes stored content no item refers to any more, e.g.
+// of items removed with their feed. Run it while nothing extracts content:
+// an extraction writes to the store before it sets the item's ref.
+func (r *ItemRepository) CleanupContent(ctx context.Context) (int, error) {
+	if r.contentStore == nil {
+		return 0, nil
+	}
+
+	keys, err := r.contentStore.Keys()
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT content_ref FROM feed_items WHERE content_ref IS NOT NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get content refs: %w", err)
+	}
+	defer rows.Close()
+
+	keep := make(map[string]struct{})
+	for rows.Next() {
+		var ref string
+		if err := rows.Scan(&ref); err != nil {
+			return 0, fmt.Errorf("failed to scan content ref: %w", err)
+		}
+		keep[ref] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating content refs: %w", err)
+	}
+
+	deleted := 0
+	for _, key := range keys {
+		if _, ok := keep[key]; ok {
+			continue
+		}
+		if err := r.contentStore.Delete(key); err != nil {
+			slog.Warn("Failed to delete orphaned content", "key", key, "error", err)
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// resolveContent loads content held in the content store. An unreadable
+// entry leaves the item without content rather than failing the caller.
+func (r *ItemRepository) resolveContent(items []Item) {
+	for i := range items {
+		if items[i].ContentRef == "" || r.contentStore == nil {
+			continue
+		}
+		content, err := r.contentStore.Get(items[i].ContentRef)
+		if err != nil {
+			slog.Warn("Failed to load stored content", "item_id", items[i].ID, "error", err)
+			continue
+		}
+		items[i].Content = string(content)
+	}
 }
 
 func (r *ItemRepository) scanItemRows(rows *sql.Rows) ([]Item, error) {
 	var items []Item
 	for rows.Next() {
-		var item Item
-		err := rows.Scan(
-			&item.ID, &item.GUID, &item.Link, &item.Title,
-			&item.Description, &item.Content, &item.PublishedAt, &item.UpdatedAt,
-			pq.Array(&item.Authors), pq.Array(&item.Categories),
-			&item.IsFiltered,
-			&item.ContentHash, &item.CreatedAt,
-			&item.EnclosureURL, &item.EnclosureLength, &item.EnclosureType,
-			&item.ITunesDuration, &item.ITunesEpisode, &item.ITunesSeason, &item.ITunesEpisodeType, &item.ITunesImage,
-			&item.ContentExtractionStatus,
-			&item.MediaStatus, &item.MediaPath, &item.MediaSize,
-		)
+		item, err := scanItem(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan item row: %w", err)
 		}
-		items = append(items, item)
+		items = append(items, *item)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -195,33 +838,8 @@ func (r *ItemRepository) scanItemRows(rows *sql.Rows) ([]Item, error) {
 	return items, nil
 }
 
-func (r *ItemRepository) GetItemByID(itemID string) (*Item, error) {
-	var item Item
-	err := r.db.QueryRow(`
-		SELECT fi.id, fi.guid, COALESCE(fi.link, ''), COALESCE(fi.title, ''),
-		       COALESCE(fi.description, ''), COALESCE(fi.content, ''),
-		       fi.published_at, fi.updated_at, COALESCE(fi.authors, '{}'),
-		       COALESCE(fi.categories, '{}'),
-		       fi.is_filtered,
-		       fi.content_hash, fi.created_at,
-		       COALESCE(fi.enclosure_url, ''), COALESCE(fi.enclosure_length, 0), COALESCE(fi.enclosure_type, ''),
-		       COALESCE(fi.itunes_duration, 0), COALESCE(fi.itunes_episode, 0), COALESCE(fi.itunes_season, 0), COALESCE(fi.itunes_episode_type, ''), COALESCE(fi.itunes_image, ''),
-		       fi.content_extraction_status,
-		       fi.media_status, COALESCE(fi.media_path, ''), COALESCE(fi.media_size, 0)
-		FROM feed_items fi
-		WHERE fi.id = $1
-	`, itemID).Scan(
-		&item.ID, &item.GUID, &item.Link, &item.Title,
-		&item.Description, &item.Content, &item.PublishedAt, &item.UpdatedAt,
-		pq.Array(&item.Authors), pq.Array(&item.Categories),
-		&item.IsFiltered,
-		&item.ContentHash, &item.CreatedAt,
-		&item.EnclosureURL, &item.EnclosureLength, &item.EnclosureType,
-		&item.ITunesDuration, &item.ITunesEpisode, &item.ITunesSeason, &item.ITunesEpisodeType, &item.ITunesImage,
-		&item.ContentExtractionStatus,
-		&item.MediaStatus, &item.MediaPath, &item.MediaSize,
-	)
-
+func (r *ItemRepository) GetItemByID(ctx context.Context, itemID string) (*Item, error) {
+	item, err := scanItem(r.db.QueryRowContext(ctx, `SELECT `+itemColumns+` FROM feed_items fi WHERE fi.id = $1`, itemID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -229,11 +847,13 @@ func (r *ItemRepository) GetItemByID(itemID string) (*Item, error) {
 		return nil, fmt.Errorf("failed to get item by ID: %w", err)
 	}
 
-	return &item, nil
+	items := []Item{*item}
+	r.resolveContent(items)
+	return &items[0], nil
 }
 
-func (r *ItemRepository) UpdateMediaStatus(itemID, status, mediaPath string, mediaSize int64, duration int) error {
-	_, err := r.db.Exec(`
+func (r *ItemRepository) UpdateMediaStatus(ctx context.Context, itemID, status, mediaPath string, mediaSize int64, duration int) error {
+	err := r.updateItem(ctx, `
 		UPDATE feed_items
 		SET media_status = $2, media_path = $3, media_size = $4,
 			itunes_duration = CASE WHEN $5 > 0 THEN $5 ELSE itunes_duration END
@@ -253,9 +873,9 @@ type MediaInfo struct {
 	ITunesDuration int
 }
 
-func (r *ItemRepository) GetReadyMediaByPath(mediaPath string) (*MediaInfo, error) {
+func (r *ItemRepository) GetReadyMediaByPath(ctx context.Context, mediaPath string) (*MediaInfo, error) {
 	var info MediaInfo
-	err := r.db.QueryRow(`
+	err := r.db.QueryRowContext(ctx, `
 		SELECT media_path, media_size, COALESCE(itunes_duration, 0) FROM feed_items
 		WHERE media_path = $1 AND media_status = 'ready'
 		LIMIT 1
@@ -271,8 +891,8 @@ func (r *ItemRepository) GetReadyMediaByPath(mediaPath string) (*MediaInfo, erro
 	return &info, nil
 }
 
-func (r *ItemRepository) GetAllActiveMediaPaths() ([]string, error) {
-	rows, err := r.db.Query(`
+func (r *ItemRepository) GetAllActiveMediaPaths(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT DISTINCT sub.media_path FROM (
 			SELECT fi.media_path,
 			       ROW_NUMBER() OVER (PARTITION BY fi.feed_id ORDER BY fi.published_at DESC) AS rn,
@@ -307,8 +927,8 @@ func (r *ItemRepository) GetAllActiveMediaPaths() ([]string, error) {
 	return paths, nil
 }
 
-func (r *ItemRepository) UpdateItemPublishedAt(itemID string, publishedAt time.Time) error {
-	_, err := r.db.Exec(`
+func (r *ItemRepository) UpdateItemPublishedAt(ctx context.Context, itemID string, publishedAt time.Time) error {
+	err := r.updateItem(ctx, `
 		UPDATE feed_items SET published_at = $2 WHERE id = $1
 	`, itemID, publishedAt)
 
@@ -319,12 +939,31 @@ func (r *ItemRepository) UpdateItemPublishedAt(itemID string, publishedAt time.T
 	return nil
 }
 
-func (r *ItemRepository) UpdateContentExtractionStatus(itemID, status, content string) error {
-	_, err := r.db.Exec(`
+// UpdateContentExtractionStatus records the outcome of an extraction. An
+// empty content keeps the item's current content; note explains the outcome
+// (quality score, rejection reason) for debugging.
+func (r *ItemRepository) UpdateContentExtractionStatus(ctx context.Context, itemID, status, content, note string) error {
+	if r.contentStore != nil && content != "" {
+		if err := r.contentStore.Put(itemID, []byte(content)); err != nil {
+			return fmt.Errorf("failed to store extracted content: %w", err)
+		}
+		err := r.updateItem(ctx, `
+			UPDATE feed_items
+			SET content_extraction_status = $2, content = NULL, content_ref = $1, content_extraction_note = NULLIF($3, '')
+			WHERE id = $1
+		`, itemID, status, note)
+		if err != nil {
+			return fmt.Errorf("failed to update content extraction status: %w", err)
+		}
+		return nil
+	}
+
+	err := r.updateItem(ctx, `
 		UPDATE feed_items
-		SET content_extraction_status = $2, content = CASE WHEN $3 = '' THEN content ELSE $3 END
+		SET content_extraction_status = $2, content = CASE WHEN $3 = '' THEN content ELSE $3 END,
+		    content_ref = CASE WHEN $3 = '' THEN content_ref END, content_extraction_note = NULLIF($4, '')
 		WHERE id = $1
-	`, itemID, status, content)
+	`, itemID, status, content, note)
 
 	if err != nil {
 		return fmt.Errorf("failed to update content extraction status: %w", err)
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// memoryStore is a storage.ContentStore in a map.
+type memoryStore map[string][]byte
+
+func (m memoryStore) Put(key string, content []byte) error {
+	m[key] = content
+	return nil
+}
+
+func (m memoryStore) Get(key string) ([]byte, error) {
+	content, ok := m[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return content, nil
+}
+
+func (m memoryStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memoryStore) Keys() ([]string, error) {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func TestResolveContent(t *testing.T) {
+	store := memoryStore{"a": []byte("<p>stored</p>")}
+	r := NewItemRepository(nil, nil, store)
+
+	items := []Item{
+		{ID: "a", ContentRef: "a"},
+		{ID: "b", Item: types.Item{Content: "<p>inline</p>"}},
+		{ID: "c", ContentRef: "c"},
+	}
+	r.resolveContent(items)
+
+	if items[0].Content != "<p>stored</p>" {
+		t.Errorf("expected the stored content, got %q", items[0].Content)
+	}
+	if items[1].Content != "<p>inline</p>" {
+		t.Errorf("expected the inline content to stay, got %q", items[1].Content)
+	}
+	if items[2].Content != "" {
+		t.Errorf("expected a missing entry to leave the item without content, got %q", items[2].Content)
+	}
+
+	// Without a store, refs are left alone.
+	items = []Item{{ID: "a", ContentRef: "a"}}
+	NewItemRepository(nil, nil, nil).resolveContent(items)
+	if items[0].Content != "" {
+		t.Errorf("expected no content without a store, got %q", items[0].Content)
+	}
+}
@@ -1,11 +1,15 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"math"
 	"math/rand/v2"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 const maxBackoffSeconds = 900 // 15 minutes
@@ -20,6 +24,7 @@ type Job struct {
 	MaxRetries   int
 	ErrorMessage *string
 	RunAfter     *time.Time
+	Priority     int // 1 for jobs of high-priority feeds, claimed first
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -34,16 +39,28 @@ func NewJobRepository(db *DB) *JobRepository {
 
 // CreateJob inserts a new job if no duplicate (same feed+type+item) is pending or processing.
 // Returns true if the job was created, false if a duplicate exists.
-func (r *JobRepository) CreateJob(jobType, feedID string, itemID *string, maxRetries int) (bool, error) {
-	result, err := r.db.Exec(`
-		INSERT INTO jobs (job_type, feed_id, item_id, max_retries)
-		SELECT $1, $2, $3, $4
+func (r *JobRepository) CreateJob(ctx context.Context, jobType, feedID string, itemID *string, maxRetries int) (bool, error) {
+	return r.CreateJobAfter(ctx, jobType, feedID, itemID, maxRetries, nil)
+}
+
+// jobPriority is the SQL for the priority of a new job of the feed whose ID
+// is the expression feedID: 1 for feeds with priority: high, else 0.
+func jobPriority(feedID string) string {
+	return `COALESCE((SELECT 1 FROM feeds WHERE id = ` + feedID + ` AND settings->>'priority' = 'high'), 0)`
+}
+
+// CreateJobAfter is CreateJob for a job that must not run before runAfter (nil = immediately).
+// Jobs of feeds with priority: high get priority 1, so ClaimJob picks them first.
+func (r *JobRepository) CreateJobAfter(ctx context.Context, jobType, feedID string, itemID *string, maxRetries int, runAfter *time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO jobs (job_type, feed_id, item_id, max_retries, run_after, priority)
+		SELECT $1, $2, $3, $4, $5, `+jobPriority("$2")+`
 		WHERE NOT EXISTS (
 			SELECT 1 FROM jobs
 			WHERE feed_id = $2 AND job_type = $1 AND item_id IS NOT DISTINCT FROM $3
 			AND status IN ('pending', 'processing')
 		)
-	`, jobType, feedID, itemID, maxRetries)
+	`, jobType, feedID, itemID, maxRetries, runAfter)
 	if err != nil {
 		return false, fmt.Errorf("failed to create job: %w", err)
 	}
@@ -56,24 +73,25 @@ func (r *JobRepository) CreateJob(jobType, feedID string, itemID *string, maxRet
 	return rows > 0, nil
 }
 
-// ClaimJob atomically claims the oldest pending job using FOR UPDATE SKIP LOCKED.
-// Skips jobs with a future run_after timestamp (backoff). Returns nil if no jobs are available.
-func (r *JobRepository) ClaimJob() (*Job, error) {
+// ClaimJob atomically claims the oldest pending job of the highest priority using FOR UPDATE SKIP LOCKED.
+// Skips jobs with a run_after timestamp after now (backoff) and stamps the claim
+// at now, which ResetStaleJobs measures from. Returns nil if no jobs are available.
+func (r *JobRepository) ClaimJob(ctx context.Context, now time.Time) (*Job, error) {
 	var job Job
-	err := r.db.QueryRow(`
-		UPDATE jobs SET status = 'processing', updated_at = NOW()
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE jobs SET status = 'processing', updated_at = $1
 		WHERE id = (
 			SELECT id FROM jobs
 			WHERE status = 'pending'
-			  AND (run_after IS NULL OR run_after <= NOW())
-			ORDER BY created_at LIMIT 1
+			  AND (run_after IS NULL OR run_after <= $1)
+			ORDER BY priority DESC, created_at LIMIT 1
 			FOR UPDATE SKIP LOCKED
 		)
-		RETURNING id, job_type, feed_id, item_id, status, retries, max_retries, error_message, run_after, created_at, updated_at
-	`).Scan(
+		RETURNING id, job_type, feed_id, item_id, status, retries, max_retries, error_message, run_after, priority, created_at, updated_at
+	`, now).Scan(
 		&job.ID, &job.JobType, &job.FeedID, &job.ItemID, &job.Status,
 		&job.Retries, &job.MaxRetries, &job.ErrorMessage, &job.RunAfter,
-		&job.CreatedAt, &job.UpdatedAt,
+		&job.Priority, &job.CreatedAt, &job.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -87,29 +105,46 @@ func (r *JobRepository) ClaimJob() (*Job, error) {
 }
 
 // CompleteJob deletes a successfully completed job.
-func (r *JobRepository) CompleteJob(jobID string) error {
-	_, err := r.db.Exec("DELETE FROM jobs WHERE id = $1", jobID)
+func (r *JobRepository) CompleteJob(ctx context.Context, jobID string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM jobs WHERE id = $1", jobID)
 	if err != nil {
 		return fmt.Errorf("failed to complete job: %w", err)
 	}
 	return nil
 }
 
-// FailJob increments retries and schedules the next attempt with exponential backoff + jitter.
-// If max retries reached, deletes the job. Otherwise sets status back to pending.
-func (r *JobRepository) FailJob(jobID string, errMsg string) error {
-	_, err := r.db.Exec(`
+// FailJob increments retries, records the error in the job's history and
+// schedules the next attempt with exponential backoff + jitter. Once max
+// retries are reached the job is moved to dead_letter_jobs; jobs without
+// retries (max_retries = 0, e.g. scheduled fetches) are simply deleted.
+// A permanent failure uses up the remaining retries at once. The error and
+// a dead letter's failed_at are stamped and the backoff runs from now.
+func (r *JobRepository) FailJob(ctx context.Context, jobID string, errMsg string, permanent bool, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
 		UPDATE jobs SET
-			retries = retries + 1,
+			retries = CASE WHEN $3 THEN GREATEST(retries + 1, max_retries) ELSE retries + 1 END,
 			error_message = $2,
-			updated_at = NOW()
+			error_history = error_history || jsonb_build_array(jsonb_build_object('error', $2::text, 'at', $4::timestamptz)),
+			updated_at = $4
 		WHERE id = $1
-	`, jobID, errMsg)
+	`, jobID, errMsg, permanent, now)
 	if err != nil {
 		return fmt.Errorf("failed to update job retries: %w", err)
 	}
 
-	_, err = r.db.Exec(`
+	_, err = r.db.ExecContext(ctx, `
+		WITH moved AS (
+			DELETE FROM jobs WHERE id = $1 AND max_retries > 0 AND retries >= max_retries
+			RETURNING id, job_type, feed_id, item_id, retries, max_retries, error_history, created_at
+		)
+		INSERT INTO dead_letter_jobs (id, job_type, feed_id, item_id, retries, max_retries, error_history, created_at, failed_at)
+		SELECT id, job_type, feed_id, item_id, retries, max_retries, error_history, created_at, $2 FROM moved
+	`, jobID, now)
+	if err != nil {
+		return fmt.Errorf("failed to move exhausted job to dead letter queue: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
 		DELETE FROM jobs WHERE id = $1 AND retries >= max_retries
 	`, jobID)
 	if err != nil {
@@ -118,9 +153,9 @@ func (r *JobRepository) FailJob(jobID string, errMsg string) error {
 
 	// Exponential backoff with jitter: base = min(2^retries, 900s), jitter adds 0-100% of base
 	var retries int
-	err = r.db.QueryRow(`SELECT retries FROM jobs WHERE id = $1`, jobID).Scan(&retries)
+	err = r.db.QueryRowContext(ctx, `SELECT retries FROM jobs WHERE id = $1`, jobID).Scan(&retries)
 	if err == sql.ErrNoRows {
-		return nil // job was deleted (retries exhausted)
+		return nil // job was dead-lettered or deleted (retries exhausted)
 	}
 	if err != nil {
 		return fmt.Errorf("failed to read job retries: %w", err)
@@ -128,10 +163,10 @@ func (r *JobRepository) FailJob(jobID string, errMsg string) error {
 
 	backoff := retryBackoff(retries)
 
-	_, err = r.db.Exec(`
-		UPDATE jobs SET status = 'pending', run_after = $2, updated_at = NOW()
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE jobs SET status = 'pending', run_after = $2, updated_at = $3
 		WHERE id = $1 AND status = 'processing'
-	`, jobID, time.Now().Add(backoff))
+	`, jobID, now.Add(backoff), now)
 	if err != nil {
 		return fmt.Errorf("failed to reset job to pending: %w", err)
 	}
@@ -146,23 +181,51 @@ func retryBackoff(retries int) time.Duration {
 }
 
 // DelayJob sets a job back to pending with a specific run_after time without incrementing retries.
-func (r *JobRepository) DelayJob(jobID string, runAfter time.Time) error {
-	_, err := r.db.Exec(`
-		UPDATE jobs SET status = 'pending', run_after = $2, updated_at = NOW()
+func (r *JobRepository) DelayJob(ctx context.Context, jobID string, runAfter, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET status = 'pending', run_after = $2, updated_at = $3
 		WHERE id = $1
-	`, jobID, runAfter)
+	`, jobID, runAfter, now)
 	if err != nil {
 		return fmt.Errorf("failed to delay job: %w", err)
 	}
 	return nil
 }
 
-// ResetStaleJobs resets jobs stuck in 'processing' state beyond the timeout back to 'pending'.
-func (r *JobRepository) ResetStaleJobs(timeout time.Duration) (int, error) {
-	result, err := r.db.Exec(`
-		UPDATE jobs SET status = 'pending', updated_at = NOW()
-		WHERE status = 'processing' AND updated_at < $1
-	`, time.Now().Add(-timeout))
+// CountReadyJobs returns the number of pending jobs that can be claimed at
+// now.
+func (r *JobRepository) CountReadyJobs(ctx context.Context, now time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM jobs
+		WHERE status = 'pending' AND (run_after IS NULL OR run_after <= $1)
+	`, now).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count ready jobs: %w", err)
+	}
+	return count, nil
+}
+
+// ResetStaleJobs resets jobs stuck in 'processing' state beyond the timeout
+// of their type back to 'pending'. Job types missing from timeouts are
+// never reset.
+func (r *JobRepository) ResetStaleJobs(ctx context.Context, timeouts map[string]time.Duration, now time.Time) (int, error) {
+	if len(timeouts) == 0 {
+		return 0, nil
+	}
+	jobTypes := make([]string, 0, len(timeouts))
+	seconds := make([]float64, 0, len(timeouts))
+	for jobType, timeout := range timeouts {
+		jobTypes = append(jobTypes, jobType)
+		seconds = append(seconds, timeout.Seconds())
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET status = 'pending', updated_at = $3
+		FROM unnest($1::text[], $2::float8[]) AS stale(job_type, secs)
+		WHERE jobs.job_type = stale.job_type AND jobs.status = 'processing'
+		  AND jobs.updated_at < $3::timestamptz - make_interval(secs => stale.secs)
+	`, pq.Array(jobTypes), pq.Array(seconds), now)
 	if err != nil {
 		return 0, fmt.Errorf("failed to reset stale jobs: %w", err)
 	}
@@ -174,3 +237,109 @@ func (r *JobRepository) ResetStaleJobs(timeout time.Duration) (int, error) {
 
 	return int(rows), nil
 }
+
+type JobError struct {
+	Error string    `json:"error"`
+	At    time.Time `json:"at"`
+}
+
+type DeadLetterJob struct {
+	ID           string
+	JobType      string
+	FeedID       string
+	FeedName     string
+	ItemID       *string
+	Retries      int
+	MaxRetries   int
+	ErrorHistory []JobError
+	CreatedAt    time.Time
+	FailedAt     time.Time
+}
+
+// GetDeadLetterJobs returns permanently failed jobs, most recent first.
+func (r *JobRepository) GetDeadLetterJobs(ctx context.Context, limit int) ([]DeadLetterJob, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT d.id, d.job_type, d.feed_id, f.name, d.item_id, d.retries, d.max_retries,
+		       d.error_history, d.created_at, d.failed_at
+		FROM dead_letter_jobs d
+		JOIN feeds f ON d.feed_id = f.id
+		ORDER BY d.failed_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letter jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []DeadLetterJob
+	for rows.Next() {
+		var job DeadLetterJob
+		var history []byte
+		if err := rows.Scan(
+			&job.ID, &job.JobType, &job.FeedID, &job.FeedName, &job.ItemID, &job.Retries,
+			&job.MaxRetries, &history, &job.CreatedAt, &job.FailedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter job: %w", err)
+		}
+		if err := json.Unmarshal(history, &job.ErrorHistory); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal error history: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dead letter jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// GetDeadLetterCount returns the number of jobs in the dead letter queue.
+func (r *JobRepository) GetDeadLetterCount(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM dead_letter_jobs`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count dead letter jobs: %w", err)
+	}
+	return count, nil
+}
+
+// RetryDeadLetterJob moves a dead-lettered job back into the queue with its
+// retry budget restored and its feed's priority. Returns false if no such dead letter job exists. If
+// an equivalent job is already pending, the dead letter entry is just removed.
+func (r *JobRepository) RetryDeadLetterJob(ctx context.Context, id string) (bool, error) {
+	var moved int
+	err := r.db.QueryRowContext(ctx, `
+		WITH moved AS (
+			DELETE FROM dead_letter_jobs WHERE id = $1
+			RETURNING job_type, feed_id, item_id, max_retries
+		), inserted AS (
+			INSERT INTO jobs (job_type, feed_id, item_id, max_retries, priority)
+			SELECT m.job_type, m.feed_id, m.item_id, m.max_retries, `+jobPriority("m.feed_id")+` FROM moved m
+			WHERE NOT EXISTS (
+				SELECT 1 FROM jobs j
+				WHERE j.feed_id = m.feed_id AND j.job_type = m.job_type AND j.item_id IS NOT DISTINCT FROM m.item_id
+				AND j.status IN ('pending', 'processing')
+			)
+		)
+		SELECT COUNT(*) FROM moved
+	`, id).Scan(&moved)
+	if err != nil {
+		return false, fmt.Errorf("failed to retry dead letter job: %w", err)
+	}
+	return moved > 0, nil
+}
+
+// DeleteDeadLetterJob discards a dead-lettered job. Returns false if it did not exist.
+func (r *JobRepository) DeleteDeadLetterJob(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM dead_letter_jobs WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete dead letter job: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS dead_letter_jobs;
+ALTER TABLE jobs DROP COLUMN error_history;
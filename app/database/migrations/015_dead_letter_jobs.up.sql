@@ -0,0 +1,15 @@
+ALTER TABLE jobs ADD COLUMN error_history JSONB NOT NULL DEFAULT '[]';
+
+CREATE TABLE dead_letter_jobs (
+    id UUID PRIMARY KEY,
+    job_type TEXT NOT NULL,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    item_id UUID REFERENCES feed_items(id) ON DELETE CASCADE,
+    retries INTEGER NOT NULL,
+    max_retries INTEGER NOT NULL,
+    error_history JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL,
+    failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_dead_letter_jobs_failed_at ON dead_letter_jobs(failed_at DESC);
@@ -0,0 +1 @@
+ALTER TABLE feeds ADD COLUMN is_paused BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_feed_items_series;
+ALTER TABLE feed_items DROP COLUMN series_id;
@@ -0,0 +1,3 @@
+ALTER TABLE feed_items ADD COLUMN series_id TEXT;
+
+CREATE INDEX idx_feed_items_series ON feed_items(feed_id, series_id) WHERE series_id IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE feed_items ADD COLUMN score INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE feeds ADD COLUMN refilter_at TIMESTAMPTZ;
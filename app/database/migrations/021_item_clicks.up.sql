@@ -0,0 +1,7 @@
+-- Daily click counts for short item links (/r/:id); no per-visitor data is kept
+CREATE TABLE item_clicks (
+    item_id UUID NOT NULL REFERENCES feed_items(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (item_id, day)
+);
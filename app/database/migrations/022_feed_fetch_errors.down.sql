@@ -0,0 +1,2 @@
+ALTER TABLE feeds DROP COLUMN failing_since;
+ALTER TABLE feeds DROP COLUMN last_error;
@@ -0,0 +1,3 @@
+-- Track ongoing upstream failures; both are cleared on the next successful fetch
+ALTER TABLE feeds ADD COLUMN last_error TEXT;
+ALTER TABLE feeds ADD COLUMN failing_since TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+-- Items published before this are not ingested (set when initial_max_items trims the first fetch)
+ALTER TABLE feeds ADD COLUMN ingest_cutoff_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+-- Release time of an item in drip-feed mode; unreleased items are withheld from output
+ALTER TABLE feed_items ADD COLUMN released_at TIMESTAMPTZ;
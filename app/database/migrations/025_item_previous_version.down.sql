@@ -0,0 +1,3 @@
+ALTER TABLE feed_items DROP COLUMN changed_at;
+ALTER TABLE feed_items DROP COLUMN previous_description;
+ALTER TABLE feed_items DROP COLUMN previous_title;
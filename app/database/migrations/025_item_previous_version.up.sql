@@ -0,0 +1,4 @@
+-- Previous title/description of items whose title or description changed upstream
+ALTER TABLE feed_items ADD COLUMN previous_title TEXT;
+ALTER TABLE feed_items ADD COLUMN previous_description TEXT;
+ALTER TABLE feed_items ADD COLUMN changed_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+-- Key of extracted content held in the content store instead of feed_items.content
+ALTER TABLE feed_items ADD COLUMN content_ref TEXT;
@@ -0,0 +1,7 @@
+DO $$
+BEGIN
+    EXECUTE 'ALTER TABLE feed_items ALTER COLUMN content SET COMPRESSION default';
+    EXECUTE 'ALTER TABLE feed_items ALTER COLUMN description SET COMPRESSION default';
+EXCEPTION WHEN OTHERS THEN
+    RAISE NOTICE 'column compression not available: %', SQLERRM;
+END $$;
@@ -0,0 +1,10 @@
+-- Compress large text columns with lz4 (PostgreSQL 14+ built with lz4; faster
+-- than the default pglz at a similar ratio). Only newly written values use it.
+-- Servers without lz4 support keep the default and log a notice.
+DO $$
+BEGIN
+    EXECUTE 'ALTER TABLE feed_items ALTER COLUMN content SET COMPRESSION lz4';
+    EXECUTE 'ALTER TABLE feed_items ALTER COLUMN description SET COMPRESSION lz4';
+EXCEPTION WHEN OTHERS THEN
+    RAISE NOTICE 'lz4 column compression not available, keeping default: %', SQLERRM;
+END $$;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_item_clicks_day;
+DROP INDEX IF EXISTS idx_feeds_refilter_at;
+DROP INDEX IF EXISTS idx_feed_items_feed_created;
+DROP INDEX IF EXISTS idx_feed_items_feed_hash;
+CREATE INDEX idx_content_hash ON feed_items(content_hash);
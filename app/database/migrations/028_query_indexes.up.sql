@@ -0,0 +1,12 @@
+-- Duplicate checks filter by feed and hash (CheckDuplicate)
+DROP INDEX IF EXISTS idx_content_hash;
+CREATE INDEX idx_feed_items_feed_hash ON feed_items(feed_id, content_hash);
+
+-- Latest stored items per feed (GetLatestItems, ctl tail)
+CREATE INDEX idx_feed_items_feed_created ON feed_items(feed_id, created_at DESC);
+
+-- Feeds waiting for a timed-exclude refilter (GetFeedsDueRefilter)
+CREATE INDEX idx_feeds_refilter_at ON feeds(refilter_at) WHERE refilter_at IS NOT NULL;
+
+-- Click analytics scan by day range (GetClickSeries, GetTopItems)
+CREATE INDEX idx_item_clicks_day ON item_clicks(day);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS feed_config_history;
+ALTER TABLE feeds DROP COLUMN IF EXISTS refiltered_at;
+ALTER TABLE feeds DROP COLUMN IF EXISTS refiltered_config_version;
+ALTER TABLE feeds DROP COLUMN IF EXISTS config_changed_at;
+ALTER TABLE feeds DROP COLUMN IF EXISTS config_version;
@@ -0,0 +1,17 @@
+-- Config version, bumped whenever the feed file's hash changes, and the
+-- version the last refilter applied
+ALTER TABLE feeds ADD COLUMN config_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN config_changed_at TIMESTAMPTZ;
+ALTER TABLE feeds ADD COLUMN refiltered_config_version INTEGER;
+ALTER TABLE feeds ADD COLUMN refiltered_at TIMESTAMPTZ;
+
+-- Recent configurations of each feed; older versions are pruned on upsert
+CREATE TABLE feed_config_history (
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    config_hash VARCHAR(64) NOT NULL,
+    settings JSONB,
+    filters JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (feed_id, version)
+);
@@ -0,0 +1 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS description_override;
@@ -0,0 +1,2 @@
+-- Description from the feed config; overrides the source description in output
+ALTER TABLE feeds ADD COLUMN description_override TEXT;
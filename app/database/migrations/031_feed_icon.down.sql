@@ -0,0 +1,2 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS icon_checked_at;
+ALTER TABLE feeds DROP COLUMN IF EXISTS icon_url;
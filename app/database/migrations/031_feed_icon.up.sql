@@ -0,0 +1,3 @@
+-- Site icon discovered for feeds without an image, and when it was looked up
+ALTER TABLE feeds ADD COLUMN icon_url TEXT;
+ALTER TABLE feeds ADD COLUMN icon_checked_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+UPDATE feed_items SET content_extraction_status = 'failed' WHERE content_extraction_status = 'fallback';
+ALTER TABLE feed_items DROP COLUMN IF EXISTS content_extraction_note;
@@ -0,0 +1,2 @@
+-- Quality score of the last extraction and why it was used or rejected
+ALTER TABLE feed_items ADD COLUMN content_extraction_note TEXT;
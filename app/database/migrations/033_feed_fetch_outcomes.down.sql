@@ -0,0 +1,3 @@
+UPDATE feeds SET last_fetched_at = last_success_at;
+ALTER TABLE feeds DROP COLUMN IF EXISTS last_error_at;
+ALTER TABLE feeds DROP COLUMN IF EXISTS last_success_at;
@@ -0,0 +1,9 @@
+-- Separate fetch attempts from successes: last_fetched_at now records every
+-- attempt, last_success_at/last_error_at the latest outcome of each kind
+ALTER TABLE feeds ADD COLUMN last_success_at TIMESTAMPTZ;
+ALTER TABLE feeds ADD COLUMN last_error_at TIMESTAMPTZ;
+
+-- Until now last_fetched_at was only set by successful fetches. The time of
+-- the last error wasn't recorded, so last_error_at stays NULL until the next
+-- failed fetch sets it
+UPDATE feeds SET last_success_at = last_fetched_at;
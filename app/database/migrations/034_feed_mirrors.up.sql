@@ -0,0 +1,8 @@
+-- Upstream documents of feeds in mirror mode, served as fetched
+CREATE TABLE feed_mirrors (
+    feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+    body BYTEA NOT NULL,
+    content_type TEXT NOT NULL DEFAULT '',
+    etag TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+ALTER TABLE feed_items DROP COLUMN IF EXISTS manual_filter_at;
+ALTER TABLE feed_items DROP COLUMN IF EXISTS manual_filter_reason;
+ALTER TABLE feed_items DROP COLUMN IF EXISTS manual_filter;
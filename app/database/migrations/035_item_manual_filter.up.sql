@@ -0,0 +1,5 @@
+-- Moderation overrides: a non-NULL manual_filter pins is_filtered, whatever
+-- the feed's filters decide on refetch or refilter
+ALTER TABLE feed_items ADD COLUMN manual_filter BOOLEAN;
+ALTER TABLE feed_items ADD COLUMN manual_filter_reason TEXT;
+ALTER TABLE feed_items ADD COLUMN manual_filter_at TIMESTAMPTZ;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_feed_items_title_tsv;
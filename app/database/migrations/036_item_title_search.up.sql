@@ -0,0 +1,2 @@
+-- Full-text index on titles for similar-item lookups (GetSimilarItems)
+CREATE INDEX idx_feed_items_title_tsv ON feed_items USING GIN (to_tsvector('english', COALESCE(title, '')));
@@ -0,0 +1,2 @@
+-- When an item was saved to the read-later service, so it's saved only once
+ALTER TABLE feed_items ADD COLUMN read_later_saved_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+-- Magnet link of torrent feed items, published per the magnet setting
+ALTER TABLE feed_items ADD COLUMN magnet_uri TEXT;
@@ -0,0 +1,2 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS source_hub_url;
+ALTER TABLE feeds DROP COLUMN IF EXISTS source_self_url;
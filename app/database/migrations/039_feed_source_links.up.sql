@@ -0,0 +1,3 @@
+-- The source's rel=self and rel=hub links, as declared in its document
+ALTER TABLE feeds ADD COLUMN source_self_url TEXT;
+ALTER TABLE feeds ADD COLUMN source_hub_url TEXT;
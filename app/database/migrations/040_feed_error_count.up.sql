@@ -0,0 +1,2 @@
+-- Fetches failed in a row, reset by the next successful fetch
+ALTER TABLE feeds ADD COLUMN error_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS http_last_modified;
+ALTER TABLE feeds DROP COLUMN IF EXISTS http_etag;
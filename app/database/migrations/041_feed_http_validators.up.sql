@@ -0,0 +1,4 @@
+-- ETag and Last-Modified of the last fully processed source response,
+-- sent back as If-None-Match/If-Modified-Since on the next fetch
+ALTER TABLE feeds ADD COLUMN http_etag TEXT;
+ALTER TABLE feeds ADD COLUMN http_last_modified TEXT;
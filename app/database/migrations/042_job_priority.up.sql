@@ -0,0 +1,4 @@
+-- Jobs of high-priority feeds are claimed ahead of others (higher first)
+ALTER TABLE jobs ADD COLUMN priority SMALLINT NOT NULL DEFAULT 0;
+DROP INDEX IF EXISTS idx_jobs_pending;
+CREATE INDEX idx_jobs_pending ON jobs(priority DESC, created_at) WHERE status = 'pending';
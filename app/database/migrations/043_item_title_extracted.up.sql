@@ -0,0 +1,3 @@
+-- Set when an item's title was taken from its page by extraction (sitemap
+-- feeds), so updates from the source keep it instead of the stand-in
+ALTER TABLE feed_items ADD COLUMN title_extracted BOOLEAN NOT NULL DEFAULT FALSE;
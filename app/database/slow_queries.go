@@ -0,0 +1,107 @@
+package database
+
+import (
+	"cmp"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlowQuery aggregates the executions of one statement that took longer
+// than the slow query threshold.
+type SlowQuery struct {
+	Fingerprint string
+	Statement   string
+	Count       int
+	Total       time.Duration
+	Max         time.Duration
+	LastAt      time.Time
+}
+
+type slowQueryLog struct {
+	threshold time.Duration
+	mu        sync.Mutex
+	queries   map[string]*SlowQuery
+}
+
+// LogSlowQueries logs statements that take longer than threshold and
+// keeps per-statement counts for SlowQueries. Zero disables it. Call before
+// the connection is shared between goroutines.
+func (db *DB) LogSlowQueries(threshold time.Duration) {
+	if threshold <= 0 {
+		db.slow = nil
+		return
+	}
+	db.slow = &slowQueryLog{threshold: threshold, queries: make(map[string]*SlowQuery)}
+}
+
+// ShareSlowQueryLog makes db record slow queries into primary's log, so a
+// read replica shows up in the same statistics.
+func (db *DB) ShareSlowQueryLog(primary *DB) {
+	db.slow = primary.slow
+}
+
+// SlowQueries returns the recorded slow statements, most total time first.
+func (db *DB) SlowQueries() []SlowQuery {
+	if db.slow == nil {
+		return nil
+	}
+
+	db.slow.mu.Lock()
+	result := make([]SlowQuery, 0, len(db.slow.queries))
+	for _, q := range db.slow.queries {
+		result = append(result, *q)
+	}
+	db.slow.mu.Unlock()
+
+	slices.SortFunc(result, func(a, b SlowQuery) int { return cmp.Compare(b.Total, a.Total) })
+	return result
+}
+
+// observe records a statement that started at start. For queries returning
+// rows this is the time until the first rows are available.
+func (db *DB) observe(query string, start time.Time) {
+	if db.slow == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	if elapsed < db.slow.threshold {
+		return
+	}
+
+	statement := strings.Join(strings.Fields(query), " ")
+	fingerprint := queryFingerprint(statement)
+	slog.Warn("Slow query", "fingerprint", fingerprint, "duration", elapsed, "statement", truncate(statement, 200))
+
+	db.slow.mu.Lock()
+	defer db.slow.mu.Unlock()
+	q, ok := db.slow.queries[fingerprint]
+	if !ok {
+		q = &SlowQuery{Fingerprint: fingerprint, Statement: truncate(statement, 500)}
+		db.slow.queries[fingerprint] = q
+	}
+	q.Count++
+	q.Total += elapsed
+	q.Max = max(q.Max, elapsed)
+	q.LastAt = time.Now()
+}
+
+// queryFingerprint identifies a statement independent of its layout.
+// Repository SQL is static and parameterized, so the whitespace-normalized
+// text already identifies the statement.
+func queryFingerprint(statement string) string {
+	h := fnv.New64a()
+	h.Write([]byte(statement))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// StateRepository stores small pieces of application state that must
+// survive restarts (e.g. the maintenance flag) as key/value pairs.
+type StateRepository struct {
+	db *DB
+}
+
+func NewStateRepository(db *DB) *StateRepository {
+	return &StateRepository{db: db}
+}
+
+// GetValue returns the value stored under key, or nil if it is not set.
+func (r *StateRepository) GetValue(ctx context.Context, key string) (*string, error) {
+	var value string
+	err := r.db.QueryRowContext(ctx, `SELECT value FROM app_state WHERE key = $1`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app state %s: %w", key, err)
+	}
+	return &value, nil
+}
+
+func (r *StateRepository) SetValue(ctx context.Context, key, value string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO app_state (key, value) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
+	`, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to set app state %s: %w", key, err)
+	}
+	return nil
+}
@@ -9,29 +9,54 @@ import (
 )
 
 type Feed struct {
-	ID              string // Database UUID
-	Name            string // Configuration feed identifier derived from filename
-	FeedURL         string // RSS/Atom feed URL from configuration
-	Link            string // Homepage URL from feed's <link> element (RSS 2.0 spec)
-	Title           string // Custom title from config (optional override)
-	SourceTitle     string // Title from source feed
-	Description     string // Feed's original description from RSS/Atom
-	ImageURL        string
-	Language        string
-	LastFetchedAt   *time.Time
-	NextFetchAt     *time.Time
-	FeedPublishedAt *time.Time // Feed's own pubDate/published from RSS/Atom
-	FeedUpdatedAt   *time.Time // Feed's own updated/lastBuildDate from RSS/Atom
-	CreatedAt       time.Time
-	UpdatedAt       time.Time // Tracks last successful processing (replaces last_success)
+	ID                  string // Database UUID
+	Name                string // Configuration feed identifier derived from filename
+	FeedURL             string // RSS/Atom feed URL from configuration
+	Link                string // Homepage URL from feed's <link> element (RSS 2.0 spec)
+	Title               string // Custom title from config (optional override)
+	SourceTitle         string // Title from source feed
+	Description         string // Feed's original description from RSS/Atom
+	DescriptionOverride string // Custom description from config (optional override)
+	ImageURL            string
+	Language            string
+	SourceSelfURL       string     // rel=self link the source declares, "" if none
+	SourceHubURL        string     // rel=hub (WebSub) link the source declares, "" if none
+	HTTPETag            string     // ETag of the last processed source response, sent as If-None-Match
+	HTTPLastModified    string     // Last-Modified of that response, sent as If-Modified-Since
+	LastFetchedAt       *time.Time // Last fetch attempt, successful or not
+	NextFetchAt         *time.Time
+	FeedPublishedAt     *time.Time // Feed's own pubDate/published from RSS/Atom
+	FeedUpdatedAt       *time.Time // Feed's own updated/lastBuildDate from RSS/Atom
+	CreatedAt           time.Time
+	UpdatedAt           time.Time // Tracks last successful processing (replaces last_success)
 
 	// Configuration fields
 	FeedType   string          // Feed type: "", "podcast", "youtube"
 	IsEnabled  bool            // Whether the feed is enabled
+	IsPaused   bool            // Paused via API; overrides IsEnabled until the next reload
 	Settings   json.RawMessage // JSONB feed settings
 	Filters    json.RawMessage // JSONB feed filters
 	ConfigHash *string         // SHA-256 hash of config file for change detection
 
+	ConfigVersion           int        // Bumped whenever ConfigHash changes
+	ConfigChangedAt         *time.Time // When ConfigVersion was last bumped
+	RefilteredConfigVersion *int       // Config version applied by the last refilter
+	RefilteredAt            *time.Time
+
+	// Upstream fetch outcomes. FailingSince and ErrorCount are cleared by the
+	// next successful fetch; LastError and LastErrorAt keep the most recent
+	// failure.
+	LastSuccessAt *time.Time
+	LastErrorAt   *time.Time
+	LastError     string
+	FailingSince  *time.Time
+	ErrorCount    int // Fetches failed in a row
+
+	IngestCutoffAt *time.Time // Items published earlier are skipped (set by initial_max_items)
+
+	IconURL       string     // Site icon used as the image when the source has none
+	IconCheckedAt *time.Time // When the site was last searched for an icon
+
 	// iTunes podcast extension fields
 	ITunesAuthor     string
 	ITunesImage      string
@@ -40,6 +65,23 @@ type Feed struct {
 	ITunesOwnerEmail string
 }
 
+// Mirror is the upstream document stored for a feed in mirror mode.
+type Mirror struct {
+	Body        []byte
+	ContentType string
+	ETag        string
+	ChangedAt   time.Time
+}
+
+// ConfigRevision is one entry of a feed's configuration history.
+type ConfigRevision struct {
+	Version    int
+	ConfigHash string
+	Settings   json.RawMessage
+	Filters    json.RawMessage
+	CreatedAt  time.Time
+}
+
 func (f *Feed) DisplayTitle() string {
 	if f.Title != "" {
 		return f.Title
@@ -47,18 +89,25 @@ func (f *Feed) DisplayTitle() string {
 	return f.SourceTitle
 }
 
+func (f *Feed) DisplayDescription() string {
+	if f.DescriptionOverride != "" {
+		return f.DescriptionOverride
+	}
+	return f.Description
+}
+
 func (f *Feed) GetSettings() (*types.Settings, error) {
 	if f.Settings == nil {
 		return &types.Settings{
-			RefreshInterval: 1800,
+			RefreshInterval: types.Duration(30 * time.Minute),
 			MaxItems:        50,
-			Timeout:         30,
+			Timeout:         types.Duration(30 * time.Second),
 		}, nil
 	}
 
 	var settings types.Settings
 	if err := json.Unmarshal(f.Settings, &settings); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+		return nil, fmt.Errorf("%w: failed to unmarshal settings: %w", types.ErrFilteredConfigInvalid, err)
 	}
 	return &settings, nil
 }
@@ -70,14 +119,33 @@ func (f *Feed) GetFilters() ([]types.Filter, error) {
 
 	var filters []types.Filter
 	if err := json.Unmarshal(f.Filters, &filters); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal filters: %w", err)
+		return nil, fmt.Errorf("%w: failed to unmarshal filters: %w", types.ErrFilteredConfigInvalid, err)
 	}
 	return filters, nil
 }
 
+// Item is a stored feed item: the shared types.Item plus row metadata.
 type Item struct {
-	ID        string
-	FeedID    string
-	CreatedAt time.Time
+	ID         string
+	FeedID     string
+	CreatedAt  time.Time
+	ReleasedAt *time.Time // Set when released in drip-feed mode
+
+	// Version before the last upstream change of title or description
+	PreviousTitle       string
+	PreviousDescription string
+	ChangedAt           *time.Time
+
+	ExtractionNote string // Quality score and decision of the last content extraction
+
+	ContentRef string // Content store key when content lives outside the database
+
+	// Moderation override of is_filtered, nil when the filters decide
+	ManualFilter       *bool
+	ManualFilterReason string
+	ManualFilterAt     *time.Time
+
+	ReadLaterSavedAt *time.Time // When the item was saved to the read-later service
+
 	types.Item
 }
@@ -0,0 +1,128 @@
+// Package demo seeds a database with synthetic feeds (`rss-comb demo`), so
+// the API and feed output can be explored without configuring real sources
+// and tests have fixtures. Documents come from feedgen through an
+// in-process transport: seeding never touches the network, and the same
+// clock time always yields the same items.
+package demo
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/feedgen"
+	"github.com/lysyi3m/rss-comb/app/jobs"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+//go:embed feeds/*.yml
+var configs embed.FS
+
+// Host is the source host of the demo feeds. The .invalid TLD never
+// resolves, so a resumed demo feed fails to fetch instead of reaching a
+// real site.
+const Host = "demo.rss-comb.invalid"
+
+// sources shape each demo feed's generated document.
+var sources = map[string]feedgen.Options{
+	"demo-news":     {Items: 40, Seed: 101, Interval: 2 * time.Hour},
+	"demo-podcast":  {Items: 12, Seed: 202, Interval: 7 * 24 * time.Hour, Words: 60, Podcast: true},
+	"demo-releases": {Items: 60, Seed: 303, Interval: 3 * time.Hour},
+}
+
+// Names returns the demo feed names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Transport answers requests for Host with the demo documents as of the
+// clock's time; the newest item is published at or before then. Requests
+// for other hosts, such as icon lookups, get 404.
+type Transport struct {
+	Clock types.Clock
+}
+
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name, _ := strings.CutSuffix(strings.TrimPrefix(req.URL.Path, "/"), ".xml")
+	opts, ok := sources[name]
+	if req.URL.Hostname() != Host || !ok {
+		return response(req, http.StatusNotFound, nil), nil
+	}
+
+	opts.Newest = int64(t.Clock.Now().Sub(feedgen.Epoch) / opts.Interval)
+	resp := response(req, http.StatusOK, feedgen.Generate(opts))
+	resp.Header.Set("Content-Type", "application/rss+xml; charset=utf-8")
+	return resp, nil
+}
+
+func response(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// Seed loads the demo feed configs and fills each feed by running fetch, a
+// fetch_feed handler whose HTTP client uses Transport. The feeds are left
+// paused so the scheduler doesn't fetch them. Seeding again adds the items
+// published since the last run. Timed filter rules are scheduled from now.
+func Seed(ctx context.Context, defaults *types.Settings, feedRepo *database.FeedRepository, fetch jobs.HandlerFunc, loc *time.Location, now time.Time) error {
+	dir, err := os.MkdirTemp("", "rss-comb-demo")
+	if err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range Names() {
+		data, err := configs.ReadFile("feeds/" + name + ".yml")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".yml"), data, 0644); err != nil {
+			return fmt.Errorf("failed to write demo config: %w", err)
+		}
+
+		if _, err := feed.ConfigSync(ctx, dir, name, defaults, feedRepo, loc, now); err != nil {
+			return fmt.Errorf("[%s] %w", name, err)
+		}
+		if _, err := feedRepo.SetFeedPaused(ctx, name, false); err != nil {
+			return err
+		}
+		dbFeed, err := feedRepo.GetFeed(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := fetch(ctx, &database.Job{JobType: "fetch_feed", FeedID: dbFeed.ID}); err != nil {
+			return err
+		}
+		if _, err := feedRepo.SetFeedPaused(ctx, name, true); err != nil {
+			return err
+		}
+		slog.Info("Demo feed seeded", "feed", name)
+	}
+
+	return nil
+}
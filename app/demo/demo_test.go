@@ -0,0 +1,84 @@
+package demo
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestConfigs(t *testing.T) {
+	files, err := fs.Glob(configs, "feeds/*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, strings.TrimSuffix(filepath.Base(file), ".yml"))
+	}
+	if !slices.Equal(names, Names()) {
+		t.Fatalf("configs %v don't match sources %v", names, Names())
+	}
+
+	dir := t.TempDir()
+	for _, name := range names {
+		data, _ := configs.ReadFile("feeds/" + name + ".yml")
+		if err := os.WriteFile(filepath.Join(dir, name+".yml"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		config, _, err := feed.LoadConfig(dir, name, nil, time.UTC)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !strings.Contains(config.URL, "://"+Host+"/"+name+".xml") {
+			t.Errorf("%s: unexpected url %q", name, config.URL)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	client := &http.Client{Transport: Transport{Clock: types.FixedClock(now)}}
+
+	get := func(url string) (int, []byte) {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, body
+	}
+
+	status, first := get("https://" + Host + "/demo-news.xml")
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if _, second := get("https://" + Host + "/demo-news.xml"); !bytes.Equal(first, second) {
+		t.Error("expected the same document for the same time")
+	}
+
+	_, items, err := feed.ForType("").Parse(first, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 40 || items[0].PublishedAt.After(now) || now.Sub(items[0].PublishedAt) > 2*time.Hour {
+		t.Errorf("expected 40 items up to now, got %d, newest %v", len(items), items[0].PublishedAt)
+	}
+
+	for _, url := range []string{"https://" + Host + "/unknown.xml", "https://synthetic.example/favicon.ico"} {
+		if status, _ := get(url); status != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", url, status)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package e2e
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lysyi3m/rss-comb/app/api"
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/demo"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/jobs"
+)
+
+// apiKey authenticates the harness's /api requests.
+const apiKey = "e2e-key"
+
+// Start is the harness clock's initial time.
+var Start = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// Clock is a settable types.Clock shared by the fetcher and the server.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Harness wires the fetch handler and the HTTP server to a fresh database
+// and a stub upstream. Fetches run synchronously through the fetch_feed
+// job handler, without the worker pool or scheduler, so tests decide
+// exactly when each feed is fetched.
+type Harness struct {
+	Upstream *Upstream
+	Clock    *Clock
+	Feeds    *database.FeedRepository
+	Items    *database.ItemRepository
+	Jobs     *database.JobRepository
+
+	t         testing.TB
+	cfg       *cfg.Cfg
+	feedsDir  string
+	blocklist *feed.Blocklist
+	fetch     jobs.HandlerFunc
+	demoFetch jobs.HandlerFunc
+	extract   jobs.HandlerFunc
+	server    *gin.Engine
+}
+
+// New returns a harness on a new database, which is dropped when the test
+// ends. The test is skipped unless E2E_DB_HOST names a Postgres server the
+// E2E_DB_USER account may create databases on.
+func New(t testing.TB) *Harness {
+	t.Helper()
+
+	db := tempDatabase(t)
+	if _, _, err := database.RunMigrations(db); err != nil {
+		t.Fatalf("migrations failed: %v", err)
+	}
+
+	clock := &Clock{now: Start}
+	c := &cfg.Cfg{
+		BaseUrl:      "https://feeds.example.com",
+		MediaDir:     t.TempDir(),
+		Location:     time.UTC,
+		UserAgent:    "rss-comb-e2e",
+		APIAccessKey: apiKey,
+		Clock:        clock,
+	}
+
+	feedRepo := database.NewFeedRepository(db)
+	itemRepo := database.NewItemRepository(db, nil, nil)
+	jobRepo := database.NewJobRepository(db)
+	stateRepo := database.NewStateRepository(db)
+
+	feedCache := api.NewFeedCache(time.Minute, clock)
+	itemRepo.OnChange(feedCache.Invalidate)
+
+	blocklist := feed.NewBlocklist(filepath.Join(t.TempDir(), "blocklist.yml"), feedRepo, stateRepo)
+	if err := blocklist.Load(); err != nil {
+		t.Fatalf("blocklist: %v", err)
+	}
+	maintenance, err := jobs.NewMaintenance(context.Background(), stateRepo)
+	if err != nil {
+		t.Fatalf("maintenance: %v", err)
+	}
+
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	crawlLimiter, err := jobs.NewCrawlLimiter(0, "", clock)
+	if err != nil {
+		t.Fatalf("crawl limiter: %v", err)
+	}
+	extractor := jobs.NewExtractor(httpClient, c.UserAgent, jobs.RedirectPolicy{MaxRedirects: 5}, crawlLimiter, nil)
+	demoClient := &http.Client{Transport: demo.Transport{Clock: clock}}
+	handler := api.NewHandler(c, db, feedRepo, itemRepo, jobRepo, database.NewClickRepository(db), feedCache, blocklist,
+		nil, jobs.NewDryRunner(blocklist, itemRepo, httpClient, c.UserAgent, clock), nil, nil, nil, maintenance, new(slog.LevelVar))
+
+	return &Harness{
+		Upstream:  NewUpstream(t),
+		Clock:     clock,
+		Feeds:     feedRepo,
+		Items:     itemRepo,
+		Jobs:      jobRepo,
+		t:         t,
+		cfg:       c,
+		feedsDir:  t.TempDir(),
+		blocklist: blocklist,
+		fetch:     jobs.FetchFeedHandler(blocklist, feedRepo, itemRepo, jobRepo, httpClient, c.UserAgent, c.MediaDir, nil, false, c.Location, clock),
+		demoFetch: jobs.FetchFeedHandler(blocklist, feedRepo, itemRepo, jobRepo, demoClient, c.UserAgent, c.MediaDir, nil, false, c.Location, clock),
+		extract:   jobs.ExtractContentHandler(blocklist, feedRepo, itemRepo, extractor, clock),
+		server:    api.NewServer(handler, c),
+	}
+}
+
+// AddFeed writes config as feeds/<name>.yml and loads it, as at startup.
+// "{{upstream}}" in config is replaced with the stub upstream's address.
+func (h *Harness) AddFeed(name, config string) *database.Feed {
+	h.t.Helper()
+
+	config = strings.ReplaceAll(config, "{{upstream}}", h.Upstream.URL(""))
+	if err := os.WriteFile(filepath.Join(h.feedsDir, name+".yml"), []byte(config), 0644); err != nil {
+		h.t.Fatal(err)
+	}
+	if _, err := feed.ConfigSync(context.Background(), h.feedsDir, name, nil, h.Feeds, h.cfg.Location, h.Clock.Now()); err != nil {
+		h.t.Fatalf("loading feed %s: %v", name, err)
+	}
+	return h.Feed(name)
+}
+
+// Feed returns the stored state of the feed.
+func (h *Harness) Feed(name string) *database.Feed {
+	h.t.Helper()
+
+	dbFeed, err := h.Feeds.GetFeed(context.Background(), name)
+	if err != nil || dbFeed == nil {
+		h.t.Fatalf("feed %s: %v", name, err)
+	}
+	return dbFeed
+}
+
+// SeedDemo loads the demo feeds (see package demo) as of the harness clock,
+// as fixtures that need no stub sources.
+func (h *Harness) SeedDemo() {
+	h.t.Helper()
+
+	if err := demo.Seed(context.Background(), &h.cfg.FeedDefaults, h.Feeds, h.demoFetch, h.cfg.Location, h.Clock.Now()); err != nil {
+		h.t.Fatalf("seeding demo feeds: %v", err)
+	}
+}
+
+// Fetch runs a fetch_feed job for the feed and returns the job's error.
+func (h *Harness) Fetch(name string) error {
+	h.t.Helper()
+
+	return h.fetch(context.Background(), &database.Job{JobType: "fetch_feed", FeedID: h.Feed(name).ID})
+}
+
+// SetLocation sets the TIMEZONE the server counts days in.
+func (h *Harness) SetLocation(loc *time.Location) {
+	h.cfg.Location = loc
+}
+
+// Extract runs an extract_content job for the feed's item with the given
+// GUID and returns the job's error.
+func (h *Harness) Extract(name, guid string) error {
+	h.t.Helper()
+
+	id := h.ItemID(name, guid)
+	return h.extract(context.Background(), &database.Job{JobType: "extract_content", FeedID: h.Feed(name).ID, ItemID: &id})
+}
+
+// Refilter applies the feed's filters to its stored items again, as a
+// refilter_feed job does.
+func (h *Harness) Refilter(name string) error {
+	h.t.Helper()
+
+	return feed.Refilter(context.Background(), name, h.blocklist, h.Feeds, h.Items, h.Clock.Now())
+}
+
+// Counts returns the feed's stored and filtered item counts.
+func (h *Harness) Counts(name string) *database.ItemCounts {
+	h.t.Helper()
+
+	counts, err := h.Items.GetItemCounts(context.Background(), h.Feed(name).ID)
+	if err != nil {
+		h.t.Fatalf("item counts of %s: %v", name, err)
+	}
+	return counts
+}
+
+// ItemID returns the ID of the feed's stored item with the given GUID.
+func (h *Harness) ItemID(name, guid string) string {
+	h.t.Helper()
+
+	items, err := h.Items.GetAllItems(context.Background(), h.Feed(name).ID)
+	if err != nil {
+		h.t.Fatalf("items of %s: %v", name, err)
+	}
+	for _, item := range items {
+		if item.GUID == guid {
+			return item.ID
+		}
+	}
+	h.t.Fatalf("%s has no item %s", name, guid)
+	return ""
+}
+
+// Get serves a request for path, with headers given as name, value pairs.
+func (h *Harness) Get(path string, headers ...string) *httptest.ResponseRecorder {
+	return h.Do(http.MethodGet, path, "", headers...)
+}
+
+// API serves an authenticated /api request with an optional JSON body.
+func (h *Harness) API(method, path, body string) *httptest.ResponseRecorder {
+	return h.Do(method, path, body, "X-API-Key", apiKey, "Content-Type", "application/json")
+}
+
+// Do serves a request for path with the given body and headers, given as
+// name, value pairs.
+func (h *Harness) Do(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.server.ServeHTTP(w, req)
+	return w
+}
+
+// tempDatabase creates a database with a random name and drops it when the
+// test ends.
+func tempDatabase(t testing.TB) *database.DB {
+	t.Helper()
+
+	host := os.Getenv("E2E_DB_HOST")
+	if host == "" {
+		t.Skip("E2E_DB_HOST not set, skipping end-to-end test")
+	}
+	port := cmp.Or(os.Getenv("E2E_DB_PORT"), "5432")
+	user := os.Getenv("E2E_DB_USER")
+	password := os.Getenv("E2E_DB_PASSWORD")
+
+	admin, err := database.NewConnection(host, port, user, password, cmp.Or(os.Getenv("E2E_DB_NAME"), "postgres"))
+	if err != nil {
+		t.Fatalf("connecting to %s: %v", host, err)
+	}
+
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	name := "rss_comb_e2e_" + hex.EncodeToString(suffix)
+	if _, err := admin.ExecContext(context.Background(), "CREATE DATABASE "+name); err != nil {
+		admin.Close()
+		t.Fatalf("creating database: %v", err)
+	}
+
+	db, err := database.NewConnection(host, port, user, password, name)
+	if err != nil {
+		admin.Close()
+		t.Fatalf("connecting to %s: %v", name, err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+		if _, err := admin.ExecContext(context.Background(), "DROP DATABASE "+name+" WITH (FORCE)"); err != nil {
+			t.Logf("dropping database %s: %v", name, err)
+		}
+		admin.Close()
+	})
+	return db
+}
@@ -0,0 +1,744 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/jobs"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func entry(n int) Entry {
+	return Entry{
+		GUID:        fmt.Sprintf("urn:stub:%d", n),
+		Title:       fmt.Sprintf("Post number %d", n),
+		Link:        fmt.Sprintf("https://stub.example/posts/%d", n),
+		Description: fmt.Sprintf("<p>Body of post %d</p>", n),
+		Published:   Start.Add(-time.Duration(10-n) * time.Hour),
+	}
+}
+
+func TestPipeline_Formats(t *testing.T) {
+	h := New(t)
+
+	for _, tt := range []struct {
+		name   string
+		format Format
+	}{
+		{"rss", RSS},
+		{"atom", Atom},
+		{"json", JSONFeed},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h.Upstream.Serve("/"+tt.name, tt.format, entry(2), entry(1))
+			h.AddFeed(tt.name, "url: \"{{upstream}}/"+tt.name+"\"\n")
+
+			if err := h.Fetch(tt.name); err != nil {
+				t.Fatalf("fetch failed: %v", err)
+			}
+			if counts := h.Counts(tt.name); counts.Total != 2 {
+				t.Errorf("expected 2 stored items, got %d", counts.Total)
+			}
+
+			w := h.Get("/feeds/" + tt.name)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", w.Code)
+			}
+			body := w.Body.String()
+			for _, title := range []string{"Post number 1", "Post number 2"} {
+				if !strings.Contains(body, title) {
+					t.Errorf("output is missing %q", title)
+				}
+			}
+
+			w = h.Get("/feeds/" + tt.name + ".atom")
+			if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/atom+xml") {
+				t.Errorf("expected an Atom document, got %d %q", w.Code, w.Header().Get("Content-Type"))
+			}
+			for _, w := range []*httptest.ResponseRecorder{h.Get("/feeds/" + tt.name + ".json"), h.Get("/feeds/"+tt.name, "Accept", "application/feed+json")} {
+				if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/feed+json") {
+					t.Errorf("expected a JSON Feed document, got %d %q", w.Code, w.Header().Get("Content-Type"))
+				}
+			}
+		})
+	}
+}
+
+func TestPipeline_NewItems(t *testing.T) {
+	h := New(t)
+	h.Upstream.Serve("/feed", RSS, entry(2), entry(1))
+	h.AddFeed("news", "url: \"{{upstream}}/feed\"\n")
+
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("first fetch failed: %v", err)
+	}
+	etag := h.Get("/feeds/news").Header().Get("ETag")
+
+	h.Clock.Advance(time.Hour)
+	h.Upstream.Add("/feed", entry(3))
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("second fetch failed: %v", err)
+	}
+
+	if counts := h.Counts("news"); counts.Total != 3 {
+		t.Errorf("expected 3 stored items, got %d", counts.Total)
+	}
+	w := h.Get("/feeds/news", "If-None-Match", etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected a new document after new items, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Post number 3") {
+		t.Error("output is missing the new item")
+	}
+}
+
+func TestPipeline_ChangedGUIDs(t *testing.T) {
+	h := New(t)
+	h.Upstream.Serve("/feed", RSS, entry(2), entry(1))
+	h.AddFeed("news", "url: \"{{upstream}}/feed\"\n")
+
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("first fetch failed: %v", err)
+	}
+	h.Upstream.ChangeGUIDs("/feed", "-migrated")
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("second fetch failed: %v", err)
+	}
+
+	if counts := h.Counts("news"); counts.Total != 2 {
+		t.Errorf("changed GUIDs must not duplicate items, got %d stored", counts.Total)
+	}
+}
+
+func TestPipeline_NotModified(t *testing.T) {
+	h := New(t)
+	h.Upstream.Serve("/feed", RSS, entry(1))
+	h.AddFeed("news", "url: \"{{upstream}}/feed\"\n")
+
+	for range 2 {
+		if err := h.Fetch("news"); err != nil {
+			t.Fatalf("fetch failed: %v", err)
+		}
+	}
+
+	if total, notModified := h.Upstream.Requests("/feed"); total != 2 || notModified != 1 {
+		t.Errorf("expected the second fetch to be answered with 304, got %d requests and %d 304s", total, notModified)
+	}
+	if dbFeed := h.Feed("news"); dbFeed.HTTPETag == "" || dbFeed.LastSuccessAt == nil {
+		t.Errorf("expected validators and a successful fetch to be stored, got %+v", dbFeed)
+	}
+}
+
+func TestPipeline_RateLimited(t *testing.T) {
+	h := New(t)
+	h.Upstream.Serve("/feed", RSS, entry(1))
+	h.AddFeed("news", "url: \"{{upstream}}/feed\"\n")
+
+	h.Upstream.Fail("/feed", http.StatusTooManyRequests, time.Minute)
+	if err := h.Fetch("news"); err == nil {
+		t.Fatal("expected the fetch to fail")
+	}
+	if dbFeed := h.Feed("news"); dbFeed.ErrorCount != 1 || dbFeed.LastError == "" {
+		t.Errorf("expected the error to be recorded, got %+v", dbFeed)
+	}
+	if dbFeed := h.Feed("news"); dbFeed.FailingSince == nil || !dbFeed.FailingSince.Equal(Start) {
+		t.Errorf("expected the failure to be recorded at the harness time, got %v", dbFeed.FailingSince)
+	}
+
+	h.Upstream.Recover("/feed")
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("fetch after recovery failed: %v", err)
+	}
+	if dbFeed := h.Feed("news"); dbFeed.ErrorCount != 0 {
+		t.Errorf("expected the error count to reset, got %d", dbFeed.ErrorCount)
+	}
+	if counts := h.Counts("news"); counts.Total != 1 {
+		t.Errorf("expected 1 stored item, got %d", counts.Total)
+	}
+}
+
+func TestPipeline_Filters(t *testing.T) {
+	h := New(t)
+	spam := entry(3)
+	spam.Title = "Sponsored: buy now"
+	h.Upstream.Serve("/feed", RSS, spam, entry(2), entry(1))
+	h.AddFeed("news", `url: "{{upstream}}/feed"
+filters:
+  - field: "title"
+    excludes:
+      - "sponsored"
+`)
+
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+
+	if counts := h.Counts("news"); counts.Total != 3 || counts.Filtered != 1 {
+		t.Errorf("expected 3 stored items with 1 filtered, got %+v", counts)
+	}
+	if body := h.Get("/feeds/news").Body.String(); strings.Contains(body, "Sponsored") {
+		t.Error("filtered item is in the output")
+	}
+}
+
+func TestPipeline_Demo(t *testing.T) {
+	h := New(t)
+	h.SeedDemo()
+
+	for _, name := range []string{"demo-news", "demo-podcast", "demo-releases"} {
+		dbFeed := h.Feed(name)
+		if !dbFeed.IsPaused || dbFeed.LastSuccessAt == nil {
+			t.Errorf("%s: expected a fetched, paused feed, got %+v", name, dbFeed)
+		}
+		if counts := h.Counts(name); counts.Total == 0 {
+			t.Errorf("%s: no items seeded", name)
+		}
+		if w := h.Get("/feeds/" + name); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<item>") {
+			t.Errorf("%s: expected a feed with items, got %d", name, w.Code)
+		}
+	}
+
+	if counts := h.Counts("demo-releases"); counts.Filtered == 0 || counts.Filtered == counts.Total {
+		t.Errorf("expected the releases include filter to drop some items, got %+v", counts)
+	}
+
+	// Seeding later picks up the items published since.
+	before := h.Counts("demo-news").Total
+	h.Clock.Advance(6 * time.Hour)
+	h.SeedDemo()
+	if after := h.Counts("demo-news").Total; after != before+3 {
+		t.Errorf("expected 3 new items after 6 hours, got %d -> %d", before, after)
+	}
+}
+
+func TestPipeline_Priority(t *testing.T) {
+	h := New(t)
+	h.AddFeed("news", "url: \"{{upstream}}/news\"\n")
+	h.AddFeed("status", "url: \"{{upstream}}/status\"\nsettings:\n  priority: \"high\"\n")
+
+	settings, err := h.Feed("status").GetSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.RefreshInterval != types.Duration(time.Minute) {
+		t.Errorf("expected the high-priority refresh interval, got %s", settings.RefreshInterval)
+	}
+
+	ctx := context.Background()
+	for _, name := range []string{"news", "status"} {
+		if _, err := h.Jobs.CreateJob(ctx, "fetch_feed", h.Feed(name).ID, nil, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	job, err := h.Jobs.ClaimJob(ctx, h.Clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job == nil || job.FeedID != h.Feed("status").ID || job.Priority != 1 {
+		t.Fatalf("expected the high-priority feed's job first, got %+v", job)
+	}
+
+	// A dead-lettered job keeps its precedence when it is retried.
+	if err := h.Jobs.CompleteJob(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Jobs.CreateJob(ctx, "fetch_feed", h.Feed("status").ID, nil, 3); err != nil {
+		t.Fatal(err)
+	}
+	if job, err = h.Jobs.ClaimJob(ctx, h.Clock.Now()); err != nil || job == nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if err := h.Jobs.FailJob(ctx, job.ID, "gone", true, h.Clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	dead, err := h.Jobs.GetDeadLetterJobs(ctx, 10)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("expected one dead letter job, got %d (%v)", len(dead), err)
+	}
+	if found, err := h.Jobs.RetryDeadLetterJob(ctx, dead[0].ID); err != nil || !found {
+		t.Fatalf("retry: %v", err)
+	}
+	job, err = h.Jobs.ClaimJob(ctx, h.Clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job == nil || job.FeedID != h.Feed("status").ID || job.Priority != 1 {
+		t.Errorf("expected the retried job ahead of the normal one, got %+v", job)
+	}
+}
+
+func TestPipeline_Clock(t *testing.T) {
+	h := New(t)
+	h.Upstream.Serve("/feed", RSS, entry(1))
+	h.AddFeed("news", "url: \"{{upstream}}/feed\"\nsettings:\n  refresh_interval: 1h\n")
+
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if dbFeed := h.Feed("news"); dbFeed.LastSuccessAt == nil || !dbFeed.LastSuccessAt.Equal(Start) {
+		t.Errorf("expected the fetch to be recorded at the harness time, got %v", dbFeed.LastSuccessAt)
+	}
+
+	ctx := context.Background()
+	due, err := h.Feeds.GetDueFeeds(ctx, h.Clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Errorf("expected no due feeds right after the fetch, got %+v", due)
+	}
+
+	h.Clock.Advance(2 * time.Hour)
+	due, err = h.Feeds.GetDueFeeds(ctx, h.Clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].Name != "news" {
+		t.Errorf("expected the feed to be due after its refresh interval, got %+v", due)
+	}
+}
+
+func TestPipeline_StaleJobs(t *testing.T) {
+	h := New(t)
+	h.AddFeed("news", "url: \"{{upstream}}/news\"\n")
+
+	ctx := context.Background()
+	if _, err := h.Jobs.CreateJob(ctx, "fetch_feed", h.Feed("news").ID, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	job, err := h.Jobs.ClaimJob(ctx, h.Clock.Now())
+	if err != nil || job == nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	timeouts := map[string]time.Duration{"fetch_feed": 10 * time.Minute}
+	h.Clock.Advance(5 * time.Minute)
+	if reset, err := h.Jobs.ResetStaleJobs(ctx, timeouts, h.Clock.Now()); err != nil || reset != 0 {
+		t.Fatalf("expected a running job to be left alone, got %d (%v)", reset, err)
+	}
+
+	h.Clock.Advance(6 * time.Minute)
+	if reset, err := h.Jobs.ResetStaleJobs(ctx, timeouts, h.Clock.Now()); err != nil || reset != 1 {
+		t.Fatalf("expected the stuck job to be reset, got %d (%v)", reset, err)
+	}
+	if job, err := h.Jobs.ClaimJob(ctx, h.Clock.Now()); err != nil || job == nil {
+		t.Errorf("expected the reset job to be claimable again, got %v (%v)", job, err)
+	}
+}
+
+func TestPipeline_Moderation(t *testing.T) {
+	h := New(t)
+	h.Upstream.Serve("/feed", RSS, entry(2), entry(1))
+	h.AddFeed("news", "url: \"{{upstream}}/feed\"\nsettings:\n  extract_content: true\nfilters:\n  - field: \"title\"\n    excludes: [\"number 1\"]\n")
+
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	ctx := context.Background()
+	for job, _ := h.Jobs.ClaimJob(ctx, h.Clock.Now()); job != nil; job, _ = h.Jobs.ClaimJob(ctx, h.Clock.Now()) {
+		h.Jobs.CompleteJob(ctx, job.ID)
+	}
+
+	id := h.ItemID("news", entry(1).GUID)
+	if w := h.API(http.MethodPost, "/api/items/"+id+"/filter", `{"filtered": false, "reason": "wanted"}`); w.Code != http.StatusOK {
+		t.Fatalf("moderation failed: %d %s", w.Code, w.Body)
+	}
+	if err := h.Refilter("news"); err != nil {
+		t.Fatalf("refilter failed: %v", err)
+	}
+	if counts := h.Counts("news"); counts.Filtered != 0 {
+		t.Errorf("expected refilter to keep the moderated item visible, got %d filtered", counts.Filtered)
+	}
+
+	// An upstream edit stores the item again; its jobs follow the override.
+	edited := entry(1)
+	edited.Description = "<p>Edited body</p>"
+	h.Upstream.Serve("/feed", RSS, entry(2), edited)
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("second fetch failed: %v", err)
+	}
+	job, err := h.Jobs.ClaimJob(ctx, h.Clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job == nil || job.JobType != "extract_content" || job.ItemID == nil || *job.ItemID != id {
+		t.Errorf("expected an extract_content job for the moderated item, got %+v", job)
+	}
+	if counts := h.Counts("news"); counts.Filtered != 0 {
+		t.Errorf("expected the update to keep the override, got %d filtered", counts.Filtered)
+	}
+
+	if w := h.API(http.MethodDelete, "/api/items/"+id+"/filter", ""); w.Code != http.StatusOK {
+		t.Fatalf("clearing the override failed: %d %s", w.Code, w.Body)
+	}
+	if counts := h.Counts("news"); counts.Filtered != 1 {
+		t.Errorf("expected the filters to decide again, got %d filtered", counts.Filtered)
+	}
+}
+
+func TestPipeline_DryRun(t *testing.T) {
+	h := New(t)
+	h.Upstream.Serve("/feed", RSS, entry(3), entry(2), entry(1))
+	h.AddFeed("news", "url: \"{{upstream}}/feed\"\nfilters:\n  - field: \"title\"\n    excludes: [\"number 1\"]\n")
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+
+	h.Upstream.Add("/feed", entry(4))
+	w := h.API(http.MethodPost, "/api/feeds/news/refresh?dry_run=true", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("dry run failed: %d %s", w.Code, w.Body)
+	}
+	var result struct {
+		Counts map[string]int `json:"counts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if c := result.Counts; c["total"] != 4 || c["duplicates"] != 3 || c["new"] != 1 || c["filtered"] != 0 {
+		t.Errorf("unexpected dry run counts %v", c)
+	}
+	if counts := h.Counts("news"); counts.Total != 3 {
+		t.Errorf("expected the dry run to store nothing, got %d items", counts.Total)
+	}
+
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("second fetch failed: %v", err)
+	}
+	if counts := h.Counts("news"); counts.Total != 3+result.Counts["new"] {
+		t.Errorf("expected the fetch to store what the dry run planned, got %d items", counts.Total)
+	}
+}
+
+func TestPipeline_Inbound(t *testing.T) {
+	h := New(t)
+	h.AddFeed("letters", "type: newsletter\ntitle: \"Letters\"\nsettings:\n  inbound_token: \"0123456789abcdef\"\nfilters:\n  - field: \"title\"\n    excludes: [\"unsubscribe\"]\n")
+	email := "Message-ID: <1@stub.example>\r\nSubject: Weekly digest\r\nFrom: news@stub.example\r\n\r\nHello readers.\r\n"
+
+	for _, headers := range [][]string{nil, {"X-Inbound-Token", "wrong-token-value"}} {
+		if w := h.Do(http.MethodPost, "/inbound/letters", email, headers...); w.Code != http.StatusUnauthorized {
+			t.Errorf("%v: expected 401, got %d", headers, w.Code)
+		}
+	}
+	if w := h.Do(http.MethodPost, "/inbound/letters?token=0123456789abcdef", email); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the token to be refused in the query, got %d", w.Code)
+	}
+
+	w := h.Do(http.MethodPost, "/inbound/letters", email, "X-Inbound-Token", "0123456789abcdef", "Content-Type", "message/rfc822")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body)
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	w = h.Do(http.MethodPost, "/inbound/letters", email, "X-Inbound-Token", "0123456789abcdef", "Content-Type", "message/rfc822")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), created.ID) {
+		t.Errorf("expected a redelivery to return the stored item, got %d: %s", w.Code, w.Body)
+	}
+
+	w = h.Do(http.MethodPost, "/inbound/letters", `{"Subject": "How to unsubscribe", "MessageID": "2@stub.example", "TextBody": "Bye."}`,
+		"X-Inbound-Token", "0123456789abcdef", "Content-Type", "application/json")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for JSON, got %d: %s", w.Code, w.Body)
+	}
+	if counts := h.Counts("letters"); counts.Total != 2 || counts.Filtered != 1 {
+		t.Errorf("expected two emails, one filtered, got %+v", counts)
+	}
+
+	if w := h.API(http.MethodPost, "/api/feeds/letters/refresh?dry_run=true", ""); w.Code != http.StatusConflict {
+		t.Errorf("expected a newsletter dry run to be refused with 409, got %d", w.Code)
+	}
+}
+
+func TestPipeline_SitemapTitles(t *testing.T) {
+	h := New(t)
+	h.Upstream.Serve("/release-2", Page, Entry{Title: "Release notes 2.0", Description: "<p>" + strings.Repeat("Everything that changed in this release. ", 40) + "</p>"})
+	page := Entry{Link: h.Upstream.URL("/release-2"), Published: Start.Add(-time.Hour)}
+	h.Upstream.Serve("/sitemap.xml", Sitemap, page)
+	h.AddFeed("site", "type: sitemap\nurl: \"{{upstream}}/sitemap.xml\"\nfilters:\n  - field: \"title\"\n    includes: [\"notes\"]\n    weight: 5\n")
+
+	ctx := context.Background()
+	item := func() (string, int) {
+		t.Helper()
+		stored, err := h.Items.GetItemByID(ctx, h.ItemID("site", page.Link))
+		if err != nil || stored == nil {
+			t.Fatalf("item: %v", err)
+		}
+		return stored.Title, stored.Score
+	}
+
+	if err := h.Fetch("site"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if err := h.Extract("site", page.Link); err != nil {
+		t.Fatalf("extraction failed: %v", err)
+	}
+	if title, score := item(); title != "Release notes 2.0" || score != 5 {
+		t.Errorf("expected the page title, scored, got %q (%d)", title, score)
+	}
+
+	// A new lastmod stores the item again with the URL-derived stand-in.
+	page.Published = Start
+	h.Upstream.Serve("/sitemap.xml", Sitemap, page)
+	if err := h.Fetch("site"); err != nil {
+		t.Fatalf("second fetch failed: %v", err)
+	}
+	if title, _ := item(); title != "Release notes 2.0" {
+		t.Errorf("expected the update to keep the extracted title, got %q", title)
+	}
+	if err := h.Extract("site", page.Link); err != nil {
+		t.Fatalf("second extraction failed: %v", err)
+	}
+	if _, score := item(); score != 5 {
+		t.Errorf("expected extraction to score the page title again, got %d", score)
+	}
+}
+
+func TestPipeline_Delay(t *testing.T) {
+	h := New(t)
+	upcoming := entry(4)
+	upcoming.Published = Start.Add(time.Hour)
+	h.Upstream.Serve("/feed", RSS, upcoming, entry(9), entry(1))
+	h.AddFeed("news", "url: \"{{upstream}}/feed\"\n")
+	h.AddFeed("delayed", "url: \"{{upstream}}/feed\"\nsettings:\n  delay: \"2h\"\n")
+
+	for _, name := range []string{"news", "delayed"} {
+		if err := h.Fetch(name); err != nil {
+			t.Fatalf("%s: fetch failed: %v", name, err)
+		}
+	}
+
+	// Without a delay, items dated in the future are shown right away.
+	body := h.Get("/feeds/news").Body.String()
+	for _, title := range []string{"Post number 1", "Post number 9", "Post number 4"} {
+		if !strings.Contains(body, title) {
+			t.Errorf("news: output is missing %q", title)
+		}
+	}
+
+	// Post 9 is an hour old and post 4 is upcoming, so only post 1 is past
+	// the delay.
+	body = h.Get("/feeds/delayed").Body.String()
+	if !strings.Contains(body, "Post number 1") || strings.Contains(body, "Post number 9") || strings.Contains(body, "Post number 4") {
+		t.Errorf("delayed: expected only post 1, got %s", body)
+	}
+
+	h.Clock.Advance(90 * time.Minute)
+	body = h.Get("/feeds/delayed").Body.String()
+	if !strings.Contains(body, "Post number 9") || strings.Contains(body, "Post number 4") {
+		t.Errorf("delayed: expected post 9 after its delay, got %s", body)
+	}
+}
+
+func TestPipeline_UpcomingEvents(t *testing.T) {
+	h := New(t)
+	upcoming := entry(2)
+	upcoming.Published = Start.Add(7 * 24 * time.Hour)
+	h.Upstream.Serve("/calendar.ics", ICS, upcoming, entry(1))
+	h.AddFeed("events", "url: \"{{upstream}}/calendar.ics\"\ntype: \"ics\"\n")
+
+	if err := h.Fetch("events"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+
+	// Events are dated by their start, so next week's event is served now.
+	body := h.Get("/feeds/events").Body.String()
+	for _, title := range []string{"Post number 1", "Post number 2"} {
+		if !strings.Contains(body, title) {
+			t.Errorf("output is missing %q", title)
+		}
+	}
+}
+
+func TestPipeline_Analytics(t *testing.T) {
+	h := New(t)
+	h.Upstream.Serve("/feed", RSS, entry(2), entry(1))
+	h.AddFeed("news", "url: \"{{upstream}}/feed\"\nsettings:\n  short_links: true\n")
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+
+	popular, rare := h.ItemID("news", "urn:stub:2"), h.ItemID("news", "urn:stub:1")
+	for range 3 {
+		if w := h.Get("/r/" + popular); w.Code != http.StatusFound {
+			t.Fatalf("expected a redirect, got %d", w.Code)
+		}
+	}
+	h.Get("/r/" + rare)
+
+	// Feeds without short_links don't redirect.
+	h.Upstream.Serve("/plain", RSS, entry(3))
+	h.AddFeed("plain", "url: \"{{upstream}}/plain\"\n")
+	if err := h.Fetch("plain"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if w := h.Get("/r/" + h.ItemID("plain", "urn:stub:3")); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an item of a feed without short_links, got %d", w.Code)
+	}
+
+	var result struct {
+		Feeds []struct {
+			Feed   string `json:"feed"`
+			Clicks int    `json:"clicks"`
+		} `json:"feeds"`
+		Suppressed int `json:"suppressed"`
+		Items      []struct {
+			ID string `json:"id"`
+		} `json:"items"`
+	}
+	w := h.API(http.MethodGet, "/api/analytics?feed=news", "")
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &result) != nil {
+		t.Fatalf("expected analytics, got %d: %s", w.Code, w.Body)
+	}
+	if len(result.Feeds) != 1 || result.Feeds[0].Clicks != 4 || len(result.Items) != 2 {
+		t.Errorf("expected 4 clicks on 2 items, got %+v", result)
+	}
+
+	// Both items were clicked on the same day, so the day isn't suppressed.
+	w = h.API(http.MethodGet, "/api/analytics?feed=news&min_clicks=2", "")
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &result) != nil {
+		t.Fatalf("expected analytics, got %d: %s", w.Code, w.Body)
+	}
+	if result.Suppressed != 0 || result.Feeds[0].Clicks != 4 || len(result.Items) != 1 || result.Items[0].ID != popular {
+		t.Errorf("expected only the popular item, got %+v", result)
+	}
+
+	w = h.API(http.MethodGet, "/api/analytics?feed=news&min_clicks=5", "")
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &result) != nil {
+		t.Fatalf("expected analytics, got %d: %s", w.Code, w.Body)
+	}
+	if result.Suppressed != 4 || len(result.Feeds) != 0 || len(result.Items) != 0 {
+		t.Errorf("expected everything suppressed, got %+v", result)
+	}
+
+	if w := h.API(http.MethodGet, "/api/analytics?feed=missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown feed, got %d", w.Code)
+	}
+}
+
+func TestPipeline_Timeline(t *testing.T) {
+	h := New(t)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	h.SetLocation(berlin)
+	h.Upstream.Serve("/feed", RSS, entry(1))
+	h.AddFeed("news", "url: \"{{upstream}}/feed\"\n")
+
+	// 23:30 UTC on June 1st is 01:30 on June 2nd in Berlin.
+	h.Clock.Advance(11*time.Hour + 30*time.Minute)
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+
+	var result struct {
+		Timeline []struct {
+			Day string `json:"day"`
+			New int    `json:"new"`
+		} `json:"timeline"`
+	}
+	w := h.API(http.MethodGet, "/api/feeds/news/timeline?days=2", "")
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &result) != nil {
+		t.Fatalf("expected a timeline, got %d: %s", w.Code, w.Body)
+	}
+	if len(result.Timeline) != 2 || result.Timeline[0].Day != "2025-06-01" || result.Timeline[0].New != 0 ||
+		result.Timeline[1].Day != "2025-06-02" || result.Timeline[1].New != 1 {
+		t.Errorf("expected the item on the local June 2nd, got %+v", result.Timeline)
+	}
+
+	var stats struct {
+		Items struct {
+			ByDay []struct {
+				Day    string `json:"day"`
+				Stored int    `json:"stored"`
+			} `json:"by_day"`
+		} `json:"items"`
+	}
+	w = h.API(http.MethodGet, "/api/stats/instance?days=2", "")
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &stats) != nil {
+		t.Fatalf("expected stats, got %d: %s", w.Code, w.Body)
+	}
+	if byDay := stats.Items.ByDay; len(byDay) != 1 || byDay[0].Day != "2025-06-02" || byDay[0].Stored != 1 {
+		t.Errorf("expected the instance stats to agree with the timeline, got %+v", byDay)
+	}
+}
+
+func TestPipeline_AnalyticsDays(t *testing.T) {
+	h := New(t)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	h.SetLocation(berlin)
+	h.Upstream.Serve("/feed", RSS, entry(1))
+	h.AddFeed("news", "url: \"{{upstream}}/feed\"\nsettings:\n  short_links: true\n")
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+
+	// 23:30 UTC on June 1st is already June 2nd in Berlin.
+	h.Clock.Advance(11*time.Hour + 30*time.Minute)
+	h.Get("/r/" + h.ItemID("news", "urn:stub:1"))
+
+	var result struct {
+		Since string `json:"since"`
+		Feeds []struct {
+			Clicks int `json:"clicks"`
+		} `json:"feeds"`
+	}
+	w := h.API(http.MethodGet, "/api/analytics?feed=news&days=1", "")
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &result) != nil {
+		t.Fatalf("expected analytics, got %d: %s", w.Code, w.Body)
+	}
+	if result.Since != "2025-06-02" || len(result.Feeds) != 1 || result.Feeds[0].Clicks != 1 {
+		t.Errorf("expected the click on the local June 2nd, got %+v", result)
+	}
+}
+
+func TestPipeline_Archive(t *testing.T) {
+	h := New(t)
+	spam := entry(3)
+	spam.Title = "Sponsored: buy now"
+	h.Upstream.Serve("/feed", RSS, spam, entry(2), entry(1))
+	h.AddFeed("news", `url: "{{upstream}}/feed"
+settings:
+  archive: true
+  max_items: 1
+filters:
+  - field: "title"
+    excludes:
+      - "sponsored"
+`)
+	if err := h.Fetch("news"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	archiver, err := jobs.NewArchiver(dir, h.Feeds, h.Items, time.UTC, h.Clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := archiver.Export(context.Background()); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	// Both visible items are archived, beyond max_items; the filtered one isn't.
+	for guid, want := range map[string]bool{"urn:stub:1": true, "urn:stub:2": true, "urn:stub:3": false} {
+		_, err := os.Stat(filepath.Join(dir, "news", h.ItemID("news", guid)+".html"))
+		if (err == nil) != want {
+			t.Errorf("%s: expected archived = %v, got %v", guid, want, err)
+		}
+	}
+}
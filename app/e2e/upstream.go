@@ -0,0 +1,334 @@
+// Package e2e runs the whole pipeline — fetch, parse, filter, store and
+// serve — against a stub upstream and a throwaway database, for tests of
+// behavior that spans several packages. It is only imported by tests.
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Format is the document format a stub source is served in.
+type Format int
+
+const (
+	RSS Format = iota
+	Atom
+	JSONFeed
+	ICS     // iCalendar, one event per entry starting at its published time
+	Sitemap // One URL per entry: its link, with its published time as lastmod
+	Page    // An HTML article page of the first entry's title and description
+)
+
+// Entry is one item of a stub source.
+type Entry struct {
+	GUID        string
+	Title       string
+	Link        string
+	Description string
+	Published   time.Time
+}
+
+// Upstream is an HTTP server standing in for feed sources. Each path serves
+// a list of entries that tests mutate between fetches. Responses carry an
+// ETag and Last-Modified that change with every mutation and are honored in
+// conditional requests, like a well-behaved origin.
+type Upstream struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	sources map[string]*source
+}
+
+type source struct {
+	format     Format
+	entries    []Entry // newest first
+	version    int
+	modified   time.Time
+	status     int // forced response status, 0 = serve the document
+	retryAfter time.Duration
+
+	requests    int
+	notModified int
+}
+
+// NewUpstream starts an upstream that is closed when the test ends.
+func NewUpstream(t testing.TB) *Upstream {
+	u := &Upstream{sources: make(map[string]*source)}
+	u.server = httptest.NewServer(http.HandlerFunc(u.serve))
+	t.Cleanup(u.server.Close)
+	return u
+}
+
+// URL returns the address of the source at path.
+func (u *Upstream) URL(path string) string {
+	return u.server.URL + path
+}
+
+// Serve replaces the source at path with entries, newest first.
+func (u *Upstream) Serve(path string, format Format, entries ...Entry) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s := u.sources[path]
+	if s == nil {
+		s = &source{}
+		u.sources[path] = s
+	}
+	s.format = format
+	s.entries = append([]Entry(nil), entries...)
+	s.touch()
+}
+
+// Add publishes entries at the top of the source at path.
+func (u *Upstream) Add(path string, entries ...Entry) {
+	u.mutate(path, func(s *source) {
+		s.entries = append(append([]Entry(nil), entries...), s.entries...)
+	})
+}
+
+// ChangeGUIDs rewrites the GUID of every entry at path, as sources do when
+// they move to a new CMS or URL scheme. Titles and links stay the same.
+func (u *Upstream) ChangeGUIDs(path, suffix string) {
+	u.mutate(path, func(s *source) {
+		for i := range s.entries {
+			s.entries[i].GUID += suffix
+		}
+	})
+}
+
+// Fail makes the source at path answer with status until Recover. A
+// positive retryAfter is sent as the Retry-After header.
+func (u *Upstream) Fail(path string, status int, retryAfter time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s := u.mustSource(path)
+	s.status = status
+	s.retryAfter = retryAfter
+}
+
+// Recover makes the source at path serve its document again.
+func (u *Upstream) Recover(path string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.mustSource(path).status = 0
+}
+
+// Requests returns how many requests the source at path received and how
+// many of them were answered with 304 Not Modified.
+func (u *Upstream) Requests(path string) (total, notModified int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s := u.mustSource(path)
+	return s.requests, s.notModified
+}
+
+func (u *Upstream) mutate(path string, fn func(s *source)) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s := u.mustSource(path)
+	fn(s)
+	s.touch()
+}
+
+func (u *Upstream) mustSource(path string) *source {
+	s := u.sources[path]
+	if s == nil {
+		panic("e2e: no source at " + path)
+	}
+	return s
+}
+
+func (s *source) touch() {
+	s.version++
+	// Last-Modified has second precision; each version gets its own second.
+	s.modified = time.Date(2024, 1, 1, 0, 0, s.version, 0, time.UTC)
+}
+
+func (s *source) etag() string {
+	return `"v` + strconv.Itoa(s.version) + `"`
+}
+
+func (u *Upstream) serve(w http.ResponseWriter, r *http.Request) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s := u.sources[r.URL.Path]
+	if s == nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.requests++
+
+	if s.status != 0 {
+		if s.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(s.retryAfter.Seconds())))
+		}
+		http.Error(w, http.StatusText(s.status), s.status)
+		return
+	}
+
+	w.Header().Set("ETag", s.etag())
+	w.Header().Set("Last-Modified", s.modified.Format(http.TimeFormat))
+	if r.Header.Get("If-None-Match") == s.etag() {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, contentType := render(s.format, r.URL.Path, s.entries)
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+func render(format Format, path string, entries []Entry) ([]byte, string) {
+	title := "Stub " + path
+	switch format {
+	case Atom:
+		return renderAtom(title, entries), "application/atom+xml"
+	case JSONFeed:
+		return renderJSONFeed(title, entries), "application/feed+json"
+	case ICS:
+		return renderICS(title, entries), "text/calendar"
+	case Sitemap:
+		return renderSitemap(entries), "application/xml"
+	case Page:
+		return renderPage(entries[0]), "text/html; charset=utf-8"
+	default:
+		return renderRSS(title, entries), "application/rss+xml"
+	}
+}
+
+func renderRSS(title string, entries []Entry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<rss version=\"2.0\">\n<channel>\n")
+	writeElement(&buf, "title", title)
+	buf.WriteString("<link>https://stub.example/</link>\n")
+	for _, e := range entries {
+		buf.WriteString("<item>\n")
+		writeElement(&buf, "title", e.Title)
+		writeElement(&buf, "link", e.Link)
+		buf.WriteString(`<guid isPermaLink="false">`)
+		xml.EscapeText(&buf, []byte(e.GUID))
+		buf.WriteString("</guid>\n")
+		writeElement(&buf, "pubDate", e.Published.UTC().Format(time.RFC1123Z))
+		writeElement(&buf, "description", e.Description)
+		buf.WriteString("</item>\n")
+	}
+	buf.WriteString("</channel>\n</rss>\n")
+	return buf.Bytes()
+}
+
+func renderAtom(title string, entries []Entry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<feed xmlns=\"http://www.w3.org/2005/Atom\">\n")
+	writeElement(&buf, "title", title)
+	buf.WriteString("<id>https://stub.example/</id>\n")
+	if len(entries) > 0 {
+		writeElement(&buf, "updated", entries[0].Published.UTC().Format(time.RFC3339))
+	}
+	for _, e := range entries {
+		buf.WriteString("<entry>\n")
+		writeElement(&buf, "id", e.GUID)
+		writeElement(&buf, "title", e.Title)
+		buf.WriteString(`<link href="`)
+		xml.EscapeText(&buf, []byte(e.Link))
+		buf.WriteString("\"/>\n")
+		writeElement(&buf, "published", e.Published.UTC().Format(time.RFC3339))
+		writeElement(&buf, "updated", e.Published.UTC().Format(time.RFC3339))
+		writeElement(&buf, "summary", e.Description)
+		buf.WriteString("</entry>\n")
+	}
+	buf.WriteString("</feed>\n")
+	return buf.Bytes()
+}
+
+func renderSitemap(entries []Entry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">\n")
+	for _, e := range entries {
+		buf.WriteString("<url>\n")
+		writeElement(&buf, "loc", e.Link)
+		writeElement(&buf, "lastmod", e.Published.UTC().Format(time.RFC3339))
+		buf.WriteString("</url>\n")
+	}
+	buf.WriteString("</urlset>\n")
+	return buf.Bytes()
+}
+
+func renderPage(e Entry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	writeElement(&buf, "title", e.Title)
+	buf.WriteString("</head>\n<body>\n<article>\n")
+	writeElement(&buf, "h1", e.Title)
+	buf.WriteString(e.Description)
+	buf.WriteString("\n</article>\n</body>\n</html>\n")
+	return buf.Bytes()
+}
+
+func writeElement(buf *bytes.Buffer, name, text string) {
+	fmt.Fprintf(buf, "<%s>", name)
+	xml.EscapeText(buf, []byte(text))
+	fmt.Fprintf(buf, "</%s>\n", name)
+}
+
+func renderJSONFeed(title string, entries []Entry) []byte {
+	type jsonItem struct {
+		ID            string `json:"id"`
+		URL           string `json:"url"`
+		Title         string `json:"title"`
+		ContentHTML   string `json:"content_html"`
+		DatePublished string `json:"date_published"`
+	}
+	doc := struct {
+		Version     string     `json:"version"`
+		Title       string     `json:"title"`
+		HomePageURL string     `json:"home_page_url"`
+		Items       []jsonItem `json:"items"`
+	}{Version: "https://jsonfeed.org/version/1.1", Title: title, HomePageURL: "https://stub.example/", Items: []jsonItem{}}
+
+	for _, e := range entries {
+		doc.Items = append(doc.Items, jsonItem{
+			ID:            e.GUID,
+			URL:           e.Link,
+			Title:         e.Title,
+			ContentHTML:   e.Description,
+			DatePublished: e.Published.UTC().Format(time.RFC3339),
+		})
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func renderICS(title string, entries []Entry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//rss-comb//e2e//EN\r\n")
+	fmt.Fprintf(&buf, "X-WR-CALNAME:%s\r\n", title)
+	for _, e := range entries {
+		buf.WriteString("BEGIN:VEVENT\r\n")
+		fmt.Fprintf(&buf, "UID:%s\r\n", e.GUID)
+		fmt.Fprintf(&buf, "DTSTART:%s\r\n", e.Published.UTC().Format("20060102T150405Z"))
+		fmt.Fprintf(&buf, "SUMMARY:%s\r\n", e.Title)
+		fmt.Fprintf(&buf, "URL:%s\r\n", e.Link)
+		buf.WriteString("END:VEVENT\r\n")
+	}
+	buf.WriteString("END:VCALENDAR\r\n")
+	return buf.Bytes()
+}
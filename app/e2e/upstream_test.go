@@ -0,0 +1,112 @@
+package e2e
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/mmcdole/gofeed"
+)
+
+func TestUpstream_Formats(t *testing.T) {
+	u := NewUpstream(t)
+
+	for _, format := range []Format{RSS, Atom, JSONFeed} {
+		u.Serve("/feed", format, entry(2), entry(1))
+
+		resp, err := http.Get(u.URL("/feed"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := gofeed.NewParser().Parse(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("format %d: %v", format, err)
+		}
+		if len(parsed.Items) != 2 || parsed.Items[0].GUID != "urn:stub:2" || parsed.Items[0].Title != "Post number 2" {
+			t.Errorf("format %d: unexpected items %+v", format, parsed.Items)
+		}
+	}
+}
+
+func TestUpstream_ICS(t *testing.T) {
+	u := NewUpstream(t)
+	u.Serve("/calendar.ics", ICS, entry(2), entry(1))
+
+	resp, err := http.Get(u.URL("/calendar.ics"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, items, err := feed.ForType("ics").Parse(data, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].GUID != "urn:stub:2" || !items[0].PublishedAt.Equal(entry(2).Published) {
+		t.Errorf("unexpected items %+v", items)
+	}
+}
+
+func TestUpstream_Conditional(t *testing.T) {
+	u := NewUpstream(t)
+	u.Serve("/feed", RSS, entry(1))
+
+	get := func(etag string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, u.URL("/feed"), nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	etag := get("").Header.Get("ETag")
+	if resp := get(etag); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 for an unchanged source, got %d", resp.StatusCode)
+	}
+
+	u.Add("/feed", entry(2))
+	if resp := get(etag); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 after a mutation, got %d", resp.StatusCode)
+	}
+
+	u.Fail("/feed", http.StatusTooManyRequests, 2*time.Minute)
+	if resp := get(""); resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "120" {
+		t.Errorf("expected 429 with Retry-After, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	if total, notModified := u.Requests("/feed"); total != 4 || notModified != 1 {
+		t.Errorf("expected 4 requests and one 304, got %d and %d", total, notModified)
+	}
+}
+
+func TestUpstream_ChangeGUIDs(t *testing.T) {
+	u := NewUpstream(t)
+	u.Serve("/feed", RSS, entry(1))
+	u.ChangeGUIDs("/feed", "-new")
+
+	resp, err := http.Get(u.URL("/feed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	parsed, err := gofeed.NewParser().Parse(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guid := parsed.Items[0].GUID; !strings.HasSuffix(guid, "-new") {
+		t.Errorf("expected the changed GUID, got %q", guid)
+	}
+}
@@ -0,0 +1,155 @@
+package feed
+
+import (
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+)
+
+// The archive is a static site: an index of archived feeds, a page per feed
+// listing its items and a page per item. Like the preview, it shows item
+// text rather than source markup, so it can be hosted on any origin.
+const archiveStyle = `<style>
+body { font-family: system-ui, sans-serif; max-width: 46rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; color: #222; }
+.meta, nav { color: #666; font-size: 0.9rem; }
+li { margin: 0.4rem 0; }
+</style>`
+
+var archiveTemplates = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Archive</title>
+` + archiveStyle + `
+</head>
+<body>
+<h1>Archive</h1>
+<ul>
+{{range .}}<li><a href="{{.Name}}/index.html">{{.Title}}</a> <span class="meta">{{.ItemCount}} items</span></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+func init() {
+	template.Must(archiveTemplates.New("feed").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Feed.Title}}</title>
+` + archiveStyle + `
+</head>
+<body>
+<nav><a href="../index.html">Archive</a></nav>
+<h1>{{.Feed.Title}}</h1>
+{{with .Feed.Description}}<p>{{.}}</p>{{end}}
+{{with .Feed.Link}}<p class="meta"><a href="{{.}}" rel="noopener noreferrer">{{.}}</a></p>{{end}}
+<ul>
+{{range .Items}}<li><a href="{{.ID}}.html">{{.Title}}</a> <span class="meta">{{.PublishedAt.Format "2006-01-02"}}</span></li>
+{{else}}<li>No items yet.</li>
+{{end}}</ul>
+</body>
+</html>
+`))
+	template.Must(archiveTemplates.New("item").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Item.Title}} – {{.Feed.Title}}</title>
+{{with .Item.Link}}<link rel="canonical" href="{{.}}">{{end}}
+` + archiveStyle + `
+</head>
+<body>
+<nav><a href="../index.html">Archive</a> › <a href="index.html">{{.Feed.Title}}</a></nav>
+<article>
+<h1>{{.Item.Title}}</h1>
+<p class="meta">{{.Item.PublishedAt.Format "2006-01-02 15:04"}}{{with .Item.Author}} · {{.}}{{end}}</p>
+{{range .Item.Paragraphs}}<p>{{.}}</p>
+{{end}}{{with .Item.Link}}<p><a href="{{.}}" rel="noopener noreferrer">Read the original</a></p>{{end}}
+</article>
+</body>
+</html>
+`))
+}
+
+// ArchiveFeed describes a feed on the archive index.
+type ArchiveFeed struct {
+	Name        string
+	Title       string
+	Description string
+	Link        string
+	ItemCount   int
+}
+
+// ArchiveItem is an item prepared for its archive page.
+type ArchiveItem struct {
+	ID          string
+	Title       string
+	Link        string
+	Author      string
+	PublishedAt time.Time
+	Paragraphs  []string // Text of the content, or of the description
+}
+
+// NewArchiveItem prepares a stored item for the archive, with its date in
+// location.
+func NewArchiveItem(item database.Item, location *time.Location) ArchiveItem {
+	body := item.Content
+	if strings.TrimSpace(body) == "" {
+		body = item.Description
+	}
+	archived := ArchiveItem{
+		ID:          item.ID,
+		Title:       item.Title,
+		Link:        item.Link,
+		PublishedAt: item.PublishedAt.In(location),
+		Paragraphs:  textParagraphs(body),
+	}
+	if len(item.Authors) > 0 {
+		archived.Author = item.Authors[0]
+	}
+	return archived
+}
+
+// blockBoundary splits HTML into paragraphs at block-level tags.
+var blockBoundary = regexp.MustCompile(`(?i)<br\s*/?>|</?(p|div|li|h[1-6]|blockquote|pre|tr|section|article)\b[^>]*>`)
+
+// textParagraphs returns the text of an HTML fragment as paragraphs, with
+// scripts and styles dropped.
+func textParagraphs(s string) []string {
+	var paragraphs []string
+	for _, block := range blockBoundary.Split(scriptOrStyle.ReplaceAllString(s, " "), -1) {
+		if text := strings.Join(plainText(block), " "); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	return paragraphs
+}
+
+// WriteArchiveIndex renders the archive's front page listing its feeds.
+func WriteArchiveIndex(w io.Writer, feeds []ArchiveFeed) error {
+	return archiveTemplates.ExecuteTemplate(w, "index", feeds)
+}
+
+// WriteArchiveFeed renders a feed's page listing its items, newest first.
+func WriteArchiveFeed(w io.Writer, f ArchiveFeed, items []ArchiveItem) error {
+	return archiveTemplates.ExecuteTemplate(w, "feed", struct {
+		Feed  ArchiveFeed
+		Items []ArchiveItem
+	}{f, items})
+}
+
+// WriteArchiveItem renders an item's page.
+func WriteArchiveItem(w io.Writer, f ArchiveFeed, item ArchiveItem) error {
+	return archiveTemplates.ExecuteTemplate(w, "item", struct {
+		Feed ArchiveFeed
+		Item ArchiveItem
+	}{f, item})
+}
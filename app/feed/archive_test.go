@@ -0,0 +1,66 @@
+package feed
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestNewArchiveItem(t *testing.T) {
+	published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	item := database.Item{ID: "a", Item: types.Item{
+		Title:       "First",
+		Description: "<p>Short</p>",
+		Content:     `<h2>Intro</h2><p>Hello <b>world</b></p><script>alert(1)</script>Line<br>Next`,
+		Authors:     []string{"Jane"},
+		PublishedAt: published,
+	}}
+
+	archived := NewArchiveItem(item, time.FixedZone("UTC+2", 2*3600))
+
+	want := []string{"Intro", "Hello world", "Line", "Next"}
+	if !reflect.DeepEqual(archived.Paragraphs, want) {
+		t.Errorf("expected paragraphs %q, got %q", want, archived.Paragraphs)
+	}
+	if archived.Author != "Jane" || archived.PublishedAt.Hour() != 14 {
+		t.Errorf("unexpected author %q or date %v", archived.Author, archived.PublishedAt)
+	}
+
+	item.Content = ""
+	if got := NewArchiveItem(item, time.UTC).Paragraphs; !reflect.DeepEqual(got, []string{"Short"}) {
+		t.Errorf("expected the description without content, got %q", got)
+	}
+}
+
+func TestWriteArchivePages(t *testing.T) {
+	f := ArchiveFeed{Name: "news", Title: "News & Views", Link: "https://example.com", ItemCount: 1}
+	item := ArchiveItem{ID: "abc", Title: "<Hello>", Link: "https://example.com/1", PublishedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Paragraphs: []string{"<script>alert(1)</script>"}}
+
+	var index, feedPage, itemPage strings.Builder
+	if err := WriteArchiveIndex(&index, []ArchiveFeed{f}); err != nil {
+		t.Fatalf("WriteArchiveIndex failed: %v", err)
+	}
+	if err := WriteArchiveFeed(&feedPage, f, []ArchiveItem{item}); err != nil {
+		t.Fatalf("WriteArchiveFeed failed: %v", err)
+	}
+	if err := WriteArchiveItem(&itemPage, f, item); err != nil {
+		t.Fatalf("WriteArchiveItem failed: %v", err)
+	}
+
+	for page, wants := range map[string][]string{
+		index.String():    {`<a href="news/index.html">News &amp; Views</a>`, "1 items"},
+		feedPage.String(): {`<a href="abc.html">&lt;Hello&gt;</a>`, "2024-03-01", `<a href="../index.html">`},
+		itemPage.String(): {`<link rel="canonical" href="https://example.com/1">`, "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>", `<a href="index.html">News &amp; Views</a>`},
+	} {
+		for _, want := range wants {
+			if !strings.Contains(page, want) {
+				t.Errorf("expected %s in page:\n%s", want, page)
+			}
+		}
+	}
+}
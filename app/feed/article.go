@@ -0,0 +1,183 @@
+package feed
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// maxMetaRefreshDelay is the longest meta refresh treated as a redirect;
+// longer ones are pages reloading themselves, not pointers elsewhere.
+const maxMetaRefreshDelay = 10
+
+// DecodeHTML converts an HTML page to UTF-8. The charset is forced when
+// set, otherwise taken from a BOM, the Content-Type header or the page's
+// <meta> tags, then sniffed as UTF-8 with windows-1252 as the fallback, as
+// browsers do.
+func DecodeHTML(data []byte, contentType, forced string) ([]byte, error) {
+	if forced != "" {
+		enc, name := charset.Lookup(forced)
+		if enc == nil {
+			return nil, fmt.Errorf("unknown charset %q", forced)
+		}
+		if name == "utf-8" {
+			return data, nil
+		}
+		decoded, err := enc.NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s page: %w", name, err)
+		}
+		return decoded, nil
+	}
+
+	enc, name, _ := charset.DetermineEncoding(data, contentType)
+	if name == "utf-8" {
+		return data, nil
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s page: %w", name, err)
+	}
+	return decoded, nil
+}
+
+// ValidCharset reports whether name is a charset DecodeHTML can force.
+func ValidCharset(name string) bool {
+	enc, _ := charset.Lookup(name)
+	return enc != nil
+}
+
+// MetaRefreshURL returns the absolute URL a page redirects to with
+// <meta http-equiv="refresh" content="0; url=...">, or "" if it doesn't
+// redirect elsewhere.
+func MetaRefreshURL(page []byte, pageURL string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) == "body" {
+				return ""
+			}
+			if string(name) != "meta" || !hasAttr {
+				continue
+			}
+
+			var equiv, content string
+			for {
+				key, val, more := z.TagAttr()
+				switch string(key) {
+				case "http-equiv":
+					equiv = string(val)
+				case "content":
+					content = string(val)
+				}
+				if !more {
+					break
+				}
+			}
+			if !strings.EqualFold(equiv, "refresh") {
+				continue
+			}
+
+			target := parseRefresh(content)
+			if target == "" {
+				return ""
+			}
+			u, err := base.Parse(target)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.String() == base.String() {
+				return ""
+			}
+			return u.String()
+		}
+	}
+}
+
+// parseRefresh returns the URL of a refresh directive such as
+// "0; URL='/next'", or "" when it has none or waits too long.
+func parseRefresh(content string) string {
+	delay, rest, _ := strings.Cut(content, ";")
+	if seconds, err := strconv.ParseFloat(strings.TrimSpace(delay), 64); err != nil || seconds > maxMetaRefreshDelay {
+		return ""
+	}
+
+	rest = strings.TrimSpace(rest)
+	if len(rest) >= 4 && strings.EqualFold(rest[:3], "url") {
+		if after, ok := strings.CutPrefix(strings.TrimSpace(rest[3:]), "="); ok {
+			rest = strings.TrimSpace(after)
+		}
+	}
+	return strings.Trim(rest, `'"`)
+}
+
+// PageTitle returns a page's og:title, or its <title> when it has none.
+// Only the <head> is read.
+func PageTitle(page []byte) string {
+	var ogTitle, title string
+	inTitle := false
+	result := func() string {
+		return strings.Join(strings.Fields(cmp.Or(ogTitle, title)), " ")
+	}
+
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return result()
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = string(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return result()
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "body":
+				return result()
+			case "title":
+				inTitle = true
+				continue
+			}
+			if string(name) != "meta" || !hasAttr {
+				continue
+			}
+
+			var property, content string
+			for {
+				key, val, more := z.TagAttr()
+				switch string(key) {
+				case "property", "name":
+					property = string(val)
+				case "content":
+					content = string(val)
+				}
+				if !more {
+					break
+				}
+			}
+			if property == "og:title" && ogTitle == "" {
+				ogTitle = content
+			}
+		}
+	}
+}
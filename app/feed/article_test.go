@@ -0,0 +1,60 @@
+package feed
+
+import "testing"
+
+func TestDecodeHTML(t *testing.T) {
+	// "Привет" in windows-1251
+	cp1251 := []byte{0xcf, 0xf0, 0xe8, 0xe2, 0xe5, 0xf2}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		forced      string
+		want        string
+	}{
+		{name: "utf-8 passthrough", data: []byte("<p>Привет</p>"), contentType: "text/html; charset=utf-8", want: "<p>Привет</p>"},
+		{name: "header charset", data: append([]byte("<p>"), append(cp1251, "</p>"...)...), contentType: "text/html; charset=windows-1251", want: "<p>Привет</p>"},
+		{name: "meta charset", data: append([]byte(`<meta charset="windows-1251"><p>`), cp1251...), contentType: "text/html", want: `<meta charset="windows-1251"><p>Привет`},
+		{name: "forced overrides header", data: cp1251, contentType: "text/html; charset=utf-8", forced: "cp1251", want: "Привет"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeHTML(tt.data, tt.contentType, tt.forced)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := DecodeHTML([]byte("x"), "", "no-such-charset"); err == nil {
+		t.Error("expected error for unknown charset")
+	}
+}
+
+func TestMetaRefreshURL(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{name: "relative", page: `<head><meta http-equiv="refresh" content="0; url=/story/1"></head>`, want: "https://example.com/story/1"},
+		{name: "quoted and uppercase", page: `<head><META HTTP-EQUIV="Refresh" CONTENT="1;URL='https://other.example/a'"></head>`, want: "https://other.example/a"},
+		{name: "reload without url", page: `<head><meta http-equiv="refresh" content="300"></head>`, want: ""},
+		{name: "long delay", page: `<head><meta http-equiv="refresh" content="60; url=/next"></head>`, want: ""},
+		{name: "self", page: `<head><meta http-equiv="refresh" content="0; url=https://example.com/a"></head>`, want: ""},
+		{name: "none", page: `<head><title>x</title></head><body>text</body>`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MetaRefreshURL([]byte(tt.page), "https://example.com/a"); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package feed
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// writeAtom renders a document as Atom 1.0. Entries carry their own
+// updated time and every author, and content is sent as XHTML rather than
+// escaped HTML. RSS-only channel data (ttl, skip hours and days, iTunes
+// tags) has no Atom counterpart and is left out.
+func writeAtom(w feedWriter, doc *Document) {
+	w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	w.WriteString("\n")
+	w.WriteString(`<feed xmlns="http://www.w3.org/2005/Atom"`)
+	if doc.Language != "" {
+		w.WriteString(fmt.Sprintf(" xml:lang=\"%s\"", html.EscapeString(doc.Language)))
+	}
+	writeNamespaces(w, doc.Namespaces)
+	w.WriteString(">\n")
+
+	writeAtomFeed(w, doc)
+
+	for _, item := range doc.Items {
+		writeAtomEntry(w, doc, item)
+	}
+
+	w.WriteString("</feed>")
+}
+
+func writeAtomFeed(w feedWriter, doc *Document) {
+	writeElement(w, "id", doc.SelfURL, 2)
+	w.WriteString("  <title>")
+	xml.EscapeText(w, []byte(doc.Title))
+	w.WriteString("</title>\n")
+	writeElement(w, "subtitle", doc.Description, 2)
+	w.WriteString(fmt.Sprintf("  <link rel=\"self\" type=\"application/atom+xml\" href=\"%s\" />\n",
+		html.EscapeString(FormatAtom.URL(doc.SelfURL))))
+	if doc.Link != "" {
+		w.WriteString(fmt.Sprintf("  <link rel=\"alternate\" href=\"%s\" />\n", html.EscapeString(doc.Link)))
+	}
+	writeElement(w, "updated", doc.LastBuildDate.Format(time.RFC3339), 2)
+	writeElement(w, "generator", doc.Generator, 2)
+	writeElement(w, "logo", doc.ImageURL, 2)
+
+	// A feed-level author keeps entries without one valid.
+	author := doc.Title
+	if doc.ITunes != nil {
+		author = cmp.Or(doc.ITunes.Author, author)
+	}
+	writeAtomAuthor(w, author, 2)
+
+	if doc.Provenance != nil {
+		writeProvenance(w, doc, 2)
+	}
+
+	if doc.Robots != "" {
+		w.WriteString(fmt.Sprintf("  <xhtml:meta xmlns:xhtml=\"http://www.w3.org/1999/xhtml\" name=\"robots\" content=\"%s\" />\n",
+			html.EscapeString(doc.Robots)))
+	}
+
+	// Unprefixed channel elements are RSS vocabulary; only namespaced
+	// extensions carry over.
+	var elements []types.ChannelElement
+	for _, element := range doc.Elements {
+		if strings.Contains(element.Name, ":") {
+			elements = append(elements, element)
+		}
+	}
+	writeChannelElements(w, elements, 2)
+}
+
+func writeAtomEntry(w feedWriter, doc *Document, item DocumentItem) {
+	w.WriteString("  <entry>\n")
+
+	writeElement(w, "id", atomID(doc, item), 4)
+	w.WriteString("    <title>")
+	xml.EscapeText(w, []byte(item.Title))
+	w.WriteString("</title>\n")
+	if item.Link != "" {
+		w.WriteString(fmt.Sprintf("    <link rel=\"alternate\" href=\"%s\" />\n", html.EscapeString(item.Link)))
+	}
+	writeElement(w, "published", item.PublishedAt.Format(time.RFC3339), 4)
+	writeElement(w, "updated", cmp.Or(item.UpdatedAt, item.PublishedAt).Format(time.RFC3339), 4)
+
+	for _, author := range item.Authors {
+		writeAtomAuthor(w, author, 4)
+	}
+
+	for _, category := range item.Categories {
+		w.WriteString(fmt.Sprintf("    <category term=\"%s\" />\n", html.EscapeString(category)))
+	}
+
+	content := item.Description
+	if item.Content != "" {
+		if item.Description != "" {
+			w.WriteString("    <summary type=\"html\">")
+			xml.EscapeText(w, []byte(item.Description))
+			w.WriteString("</summary>\n")
+		}
+		content = item.Content
+	}
+	if content != "" {
+		w.WriteString("    <content type=\"xhtml\"><div xmlns=\"http://www.w3.org/1999/xhtml\">")
+		writeXHTML(w, content)
+		w.WriteString("</div></content>\n")
+	}
+
+	if enc := item.Enclosure; enc != nil {
+		w.WriteString(fmt.Sprintf("    <link rel=\"enclosure\" href=\"%s\" length=\"%d\" type=\"%s\" />\n",
+			html.EscapeString(enc.URL), enc.Length, html.EscapeString(enc.Type)))
+	}
+
+	if doc.Provenance != nil {
+		w.WriteString("    <source>\n")
+		writeElement(w, "id", doc.Provenance.SourceURL, 6)
+		writeElement(w, "title", doc.Provenance.SourceTitle, 6)
+		w.WriteString(fmt.Sprintf("      <link rel=\"self\" href=\"%s\" />\n", html.EscapeString(doc.Provenance.SourceURL)))
+		w.WriteString("    </source>\n")
+	}
+
+	w.WriteString("  </entry>\n")
+}
+
+// writeAtomAuthor writes an RSS-style author as Atom's name and email.
+func writeAtomAuthor(w feedWriter, author string, indent int) {
+	name, email := splitAuthor(author)
+	if name == "" {
+		return
+	}
+
+	pad := strings.Repeat(" ", indent)
+	w.WriteString(pad + "<author>\n")
+	writeElement(w, "name", name, indent+2)
+	writeElement(w, "email", email, indent+2)
+	w.WriteString(pad + "</author>\n")
+}
+
+// atomID returns the entry's GUID when it is already an IRI. Other GUIDs,
+// like bare numbers, are wrapped in a URN scoped to the feed so entries
+// stay unique across feeds.
+func atomID(doc *Document, item DocumentItem) string {
+	if item.GUID == "" {
+		return cmp.Or(item.Link, fmt.Sprintf("urn:rss-comb:%s:%s", url.PathEscape(doc.Name), item.ID))
+	}
+	if u, err := url.Parse(item.GUID); err == nil && u.Scheme != "" && u.Opaque+u.Host+u.Path != "" {
+		return item.GUID
+	}
+	return fmt.Sprintf("urn:rss-comb:%s:%s", url.PathEscape(doc.Name), url.PathEscape(item.GUID))
+}
@@ -0,0 +1,126 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestBuildFormat_Atom(t *testing.T) {
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC, Version: "test"}
+	published := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	changed := published.Add(3 * time.Hour)
+	items := []database.Item{
+		{ID: "a", ChangedAt: &changed, Item: types.Item{GUID: "https://example.com/a", Title: "A & B", Link: "https://example.com/a",
+			Description: "<p>Intro<br>text", Content: "<p>Full <img src=x> text<script>alert(1)</script>",
+			PublishedAt: published, Authors: []string{"jane@example.com (Jane Doe)", "John Roe"}, Categories: []string{"go"}}},
+		{ID: "b", Item: types.Item{GUID: "1234", Title: "B", Link: "https://example.com/b", Description: "Plain", PublishedAt: published}},
+	}
+	f := database.Feed{Name: "test", Title: "Test", Link: "https://example.com", FeedURL: "https://example.com/feed.xml"}
+
+	out, err := BuildFormat(ForType(""), FormatAtom, f, items, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Links   []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Authors   []struct {
+				Name  string `xml:"name"`
+				Email string `xml:"email"`
+			} `xml:"author"`
+			Summary string `xml:"summary"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Inner string `xml:",innerxml"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, out)
+	}
+
+	if doc.ID != "https://feeds.example.com/feeds/test" || doc.Updated != "2024-01-01T09:00:00Z" {
+		t.Errorf("unexpected feed id %q or updated %q", doc.ID, doc.Updated)
+	}
+	if len(doc.Links) != 2 || doc.Links[0].Rel != "self" || doc.Links[0].Href != "https://feeds.example.com/feeds/test.atom" {
+		t.Errorf("unexpected feed links %+v", doc.Links)
+	}
+	if len(doc.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(doc.Entries))
+	}
+
+	a := doc.Entries[0]
+	if a.ID != "https://example.com/a" || a.Title != "A & B" {
+		t.Errorf("unexpected entry id %q or title %q", a.ID, a.Title)
+	}
+	if a.Published != "2024-01-01T09:00:00Z" || a.Updated != "2024-01-01T12:00:00Z" {
+		t.Errorf("expected the change time as updated, got %q and %q", a.Published, a.Updated)
+	}
+	if len(a.Authors) != 2 || a.Authors[0].Name != "Jane Doe" || a.Authors[0].Email != "jane@example.com" || a.Authors[1].Name != "John Roe" {
+		t.Errorf("unexpected authors %+v", a.Authors)
+	}
+	if a.Summary != "<p>Intro<br>text" {
+		t.Errorf("expected the description as HTML summary, got %q", a.Summary)
+	}
+	if a.Content.Type != "xhtml" || !strings.Contains(a.Content.Inner, `<p>Full <img src="x"/> text</p>`) || strings.Contains(a.Content.Inner, "alert") {
+		t.Errorf("unexpected content %q", a.Content.Inner)
+	}
+
+	b := doc.Entries[1]
+	if b.ID != "urn:rss-comb:test:1234" {
+		t.Errorf("expected a URN for a GUID that isn't an IRI, got %q", b.ID)
+	}
+	if b.Updated != b.Published || b.Summary != "" || !strings.Contains(b.Content.Inner, "Plain") {
+		t.Errorf("unexpected entry %+v", b)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"", "rss"} {
+		if format, err := ParseFormat(s); err != nil || format != FormatRSS {
+			t.Errorf("%q: got %q, %v", s, format, err)
+		}
+	}
+	for _, want := range []Format{FormatAtom, FormatJSON} {
+		if format, err := ParseFormat(string(want)); err != nil || format != want {
+			t.Errorf("%s: got %q, %v", want, format, err)
+		}
+	}
+	if _, err := ParseFormat("rdf"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestBuildPlaceholder_Atom(t *testing.T) {
+	c := &cfg.Cfg{Location: time.UTC, Port: "8080"}
+	out := BuildError("broken", FormatAtom, c)
+
+	var doc struct {
+		Entries []struct {
+			ID string `xml:"id"`
+		} `xml:"http://www.w3.org/2005/Atom entry"`
+	}
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("error feed is not valid XML: %v\n%s", err, out)
+	}
+	if len(doc.Entries) != 1 || !strings.HasPrefix(doc.Entries[0].ID, "rss-comb:error:broken:") {
+		t.Errorf("unexpected entries %+v", doc.Entries)
+	}
+}
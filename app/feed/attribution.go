@@ -0,0 +1,47 @@
+package feed
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+)
+
+var attributionPlaceholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// attributionFields are the placeholders an attribution footer may use.
+var attributionFields = map[string]bool{
+	"{link}":         true, // The item's original link (never the short link)
+	"{title}":        true,
+	"{author}":       true,
+	"{source_title}": true, // The source feed's own title
+	"{source_link}":  true, // The source site, or the feed URL if it has none
+}
+
+func validateAttribution(footer string) error {
+	for _, placeholder := range attributionPlaceholder.FindAllString(footer, -1) {
+		if !attributionFields[placeholder] {
+			return fmt.Errorf("unknown attribution placeholder %s (must be one of: {link}, {title}, {author}, {source_title}, {source_link})", placeholder)
+		}
+	}
+	return nil
+}
+
+// attributionFooter fills in an attribution footer for an item. The footer
+// is HTML, so the values are escaped.
+func attributionFooter(footer string, feed database.Feed, item database.Item) string {
+	var author string
+	if len(item.Authors) > 0 {
+		author = item.Authors[0]
+	}
+	return strings.NewReplacer(
+		"{link}", html.EscapeString(item.Link),
+		"{title}", html.EscapeString(item.Title),
+		"{author}", html.EscapeString(author),
+		"{source_title}", html.EscapeString(cmp.Or(feed.SourceTitle, feed.DisplayTitle(), feed.FeedURL)),
+		"{source_link}", html.EscapeString(cmp.Or(feed.Link, feed.FeedURL)),
+	).Replace(footer)
+}
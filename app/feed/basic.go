@@ -1,7 +1,8 @@
 package feed
 
 import (
-	"bytes"
+	"io"
+	"time"
 
 	"github.com/lysyi3m/rss-comb/app/cfg"
 	"github.com/lysyi3m/rss-comb/app/database"
@@ -10,13 +11,13 @@ import (
 
 type basicType struct{}
 
-func (basicType) Parse(data []byte) (*Metadata, []types.Item, error) {
+func (basicType) Parse(data []byte, _ time.Time) (*Metadata, []types.Item, error) {
 	feed, err := parseWithGofeed(data)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	metadata := extractBaseMetadata(feed)
+	metadata := extractBaseMetadata(feed, data)
 
 	items := make([]types.Item, 0, len(feed.Items))
 	for _, item := range feed.Items {
@@ -28,22 +29,14 @@ func (basicType) Parse(data []byte) (*Metadata, []types.Item, error) {
 	return metadata, items, nil
 }
 
-func (basicType) Build(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
-	var buf bytes.Buffer
-
-	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
-	buf.WriteString("\n")
-	buf.WriteString(`<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:atom="http://www.w3.org/2005/Atom">`)
-	buf.WriteString("\n  <channel>\n")
-
-	writeChannelHeader(&buf, feed, items, cfg)
+func (t basicType) Build(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
+	return build(t, FormatRSS, feed, items, cfg)
+}
 
-	for _, item := range items {
-		writeBaseItem(&buf, item, cfg)
-		buf.WriteString("    </item>\n")
-	}
+func (t basicType) Write(w io.Writer, feed database.Feed, items []database.Item, cfg *cfg.Cfg) error {
+	return stream(t, FormatRSS, w, feed, items, cfg)
+}
 
-	buf.WriteString("  </channel>\n</rss>")
+func (basicType) itunes() bool { return false }
 
-	return buf.String(), nil
-}
+func (basicType) enclosure(database.Item, *cfg.Cfg) *Enclosure { return nil }
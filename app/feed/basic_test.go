@@ -1,14 +1,20 @@
 package feed
 
 import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
 	"github.com/lysyi3m/rss-comb/app/types"
 )
 
 func TestBasicParse_InvalidFeed(t *testing.T) {
 	bt := basicType{}
-	_, _, err := bt.Parse([]byte("invalid xml"))
+	_, _, err := bt.Parse([]byte("invalid xml"), time.Now())
 
 	if err == nil {
 		t.Error("Expected error for invalid XML")
@@ -32,7 +38,7 @@ func TestBasicParse_RSSFeed(t *testing.T) {
 </rss>`
 
 	bt := basicType{}
-	metadata, items, err := bt.Parse([]byte(rssData))
+	metadata, items, err := bt.Parse([]byte(rssData), time.Now())
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -70,7 +76,7 @@ func TestBasicParse_IgnoresITunesData(t *testing.T) {
 </rss>`
 
 	bt := basicType{}
-	metadata, items, err := bt.Parse([]byte(podcastRSS))
+	metadata, items, err := bt.Parse([]byte(podcastRSS), time.Now())
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -105,7 +111,7 @@ func TestBasicParse_TrackingParamsRemoved(t *testing.T) {
 </rss>`
 
 	bt := basicType{}
-	_, items, err := bt.Parse([]byte(rssData))
+	_, items, err := bt.Parse([]byte(rssData), time.Now())
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -143,6 +149,11 @@ func TestContentHashGeneration(t *testing.T) {
 	if hash1 == hash3 {
 		t.Error("Expected different hash for different items")
 	}
+
+	// Stored hashes drive deduplication, so the format must not drift.
+	if want := "b86f04a68a57e3d5a79a96bab2d097a060421d6cbcc5f07aaa5c8acd43e6685b"; hash1 != want {
+		t.Errorf("hash format changed: %s", hash1)
+	}
 }
 
 func TestNormalizeURL(t *testing.T) {
@@ -207,3 +218,205 @@ func TestNormalizeURL(t *testing.T) {
 		})
 	}
 }
+
+func TestBasicBuild_ShortLinks(t *testing.T) {
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC}
+	items := []database.Item{{
+		ID:   "0b7c7a0e-2f6d-4d1e-9a55-5c3a8e0f1b2c",
+		Item: types.Item{GUID: "post-1", Title: "Post", Link: "https://example.com/post-1", PublishedAt: time.Now()},
+	}}
+
+	plain, err := basicType{}.Build(database.Feed{Name: "test"}, items, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(plain, "<link>https://example.com/post-1</link>") {
+		t.Errorf("expected original item link, got:\n%s", plain)
+	}
+
+	settings, _ := json.Marshal(types.Settings{ShortLinks: true})
+	short, err := basicType{}.Build(database.Feed{Name: "test", Settings: settings}, items, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(short, "<link>https://feeds.example.com/r/0b7c7a0e-2f6d-4d1e-9a55-5c3a8e0f1b2c</link>") {
+		t.Errorf("expected short item link, got:\n%s", short)
+	}
+}
+
+func TestBasicBuild_Robots(t *testing.T) {
+	c := &cfg.Cfg{Location: time.UTC}
+
+	plain, err := basicType{}.Build(database.Feed{Name: "test"}, nil, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(plain, `name="robots"`) {
+		t.Error("expected no robots meta without the setting")
+	}
+
+	settings, _ := json.Marshal(types.Settings{Robots: "noindex"})
+	rss, err := basicType{}.Build(database.Feed{Name: "test", Settings: settings}, nil, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(rss, `<xhtml:meta xmlns:xhtml="http://www.w3.org/1999/xhtml" name="robots" content="noindex" />`) {
+		t.Errorf("expected robots meta, got:\n%s", rss)
+	}
+}
+
+func TestBasicBuild_TitleAndDescriptionOverride(t *testing.T) {
+	c := &cfg.Cfg{Location: time.UTC}
+	dbFeed := database.Feed{
+		Name:                "test",
+		SourceTitle:         "Upstream Title",
+		Description:         "Upstream description",
+		Title:               "Rebranded",
+		DescriptionOverride: "Our curated mix",
+	}
+
+	rss, err := basicType{}.Build(dbFeed, nil, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(rss, "<title>Rebranded</title>") || !strings.Contains(rss, "<description>Our curated mix</description>") {
+		t.Errorf("expected overridden title and description, got:\n%s", rss)
+	}
+	if strings.Contains(rss, "Upstream") {
+		t.Errorf("expected upstream metadata to be replaced, got:\n%s", rss)
+	}
+}
+
+func TestBasicBuild_Provenance(t *testing.T) {
+	c := &cfg.Cfg{Location: time.UTC, Version: "1.2.3"}
+	fetched := time.Date(2025, 4, 2, 8, 0, 0, 0, time.UTC)
+	items := []database.Item{{Item: types.Item{GUID: "post-1", Title: "Post", PublishedAt: fetched}}}
+	settings, _ := json.Marshal(types.Settings{Provenance: true})
+	f := database.Feed{Name: "test", FeedURL: "https://example.com/feed.xml", SourceTitle: "Example", LastSuccessAt: &fetched, Settings: settings}
+
+	rss, err := basicType{}.Build(f, items, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`<comb:fetchedAt>2025-04-02T08:00:00Z</comb:fetchedAt>`,
+		`<comb:version>1.2.3</comb:version>`,
+		`<source url="https://example.com/feed.xml">Example</source>`,
+	} {
+		if !strings.Contains(rss, want) {
+			t.Errorf("expected %s in output:\n%s", want, rss)
+		}
+	}
+
+	var doc struct{}
+	if err := xml.Unmarshal([]byte(rss), &doc); err != nil {
+		t.Errorf("output is not valid XML: %v", err)
+	}
+}
+
+func TestBasicBuild_ChannelElements(t *testing.T) {
+	c := &cfg.Cfg{Location: time.UTC}
+	settings, _ := json.Marshal(types.Settings{
+		Namespaces: map[string]string{"ex": "https://example.com/ns"},
+		ChannelElements: []types.ChannelElement{
+			{Name: "webMaster", Value: "ops@example.com (Ops & Co)"},
+			{Name: "podcast:locked", Value: "yes", Attributes: map[string]string{"owner": "me@example.com"}},
+			{Name: "ex:contact", Children: []types.ChannelElement{{Name: "ex:url", Attributes: map[string]string{"href": "https://example.com"}}}},
+		},
+	})
+
+	rss, err := basicType{}.Build(database.Feed{Name: "test", Settings: settings}, nil, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		` xmlns:ex="https://example.com/ns" xmlns:podcast="https://podcastindex.org/namespace/1.0">`,
+		`<webMaster>ops@example.com (Ops &amp; Co)</webMaster>`,
+		`<podcast:locked owner="me@example.com">yes</podcast:locked>`,
+		"<ex:contact>\n      <ex:url href=\"https://example.com\" />\n    </ex:contact>",
+	} {
+		if !strings.Contains(rss, want) {
+			t.Errorf("expected %s in output:\n%s", want, rss)
+		}
+	}
+
+	var doc struct{}
+	if err := xml.Unmarshal([]byte(rss), &doc); err != nil {
+		t.Errorf("output is not valid XML: %v", err)
+	}
+}
+
+func TestWrite_MatchesBuild(t *testing.T) {
+	c := &cfg.Cfg{Location: time.UTC}
+	items := []database.Item{{
+		ID: "item-1",
+		Item: types.Item{
+			GUID: "https://example.com/a", Title: "A & B", Link: "https://example.com/a",
+			EnclosureURL: "https://example.com/a.mp3", EnclosureType: "audio/mpeg", EnclosureLength: 10,
+			PublishedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}}
+	f := database.Feed{Name: "test", Title: "Test", Link: "https://example.com"}
+
+	for _, typ := range []string{"basic", "podcast", "youtube"} {
+		ft := ForType(typ)
+		built, err := ft.Build(f, items, c)
+		if err != nil {
+			t.Fatalf("%s: build failed: %v", typ, err)
+		}
+		var streamed strings.Builder
+		if err := ft.Write(&streamed, f, items, c); err != nil {
+			t.Fatalf("%s: write failed: %v", typ, err)
+		}
+		if streamed.String() != built {
+			t.Errorf("%s: streamed document differs from built one", typ)
+		}
+	}
+}
+
+func TestBasicParse_SourceLinks(t *testing.T) {
+	atomData := `<?xml version="1.0" encoding="ISO-8859-1"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Atom Feed</title>
+  <link rel="alternate" type="text/html" href="https://example.com/"/>
+  <link rel="self" href="https://example.com/feed.atom"/>
+  <link rel="hub" href="https://pubsubhubbub.appspot.com/"/>
+  <updated>2024-07-01T10:00:00Z</updated>
+  <id>urn:example</id>
+  <entry>
+    <title>Entry</title>
+    <link rel="hub" href="https://ignored.example.com/"/>
+    <id>urn:example:1</id>
+    <updated>2024-07-01T10:00:00Z</updated>
+  </entry>
+</feed>`
+
+	metadata, _, err := basicType{}.Parse([]byte(atomData), time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if metadata.Link != "https://example.com/" || metadata.SelfURL != "https://example.com/feed.atom" || metadata.HubURL != "https://pubsubhubbub.appspot.com/" {
+		t.Errorf("Unexpected links: alternate %q, self %q, hub %q", metadata.Link, metadata.SelfURL, metadata.HubURL)
+	}
+
+	rssData := `<?xml version="1.0"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    <title>RSS Feed</title>
+    <link>https://example.com</link>
+    <atom:link href="https://example.com/rss" rel="self" type="application/rss+xml"/>
+    <atom:link href="https://hub.example.com/" rel="hub"/>
+    <description>Test</description>
+  </channel>
+</rss>`
+
+	metadata, _, err = basicType{}.Parse([]byte(rssData), time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if metadata.SelfURL != "https://example.com/rss" || metadata.HubURL != "https://hub.example.com/" {
+		t.Errorf("Unexpected links: self %q, hub %q", metadata.SelfURL, metadata.HubURL)
+	}
+}
@@ -0,0 +1,92 @@
+package feed
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feedgen"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// Pipeline benchmarks over synthetic feeds. Storage needs PostgreSQL and is
+// covered by load-testing a running instance against `rss-comb feedgen
+// --serve` instead. Compare runs with benchstat:
+//
+//	go test ./app/feed -run '^$' -bench . -benchmem -count 10 > new.txt
+
+var benchSizes = []int{100, 1000}
+
+var benchFilters = []types.Filter{
+	{Field: "title", Excludes: []string{"sponsored", "/\\bbeta\\b/"}},
+	{Field: "description", Includes: []string{"release", "update", "security"}},
+	{Field: "categories", Excludes: []string{"Culture"}},
+	{Field: "title", Includes: []string{"security", "kernel"}, Weight: 5},
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, typ := range []string{"basic", "podcast"} {
+		for _, n := range benchSizes {
+			data := feedgen.Generate(feedgen.Options{Items: n, Podcast: typ == "podcast"})
+			ft := ForType(typ)
+			b.Run(fmt.Sprintf("%s/items=%d", typ, n), func(b *testing.B) {
+				b.SetBytes(int64(len(data)))
+				for b.Loop() {
+					if _, _, err := ft.Parse(data, time.Now()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkFilter(b *testing.B) {
+	for _, n := range benchSizes {
+		_, items, err := ForType("basic").Parse(feedgen.Generate(feedgen.Options{Items: n}), time.Now())
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("items=%d", n), func(b *testing.B) {
+			for b.Loop() {
+				Filter(items, benchFilters, 0, time.Now())
+			}
+		})
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC}
+	for _, typ := range []string{"basic", "podcast"} {
+		for _, n := range benchSizes {
+			ft := ForType(typ)
+			_, parsed, err := ft.Parse(feedgen.Generate(feedgen.Options{Items: n, Podcast: typ == "podcast"}), time.Now())
+			if err != nil {
+				b.Fatal(err)
+			}
+			items := make([]database.Item, len(parsed))
+			for i, item := range parsed {
+				items[i] = database.Item{ID: fmt.Sprintf("item-%d", i), Item: item, CreatedAt: item.PublishedAt}
+			}
+			f := database.Feed{Name: "bench", FeedType: typ, Title: "Bench", Link: "https://synthetic.example"}
+
+			b.Run(fmt.Sprintf("%s/items=%d", typ, n), func(b *testing.B) {
+				for b.Loop() {
+					if _, err := ft.Build(f, items, c); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run(fmt.Sprintf("%s/items=%d/stream", typ, n), func(b *testing.B) {
+				for b.Loop() {
+					if err := ft.Write(io.Discard, f, items, c); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+const blocklistStateKey = "blocklist_hash"
+
+// Blocklist is the global mute list kept in a YAML file. It is applied to
+// every feed before the feed's own filters.
+type Blocklist struct {
+	path      string
+	feedRepo  *database.FeedRepository
+	stateRepo *database.StateRepository
+
+	mu   sync.RWMutex
+	list types.Blocklist
+}
+
+func NewBlocklist(path string, feedRepo *database.FeedRepository, stateRepo *database.StateRepository) *Blocklist {
+	return &Blocklist{path: path, feedRepo: feedRepo, stateRepo: stateRepo}
+}
+
+// Load reads the blocklist file. A missing file is an empty blocklist.
+func (b *Blocklist) Load() error {
+	data, err := os.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		b.set(types.Blocklist{})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read blocklist: %w", err)
+	}
+
+	var list types.Blocklist
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("failed to parse blocklist: %w", err)
+	}
+	b.set(list)
+	return nil
+}
+
+// Save replaces the blocklist and writes it to the file.
+func (b *Blocklist) Save(list types.Blocklist) error {
+	list = normalizeBlocklist(list)
+
+	data, err := yaml.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to marshal blocklist: %w", err)
+	}
+
+	// Write to a temporary file first so a crash can't leave a truncated list.
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), ".blocklist-*.yml")
+	if err != nil {
+		return fmt.Errorf("failed to write blocklist: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blocklist: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blocklist: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), b.path); err != nil {
+		return fmt.Errorf("failed to write blocklist: %w", err)
+	}
+
+	b.set(list)
+	return nil
+}
+
+// Sync schedules a refilter of every feed when the blocklist differs from
+// the one last applied, so muted items disappear (or reappear) from stored
+// items too.
+func (b *Blocklist) Sync(ctx context.Context, now time.Time) error {
+	hash := b.hash()
+
+	stored, err := b.stateRepo.GetValue(ctx, blocklistStateKey)
+	if err != nil {
+		return err
+	}
+	if stored != nil && *stored == hash {
+		return nil
+	}
+	// Nothing to undo for a blocklist that never existed.
+	if stored == nil && len(b.Filters()) == 0 {
+		return b.stateRepo.SetValue(ctx, blocklistStateKey, hash)
+	}
+
+	if err := b.feedRepo.ScheduleRefilterAll(ctx, now); err != nil {
+		return err
+	}
+	slog.Info("Blocklist changed, refiltering all feeds")
+
+	return b.stateRepo.SetValue(ctx, blocklistStateKey, hash)
+}
+
+// List returns the current blocklist.
+func (b *Blocklist) List() types.Blocklist {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.list
+}
+
+// Filters returns the blocklist as exclude filters. A nil Blocklist has none.
+func (b *Blocklist) Filters() []types.Filter {
+	if b == nil {
+		return nil
+	}
+	list := b.List()
+
+	var filters []types.Filter
+	if len(list.Authors) > 0 {
+		filters = append(filters, types.Filter{Field: "authors", Excludes: list.Authors})
+	}
+	if len(list.Domains) > 0 {
+		domains := make([]string, len(list.Domains))
+		for i, domain := range list.Domains {
+			domains[i] = "*." + domain
+		}
+		filters = append(filters, types.Filter{Field: "link_domain", Excludes: domains})
+	}
+	if len(list.Keywords) > 0 {
+		filters = append(filters,
+			types.Filter{Field: "title", Excludes: list.Keywords},
+			types.Filter{Field: "description", Excludes: list.Keywords})
+	}
+	return filters
+}
+
+// WithBlocklist returns the blocklist filters followed by the feed's own.
+func WithBlocklist(b *Blocklist, filters []types.Filter) []types.Filter {
+	blocked := b.Filters()
+	if len(blocked) == 0 {
+		return filters
+	}
+	return append(blocked, filters...)
+}
+
+func (b *Blocklist) set(list types.Blocklist) {
+	list = normalizeBlocklist(list)
+	b.mu.Lock()
+	b.list = list
+	b.mu.Unlock()
+}
+
+func (b *Blocklist) hash() string {
+	data, _ := yaml.Marshal(b.List())
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+func normalizeBlocklist(list types.Blocklist) types.Blocklist {
+	return types.Blocklist{
+		Authors:  cleanEntries(list.Authors, strings.TrimSpace),
+		Domains:  cleanEntries(list.Domains, normalizeDomain),
+		Keywords: cleanEntries(list.Keywords, strings.TrimSpace),
+	}
+}
+
+// cleanEntries normalizes entries and drops empty ones and duplicates. The
+// result is never nil so the API returns empty lists as [].
+func cleanEntries(entries []string, normalize func(string) string) []string {
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		entry = normalize(entry)
+		if entry == "" || seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		cleaned = append(cleaned, entry)
+	}
+	return cleaned
+}
+
+// normalizeDomain accepts a bare domain or a URL and returns the lowercase
+// host without a leading "www.".
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if strings.Contains(domain, "://") {
+		if u, err := url.Parse(domain); err == nil {
+			domain = u.Hostname()
+		}
+	}
+	domain = strings.TrimPrefix(domain, "www.")
+	return strings.TrimSuffix(domain, ".")
+}
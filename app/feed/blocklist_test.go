@@ -0,0 +1,101 @@
+package feed
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestBlocklist_FiltersItems(t *testing.T) {
+	b := NewBlocklist(filepath.Join(t.TempDir(), "blocklist.yml"), nil, nil)
+	if err := b.Save(types.Blocklist{
+		Authors:  []string{"Spammer"},
+		Domains:  []string{"https://www.Tabloid.example/"},
+		Keywords: []string{"sponsored"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	items := []types.Item{
+		{Title: "Fine", Link: "https://news.example/a", Authors: []string{"Jane"}},
+		{Title: "By spammer", Link: "https://news.example/b", Authors: []string{"spammer"}},
+		{Title: "Tabloid", Link: "https://tabloid.example/c"},
+		{Title: "Tabloid subdomain", Link: "https://sport.tabloid.example/d"},
+		{Title: "Not tabloid", Link: "https://nottabloid.example/e"},
+		{Title: "Deal", Description: "A SPONSORED post"},
+	}
+	feedFilters := []types.Filter{{Field: "title", Excludes: []string{"deal"}}}
+
+	result := Filter(items, WithBlocklist(b, feedFilters), 0, time.Now())
+
+	want := []bool{false, true, true, true, false, true}
+	for i, item := range result {
+		if item.IsFiltered != want[i] {
+			t.Errorf("item %q: expected filtered=%v, got %v", item.Title, want[i], item.IsFiltered)
+		}
+	}
+}
+
+func TestBlocklist_LoadMissingFile(t *testing.T) {
+	b := NewBlocklist(filepath.Join(t.TempDir(), "missing.yml"), nil, nil)
+	if err := b.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filters := b.Filters(); len(filters) != 0 {
+		t.Errorf("expected no filters for a missing file, got %v", filters)
+	}
+}
+
+func TestBlocklist_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.yml")
+	if err := NewBlocklist(path, nil, nil).Save(types.Blocklist{
+		Authors: []string{" Jane ", "Jane", ""},
+		Domains: []string{"Example.com."},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b := NewBlocklist(path, nil, nil)
+	if err := b.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list := b.List()
+	if !slices.Equal(list.Authors, []string{"Jane"}) {
+		t.Errorf("expected cleaned authors [Jane], got %v", list.Authors)
+	}
+	if !slices.Equal(list.Domains, []string{"example.com"}) {
+		t.Errorf("expected normalized domain, got %v", list.Domains)
+	}
+	if list.Keywords == nil {
+		t.Error("expected empty keywords to be [] rather than nil")
+	}
+}
+
+func TestBlocklist_LoadInvalidKeepsCurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.yml")
+	b := NewBlocklist(path, nil, nil)
+	if err := b.Save(types.Blocklist{Keywords: []string{"ad"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("keywords: [unclosed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Load(); err == nil {
+		t.Fatal("expected error for invalid YAML")
+	}
+	if !slices.Equal(b.List().Keywords, []string{"ad"}) {
+		t.Errorf("expected previous keywords to be kept, got %v", b.List().Keywords)
+	}
+}
+
+func TestBlocklist_Nil(t *testing.T) {
+	filters := []types.Filter{{Field: "title", Excludes: []string{"x"}}}
+	if got := WithBlocklist(nil, filters); len(got) != 1 {
+		t.Errorf("expected feed filters unchanged with a nil blocklist, got %v", got)
+	}
+}
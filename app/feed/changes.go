@@ -0,0 +1,161 @@
+package feed
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+)
+
+// maxDiffCells bounds the word diff table; longer texts only get a note that
+// the description was revised.
+const maxDiffCells = 250_000
+
+// diffContext is the number of unchanged words kept around each change.
+const diffContext = 5
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// MarkChanges flags items whose title or description changed upstream.
+// mode "marker" prefixes the title with "[Updated]"; mode "diff" appends a
+// summary of the change to the description. Other modes leave items as is.
+func MarkChanges(items []database.Item, mode string, loc *time.Location) []database.Item {
+	if mode != "marker" && mode != "diff" {
+		return items
+	}
+
+	for i := range items {
+		item := &items[i]
+		if item.ChangedAt == nil {
+			continue
+		}
+
+		if mode == "marker" {
+			item.Title = "[Updated] " + item.Title
+			continue
+		}
+
+		var summary strings.Builder
+		summary.WriteString(fmt.Sprintf("<hr/><p><em>Updated %s.</em>", item.ChangedAt.In(loc).Format("2006-01-02 15:04 MST")))
+		if item.PreviousTitle != item.Title {
+			summary.WriteString(fmt.Sprintf(" Previous title: &ldquo;%s&rdquo;.", html.EscapeString(item.PreviousTitle)))
+		}
+		summary.WriteString("</p>")
+		if item.PreviousDescription != item.Description {
+			summary.WriteString("<p>")
+			summary.WriteString(wordDiff(plainText(item.PreviousDescription), plainText(item.Description)))
+			summary.WriteString("</p>")
+		}
+		item.Description += summary.String()
+	}
+
+	return items
+}
+
+func plainText(s string) []string {
+	return strings.Fields(html.UnescapeString(htmlTag.ReplaceAllString(s, " ")))
+}
+
+// wordDiff renders the changed parts of two word lists as HTML with
+// <del>/<ins>, keeping a few words of context around each change.
+func wordDiff(before, after []string) string {
+	if len(before)*len(after) > maxDiffCells {
+		return "The description was revised."
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// before[i:] and after[j:].
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type op struct {
+		kind byte // '=', '-', '+'
+		word string
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			ops = append(ops, op{'=', before[i]})
+			i++
+			j++
+		case i < len(before) && (j == len(after) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', before[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', after[j]})
+			j++
+		}
+	}
+
+	// Keep unchanged words only near a change.
+	keep := make([]bool, len(ops))
+	for k, o := range ops {
+		if o.kind == '=' {
+			continue
+		}
+		for c := max(0, k-diffContext); c <= min(len(ops)-1, k+diffContext); c++ {
+			keep[c] = true
+		}
+	}
+
+	var out strings.Builder
+	var open byte
+	skipped := false
+	for k, o := range ops {
+		if !keep[k] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			closeDiffTag(&out, open)
+			open = 0
+			out.WriteString(" &hellip;")
+			skipped = false
+		}
+		if o.kind != open {
+			closeDiffTag(&out, open)
+			out.WriteString(" ")
+			switch o.kind {
+			case '-':
+				out.WriteString("<del>")
+			case '+':
+				out.WriteString("<ins>")
+			}
+			open = o.kind
+		} else {
+			out.WriteString(" ")
+		}
+		out.WriteString(html.EscapeString(o.word))
+	}
+	closeDiffTag(&out, open)
+	if skipped {
+		out.WriteString(" &hellip;")
+	}
+
+	return strings.TrimSpace(out.String())
+}
+
+func closeDiffTag(out *strings.Builder, kind byte) {
+	switch kind {
+	case '-':
+		out.WriteString("</del>")
+	case '+':
+		out.WriteString("</ins>")
+	}
+}
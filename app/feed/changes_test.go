@@ -0,0 +1,69 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestWordDiff(t *testing.T) {
+	before := strings.Fields("the quick brown fox jumps over the lazy dog")
+	after := strings.Fields("the quick red fox jumps over the lazy dog")
+
+	got := wordDiff(before, after)
+	want := "the quick <del>brown</del> <ins>red</ins> fox jumps over the lazy &hellip;"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWordDiff_SkipsUnchangedText(t *testing.T) {
+	words := strings.Fields("one two three four five six seven eight nine ten eleven twelve thirteen fourteen")
+	changed := append([]string{}, words...)
+	changed[len(changed)-1] = "fifteen"
+
+	got := wordDiff(words, changed)
+	if !strings.HasPrefix(got, "&hellip;") {
+		t.Errorf("expected leading ellipsis for skipped words, got %q", got)
+	}
+	if strings.Contains(got, "one") {
+		t.Errorf("expected distant unchanged words to be left out, got %q", got)
+	}
+}
+
+func TestMarkChanges(t *testing.T) {
+	changedAt := time.Date(2025, 6, 1, 9, 30, 0, 0, time.UTC)
+	newItems := func() []database.Item {
+		return []database.Item{
+			{
+				ChangedAt:           &changedAt,
+				PreviousTitle:       "Old title",
+				PreviousDescription: "<p>Revenue rose 5%</p>",
+				Item:                types.Item{Title: "New title", Description: "<p>Revenue rose 7%</p>"},
+			},
+			{Item: types.Item{Title: "Untouched", Description: "Same"}},
+		}
+	}
+
+	marked := MarkChanges(newItems(), "marker", time.UTC)
+	if marked[0].Title != "[Updated] New title" {
+		t.Errorf("expected marker on changed item, got %q", marked[0].Title)
+	}
+	if marked[1].Title != "Untouched" {
+		t.Errorf("expected unchanged item untouched, got %q", marked[1].Title)
+	}
+
+	diffed := MarkChanges(newItems(), "diff", time.UTC)
+	desc := diffed[0].Description
+	for _, want := range []string{"Updated 2025-06-01 09:30 UTC", "Old title", "<del>5%</del>", "<ins>7%</ins>"} {
+		if !strings.Contains(desc, want) {
+			t.Errorf("expected %q in description, got %q", want, desc)
+		}
+	}
+	if diffed[1].Description != "Same" {
+		t.Errorf("expected unchanged description, got %q", diffed[1].Description)
+	}
+}
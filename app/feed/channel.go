@@ -0,0 +1,171 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// knownNamespaces can be used in channel_elements without declaring them.
+// content, atom and itunes are declared by the renderer itself (itunes only
+// for podcast feeds, so it's listed for the others).
+var knownNamespaces = map[string]string{
+	"itunes":          "http://www.itunes.com/dtds/podcast-1.0.dtd",
+	"podcast":         "https://podcastindex.org/namespace/1.0",
+	"googleplay":      "http://www.google.com/schemas/play-podcasts/1.0",
+	"dc":              "http://purl.org/dc/elements/1.1/",
+	"sy":              "http://purl.org/rss/1.0/modules/syndication/",
+	"media":           "http://search.yahoo.com/mrss/",
+	"creativeCommons": "http://backend.userland.com/creativeCommonsRssModule",
+}
+
+// reservedPrefixes are declared on every RSS document and can't be rebound.
+var reservedPrefixes = map[string]string{
+	"content": "http://purl.org/rss/1.0/modules/content/",
+	"atom":    "http://www.w3.org/2005/Atom",
+	"xml":     "http://www.w3.org/XML/1998/namespace",
+	"xmlns":   "",
+}
+
+// generatedElements are channel elements rss-comb writes itself; adding
+// them again would make the channel invalid.
+var generatedElements = map[string]bool{
+	"title": true, "link": true, "description": true, "item": true, "image": true,
+	"language": true, "generator": true, "pubDate": true, "lastBuildDate": true,
+	"atom:link": true, "itunes:author": true, "itunes:image": true,
+	"itunes:explicit": true, "itunes:owner": true,
+}
+
+var xmlName = regexp.MustCompile(`^([A-Za-z_][\w.-]*:)?[A-Za-z_][\w.-]*$`)
+
+func validateChannelElements(elements []types.ChannelElement, namespaces map[string]string) error {
+	for prefix, uri := range namespaces {
+		if !xmlName.MatchString(prefix) || strings.Contains(prefix, ":") {
+			return fmt.Errorf("invalid namespace prefix %q", prefix)
+		}
+		if _, reserved := reservedPrefixes[prefix]; reserved {
+			return fmt.Errorf("namespace prefix %q is reserved", prefix)
+		}
+		if known, ok := knownNamespaces[prefix]; ok && known != uri {
+			return fmt.Errorf("namespace prefix %q is already bound to %s", prefix, known)
+		}
+		if uri == "" {
+			return fmt.Errorf("namespace %q has no URI", prefix)
+		}
+	}
+
+	for _, element := range elements {
+		if generatedElements[element.Name] {
+			return fmt.Errorf("channel element %q is generated by rss-comb", element.Name)
+		}
+		if err := validateChannelElement(element, namespaces); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateChannelElement(element types.ChannelElement, namespaces map[string]string) error {
+	if !xmlName.MatchString(element.Name) {
+		return fmt.Errorf("invalid channel element name %q", element.Name)
+	}
+	if _, err := namespaceURI(element.Name, namespaces); err != nil {
+		return err
+	}
+	for name := range element.Attributes {
+		if !xmlName.MatchString(name) {
+			return fmt.Errorf("invalid attribute name %q on channel element %q", name, element.Name)
+		}
+		if _, err := namespaceURI(name, namespaces); err != nil {
+			return err
+		}
+	}
+	if element.Value != "" && len(element.Children) > 0 {
+		return fmt.Errorf("channel element %q can't have both a value and children", element.Name)
+	}
+	for _, child := range element.Children {
+		if err := validateChannelElement(child, namespaces); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// namespaceURI returns the namespace a prefixed name needs declared, "" when
+// it has no prefix or one the renderer always declares.
+func namespaceURI(name string, namespaces map[string]string) (string, error) {
+	prefix, _, found := strings.Cut(name, ":")
+	if !found {
+		return "", nil
+	}
+	if _, reserved := reservedPrefixes[prefix]; reserved {
+		return "", nil
+	}
+	if uri, ok := namespaces[prefix]; ok {
+		return uri, nil
+	}
+	if uri, ok := knownNamespaces[prefix]; ok {
+		return uri, nil
+	}
+	return "", fmt.Errorf("undeclared namespace prefix %q in %q (add it to namespaces)", prefix, name)
+}
+
+// channelNamespaces collects the namespace declarations elements need,
+// keyed by prefix.
+func channelNamespaces(elements []types.ChannelElement, namespaces map[string]string) map[string]string {
+	used := make(map[string]string)
+	var collect func(name string)
+	collect = func(name string) {
+		if uri, _ := namespaceURI(name, namespaces); uri != "" {
+			prefix, _, _ := strings.Cut(name, ":")
+			used[prefix] = uri
+		}
+	}
+	var walk func(elements []types.ChannelElement)
+	walk = func(elements []types.ChannelElement) {
+		for _, element := range elements {
+			collect(element.Name)
+			for name := range element.Attributes {
+				collect(name)
+			}
+			walk(element.Children)
+		}
+	}
+	walk(elements)
+	return used
+}
+
+func writeNamespaces(w feedWriter, namespaces map[string]string) {
+	for _, prefix := range slices.Sorted(maps.Keys(namespaces)) {
+		w.WriteString(fmt.Sprintf(" xmlns:%s=\"%s\"", prefix, html.EscapeString(namespaces[prefix])))
+	}
+}
+
+func writeChannelElements(w feedWriter, elements []types.ChannelElement, indent int) {
+	for _, element := range elements {
+		w.WriteString(strings.Repeat(" ", indent))
+		w.WriteString("<" + element.Name)
+		for _, name := range slices.Sorted(maps.Keys(element.Attributes)) {
+			w.WriteString(fmt.Sprintf(" %s=\"%s\"", name, html.EscapeString(element.Attributes[name])))
+		}
+
+		switch {
+		case len(element.Children) > 0:
+			w.WriteString(">\n")
+			writeChannelElements(w, element.Children, indent+2)
+			w.WriteString(strings.Repeat(" ", indent) + "</" + element.Name + ">\n")
+		case element.Value != "":
+			w.WriteString(">")
+			xml.EscapeText(w, []byte(element.Value))
+			w.WriteString("</" + element.Name + ">\n")
+		default:
+			w.WriteString(" />\n")
+		}
+	}
+}
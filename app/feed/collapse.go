@@ -0,0 +1,52 @@
+package feed
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+)
+
+// CollapseTitles drops items whose title repeats that of a newer item
+// published less than window later, keeping the newest of each repost.
+// Titles are compared case-insensitively with whitespace normalized. The
+// order of the remaining items is unchanged.
+func CollapseTitles(items []database.Item, window time.Duration) []database.Item {
+	if window <= 0 || len(items) < 2 {
+		return items
+	}
+
+	byDate := make([]int, len(items))
+	for i := range byDate {
+		byDate[i] = i
+	}
+	slices.SortStableFunc(byDate, func(a, b int) int {
+		return items[b].PublishedAt.Compare(items[a].PublishedAt)
+	})
+
+	// Walking newest to oldest, an item is dropped if the last kept item with
+	// the same title is within the window; older reposts further apart start
+	// a new group.
+	lastKept := make(map[string]time.Time)
+	drop := make([]bool, len(items))
+	for _, i := range byDate {
+		title := strings.Join(strings.Fields(strings.ToLower(items[i].Title)), " ")
+		if title == "" {
+			continue
+		}
+		if kept, ok := lastKept[title]; ok && kept.Sub(items[i].PublishedAt) < window {
+			drop[i] = true
+			continue
+		}
+		lastKept[title] = items[i].PublishedAt
+	}
+
+	result := make([]database.Item, 0, len(items))
+	for i, item := range items {
+		if !drop[i] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestCollapseTitles(t *testing.T) {
+	base := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	item := func(id, title string, age time.Duration) database.Item {
+		return database.Item{ID: id, Item: types.Item{Title: title, PublishedAt: base.Add(-age)}}
+	}
+
+	items := []database.Item{
+		item("a", "Big Story", 0),
+		item("b", "Other news", time.Hour),
+		item("c", "big  story", 2*time.Hour),
+		item("d", "Big Story", 72*time.Hour),
+	}
+
+	result := CollapseTitles(items, 24*time.Hour)
+
+	var ids []string
+	for _, r := range result {
+		ids = append(ids, r.ID)
+	}
+	want := []string{"a", "b", "d"}
+	if len(ids) != len(want) {
+		t.Fatalf("expected %v, got %v", want, ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, ids)
+		}
+	}
+}
+
+func TestCollapseTitles_Disabled(t *testing.T) {
+	items := []database.Item{
+		{Item: types.Item{Title: "Same"}},
+		{Item: types.Item{Title: "Same"}},
+	}
+
+	if result := CollapseTitles(items, 0); len(result) != 2 {
+		t.Errorf("expected no collapsing without a window, got %d items", len(result))
+	}
+}
@@ -2,35 +2,63 @@ package feed
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/lysyi3m/rss-comb/app/types"
 	"gopkg.in/yaml.v3"
 )
 
-func LoadConfig(feedsDir, name string) (*Config, string, error) {
+// LoadConfig reads and validates a feed file. defaults (may be nil) supplies
+// fallbacks from the application config file for omitted settings; until
+// dates without a time end with that day in loc.
+func LoadConfig(feedsDir, name string, defaults *types.Settings, loc *time.Location) (*Config, string, error) {
 	configPath := filepath.Join(feedsDir, name+".yml")
 
 	data, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", fmt.Errorf("%w: no config file %s", types.ErrFeedNotFound, configPath)
+	}
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	hash := fmt.Sprintf("%x", sha256.Sum256(data))
+	// Defaults are part of the effective config, so changing them must change
+	// the hash too; without defaults the hash stays that of the file alone.
+	hashInput := data
+	if defaults != nil && !reflect.DeepEqual(*defaults, types.Settings{}) {
+		hashInput = fmt.Appendf(data, "\n%+v", *defaults)
+	}
 
 	var config Config
 	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, "", fmt.Errorf("failed to parse YAML: %w", err)
+		return nil, "", fmt.Errorf("%w: failed to parse YAML: %w", types.ErrFilteredConfigInvalid, err)
 	}
 
 	config.Name = name
 
 	if err := validateConfig(&config); err != nil {
-		return nil, "", fmt.Errorf("invalid config: %w", err)
+		return nil, "", fmt.Errorf("%w: %w", types.ErrFilteredConfigInvalid, err)
 	}
 
-	applyDefaults(&config)
+	applyDefaults(&config, defaults)
+
+	// Until dates depend on the time zone, so a new TIMEZONE changes the
+	// hash of feeds that use them.
+	resolved := false
+	for i := range config.Filters {
+		resolved = config.Filters[i].ResolveDates(loc) || resolved
+	}
+	if resolved {
+		hashInput = fmt.Appendf(hashInput, "\n%s", loc)
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(hashInput))
 
 	return &config, hash, nil
 }
@@ -40,14 +68,36 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("config cannot be nil")
 	}
 
-	if config.URL == "" {
-		return fmt.Errorf("url is required")
+	if config.Type == "newsletter" {
+		if config.URL != "" {
+			return fmt.Errorf("newsletter feeds aren't fetched; remove url and post emails to /inbound/%s", config.Name)
+		}
+		if len(config.Settings.InboundToken) < 16 {
+			return fmt.Errorf("newsletter feeds need an inbound_token of at least 16 characters")
+		}
+	} else {
+		if config.URL == "" {
+			return fmt.Errorf("url is required")
+		}
+		if config.Settings.InboundToken != "" {
+			return fmt.Errorf("inbound_token is only supported for newsletter feeds")
+		}
+	}
+
+	if _, err := ParseFetchWindow(config.Settings.FetchHours, config.Settings.FetchDays); err != nil {
+		return err
 	}
 
 	if config.Settings.RefreshInterval < 0 {
 		return fmt.Errorf("refresh_interval must be >= 0")
 	}
 
+	switch config.Settings.Priority {
+	case "", "normal", "high":
+	default:
+		return fmt.Errorf("invalid priority %q (must be normal, high, or omitted)", config.Settings.Priority)
+	}
+
 	if config.Settings.MaxItems < 0 {
 		return fmt.Errorf("max_items must be >= 0")
 	}
@@ -56,13 +106,34 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("timeout must be >= 0")
 	}
 
-	validTypes := map[string]bool{"": true, "podcast": true, "youtube": true}
+	validTypes := map[string]bool{"": true, "podcast": true, "youtube": true, "newsletter": true, "ics": true, "sitemap": true, "torrent": true}
 	if !validTypes[config.Type] {
-		return fmt.Errorf("invalid type %q (must be one of: podcast, youtube, or omitted)", config.Type)
+		return fmt.Errorf("invalid type %q (must be one of: podcast, youtube, newsletter, ics, sitemap, torrent, or omitted)", config.Type)
+	}
+
+	if config.Settings.ExtractContent && config.Type != "" && config.Type != "sitemap" {
+		return fmt.Errorf("extract_content is only supported for basic (no type) and sitemap feeds")
+	}
+
+	switch config.Settings.Magnet {
+	case "", "enclosure", "link":
+	default:
+		return fmt.Errorf("invalid magnet %q (must be enclosure, link, or omitted)", config.Settings.Magnet)
+	}
+	if config.Settings.Magnet != "" && config.Type != "torrent" {
+		return fmt.Errorf("magnet is only supported for torrent feeds")
+	}
+
+	if config.Settings.ReleaseNotes && config.Type != "" {
+		return fmt.Errorf("release_notes is only supported for basic (no type) feeds")
+	}
+
+	if config.Settings.ExtractContentMaxItems < 0 || config.Settings.ExtractContentMaxAge < 0 {
+		return fmt.Errorf("extract_content_max_items and extract_content_max_age must be >= 0")
 	}
 
-	if config.Settings.ExtractContent && config.Type != "" {
-		return fmt.Errorf("extract_content is only supported for basic (no type) feeds")
+	if config.Settings.ExtractCharset != "" && !ValidCharset(config.Settings.ExtractCharset) {
+		return fmt.Errorf("unknown extract_charset %q", config.Settings.ExtractCharset)
 	}
 
 	if config.Settings.MinDuration < 0 {
@@ -73,31 +144,267 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("min_duration is only supported for youtube feeds")
 	}
 
+	if config.Settings.MarkChanges != "" && config.Settings.MarkChanges != "marker" && config.Settings.MarkChanges != "diff" {
+		return fmt.Errorf("invalid mark_changes %q (must be one of: marker, diff)", config.Settings.MarkChanges)
+	}
+
+	if config.Settings.CollapseTitles < 0 {
+		return fmt.Errorf("collapse_titles must be >= 0")
+	}
+
+	if config.Settings.DripInterval < 0 || config.Settings.DripCount < 0 {
+		return fmt.Errorf("drip_interval and drip_count must be >= 0")
+	}
+
+	if config.Settings.Delay < 0 {
+		return fmt.Errorf("delay must be >= 0")
+	}
+
+	// ics items are dated by event start, so a delay would hold every event
+	// back until after it happened.
+	if config.Settings.Delay > 0 && config.Type == "ics" {
+		return fmt.Errorf("delay is not supported for ics feeds")
+	}
+
+	if config.Settings.InitialMaxItems < 0 {
+		return fmt.Errorf("initial_max_items must be >= 0")
+	}
+
+	if config.Settings.IgnoreItemsOlderThan < 0 {
+		return fmt.Errorf("ignore_items_older_than must be >= 0")
+	}
+
+	if config.Settings.DiagnosticAfter < 0 {
+		return fmt.Errorf("diagnostic_after must be >= 0")
+	}
+
+	if config.Settings.OrderBy != "" && config.Settings.OrderBy != "published" && config.Settings.OrderBy != "score" {
+		return fmt.Errorf("invalid order_by %q (must be one of: published, score)", config.Settings.OrderBy)
+	}
+
+	if config.Settings.Language != "" && NormalizeLanguage(config.Settings.Language) == "" {
+		return fmt.Errorf("invalid language %q (expected a language tag such as en or en-US)", config.Settings.Language)
+	}
+
+	if config.Settings.Robots != "" {
+		validDirectives := map[string]bool{
+			"all": true, "none": true, "noindex": true, "nofollow": true,
+			"noarchive": true, "nosnippet": true, "noimageindex": true,
+		}
+		for _, directive := range strings.Split(config.Settings.Robots, ",") {
+			if !validDirectives[strings.TrimSpace(directive)] {
+				return fmt.Errorf("invalid robots directive %q", strings.TrimSpace(directive))
+			}
+		}
+	}
+
+	for field := range stripSet(config.Settings.Strip) {
+		if !stripFields[field] {
+			return fmt.Errorf("invalid strip field %q (must be one of: author_emails, authors, categories)", field)
+		}
+	}
+
+	if err := validateChannelElements(config.Settings.ChannelElements, config.Settings.Namespaces); err != nil {
+		return err
+	}
+
+	if err := validateAttribution(config.Settings.Attribution); err != nil {
+		return err
+	}
+
+	if err := validateResponseHeaders(config.Settings.ResponseHeaders); err != nil {
+		return err
+	}
+
+	if config.Settings.Mirror {
+		if conflicts := mirrorConflicts(config); len(conflicts) > 0 {
+			return fmt.Errorf("mirror serves the source unchanged and can't be combined with: %s", strings.Join(conflicts, ", "))
+		}
+	}
+
 	for i, filter := range config.Filters {
-		if filter.Field == "" {
-			return fmt.Errorf("filter %d: field is required", i)
+		if err := validateFilter(filter, fmt.Sprintf("filter %d", i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mirrorConflicts lists the options of a mirrored feed that would need the
+// source parsed or the output generated.
+func mirrorConflicts(config *Config) []string {
+	s := config.Settings
+	var conflicts []string
+	add := func(set bool, name string) {
+		if set {
+			conflicts = append(conflicts, name)
 		}
+	}
+	add(config.Type != "", "type")
+	add(config.Title != "", "title")
+	add(config.Description != "", "description")
+	add(len(config.Filters) > 0, "filters")
+	add(s.ExtractContent, "extract_content")
+	add(s.MinScore != 0, "min_score")
+	add(s.OrderBy == "score", "order_by")
+	add(s.CollapseSeries, "collapse_series")
+	add(s.CollapseTitles > 0, "collapse_titles")
+	add(s.ShortLinks, "short_links")
+	add(s.Robots != "", "robots")
+	add(s.Language != "", "language")
+	add(s.DiagnosticAfter > 0, "diagnostic_after")
+	add(s.InitialMaxItems > 0, "initial_max_items")
+	add(s.IgnoreItemsOlderThan > 0, "ignore_items_older_than")
+	add(s.Delay > 0, "delay")
+	add(s.Provenance, "provenance")
+	add(s.MarkChanges != "", "mark_changes")
+	add(s.DripInterval > 0, "drip_interval")
+	add(s.Attribution != "", "attribution")
+	add(s.Strip != "", "strip")
+	add(len(s.ChannelElements) > 0, "channel_elements")
+	add(s.Archive, "archive")
+	add(s.ReadLater, "read_later")
+	add(s.ReleaseNotes, "release_notes")
+	return conflicts
+}
 
-		validFields := map[string]bool{
-			"title":       true,
-			"description": true,
-			"content":     true,
-			"link":        true,
-			"authors":     true,
-			"categories":  true,
+var validFilterFields = map[string]bool{
+	"title":          true,
+	"description":    true,
+	"content":        true,
+	"link":           true,
+	"link_domain":    true,
+	"enclosure_type": true,
+	"enclosure_size": true,
+	"authors":        true,
+	"categories":     true,
+}
+
+// validateFilter checks a field rule or, recursively, a group. name locates
+// the filter in error messages, e.g. "filter 2.any[0]".
+func validateFilter(filter types.Filter, name string) error {
+	if filter.IsGroup() {
+		groups := 0
+		for _, set := range []bool{filter.Any != nil, filter.All != nil, filter.Not != nil} {
+			if set {
+				groups++
+			}
+		}
+		if groups > 1 {
+			return fmt.Errorf("%s: use only one of any, all and not; nest groups to combine them", name)
+		}
+		if filter.Field != "" || len(filter.Includes) > 0 || len(filter.Excludes) > 0 || len(filter.TimedExcludes) > 0 {
+			return fmt.Errorf("%s: a group can't also have field, includes or excludes", name)
+		}
+		if filter.Weight != 0 {
+			return fmt.Errorf("%s: weight is only supported on field filters", name)
+		}
+		if filter.CaseSensitive || filter.WholeWord || filter.Mode != "" {
+			return fmt.Errorf("%s: set case_sensitive, whole_word and mode on the field filters inside the group", name)
 		}
 
-		if !validFields[filter.Field] {
-			return fmt.Errorf("filter %d: invalid field '%s' (must be one of: title, description, content, link, authors, categories)", i, filter.Field)
+		if filter.Not != nil {
+			return validateNestedFilter(*filter.Not, name+".not")
+		}
+		key, children := "any", filter.Any
+		if filter.All != nil {
+			key, children = "all", filter.All
+		}
+		if len(children) == 0 {
+			return fmt.Errorf("%s: %s needs at least one filter", name, key)
+		}
+		for i, child := range children {
+			if err := validateNestedFilter(child, fmt.Sprintf("%s.%s[%d]", name, key, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if filter.Field == "" {
+		return fmt.Errorf("%s: field (or any, all, not) is required", name)
+	}
+
+	if !validFilterFields[filter.Field] {
+		return fmt.Errorf("%s: invalid field '%s' (must be one of: title, description, content, link, link_domain, authors, categories, enclosure_type, enclosure_size)", name, filter.Field)
+	}
+
+	switch filter.Mode {
+	case "", "substring", "regex":
+	default:
+		return fmt.Errorf("%s: invalid mode %q (must be substring, regex, or omitted)", name, filter.Mode)
+	}
+
+	patterns := append(append([]string{}, filter.Includes...), filter.Excludes...)
+	for _, timed := range filter.TimedExcludes {
+		patterns = append(patterns, timed.Pattern)
+	}
+
+	if filter.Field == "enclosure_size" {
+		if filter.Mode == "regex" {
+			return fmt.Errorf("%s: enclosure_size doesn't support mode regex", name)
+		}
+		for _, pattern := range patterns {
+			if _, _, err := ParseSizeCondition(pattern); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return nil
+	}
+
+	opts := filterMatchOptions(filter)
+	for _, pattern := range patterns {
+		if expr, ok := patternRegex(pattern, opts); ok {
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("%s: invalid regex %q: %w", name, pattern, err)
+			}
 		}
 	}
 
 	return nil
 }
 
-func applyDefaults(config *Config) {
+func validateNestedFilter(filter types.Filter, name string) error {
+	if filter.Weight != 0 {
+		return fmt.Errorf("%s: weight is only supported on top-level filters", name)
+	}
+	return validateFilter(filter, name)
+}
+
+// highPriorityRefreshInterval is the refresh_interval of priority: high
+// feeds that don't set one.
+const highPriorityRefreshInterval = types.Duration(time.Minute)
+
+// ValidateDefaults checks the feed-defaults of the application config file
+// as the settings of a basic feed, so bad values and settings only other
+// feed types take are reported once at startup.
+func ValidateDefaults(defaults *types.Settings) error {
+	if defaults == nil {
+		return nil
+	}
+	config := Config{URL: "https://example.com/feed.xml", Settings: *defaults}
+	if err := validateConfig(&config); err != nil {
+		return fmt.Errorf("feed-defaults: %w", err)
+	}
+	return nil
+}
+
+func applyDefaults(config *Config, defaults *types.Settings) {
+	if defaults == nil {
+		defaults = &types.Settings{}
+	}
+
+	ownRefreshInterval := config.Settings.RefreshInterval
+	mergeDefaults(config, *defaults)
+
+	// High-priority feeds don't take the general default; they are meant to
+	// be checked about every scheduler tick or two.
+	if ownRefreshInterval == 0 && config.Settings.Priority == "high" {
+		config.Settings.RefreshInterval = highPriorityRefreshInterval
+	}
 	if config.Settings.RefreshInterval == 0 {
-		config.Settings.RefreshInterval = 1800 // 30 minutes
+		config.Settings.RefreshInterval = types.Duration(30 * time.Minute)
 	}
 
 	if config.Settings.MaxItems == 0 {
@@ -105,6 +412,30 @@ func applyDefaults(config *Config) {
 	}
 
 	if config.Settings.Timeout == 0 {
-		config.Settings.Timeout = 30 // seconds
+		config.Settings.Timeout = types.Duration(30 * time.Second)
+	}
+
+	// Sitemaps list only URLs; titles and text come from the pages. Release
+	// notes are fetched in place of extraction.
+	if config.Type == "sitemap" || config.Settings.ReleaseNotes {
+		config.Settings.ExtractContent = true
+	}
+}
+
+// mergeDefaults copies every setting the feed leaves unset from defaults. A
+// default the feed can't take, such as extract_content on a podcast or any
+// output option on a mirrored feed, is skipped for that feed only.
+func mergeDefaults(config *Config, defaults types.Settings) {
+	settings := reflect.ValueOf(&config.Settings).Elem()
+	fallback := reflect.ValueOf(defaults)
+	for i := range settings.NumField() {
+		field := settings.Field(i)
+		if !field.IsZero() || fallback.Field(i).IsZero() {
+			continue
+		}
+		field.Set(fallback.Field(i))
+		if validateConfig(config) != nil {
+			field.SetZero()
+		}
 	}
 }
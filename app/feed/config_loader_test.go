@@ -1,9 +1,15 @@
 package feed
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/types"
 )
 
 func TestLoadConfig_TitleOverride(t *testing.T) {
@@ -11,10 +17,11 @@ func TestLoadConfig_TitleOverride(t *testing.T) {
 	writeTestConfig(t, dir, "test-feed.yml", `
 url: "https://example.com/feed.xml"
 title: "My Custom Title"
+description: "My custom description"
 enabled: true
 `)
 
-	config, _, err := LoadConfig(dir, "test-feed")
+	config, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -22,6 +29,9 @@ enabled: true
 	if config.Title != "My Custom Title" {
 		t.Errorf("expected config.Title = 'My Custom Title', got %q", config.Title)
 	}
+	if config.Description != "My custom description" {
+		t.Errorf("expected config.Description = 'My custom description', got %q", config.Description)
+	}
 }
 
 func TestLoadConfig_TitleOmitted(t *testing.T) {
@@ -31,7 +41,7 @@ url: "https://example.com/feed.xml"
 enabled: true
 `)
 
-	config, _, err := LoadConfig(dir, "test-feed")
+	config, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -49,19 +59,229 @@ url: "https://example.com/feed.xml"
 enabled: true
 `)
 
-	config, _, err := LoadConfig(dir, "test-feed")
+	config, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if config.Settings.RefreshInterval != 1800 {
-		t.Errorf("expected default refresh_interval 1800, got %d", config.Settings.RefreshInterval)
+	if config.Settings.RefreshInterval != types.Duration(30*time.Minute) {
+		t.Errorf("expected default refresh_interval 30m, got %s", config.Settings.RefreshInterval)
 	}
 	if config.Settings.MaxItems != 50 {
 		t.Errorf("expected default max_items 50, got %d", config.Settings.MaxItems)
 	}
-	if config.Settings.Timeout != 30 {
-		t.Errorf("expected default timeout 30, got %d", config.Settings.Timeout)
+	if config.Settings.Timeout != types.Duration(30*time.Second) {
+		t.Errorf("expected default timeout 30s, got %s", config.Settings.Timeout)
+	}
+}
+
+func TestLoadConfig_AppDefaults(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  max_items: 10
+`)
+
+	defaults := &types.Settings{RefreshInterval: types.Duration(10 * time.Minute), MaxItems: 100}
+	config, _, err := LoadConfig(dir, "test-feed", defaults, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.Settings.RefreshInterval != types.Duration(10*time.Minute) {
+		t.Errorf("expected refresh_interval from defaults 10m, got %s", config.Settings.RefreshInterval)
+	}
+	if config.Settings.MaxItems != 10 {
+		t.Errorf("expected feed max_items 10 to win over defaults, got %d", config.Settings.MaxItems)
+	}
+	if config.Settings.Timeout != types.Duration(30*time.Second) {
+		t.Errorf("expected built-in timeout 30s, got %s", config.Settings.Timeout)
+	}
+
+	_, hash1, _ := LoadConfig(dir, "test-feed", nil, time.UTC)
+	_, hash2, _ := LoadConfig(dir, "test-feed", defaults, time.UTC)
+	if hash1 == hash2 {
+		t.Error("expected defaults to change the config hash")
+	}
+}
+
+func TestLoadConfig_AppDefaultsAllSettings(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "blog.yml", `
+url: "https://example.com/feed.xml"
+settings:
+  robots: "noindex"
+`)
+	writeTestConfig(t, dir, "podcast.yml", `
+url: "https://example.com/podcast.xml"
+type: podcast
+`)
+
+	defaults := &types.Settings{ExtractContent: true, Robots: "none", Delay: types.Duration(time.Hour)}
+	config, _, err := LoadConfig(dir, "blog", defaults, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !config.Settings.ExtractContent {
+		t.Error("expected extract_content from defaults")
+	}
+	if config.Settings.Delay != types.Duration(time.Hour) {
+		t.Errorf("expected delay from defaults 1h, got %s", config.Settings.Delay)
+	}
+	if config.Settings.Robots != "noindex" {
+		t.Errorf("expected feed robots to win over defaults, got %q", config.Settings.Robots)
+	}
+
+	config, _, err = LoadConfig(dir, "podcast", defaults, time.UTC)
+	if err != nil {
+		t.Fatalf("expected defaults a podcast can't take to be skipped, got: %v", err)
+	}
+	if config.Settings.ExtractContent {
+		t.Error("expected extract_content not to reach a podcast feed")
+	}
+	if config.Settings.Robots != "none" {
+		t.Errorf("expected robots from defaults, got %q", config.Settings.Robots)
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	if err := ValidateDefaults(&types.Settings{Robots: "noindex", ExtractContent: true}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateDefaults(&types.Settings{Robots: "bogus"}); err == nil {
+		t.Error("expected an invalid robots default to be rejected")
+	}
+	if err := ValidateDefaults(&types.Settings{Magnet: "link"}); err == nil {
+		t.Error("expected a torrent-only default to be rejected")
+	}
+}
+
+func TestLoadConfig_Priority(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "status.yml", `
+url: "https://example.com/status.xml"
+settings:
+  priority: "high"
+`)
+	writeTestConfig(t, dir, "slow-status.yml", `
+url: "https://example.com/status.xml"
+settings:
+  priority: "high"
+  refresh_interval: 5m
+`)
+	writeTestConfig(t, dir, "invalid.yml", `
+url: "https://example.com/status.xml"
+settings:
+  priority: "urgent"
+`)
+
+	defaults := &types.Settings{RefreshInterval: types.Duration(time.Hour)}
+	config, _, err := LoadConfig(dir, "status", defaults, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Settings.RefreshInterval != highPriorityRefreshInterval {
+		t.Errorf("expected the high-priority refresh_interval over the defaults, got %s", config.Settings.RefreshInterval)
+	}
+
+	config, _, err = LoadConfig(dir, "slow-status", defaults, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Settings.RefreshInterval != types.Duration(5*time.Minute) {
+		t.Errorf("expected the feed's refresh_interval to win, got %s", config.Settings.RefreshInterval)
+	}
+
+	if _, _, err := LoadConfig(dir, "invalid", nil, time.UTC); err == nil {
+		t.Error("expected error for an unknown priority")
+	}
+}
+
+func TestLoadConfig_TimedExcludes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+filters:
+  - field: title
+    excludes:
+      - "Sponsored"
+      - pattern: "Olympics"
+        until: 2025-08-30
+`)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	config, _, err := LoadConfig(dir, "test-feed", nil, berlin)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	filter := config.Filters[0]
+	if len(filter.Excludes) != 1 || filter.Excludes[0] != "Sponsored" {
+		t.Errorf("expected plain exclude Sponsored, got %v", filter.Excludes)
+	}
+	if len(filter.TimedExcludes) != 1 {
+		t.Fatalf("expected 1 timed exclude, got %d", len(filter.TimedExcludes))
+	}
+	// A date mutes through the end of that day in TIMEZONE.
+	want := time.Date(2025, 8, 31, 0, 0, 0, 0, berlin)
+	if filter.TimedExcludes[0].Pattern != "Olympics" || !filter.TimedExcludes[0].Until.Equal(want) {
+		t.Errorf("expected Olympics muted until %s, got %+v", want, filter.TimedExcludes[0])
+	}
+
+	_, utcHash, _ := LoadConfig(dir, "test-feed", nil, time.UTC)
+	_, berlinHash, _ := LoadConfig(dir, "test-feed", nil, berlin)
+	if utcHash == berlinHash {
+		t.Error("expected the time zone to change the hash of a config with until dates")
+	}
+}
+
+func TestLoadConfig_TimedExcludeTimestamp(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+filters:
+  - not:
+      field: title
+      excludes:
+        - pattern: "Olympics"
+          until: 2025-08-30T18:00:00Z
+        - pattern: "Paralympics"
+          until: 2025-09-08
+`)
+
+	config, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	timed := config.Filters[0].Not.TimedExcludes
+	if want := time.Date(2025, 8, 30, 18, 0, 0, 0, time.UTC); !timed[0].Until.Equal(want) {
+		t.Errorf("expected a timestamp to be kept as is, got %s", timed[0].Until)
+	}
+	if want := time.Date(2025, 9, 9, 0, 0, 0, 0, time.UTC); !timed[1].Until.Equal(want) {
+		t.Errorf("expected a date in a group to end with that day, got %s", timed[1].Until)
+	}
+}
+
+func TestLoadConfig_TimedExcludeRequiresUntil(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+filters:
+  - field: title
+    excludes:
+      - pattern: "Olympics"
+`)
+
+	if _, _, err := LoadConfig(dir, "test-feed", nil, time.UTC); err == nil {
+		t.Error("expected error for timed exclude without until")
 	}
 }
 
@@ -72,7 +292,7 @@ url: "https://example.com/feed.xml"
 enabled: true
 `)
 
-	config, _, err := LoadConfig(dir, "my-feed")
+	config, _, err := LoadConfig(dir, "my-feed", nil, time.UTC)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -93,7 +313,7 @@ enabled: true
 		}
 		writeTestConfig(t, dir, "test-feed.yml", content)
 
-		_, _, err := LoadConfig(dir, "test-feed")
+		_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 		if err != nil {
 			t.Errorf("expected no error for type %q, got: %v", typ, err)
 		}
@@ -108,10 +328,197 @@ type: invalid
 enabled: true
 `)
 
-	_, _, err := LoadConfig(dir, "test-feed")
+	_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 	if err == nil {
 		t.Error("expected error for invalid type")
 	}
+	if !errors.Is(err, types.ErrFilteredConfigInvalid) {
+		t.Errorf("expected ErrFilteredConfigInvalid, got %v", err)
+	}
+}
+
+func TestLoadConfig_MissingFile(t *testing.T) {
+	_, _, err := LoadConfig(t.TempDir(), "missing", nil, time.UTC)
+	if !errors.Is(err, types.ErrFeedNotFound) {
+		t.Errorf("expected ErrFeedNotFound, got %v", err)
+	}
+}
+
+func TestLoadConfig_InvalidEnclosureSize(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+type: podcast
+enabled: true
+filters:
+  - field: enclosure_size
+    excludes: ["tiny"]
+`)
+
+	_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+	if err == nil {
+		t.Error("expected error for invalid enclosure_size condition")
+	}
+}
+
+func TestLoadConfig_FilterGroups(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+filters:
+  - any:
+      - field: title
+        includes: ["go"]
+      - field: title
+        includes: ["rust"]
+  - not:
+      field: title
+      includes: ["jobs"]
+`)
+
+	config, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.Filters) != 2 || len(config.Filters[0].Any) != 2 || config.Filters[1].Not == nil {
+		t.Fatalf("unexpected filters: %+v", config.Filters)
+	}
+
+	data, err := json.Marshal(config.Filters)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var stored []types.Filter
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stored[0].Any) != 2 || stored[1].Not == nil || stored[1].Not.Includes[0] != "jobs" {
+		t.Errorf("groups lost in JSON round trip: %s", data)
+	}
+}
+
+func TestLoadConfig_InvalidFilterGroups(t *testing.T) {
+	tests := map[string]string{
+		"two operators": `
+  - any: [{field: title, includes: [a]}]
+    all: [{field: title, includes: [b]}]`,
+		"group with field": `
+  - field: title
+    not: {field: title, includes: [a]}`,
+		"empty any": `
+  - any: []`,
+		"invalid nested field": `
+  - not: {field: author, includes: [a]}`,
+		"nested weight": `
+  - any: [{field: title, includes: [a], weight: 2}]`,
+	}
+
+	for name, filters := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestConfig(t, dir, "test-feed.yml", "url: \"https://example.com/feed.xml\"\nenabled: true\nfilters:"+filters+"\n")
+			if _, _, err := LoadConfig(dir, "test-feed", nil, time.UTC); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestLoadConfig_FilterRegex(t *testing.T) {
+	tests := map[string]struct {
+		filters string
+		valid   bool
+	}{
+		"regex mode": {`
+  - field: title
+    mode: regex
+    excludes: ['^\[AD\]', '\bcrypto(currency)?\b']`, true},
+		"invalid regex mode pattern": {`
+  - field: title
+    mode: regex
+    excludes: ['^[AD']`, false},
+		"invalid slash pattern": {`
+  - field: title
+    includes: ['/(unclosed/']`, false},
+		"brackets in substring mode": {`
+  - field: title
+    excludes: ['[AD']`, true},
+		"unknown mode": {`
+  - field: title
+    mode: glob
+    excludes: ['*']`, false},
+		"regex enclosure size": {`
+  - field: enclosure_size
+    mode: regex
+    excludes: ['>1MB']`, false},
+		"mode on group": {`
+  - mode: regex
+    any: [{field: title, includes: [a]}]`, false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestConfig(t, dir, "test-feed.yml", "url: \"https://example.com/feed.xml\"\nenabled: true\nfilters:"+tt.filters+"\n")
+			_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestLoadConfig_InvalidLanguage(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  language: "English"
+`)
+
+	_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+	if err == nil {
+		t.Error("expected error for invalid language tag")
+	}
+}
+
+func TestLoadConfig_ExtractContentLimits(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  extract_content: true
+  extract_content_max_items: 5
+  extract_content_max_age: 2d
+`)
+
+	config, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Settings.ExtractContentMaxItems != 5 {
+		t.Errorf("expected extract_content_max_items 5, got %d", config.Settings.ExtractContentMaxItems)
+	}
+	if config.Settings.ExtractContentMaxAge != types.Duration(48*time.Hour) {
+		t.Errorf("expected extract_content_max_age 2d, got %s", config.Settings.ExtractContentMaxAge)
+	}
+
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  extract_content: true
+  extract_content_max_items: -1
+`)
+	if _, _, err := LoadConfig(dir, "test-feed", nil, time.UTC); err == nil {
+		t.Error("expected error for negative extract_content_max_items")
+	}
 }
 
 func TestLoadConfig_ExtractContentOnlyForBasicType(t *testing.T) {
@@ -124,7 +531,7 @@ settings:
   extract_content: true
 `)
 
-	_, _, err := LoadConfig(dir, "test-feed")
+	_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 	if err == nil {
 		t.Error("expected error for extract_content on non-basic type")
 	}
@@ -140,7 +547,7 @@ settings:
   min_duration: 300
 `)
 
-	_, _, err := LoadConfig(dir, "test-feed")
+	_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 	if err == nil {
 		t.Error("expected error for min_duration on non-youtube type")
 	}
@@ -156,12 +563,54 @@ settings:
   min_duration: 300
 `)
 
-	config, _, err := LoadConfig(dir, "test-feed")
+	config, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Settings.MinDuration != types.Duration(5*time.Minute) {
+		t.Errorf("expected min_duration of 300 seconds, got %s", config.Settings.MinDuration)
+	}
+}
+
+func TestLoadConfig_DelayNotForICS(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/calendar.ics"
+type: ics
+enabled: true
+settings:
+  delay: "1h"
+`)
+
+	_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+	if err == nil {
+		t.Error("expected error for delay on an ics feed")
+	}
+}
+
+func TestLoadConfig_DurationStrings(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  refresh_interval: 30m
+  timeout: 10s
+  diagnostic_after: 2d
+`)
+
+	config, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if config.Settings.MinDuration != 300 {
-		t.Errorf("expected min_duration 300, got %d", config.Settings.MinDuration)
+	if config.Settings.RefreshInterval != types.Duration(30*time.Minute) {
+		t.Errorf("expected refresh_interval 30m, got %s", config.Settings.RefreshInterval)
+	}
+	if config.Settings.Timeout != types.Duration(10*time.Second) {
+		t.Errorf("expected timeout 10s, got %s", config.Settings.Timeout)
+	}
+	if config.Settings.DiagnosticAfter != types.Duration(48*time.Hour) {
+		t.Errorf("expected diagnostic_after 2d, got %s", config.Settings.DiagnosticAfter)
 	}
 }
 
@@ -171,7 +620,7 @@ func TestLoadConfig_MissingURL(t *testing.T) {
 enabled: true
 `)
 
-	_, _, err := LoadConfig(dir, "test-feed")
+	_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 	if err == nil {
 		t.Error("expected error for missing URL")
 	}
@@ -185,12 +634,12 @@ enabled: true
 `
 	writeTestConfig(t, dir, "test-feed.yml", content)
 
-	_, hash1, err := LoadConfig(dir, "test-feed")
+	_, hash1, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	_, hash2, err := LoadConfig(dir, "test-feed")
+	_, hash2, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -201,7 +650,7 @@ enabled: true
 
 	writeTestConfig(t, dir, "test-feed.yml", content+"\n# changed")
 
-	_, hash3, err := LoadConfig(dir, "test-feed")
+	_, hash3, err := LoadConfig(dir, "test-feed", nil, time.UTC)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -211,6 +660,215 @@ enabled: true
 	}
 }
 
+func TestLoadConfig_Robots(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  robots: "noindex, nofollow"
+`)
+
+	config, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Settings.Robots != "noindex, nofollow" {
+		t.Errorf("expected robots directives, got %q", config.Settings.Robots)
+	}
+
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  robots: "noindex, hide"
+`)
+
+	if _, _, err := LoadConfig(dir, "test-feed", nil, time.UTC); err == nil {
+		t.Error("expected error for unknown robots directive")
+	}
+}
+
+func TestLoadConfig_IgnoreItemsOlderThan(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"12h", 12 * time.Hour},
+		{"86400", 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  ignore_items_older_than: `+tt.value+`
+`)
+
+			config, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := time.Duration(config.Settings.IgnoreItemsOlderThan); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+
+			// Settings are stored as JSON; the duration must survive the round trip.
+			data, err := json.Marshal(config.Settings)
+			if err != nil {
+				t.Fatalf("failed to marshal settings: %v", err)
+			}
+			var stored types.Settings
+			if err := json.Unmarshal(data, &stored); err != nil {
+				t.Fatalf("failed to unmarshal settings: %v", err)
+			}
+			if stored.IgnoreItemsOlderThan != config.Settings.IgnoreItemsOlderThan {
+				t.Errorf("round trip changed duration: %v -> %v", config.Settings.IgnoreItemsOlderThan, stored.IgnoreItemsOlderThan)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_InvalidDuration(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  ignore_items_older_than: "a month"
+`)
+
+	if _, _, err := LoadConfig(dir, "test-feed", nil, time.UTC); err == nil {
+		t.Error("expected error for invalid duration")
+	}
+}
+
+func TestLoadConfig_Mirror(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "plain.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  mirror: true
+  refresh_interval: 10m
+`)
+	writeTestConfig(t, dir, "filtered.yml", `
+url: "https://example.com/feed.xml"
+title: "Renamed"
+enabled: true
+settings:
+  mirror: true
+  extract_content: true
+filters:
+  - field: title
+    excludes: ["ad"]
+`)
+
+	config, _, err := LoadConfig(dir, "plain", nil, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !config.Settings.Mirror {
+		t.Error("expected mirror to be enabled")
+	}
+
+	_, _, err = LoadConfig(dir, "filtered", nil, time.UTC)
+	if err == nil {
+		t.Fatal("expected error for mirror with transforms")
+	}
+	for _, option := range []string{"title", "filters", "extract_content"} {
+		if !strings.Contains(err.Error(), option) {
+			t.Errorf("expected %q among the conflicts, got %v", option, err)
+		}
+	}
+}
+
+func TestLoadConfig_AttributionPlaceholders(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  attribution: "<p>Via {source_title}: {permalink}</p>"
+`)
+
+	_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+	if err == nil || !strings.Contains(err.Error(), "{permalink}") {
+		t.Errorf("expected error for unknown placeholder, got %v", err)
+	}
+}
+
+func TestLoadConfig_StripFields(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:
+  strip: "author_emails, comments"
+`)
+
+	_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+	if err == nil || !strings.Contains(err.Error(), "comments") {
+		t.Errorf("expected error for unknown strip field, got %v", err)
+	}
+}
+
+func TestLoadConfig_ChannelElements(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  string
+	}{
+		{"valid", `
+  namespaces:
+    ex: "https://example.com/ns"
+  channel_elements:
+    - name: webMaster
+      value: "ops@example.com"
+    - name: itunes:category
+      attributes: {text: Technology}
+    - name: ex:note
+      value: "hi"`, ""},
+		{"undeclared prefix", `
+  channel_elements:
+    - name: ex:note
+      value: "hi"`, `undeclared namespace prefix "ex"`},
+		{"generated element", `
+  channel_elements:
+    - name: title
+      value: "Other"`, "generated by rss-comb"},
+		{"invalid name", `
+  channel_elements:
+    - name: "web master"`, "invalid channel element name"},
+		{"rebound known prefix", `
+  namespaces:
+    itunes: "https://example.com/itunes"`, `"itunes" is already bound`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestConfig(t, dir, "test-feed.yml", `
+url: "https://example.com/feed.xml"
+enabled: true
+settings:`+tt.settings+"\n")
+
+			_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func writeTestConfig(t *testing.T, dir, filename, content string) {
 	t.Helper()
 	err := os.WriteFile(filepath.Join(dir, filename), []byte(content), 0644)
@@ -218,3 +876,60 @@ func writeTestConfig(t *testing.T, dir, filename, content string) {
 		t.Fatalf("failed to write test config: %v", err)
 	}
 }
+
+func TestLoadConfig_Newsletter(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{"valid", "type: newsletter\nsettings:\n  inbound_token: \"0123456789abcdef\"\n", ""},
+		{"url", "type: newsletter\nurl: \"https://example.com/feed.xml\"\nsettings:\n  inbound_token: \"0123456789abcdef\"\n", "aren't fetched"},
+		{"short token", "type: newsletter\nsettings:\n  inbound_token: \"secret\"\n", "at least 16 characters"},
+		{"token on a fetched feed", "url: \"https://example.com/feed.xml\"\nsettings:\n  inbound_token: \"0123456789abcdef\"\n", "only supported for newsletter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestConfig(t, dir, "test-feed.yml", "enabled: true\n"+tt.config)
+
+			_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ResponseHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers string
+		wantErr string
+	}{
+		{"valid", "Cache-Control: \"public, max-age=300\"\n    X-Team: news", ""},
+		{"invalid name", "\"Bad Header\": x", "invalid header name"},
+		{"invalid value", "X-Note: \"line\\nbreak\"", "invalid value"},
+		{"managed", "content-type: text/plain", "Content-Type is set by rss-comb"},
+		{"own header", "X-Feed-Items: \"0\"", "X-Feed-Items is set by rss-comb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestConfig(t, dir, "test-feed.yml", "url: \"https://example.com/feed.xml\"\nenabled: true\nsettings:\n  response_headers:\n    "+tt.headers+"\n")
+
+			_, _, err := LoadConfig(dir, "test-feed", nil, time.UTC)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
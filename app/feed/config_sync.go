@@ -3,15 +3,20 @@ package feed
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
 )
 
 func ConfigSync(
 	ctx context.Context,
 	feedsDir string,
 	feedName string,
+	defaults *types.Settings,
 	feedRepo *database.FeedRepository,
+	loc *time.Location,
+	now time.Time,
 ) (*Config, error) {
 	select {
 	case <-ctx.Done():
@@ -19,24 +24,31 @@ func ConfigSync(
 	default:
 	}
 
-	config, hash, err := LoadConfig(feedsDir, feedName)
+	config, hash, err := LoadConfig(feedsDir, feedName, defaults, loc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
 	err = feedRepo.UpsertFeedConfig(
+		ctx,
 		config.Name,
 		config.URL,
 		config.Title,
+		config.Description,
 		config.Type,
 		config.Enabled,
 		config.Settings,
 		config.Filters,
 		hash,
+		now,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upsert config to database: %w", err)
 	}
 
+	if err := feedRepo.ScheduleRefilter(ctx, config.Name, NextFilterExpiry(config.Filters, now), now); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
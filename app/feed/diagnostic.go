@@ -0,0 +1,35 @@
+package feed
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// DiagnosticItem returns a notice item telling readers that the upstream
+// feed has been failing for longer than after, or nil if it hasn't. The GUID
+// is derived from the start of the outage, so each outage shows up once.
+func DiagnosticItem(f database.Feed, after time.Duration, now time.Time) *database.Item {
+	if after <= 0 || f.FailingSince == nil || now.Sub(*f.FailingSince) < after {
+		return nil
+	}
+
+	since := f.FailingSince.UTC()
+	description := fmt.Sprintf("The source feed %s could not be fetched since %s.", f.FeedURL, since.Format(time.RFC1123Z))
+	if f.LastError != "" {
+		description += " Last error: " + f.LastError
+	}
+
+	return &database.Item{
+		FeedID: f.ID,
+		Item: types.Item{
+			GUID:        fmt.Sprintf("rss-comb:diagnostic:%s:%d", f.Name, since.Unix()),
+			Title:       fmt.Sprintf("rss-comb: source unreachable since %s", since.Format("2006-01-02 15:04 MST")),
+			Link:        f.Link,
+			Description: description,
+			PublishedAt: since.Add(after),
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+)
+
+func TestDiagnosticItem(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-3 * time.Hour)
+	f := database.Feed{Name: "news", FeedURL: "https://example.com/feed.xml", FailingSince: &since, LastError: "HTTP 503"}
+
+	item := DiagnosticItem(f, 2*time.Hour, now)
+	if item == nil {
+		t.Fatal("expected diagnostic item after threshold")
+	}
+	if !strings.HasPrefix(item.Title, "rss-comb: source unreachable since") {
+		t.Errorf("unexpected title %q", item.Title)
+	}
+	if !strings.Contains(item.Description, "HTTP 503") {
+		t.Errorf("expected last error in description, got %q", item.Description)
+	}
+	if again := DiagnosticItem(f, 2*time.Hour, now.Add(time.Hour)); again.GUID != item.GUID {
+		t.Errorf("expected stable GUID during one outage, got %s and %s", item.GUID, again.GUID)
+	}
+}
+
+func TestDiagnosticItem_NotShown(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Minute)
+
+	tests := []struct {
+		name  string
+		feed  database.Feed
+		after time.Duration
+	}{
+		{"healthy", database.Feed{}, time.Hour},
+		{"below threshold", database.Feed{FailingSince: &recent}, time.Hour},
+		{"disabled", database.Feed{FailingSince: &recent}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if item := DiagnosticItem(tt.feed, tt.after, now); item != nil {
+				t.Errorf("expected no diagnostic item, got %q", item.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package feed
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// Document is a feed assembled for output. Everything that doesn't depend
+// on the output format is resolved here once (display title, self link,
+// dates in the configured location, short links, enclosures), so renderers
+// only decide how to print it.
+type Document struct {
+	Name          string
+	Title         string
+	Link          string
+	Description   string
+	SelfURL       string
+	Language      string
+	ImageURL      string // Source image, or the discovered site icon
+	Robots        string
+	Generator     string
+	PublishedAt   *time.Time
+	LastBuildDate time.Time // Newest item date, or GeneratedAt for an empty feed
+	GeneratedAt   time.Time
+	TTL           int                    // Minutes readers may cache the document: the refresh interval
+	SkipHours     []int                  // GMT hours outside the fetch window
+	SkipDays      []string               // GMT weekdays outside the fetch window
+	Provenance    *Provenance            // Set when the provenance setting is on
+	ITunes        *ITunesChannel         // Set for feed types that carry iTunes metadata
+	Namespaces    map[string]string      // Declarations ChannelElements need, by prefix
+	Elements      []types.ChannelElement // Static channel elements from the channel_elements setting
+	Items         []DocumentItem         // In output order
+}
+
+type Provenance struct {
+	FetchedAt   *time.Time
+	SourceURL   string
+	SourceTitle string
+	Version     string
+}
+
+type ITunesChannel struct {
+	Author     string
+	Image      string
+	Explicit   string
+	OwnerName  string
+	OwnerEmail string
+}
+
+type DocumentItem struct {
+	ID          string
+	GUID        string
+	Title       string
+	Link        string // Short link when short_links is on
+	Description string
+	Content     string // Empty when it repeats the description
+	PublishedAt time.Time
+	UpdatedAt   time.Time // Last upstream change of title or description, else PublishedAt
+	Author      string    // First of Authors
+	Authors     []string
+	Categories  []string
+	Enclosure   *Enclosure
+	ITunes      *ITunesItem // Set for feed types that carry iTunes metadata
+}
+
+type Enclosure struct {
+	URL    string
+	Length int64
+	Type   string
+}
+
+type ITunesItem struct {
+	Duration    int
+	Episode     int
+	Season      int
+	EpisodeType string
+	Image       string
+}
+
+// documentAssembler is the type-specific part of assembling a document.
+type documentAssembler interface {
+	// itunes reports whether documents of this type carry iTunes metadata.
+	itunes() bool
+	// enclosure returns the enclosure published for an item, or nil.
+	enclosure(item database.Item, cfg *cfg.Cfg) *Enclosure
+}
+
+// NewDocument assembles the output document for a feed and the items it
+// serves, using the feed's type for enclosures and iTunes metadata.
+func NewDocument(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (*Document, error) {
+	return assemble(ForType(feed.FeedType), feed, items, cfg, cfg.Now())
+}
+
+func assemble(t documentAssembler, feed database.Feed, items []database.Item, cfg *cfg.Cfg, now time.Time) (*Document, error) {
+	settings, err := feed.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	now = now.In(cfg.Location)
+	doc := &Document{
+		Name:          feed.Name,
+		Title:         feed.DisplayTitle(),
+		Link:          feed.Link,
+		Description:   feed.DisplayDescription(),
+		SelfURL:       feedURL(cfg, feed.Name),
+		Language:      outputLanguage(feed.Language, settings.Language),
+		ImageURL:      cmp.Or(feed.ImageURL, feed.IconURL),
+		Robots:        settings.Robots,
+		Generator:     generator(cfg),
+		LastBuildDate: now,
+		GeneratedAt:   now,
+		Items:         make([]DocumentItem, 0, len(items)),
+	}
+	if doc.Description == "" && feed.FeedType == "newsletter" {
+		doc.Description = "Newsletters received by email"
+	} else if doc.Description == "" {
+		doc.Description = fmt.Sprintf("Processed feed from %s", feed.FeedURL)
+	}
+	// Polling more often than rss-comb fetches can't find anything new. A
+	// ttl in channel_elements takes precedence.
+	if !slices.ContainsFunc(settings.ChannelElements, func(e types.ChannelElement) bool { return e.Name == "ttl" }) {
+		doc.TTL = int(math.Ceil(time.Duration(settings.RefreshInterval).Minutes()))
+	}
+	if window, err := ParseFetchWindow(settings.FetchHours, settings.FetchDays); err == nil {
+		doc.SkipHours, doc.SkipDays = window.Skips(now, cfg.Location)
+	}
+	if feed.FeedPublishedAt != nil {
+		published := feed.FeedPublishedAt.In(cfg.Location)
+		doc.PublishedAt = &published
+	}
+	if settings.Provenance {
+		doc.Provenance = &Provenance{
+			SourceURL:   feed.FeedURL,
+			SourceTitle: cmp.Or(feed.SourceTitle, feed.FeedURL),
+			Version:     cfg.Version,
+		}
+		if feed.LastSuccessAt != nil {
+			fetched := feed.LastSuccessAt.In(cfg.Location)
+			doc.Provenance.FetchedAt = &fetched
+		}
+	}
+	if len(settings.ChannelElements) > 0 {
+		doc.Elements = settings.ChannelElements
+		doc.Namespaces = channelNamespaces(settings.ChannelElements, settings.Namespaces)
+		if t.itunes() {
+			delete(doc.Namespaces, "itunes") // Declared with the iTunes metadata
+		}
+	}
+	strip := stripSet(settings.Strip)
+	if t.itunes() {
+		doc.ITunes = &ITunesChannel{
+			Author:     feed.ITunesAuthor,
+			Image:      feed.ITunesImage,
+			Explicit:   feed.ITunesExplicit,
+			OwnerName:  feed.ITunesOwnerName,
+			OwnerEmail: feed.ITunesOwnerEmail,
+		}
+		if strip["author_emails"] {
+			doc.ITunes.Author = withoutEmail(doc.ITunes.Author)
+			doc.ITunes.OwnerEmail = ""
+		}
+	}
+
+	var newest time.Time
+	for _, item := range items {
+		if date := cmp.Or(item.PublishedAt, item.CreatedAt); date.After(newest) {
+			newest = date
+		}
+
+		docItem := DocumentItem{
+			ID:          item.ID,
+			GUID:        item.GUID,
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			PublishedAt: item.PublishedAt.In(cfg.Location),
+			UpdatedAt:   item.PublishedAt.In(cfg.Location),
+			Enclosure:   t.enclosure(item, cfg),
+		}
+		if item.ChangedAt != nil && item.ChangedAt.After(item.PublishedAt) {
+			docItem.UpdatedAt = item.ChangedAt.In(cfg.Location)
+		}
+		// Items that aren't stored, like notices, have no ID to redirect by.
+		if item.Link != "" && item.ID != "" && settings.ShortLinks {
+			docItem.Link = fmt.Sprintf("%s/r/%s", serviceURL(cfg), item.ID)
+		}
+		if item.MagnetURI != "" {
+			switch settings.Magnet {
+			case "enclosure":
+				docItem.Enclosure = &Enclosure{URL: item.MagnetURI, Length: item.EnclosureLength, Type: bittorrentType}
+			case "link":
+				docItem.Link = item.MagnetURI
+			}
+		}
+		if item.Content != item.Description {
+			docItem.Content = item.Content
+		}
+		// Notices such as the diagnostic item aren't stored and aren't
+		// republished content, so they get no attribution.
+		if settings.Attribution != "" && item.ID != "" {
+			footer := attributionFooter(settings.Attribution, feed, item)
+			if docItem.Content != "" {
+				docItem.Content += footer
+			} else {
+				docItem.Description += footer
+			}
+		}
+		if !strip["authors"] {
+			for _, author := range item.Authors {
+				if strip["author_emails"] {
+					author = withoutEmail(author)
+				}
+				docItem.Authors = append(docItem.Authors, author)
+			}
+			if len(docItem.Authors) > 0 {
+				docItem.Author = docItem.Authors[0]
+			}
+		}
+		for _, category := range item.Categories {
+			if category != "" && !strip["categories"] {
+				docItem.Categories = append(docItem.Categories, category)
+			}
+		}
+		if t.itunes() {
+			docItem.ITunes = &ITunesItem{
+				Duration:    item.ITunesDuration,
+				Episode:     item.ITunesEpisode,
+				Season:      item.ITunesSeason,
+				EpisodeType: item.ITunesEpisodeType,
+				Image:       item.ITunesImage,
+			}
+		}
+		doc.Items = append(doc.Items, docItem)
+	}
+	// Items may be ordered by score, so the newest one isn't always first.
+	if !newest.IsZero() {
+		doc.LastBuildDate = newest.In(cfg.Location)
+	}
+
+	return doc, nil
+}
+
+// feedURL is the public URL of a feed served by this service.
+func feedURL(cfg *cfg.Cfg, name string) string {
+	return fmt.Sprintf("%s/feeds/%s", serviceURL(cfg), name)
+}
+
+func generator(cfg *cfg.Cfg) string {
+	return fmt.Sprintf("RSS-Comb/%s", cfg.Version)
+}
@@ -0,0 +1,160 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestNewDocument(t *testing.T) {
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC, Version: "test"}
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	// Ordered by score, so the newest item is not first.
+	items := []database.Item{
+		{ID: "a", Item: types.Item{GUID: "a", Title: "A", Link: "https://example.com/a", Description: "same", Content: "same", PublishedAt: older,
+			EnclosureURL: "https://example.com/a.mp3", EnclosureType: "audio/mpeg", EnclosureLength: 5}},
+		{ID: "b", Item: types.Item{GUID: "b", Title: "B", Link: "https://example.com/b", Content: "<p>full</p>", PublishedAt: newer}},
+	}
+	f := database.Feed{Name: "test", Title: "Test", FeedURL: "https://example.com/feed.xml", FeedType: "podcast",
+		Settings: []byte(`{"short_links": true}`)}
+
+	doc, err := NewDocument(f, items, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if doc.SelfURL != "https://feeds.example.com/feeds/test" {
+		t.Errorf("unexpected self URL %q", doc.SelfURL)
+	}
+	if doc.Description != "Processed feed from https://example.com/feed.xml" {
+		t.Errorf("unexpected fallback description %q", doc.Description)
+	}
+	if !doc.LastBuildDate.Equal(newer) {
+		t.Errorf("expected lastBuildDate from the newest item, got %v", doc.LastBuildDate)
+	}
+	if doc.Items[0].ID != "a" || doc.Items[1].ID != "b" {
+		t.Error("expected items to keep their output order")
+	}
+	if doc.Items[0].Link != "https://feeds.example.com/r/a" {
+		t.Errorf("expected short link, got %q", doc.Items[0].Link)
+	}
+	if doc.Items[0].Content != "" || doc.Items[1].Content != "<p>full</p>" {
+		t.Error("expected content only where it differs from the description")
+	}
+	if doc.ITunes == nil || doc.Items[0].ITunes == nil {
+		t.Error("expected iTunes metadata for a podcast feed")
+	}
+	if enc := doc.Items[0].Enclosure; enc == nil || enc.URL != "https://example.com/a.mp3" || enc.Length != 5 {
+		t.Errorf("unexpected enclosure %+v", enc)
+	}
+
+	f.FeedType = "basic"
+	doc, err = NewDocument(f, items, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.ITunes != nil || doc.Items[0].Enclosure != nil {
+		t.Error("expected no iTunes metadata or enclosures for a basic feed")
+	}
+}
+
+func TestNewDocument_Clock(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := &cfg.Cfg{Location: time.UTC, Clock: types.FixedClock(now)}
+	f := database.Feed{Name: "test", FeedURL: "https://example.com/feed.xml"}
+
+	doc, err := NewDocument(f, nil, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !doc.GeneratedAt.Equal(now) || !doc.LastBuildDate.Equal(now) {
+		t.Errorf("expected the clock's time for an empty feed, got %v and %v", doc.GeneratedAt, doc.LastBuildDate)
+	}
+}
+
+func TestNewDocument_Attribution(t *testing.T) {
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC}
+	items := []database.Item{
+		{ID: "a", Item: types.Item{Title: "A & B", Link: "https://example.com/a", Description: "<p>short</p>"}},
+		{ID: "b", Item: types.Item{Title: "B", Link: "https://example.com/b", Description: "<p>short</p>", Content: "<p>full</p>"}},
+		{Item: types.Item{Title: "rss-comb: notice", Description: "notice"}},
+	}
+	f := database.Feed{Name: "test", SourceTitle: "Example Blog", Link: "https://example.com",
+		Settings: []byte(`{"short_links": true, "attribution": "<p>From <a href=\"{link}\">{title}</a> on {source_title}.</p>"}`)}
+
+	doc, err := NewDocument(f, items, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := doc.Items[0].Description; got != `<p>short</p><p>From <a href="https://example.com/a">A &amp; B</a> on Example Blog.</p>` {
+		t.Errorf("expected the footer after the description, got %q", got)
+	}
+	if doc.Items[1].Description != "<p>short</p>" || doc.Items[1].Content != `<p>full</p><p>From <a href="https://example.com/b">B</a> on Example Blog.</p>` {
+		t.Errorf("expected the footer after the content only, got %q / %q", doc.Items[1].Description, doc.Items[1].Content)
+	}
+	if doc.Items[2].Description != "notice" {
+		t.Errorf("expected no footer on items that aren't stored, got %q", doc.Items[2].Description)
+	}
+}
+
+func TestNewDocument_ShortLinksUnstored(t *testing.T) {
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC}
+	items := []database.Item{
+		{ID: "a", Item: types.Item{Title: "A", Link: "https://example.com/a"}},
+		{Item: types.Item{Title: "rss-comb: notice", Link: "https://example.com/notice"}},
+	}
+	f := database.Feed{Name: "test", Settings: []byte(`{"short_links": true}`)}
+
+	doc, err := NewDocument(f, items, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Items[0].Link != "https://feeds.example.com/r/a" {
+		t.Errorf("expected a short link, got %q", doc.Items[0].Link)
+	}
+	if doc.Items[1].Link != "https://example.com/notice" {
+		t.Errorf("expected items without an ID to keep their link, got %q", doc.Items[1].Link)
+	}
+}
+
+func TestNewDocument_Strip(t *testing.T) {
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC}
+	items := []database.Item{
+		{ID: "a", Item: types.Item{Title: "A", Authors: []string{"jane@example.com (Jane Doe)"}, Categories: []string{"news"}}},
+		{ID: "b", Item: types.Item{Title: "B", Authors: []string{"John Roe <john@example.com>"}}},
+		{ID: "c", Item: types.Item{Title: "C", Authors: []string{"anon@example.com"}}},
+	}
+	f := database.Feed{Name: "test", FeedType: "podcast", ITunesOwnerEmail: "owner@example.com",
+		Settings: []byte(`{"strip": "author_emails, categories"}`)}
+
+	doc, err := NewDocument(f, items, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, want := range []string{"Jane Doe", "John Roe", ""} {
+		if doc.Items[i].Author != want {
+			t.Errorf("item %d: expected author %q, got %q", i, want, doc.Items[i].Author)
+		}
+	}
+	if doc.Items[0].Categories != nil {
+		t.Errorf("expected no categories, got %v", doc.Items[0].Categories)
+	}
+	if doc.ITunes.OwnerEmail != "" {
+		t.Errorf("expected no owner email, got %q", doc.ITunes.OwnerEmail)
+	}
+
+	f.Settings = []byte(`{"strip": "authors"}`)
+	doc, err = NewDocument(f, items, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Items[0].Author != "" || len(doc.Items[0].Categories) != 1 {
+		t.Errorf("expected only the author dropped, got %q / %v", doc.Items[0].Author, doc.Items[0].Categories)
+	}
+}
@@ -0,0 +1,97 @@
+package feed
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// FeedSource is the upstream address information of a configured feed.
+type FeedSource struct {
+	Name    string
+	FeedURL string
+	SelfURL string // rel=self link the source declares, "" if none
+}
+
+// SameSourceGroup is a set of feeds that fetch the same upstream document.
+type SameSourceGroup struct {
+	URL   string   `json:"url"` // Shared address, as normalized by sourceKey
+	Feeds []string `json:"feeds"`
+}
+
+// SameSourceFeeds groups feeds whose configured URL or declared self link
+// point to the same address. A source naming another feed's URL as its self
+// link is usually that feed's URL after redirects. Feeds without a match
+// aren't returned; groups are ordered by address.
+func SameSourceFeeds(sources []FeedSource) []SameSourceGroup {
+	parent := make([]int, len(sources))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	owner := make(map[string]int) // address -> first feed using it
+	for i, source := range sources {
+		for _, raw := range []string{source.FeedURL, source.SelfURL} {
+			key := sourceKey(raw)
+			if key == "" {
+				continue
+			}
+			if j, ok := owner[key]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				owner[key] = i
+			}
+		}
+	}
+
+	members := make(map[int][]string)
+	for i, source := range sources {
+		root := find(i)
+		members[root] = append(members[root], source.Name)
+	}
+
+	var groups []SameSourceGroup
+	for root, names := range members {
+		if len(names) < 2 {
+			continue
+		}
+		slices.Sort(names)
+		groups = append(groups, SameSourceGroup{URL: sourceKey(sources[root].FeedURL), Feeds: names})
+	}
+	slices.SortFunc(groups, func(a, b SameSourceGroup) int { return strings.Compare(a.URL, b.URL) })
+	return groups
+}
+
+// sourceKey reduces a feed URL to what identifies the document: host
+// without "www." and default port, path without trailing slash, and query
+// without tracking parameters. The scheme is ignored, as most sites
+// redirect http to https.
+func sourceKey(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	query := u.Query()
+	for _, param := range trackingParams {
+		query.Del(param)
+	}
+
+	key := host + strings.TrimRight(u.EscapedPath(), "/")
+	if encoded := query.Encode(); encoded != "" {
+		key += "?" + encoded
+	}
+	return key
+}
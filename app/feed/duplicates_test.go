@@ -0,0 +1,45 @@
+package feed
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSourceKey(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://www.Example.com/feed/", "example.com/feed"},
+		{"http://example.com:80/feed?utm_source=x", "example.com/feed"},
+		{"https://example.com:8443/feed?b=2&a=1", "example.com:8443/feed?a=1&b=2"},
+		{"not a url", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := sourceKey(tt.url); got != tt.want {
+			t.Errorf("sourceKey(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSameSourceFeeds(t *testing.T) {
+	groups := SameSourceFeeds([]FeedSource{
+		{Name: "blog", FeedURL: "https://example.com/feed/"},
+		{Name: "blog-http", FeedURL: "http://www.example.com/feed"},
+		{Name: "old-address", FeedURL: "https://old.example.org/rss", SelfURL: "https://example.com/feed"},
+		{Name: "news", FeedURL: "https://news.example.net/rss"},
+		{Name: "news-mirror", FeedURL: "https://mirror.example.net/rss", SelfURL: "https://news.example.net/rss"},
+		{Name: "alone", FeedURL: "https://alone.example.com/feed"},
+	})
+
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	if want := []string{"blog", "blog-http", "old-address"}; !slices.Equal(groups[0].Feeds, want) || groups[0].URL != "example.com/feed" {
+		t.Errorf("unexpected first group %+v", groups[0])
+	}
+	if want := []string{"news", "news-mirror"}; !slices.Equal(groups[1].Feeds, want) {
+		t.Errorf("unexpected second group %+v", groups[1])
+	}
+}
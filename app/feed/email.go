@@ -0,0 +1,231 @@
+package feed
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// maxEmailParts bounds the MIME parts walked per message, so a crafted
+// message can't keep the parser busy.
+const maxEmailParts = 100
+
+var (
+	bodyInner  = regexp.MustCompile(`(?is)<body\b[^>]*>(.*)</body>`)
+	blankLines = regexp.MustCompile(`\n\s*\n`)
+
+	headerDecoder = &mime.WordDecoder{CharsetReader: func(label string, input io.Reader) (io.Reader, error) {
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := DecodeHTML(data, "", label)
+		return bytes.NewReader(decoded), err
+	}}
+)
+
+// Email is a newsletter received by email, as far as it becomes an item.
+type Email struct {
+	MessageID string
+	Subject   string
+	From      string
+	Date      string
+	HTML      string
+	Text      string
+}
+
+// ParseEmail reads a raw RFC 5322 message. The HTML body is preferred; the
+// plain text body is kept for messages without one. Attachments are
+// skipped.
+func ParseEmail(raw []byte) (Email, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return Email{}, fmt.Errorf("%w: invalid email: %w", types.ErrParse, err)
+	}
+
+	email := Email{
+		MessageID: msg.Header.Get("Message-Id"),
+		Subject:   decodeHeader(msg.Header.Get("Subject")),
+		From:      decodeHeader(msg.Header.Get("From")),
+		Date:      msg.Header.Get("Date"),
+	}
+
+	parts := 0
+	if err := email.readPart(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), "", msg.Body, &parts); err != nil {
+		return Email{}, fmt.Errorf("%w: invalid email body: %w", types.ErrParse, err)
+	}
+	return email, nil
+}
+
+// EmailFromFields reads the fields that inbound-email services post, such
+// as Mailgun's body-html or Postmark's HtmlBody. Field names are matched
+// case-insensitively.
+func EmailFromFields(fields map[string]string) Email {
+	get := func(names ...string) string {
+		for _, name := range names {
+			for key, value := range fields {
+				if strings.EqualFold(key, name) && value != "" {
+					return value
+				}
+			}
+		}
+		return ""
+	}
+
+	return Email{
+		MessageID: get("message-id", "messageid"),
+		Subject:   decodeHeader(get("subject")),
+		From:      decodeHeader(get("from", "sender")),
+		Date:      get("date"),
+		HTML:      get("html", "body-html", "htmlbody"),
+		Text:      get("text", "body-plain", "textbody", "plain"),
+	}
+}
+
+// readPart walks a MIME part, keeping the first HTML and plain text bodies
+// that aren't attachments.
+func (e *Email) readPart(contentType, transferEncoding, disposition string, body io.Reader, parts *int) error {
+	if *parts++; *parts > maxEmailParts {
+		return fmt.Errorf("more than %d MIME parts", maxEmailParts)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	if dispositionType, _, _ := mime.ParseMediaType(disposition); dispositionType == "attachment" {
+		return nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := e.readPart(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"),
+				part.Header.Get("Content-Disposition"), part, parts); err != nil {
+				return err
+			}
+		}
+	}
+
+	if mediaType != "text/html" && mediaType != "text/plain" {
+		return nil
+	}
+	if (mediaType == "text/html" && e.HTML != "") || (mediaType == "text/plain" && e.Text != "") {
+		return nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	data, err = DecodeHTML(data, contentType, "")
+	if err != nil {
+		return err
+	}
+
+	if mediaType == "text/html" {
+		e.HTML = string(data)
+	} else {
+		e.Text = string(data)
+	}
+	return nil
+}
+
+// Item turns the email into a feed item. Its GUID is the Message-ID, so a
+// message delivered twice is stored once.
+func (e Email) Item(now time.Time) types.Item {
+	item := types.Item{
+		Title:       cmp.Or(strings.TrimSpace(e.Subject), "(no subject)"),
+		PublishedAt: now,
+	}
+
+	if address, err := mail.ParseAddress(e.From); err == nil {
+		item.Authors = []string{formatAuthor(address.Name, address.Address)}
+	} else if from := strings.TrimSpace(e.From); from != "" {
+		item.Authors = []string{from}
+	}
+	if date, err := mail.ParseDate(e.Date); err == nil && !date.After(now) {
+		item.PublishedAt = date
+	}
+
+	item.Description = emailHTML(e.HTML, e.Text)
+	item.Content = item.Description
+
+	messageID := strings.Trim(strings.TrimSpace(e.MessageID), "<>")
+	if messageID == "" {
+		hash := sha256.Sum256([]byte(e.From + "|" + e.Subject + "|" + e.Date + "|" + item.Description))
+		messageID = hex.EncodeToString(hash[:16])
+	}
+	item.GUID = "email:" + messageID
+
+	// The usual title|link hash would merge every issue of a newsletter
+	// with a fixed subject, as emails have no link.
+	hash := sha256.Sum256([]byte(item.GUID))
+	item.ContentHash = hex.EncodeToString(hash[:])
+
+	return item
+}
+
+// emailHTML returns the inside of an HTML body without scripts and style
+// sheets, or the plain text as paragraphs.
+func emailHTML(htmlBody, text string) string {
+	if strings.TrimSpace(htmlBody) != "" {
+		if m := bodyInner.FindStringSubmatch(htmlBody); m != nil {
+			htmlBody = m[1]
+		}
+		return strings.TrimSpace(scriptOrStyle.ReplaceAllString(htmlBody, ""))
+	}
+	return textHTML(text)
+}
+
+// textHTML escapes plain text into paragraphs, one per blank-line separated
+// block, keeping single line breaks.
+func textHTML(text string) string {
+	var b strings.Builder
+	for _, paragraph := range blankLines.Split(strings.ReplaceAll(text, "\r\n", "\n"), -1) {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>"))
+		b.WriteString("</p>")
+	}
+	return b.String()
+}
+
+func decodeHeader(value string) string {
+	decoded, err := headerDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
@@ -0,0 +1,96 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testEmail = "From: =?UTF-8?Q?J=C3=A9r=C3=B4me?= <news@example.com>\r\n" +
+	"To: inbox@feeds.example.com\r\n" +
+	"Subject: =?UTF-8?B?V2Vla2x5IGRpZ2VzdCDinJM=?=\r\n" +
+	"Date: Mon, 02 Sep 2024 08:30:00 +0200\r\n" +
+	"Message-ID: <issue-42@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Plain version\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"<html><head><style>p { color: red; }</style></head><body><p>Caf=C3=A9 news</p>=\r\n" +
+	"<script>track()</script></body></html>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/html\r\n" +
+	"Content-Disposition: attachment; filename=\"old.html\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"PHA+YXR0YWNobWVudDwvcD4=\r\n" +
+	"--outer--\r\n"
+
+func TestParseEmail(t *testing.T) {
+	email, err := ParseEmail([]byte(testEmail))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if email.Subject != "Weekly digest ✓" {
+		t.Errorf("unexpected subject %q", email.Subject)
+	}
+	if email.Text != "Plain version" {
+		t.Errorf("unexpected text body %q", email.Text)
+	}
+
+	now := time.Date(2024, 9, 3, 0, 0, 0, 0, time.UTC)
+	item := email.Item(now)
+	if item.Title != "Weekly digest ✓" {
+		t.Errorf("unexpected title %q", item.Title)
+	}
+	if item.Description != "<p>Café news</p>" || item.Content != item.Description {
+		t.Errorf("expected the HTML body without scripts, styles or the attachment, got %q", item.Description)
+	}
+	if len(item.Authors) != 1 || item.Authors[0] != "news@example.com (Jérôme)" {
+		t.Errorf("unexpected authors %v", item.Authors)
+	}
+	if !item.PublishedAt.Equal(time.Date(2024, 9, 2, 6, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected the Date header as published date, got %v", item.PublishedAt)
+	}
+	if item.GUID != "email:issue-42@example.com" || item.ContentHash == "" {
+		t.Errorf("unexpected GUID %q / hash %q", item.GUID, item.ContentHash)
+	}
+}
+
+func TestEmailFromFields(t *testing.T) {
+	now := time.Date(2024, 9, 3, 0, 0, 0, 0, time.UTC)
+
+	// Postmark-style names; no HTML body and a date in the future.
+	email := EmailFromFields(map[string]string{
+		"Subject":   "Weekly digest",
+		"From":      "news@example.com",
+		"TextBody":  "Line one\nline two\n\n<b>not bold</b>",
+		"MessageID": "abc",
+		"Date":      "Mon, 02 Sep 2030 08:30:00 +0200",
+	})
+	item := email.Item(now)
+
+	if item.Description != "<p>Line one<br>line two</p><p>&lt;b&gt;not bold&lt;/b&gt;</p>" {
+		t.Errorf("expected escaped text paragraphs, got %q", item.Description)
+	}
+	if !item.PublishedAt.Equal(now) {
+		t.Errorf("expected a future date to be replaced by the receive time, got %v", item.PublishedAt)
+	}
+
+	// Issues with the same subject must not be taken for duplicates.
+	other := EmailFromFields(map[string]string{"subject": "Weekly digest", "body-plain": "Next week", "Message-Id": "<def>"}).Item(now)
+	if other.ContentHash == item.ContentHash || !strings.HasPrefix(other.GUID, "email:") {
+		t.Errorf("expected distinct items, got %q and %q", item.GUID, other.GUID)
+	}
+}
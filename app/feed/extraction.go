@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"codeberg.org/readeck/go-readability"
+	"golang.org/x/net/html"
 )
 
 var (
@@ -38,3 +40,102 @@ func Extract(data []byte) (string, error) {
 
 	return content, nil
 }
+
+// Extracted content scoring below this is treated as boilerplate.
+const minExtractionScore = 0.25
+
+// ExtractionQuality describes how much of an extraction looks like article
+// text rather than navigation or boilerplate.
+type ExtractionQuality struct {
+	TextLength  int     // Characters of visible text
+	LinkDensity float64 // Share of the text inside links
+	Score       float64 // 0..1; higher is better
+	Reason      string  // Why the extraction was rejected, "" if accepted
+}
+
+func (q ExtractionQuality) Accepted() bool {
+	return q.Reason == ""
+}
+
+func (q ExtractionQuality) String() string {
+	s := fmt.Sprintf("score=%.2f text=%d link_density=%.2f", q.Score, q.TextLength, q.LinkDensity)
+	if q.Reason != "" {
+		s += ": " + q.Reason
+	}
+	return s
+}
+
+// ScoreExtraction rates extracted content by the amount of text and the
+// share of it that is link text. It is rejected when it scores below
+// minExtractionScore or has less text than the item's own description,
+// which then serves readers better.
+func ScoreExtraction(content, description string) ExtractionQuality {
+	text, linkText := visibleText(content)
+	q := ExtractionQuality{TextLength: text}
+	if text > 0 {
+		q.LinkDensity = float64(linkText) / float64(text)
+	}
+	// Full marks from about two screens of text with few links.
+	q.Score = min(1, float64(text)/1500) * (1 - q.LinkDensity)
+
+	descriptionText, _ := visibleText(description)
+	switch {
+	case text == 0:
+		q.Reason = "no text"
+	case q.LinkDensity > 0.5:
+		q.Reason = "mostly links"
+	case text < descriptionText:
+		q.Reason = "shorter than the description"
+	case q.Score < minExtractionScore:
+		q.Reason = "too little text"
+	}
+	return q
+}
+
+// ScoreSourceContent rates content taken from the source's API rather
+// than extracted from a page. It is the article itself, however short or
+// link-heavy, so it is only rejected when empty or shorter than the
+// description.
+func ScoreSourceContent(content, description string) ExtractionQuality {
+	q := ScoreExtraction(content, description)
+	if q.TextLength > 0 && q.Reason != "shorter than the description" {
+		q.Reason = ""
+	}
+	return q
+}
+
+// visibleText counts the characters of text in an HTML fragment and how many
+// of them are inside links, ignoring scripts, styles and whitespace runs.
+func visibleText(fragment string) (text, linkText int) {
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	links, skipped := 0, 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return text, linkText
+		case html.StartTagToken:
+			switch name, _ := z.TagName(); string(name) {
+			case "a":
+				links++
+			case "script", "style", "noscript":
+				skipped++
+			}
+		case html.EndTagToken:
+			switch name, _ := z.TagName(); string(name) {
+			case "a":
+				links = max(0, links-1)
+			case "script", "style", "noscript":
+				skipped = max(0, skipped-1)
+			}
+		case html.TextToken:
+			if skipped > 0 {
+				continue
+			}
+			n := utf8.RuneCountInString(strings.Join(strings.Fields(string(z.Text())), " "))
+			text += n
+			if links > 0 {
+				linkText += n
+			}
+		}
+	}
+}
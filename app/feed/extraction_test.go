@@ -0,0 +1,36 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScoreExtraction(t *testing.T) {
+	article := "<p>" + strings.Repeat("Readable article text with enough words to count. ", 40) + `<a href="/x">one link</a></p>`
+	navigation := "<ul>" + strings.Repeat(`<li><a href="/section">Section link</a></li>`, 30) + "</ul><p>Menu</p>"
+
+	tests := []struct {
+		name        string
+		content     string
+		description string
+		wantReason  string
+	}{
+		{name: "article", content: article, wantReason: ""},
+		{name: "navigation", content: navigation, wantReason: "mostly links"},
+		{name: "empty", content: "<div><script>var x = 1;</script></div>", wantReason: "no text"},
+		{name: "short", content: "<p>Subscribe to read more.</p>", wantReason: "too little text"},
+		{name: "shorter than description", content: "<p>Subscribe to read more.</p>", description: "<p>" + strings.Repeat("A long summary. ", 10) + "</p>", wantReason: "shorter than the description"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := ScoreExtraction(tt.content, tt.description)
+			if q.Reason != tt.wantReason {
+				t.Errorf("expected reason %q, got %q (%s)", tt.wantReason, q.Reason, q)
+			}
+			if q.Accepted() != (tt.wantReason == "") {
+				t.Errorf("Accepted() disagrees with reason %q", q.Reason)
+			}
+		})
+	}
+}
@@ -1,14 +1,25 @@
 package feed
 
 import (
+	"bufio"
+	"bytes"
+	"io"
+	"time"
+
 	"github.com/lysyi3m/rss-comb/app/cfg"
 	"github.com/lysyi3m/rss-comb/app/database"
 	"github.com/lysyi3m/rss-comb/app/types"
 )
 
 type FeedType interface {
-	Parse(data []byte) (*Metadata, []types.Item, error)
+	documentAssembler
+	// Parse reads a fetched source at now, the time undated entries of
+	// sitemaps are stamped with.
+	Parse(data []byte, now time.Time) (*Metadata, []types.Item, error)
 	Build(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error)
+	// Write streams the same document as Build to w, so large feeds don't
+	// have to be held in memory as a whole.
+	Write(w io.Writer, feed database.Feed, items []database.Item, cfg *cfg.Cfg) error
 }
 
 func ForType(typ string) FeedType {
@@ -17,7 +28,55 @@ func ForType(typ string) FeedType {
 		return youtubeType{}
 	case "podcast":
 		return podcastType{}
+	case "newsletter":
+		return newsletterType{}
+	case "ics":
+		return icsType{}
+	case "sitemap":
+		return sitemapType{}
+	case "torrent":
+		return torrentType{}
 	default:
 		return basicType{}
 	}
 }
+
+// feedWriter is what the XML writers need; *bytes.Buffer and *bufio.Writer
+// both satisfy it.
+type feedWriter interface {
+	io.Writer
+	io.StringWriter
+	io.ByteWriter
+}
+
+// BuildFormat is Build for any output format; Build itself renders RSS.
+func BuildFormat(t FeedType, format Format, feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
+	return build(t, format, feed, items, cfg)
+}
+
+// WriteFormat is Write for any output format.
+func WriteFormat(t FeedType, format Format, w io.Writer, feed database.Feed, items []database.Item, cfg *cfg.Cfg) error {
+	return stream(t, format, w, feed, items, cfg)
+}
+
+func build(t documentAssembler, format Format, feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
+	doc, err := assemble(t, feed, items, cfg, cfg.Now())
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	format.write(&buf, doc)
+	return buf.String(), nil
+}
+
+// stream writes through a bufio.Writer, which keeps the first write error
+// and reports it from Flush.
+func stream(t documentAssembler, format Format, w io.Writer, feed database.Feed, items []database.Item, cfg *cfg.Cfg) error {
+	doc, err := assemble(t, feed, items, cfg, cfg.Now())
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriterSize(w, 32<<10)
+	format.write(bw, doc)
+	return bw.Flush()
+}
@@ -0,0 +1,160 @@
+package feed
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// FetchWindow is the part of the week a feed is fetched in, from the
+// fetch_hours and fetch_days settings, in the configured time zone. The
+// zero value allows any time.
+type FetchWindow struct {
+	hours [24]bool // Disallowed hours
+	days  [7]bool  // Disallowed weekdays, indexed by time.Weekday
+}
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseFetchWindow reads fetch_hours such as "7-22" or "6-9,17-23" (hours
+// inclusive, ranges may wrap past midnight) and fetch_days such as
+// "mon-fri" or "sat,sun". Empty values allow every hour or day.
+func ParseFetchWindow(hours, days string) (FetchWindow, error) {
+	var w FetchWindow
+	if strings.TrimSpace(hours) != "" {
+		allowed, err := parseRanges(hours, 24, func(s string) (int, bool) {
+			n, err := strconv.Atoi(s)
+			return n, err == nil && n >= 0 && n < 24
+		})
+		if err != nil {
+			return FetchWindow{}, fmt.Errorf("invalid fetch_hours %q: %w", hours, err)
+		}
+		for i := range w.hours {
+			w.hours[i] = !allowed[i]
+		}
+	}
+	if strings.TrimSpace(days) != "" {
+		allowed, err := parseRanges(days, 7, func(s string) (int, bool) {
+			for i, day := range weekdays {
+				if strings.HasPrefix(strings.ToLower(s), day) {
+					return i, true
+				}
+			}
+			return 0, false
+		})
+		if err != nil {
+			return FetchWindow{}, fmt.Errorf("invalid fetch_days %q: %w", days, err)
+		}
+		for i := range w.days {
+			w.days[i] = !allowed[i]
+		}
+	}
+	if !slices.Contains(w.hours[:], false) || !slices.Contains(w.days[:], false) {
+		return FetchWindow{}, fmt.Errorf("fetch window allows no time at all")
+	}
+	return w, nil
+}
+
+// parseRanges marks the values of comma-separated values and ranges in a
+// cycle of size n; a range whose end comes before its start wraps around.
+func parseRanges(value string, n int, parse func(string) (int, bool)) ([]bool, error) {
+	marked := make([]bool, n)
+	for _, part := range strings.Split(value, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		start, ok := parse(strings.TrimSpace(from))
+		if !ok {
+			return nil, fmt.Errorf("%q is not valid", strings.TrimSpace(from))
+		}
+		end := start
+		if isRange {
+			if end, ok = parse(strings.TrimSpace(to)); !ok {
+				return nil, fmt.Errorf("%q is not valid", strings.TrimSpace(to))
+			}
+		}
+		for i := start; ; i = (i + 1) % n {
+			marked[i] = true
+			if i == end {
+				break
+			}
+		}
+	}
+	return marked, nil
+}
+
+// Allows reports whether t, in the configured time zone, is in the window.
+func (w FetchWindow) Allows(t time.Time) bool {
+	return !w.hours[t.Hour()] && !w.days[t.Weekday()]
+}
+
+// Next returns t if it's in the window, else the start of the window's
+// next hour.
+func (w FetchWindow) Next(t time.Time) time.Time {
+	if w.Allows(t) {
+		return t
+	}
+	next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	for range 8 * 24 {
+		next = next.Add(time.Hour)
+		if w.Allows(next) {
+			return next
+		}
+	}
+	return t
+}
+
+// Skips returns the RSS skipHours and skipDays for the window. Both are in
+// GMT and apply to every week, so an hour or day is only listed when the
+// window excludes all of it, which keeps zones with half-hour offsets and
+// DST changes on the safe side.
+func (w FetchWindow) Skips(now time.Time, loc *time.Location) (hours []int, days []string) {
+	if w == (FetchWindow{}) {
+		return nil, nil
+	}
+
+	// blocked reports whether the GMT hour starting at t is outside the
+	// window from its first to its last minute.
+	blocked := func(t time.Time) bool {
+		return !w.Allows(t.In(loc)) && !w.Allows(t.Add(59*time.Minute).In(loc))
+	}
+
+	start := now.UTC().Truncate(24 * time.Hour)
+	var openDays []time.Time
+	for d := range 7 {
+		day := start.AddDate(0, 0, d)
+		closed := true
+		for h := range 24 {
+			closed = closed && blocked(day.Add(time.Duration(h)*time.Hour))
+		}
+		if closed {
+			days = append(days, day.Weekday().String())
+		} else {
+			openDays = append(openDays, day)
+		}
+	}
+
+	for h := range 24 {
+		closed := true
+		for _, day := range openDays {
+			closed = closed && blocked(day.Add(time.Duration(h)*time.Hour))
+		}
+		if closed {
+			hours = append(hours, h)
+		}
+	}
+	return hours, days
+}
+
+// NextFetch schedules a feed's next fetch: one refresh interval from now,
+// moved to the start of the fetch window when that falls outside it.
+func NextFetch(settings *types.Settings, now time.Time, loc *time.Location) time.Time {
+	next := now.Add(time.Duration(settings.RefreshInterval))
+	window, err := ParseFetchWindow(settings.FetchHours, settings.FetchDays)
+	if err != nil {
+		return next
+	}
+	return window.Next(next.In(loc)).UTC()
+}
@@ -0,0 +1,79 @@
+package feed
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+)
+
+func TestParseFetchWindow(t *testing.T) {
+	for _, tt := range [][2]string{{"25", ""}, {"7-", ""}, {"", "someday"}} {
+		if _, err := ParseFetchWindow(tt[0], tt[1]); err == nil {
+			t.Errorf("ParseFetchWindow(%q, %q): expected an error", tt[0], tt[1])
+		}
+	}
+
+	window, err := ParseFetchWindow("22-6", "mon-fri")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	friday := time.Date(2024, 7, 5, 0, 0, 0, 0, time.UTC)
+	for hour, want := range map[int]bool{22: true, 23: true, 0: true, 6: true, 7: false, 12: false} {
+		if got := window.Allows(friday.Add(time.Duration(hour) * time.Hour)); got != want {
+			t.Errorf("Allows(%02d:00 Friday) = %v, want %v", hour, got, want)
+		}
+	}
+
+	// Friday 07:30 is outside; the next window opens Friday 22:00.
+	if got := window.Next(friday.Add(7*time.Hour + 30*time.Minute)); !got.Equal(friday.Add(22 * time.Hour)) {
+		t.Errorf("unexpected next fetch %v", got)
+	}
+	// Saturday is outside entirely; the next window opens Monday 00:00.
+	if got := window.Next(friday.Add(47 * time.Hour)); !got.Equal(friday.AddDate(0, 0, 3)) {
+		t.Errorf("unexpected next fetch %v", got)
+	}
+}
+
+func TestFetchWindowSkips(t *testing.T) {
+	window, _ := ParseFetchWindow("8-19", "mon-sat")
+	now := time.Date(2024, 7, 3, 12, 0, 0, 0, time.UTC)
+
+	hours, days := window.Skips(now, time.UTC)
+	if !slices.Equal(days, []string{"Sunday"}) {
+		t.Errorf("unexpected skipDays %v", days)
+	}
+	if !slices.Equal(hours, []int{0, 1, 2, 3, 4, 5, 6, 7, 20, 21, 22, 23}) {
+		t.Errorf("unexpected skipHours %v", hours)
+	}
+
+	// In UTC+5:30, 08:00-19:59 local is 02:30-14:29 GMT; the partly open
+	// hours 2 and 14 are not skipped.
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	hours, _ = window.Skips(now, kolkata)
+	if !slices.Equal(hours, []int{0, 1, 15, 16, 17, 18, 19, 20, 21, 22, 23}) {
+		t.Errorf("unexpected skipHours %v", hours)
+	}
+}
+
+func TestBuild_TTLAndSkipHours(t *testing.T) {
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC}
+	feed := database.Feed{Name: "news", FeedURL: "https://example.com/feed",
+		Settings: []byte(`{"refresh_interval": "1h", "fetch_hours": "6-21"}`)}
+
+	out, err := ForType("").Build(feed, nil, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"<ttl>60</ttl>", "<skipHours>", "<hour>22</hour>", "<hour>5</hour>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output", want)
+		}
+	}
+	if strings.Contains(out, "<skipDays>") {
+		t.Error("expected no skipDays without fetch_days")
+	}
+}
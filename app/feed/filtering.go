@@ -1,10 +1,16 @@
 package feed
 
 import (
+	"fmt"
 	"log"
+	"net/url"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
 	"unicode/utf8"
 
 	"golang.org/x/text/unicode/norm"
@@ -19,65 +25,149 @@ func ClearRegexCache() {
 	regexCache = sync.Map{}
 }
 
-func Filter(items []types.Item, filters []types.Filter) []types.Item {
+// Filter marks items that fail the feed's filters and computes their score
+// at now, which decides the timed rules still in force. When weighted
+// filters are configured, items scoring below minScore are filtered as well.
+func Filter(items []types.Item, filters []types.Filter, minScore int, now time.Time) []types.Item {
 	if len(filters) == 0 {
 		return items
 	}
 
+	scoring := slices.ContainsFunc(filters, func(f types.Filter) bool { return f.Weight != 0 })
+
 	filtered := make([]types.Item, 0, len(items))
 	for _, item := range items {
-		item.IsFiltered = applyFilters(item, filters)
+		item.IsFiltered = applyFilters(item, filters, now)
+		item.Score = scoreItem(item, filters, now)
+		if scoring && item.Score < minScore {
+			item.IsFiltered = true
+		}
 		filtered = append(filtered, item)
 	}
 
 	return filtered
 }
 
-func applyFilters(item types.Item, filters []types.Filter) bool {
+func applyFilters(item types.Item, filters []types.Filter, now time.Time) bool {
 	for _, filter := range filters {
-		for _, exclude := range filter.Excludes {
-			if matchesFieldFilter(item, filter.Field, exclude) {
-				return true
-			}
+		if filter.Weight != 0 {
+			continue
+		}
+		if !passesFilter(item, filter, now) {
+			return true
 		}
+	}
 
-		if len(filter.Includes) > 0 {
-			matched := false
-			for _, include := range filter.Includes {
-				if matchesFieldFilter(item, filter.Field, include) {
-					matched = true
-					break
-				}
-			}
-			if !matched {
-				return true
+	return false
+}
+
+// passesFilter reports whether item passes filter. A field rule passes when
+// no exclude matches and, if includes are set, one of them matches. Groups
+// combine their filters: any passes if one passes, all if every one passes,
+// not if its filter fails.
+func passesFilter(item types.Item, filter types.Filter, now time.Time) bool {
+	switch {
+	case filter.Any != nil:
+		return slices.ContainsFunc(filter.Any, func(f types.Filter) bool { return passesFilter(item, f, now) })
+	case filter.All != nil:
+		return !slices.ContainsFunc(filter.All, func(f types.Filter) bool { return !passesFilter(item, f, now) })
+	case filter.Not != nil:
+		return !passesFilter(item, *filter.Not, now)
+	}
+
+	if matchesAny(item, filter, filter.ActiveExcludes(now)) {
+		return false
+	}
+	return len(filter.Includes) == 0 || matchesAny(item, filter, filter.Includes)
+}
+
+// NextFilterExpiry returns the earliest time after now at which a timed
+// exclude stops applying, or nil if there is none. Items must be refiltered
+// then so that muted items reappear.
+func NextFilterExpiry(filters []types.Filter, now time.Time) *time.Time {
+	var next *time.Time
+	for _, filter := range filters {
+		for _, timed := range filter.TimedExcludes {
+			if timed.Until.After(now) && (next == nil || timed.Until.Before(*next)) {
+				until := timed.Until
+				next = &until
 			}
 		}
+
+		children := append(slices.Clone(filter.Any), filter.All...)
+		if filter.Not != nil {
+			children = append(children, *filter.Not)
+		}
+		if nested := NextFilterExpiry(children, now); nested != nil && (next == nil || nested.Before(*next)) {
+			next = nested
+		}
 	}
+	return next
+}
 
+func scoreItem(item types.Item, filters []types.Filter, now time.Time) int {
+	score := 0
+	for _, filter := range filters {
+		if filter.Weight == 0 {
+			continue
+		}
+		if matchesAny(item, filter, filter.Includes) {
+			score += filter.Weight
+		}
+		if matchesAny(item, filter, filter.ActiveExcludes(now)) {
+			score -= filter.Weight
+		}
+	}
+	return score
+}
+
+// matchOptions are the per-filter flags that change how patterns match text.
+type matchOptions struct {
+	caseSensitive bool
+	wholeWord     bool
+	regex         bool // every pattern is a regular expression (mode: regex)
+}
+
+func matchesAny(item types.Item, filter types.Filter, patterns []string) bool {
+	opts := filterMatchOptions(filter)
+	for _, pattern := range patterns {
+		if matchesFieldFilter(item, filter.Field, pattern, opts) {
+			return true
+		}
+	}
 	return false
 }
 
-func matchesFieldFilter(item types.Item, field, pattern string) bool {
+func filterMatchOptions(filter types.Filter) matchOptions {
+	return matchOptions{caseSensitive: filter.CaseSensitive, wholeWord: filter.WholeWord, regex: filter.Mode == "regex"}
+}
+
+func matchesFieldFilter(item types.Item, field, pattern string, opts matchOptions) bool {
 	switch field {
 	case "title":
-		return matchesPattern(item.Title, pattern)
+		return matchesPattern(item.Title, pattern, opts)
 	case "description":
-		return matchesPattern(item.Description, pattern)
+		return matchesPattern(item.Description, pattern, opts)
 	case "content":
-		return matchesPattern(item.Content, pattern)
+		return matchesPattern(item.Content, pattern, opts)
 	case "link":
-		return matchesPattern(item.Link, pattern)
+		return matchesPattern(item.Link, pattern, opts)
+	case "link_domain":
+		return matchesLinkDomain(item.Link, pattern, opts.regex)
+	case "enclosure_type":
+		return matchesEnclosureType(item.EnclosureType, pattern, opts.regex)
+	case "enclosure_size":
+		return matchesEnclosureSize(item.EnclosureLength, pattern)
 	case "authors":
 		for _, author := range item.Authors {
-			if matchesPattern(author, pattern) {
+			if matchesPattern(author, pattern, opts) {
 				return true
 			}
 		}
 		return false
 	case "categories":
 		for _, category := range item.Categories {
-			if matchesPattern(category, pattern) {
+			if matchesPattern(category, pattern, opts) {
 				return true
 			}
 		}
@@ -87,32 +177,189 @@ func matchesFieldFilter(item types.Item, field, pattern string) bool {
 	}
 }
 
-func matchesPattern(value, pattern string) bool {
-	normalizedValue := normalizeUnicode(normalizeWhitespace(strings.ToLower(value)))
+// matchesLinkDomain matches the host of link against a link_domain pattern:
+// "example.com" matches that host only, "*.example.com" (or ".example.com")
+// also matches its subdomains, and /regex/ (or any pattern with regex) is
+// matched against the host. A leading "www." is ignored on both sides.
+func matchesLinkDomain(link, pattern string, regex bool) bool {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return false
+	}
+	host := normalizeDomain(u.Hostname())
+	if host == "" {
+		return false
+	}
+
+	if regex || isRegexPattern(pattern) {
+		return matchesPattern(host, pattern, matchOptions{regex: regex})
+	}
+
+	pattern = strings.TrimSpace(pattern)
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		pattern = "." + suffix
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "."); ok {
+		domain := normalizeDomain(suffix)
+		return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+	}
+	return host == normalizeDomain(pattern)
+}
+
+// matchesEnclosureType matches a MIME type exactly ("audio/mpeg"), by major
+// type ("audio/*") or by /regex/ (any pattern with regex). Parameters such
+// as "; codecs=..." are ignored.
+func matchesEnclosureType(mimeType, pattern string, regex bool) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if mimeType == "" {
+		return false
+	}
+
+	if regex || isRegexPattern(pattern) {
+		return matchesPattern(mimeType, pattern, matchOptions{regex: regex})
+	}
+
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if major, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mimeType, major+"/")
+	}
+	return mimeType == pattern
+}
+
+// matchesEnclosureSize compares the enclosure length with a size condition
+// such as ">1MB". Items without a known length never match, and neither do
+// invalid conditions, which config loading already rejects.
+func matchesEnclosureSize(length int64, pattern string) bool {
+	if length <= 0 {
+		return false
+	}
+	op, size, err := ParseSizeCondition(pattern)
+	if err != nil {
+		return false
+	}
+
+	switch op {
+	case ">":
+		return length > size
+	case ">=":
+		return length >= size
+	case "<":
+		return length < size
+	case "<=":
+		return length <= size
+	default:
+		return length == size
+	}
+}
+
+var sizeUnits = map[string]int64{"": 1, "b": 1, "kb": 1 << 10, "mb": 1 << 20, "gb": 1 << 30}
 
-	if isRegexPattern(pattern) {
-		regexPattern := extractRegexPattern(pattern)
+// ParseSizeCondition parses an enclosure_size pattern: a comparison
+// operator (>, >=, <, <=, = or none for =) followed by a size in bytes or
+// with a KB/MB/GB unit (powers of 1024), e.g. ">1MB" or "<=500KB".
+func ParseSizeCondition(pattern string) (string, int64, error) {
+	s := strings.TrimSpace(pattern)
+
+	op := "="
+	for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(s, candidate); ok {
+			op, s = candidate, strings.TrimSpace(rest)
+			break
+		}
+	}
+
+	number := strings.TrimRightFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(s[len(number):]))]
+	if !ok || number == "" {
+		return "", 0, fmt.Errorf("invalid size condition %q", pattern)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return "", 0, fmt.Errorf("invalid size condition %q", pattern)
+	}
+
+	return op, int64(value * float64(unit)), nil
+}
+
+func matchesPattern(value, pattern string, opts matchOptions) bool {
+	normalize := func(s string) string {
+		if !opts.caseSensitive {
+			s = strings.ToLower(s)
+		}
+		return normalizeUnicode(normalizeWhitespace(s))
+	}
+	normalizedValue := normalize(value)
+
+	if regexPattern, ok := patternRegex(pattern, opts); ok {
 		re, err := getCompiledRegex(regexPattern)
 		if err != nil {
 			log.Printf("Invalid regex pattern %q: %v, falling back to literal match", pattern, err)
-			normalizedPattern := normalizeUnicode(normalizeWhitespace(strings.ToLower(pattern)))
-			return strings.Contains(normalizedValue, normalizedPattern)
+			return containsPattern(normalizedValue, normalize(pattern), opts.wholeWord)
 		}
 		return re.MatchString(normalizedValue)
 	}
 
-	normalizedPattern := normalizeUnicode(normalizeWhitespace(strings.ToLower(pattern)))
-	return strings.Contains(normalizedValue, normalizedPattern)
+	return containsPattern(normalizedValue, normalize(pattern), opts.wholeWord)
+}
+
+// containsPattern reports whether value contains pattern; with wholeWord
+// the match must not continue a word on either side.
+func containsPattern(value, pattern string, wholeWord bool) bool {
+	if !wholeWord {
+		return strings.Contains(value, pattern)
+	}
+	if pattern == "" {
+		return false
+	}
+
+	for offset := 0; ; {
+		i := strings.Index(value[offset:], pattern)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(pattern)
+		before, _ := utf8.DecodeLastRuneInString(value[:start])
+		after, _ := utf8.DecodeRuneInString(value[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(value) || !isWordRune(after)) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(value[start:])
+		offset = start + size
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
 }
 
 func isRegexPattern(pattern string) bool {
 	return len(pattern) >= 2 && pattern[0] == '/' && pattern[len(pattern)-1] == '/'
 }
 
-func extractRegexPattern(pattern string) string {
-	extracted := pattern[1 : len(pattern)-1]
+// patternRegex returns the regular expression a pattern is matched with:
+// the whole pattern in regex mode, else the body of a /regex/ pattern.
+// Plain substring patterns return false.
+func patternRegex(pattern string, opts matchOptions) (string, bool) {
+	switch {
+	case opts.regex:
+		return applyRegexOptions(pattern, opts), true
+	case isRegexPattern(pattern):
+		return extractRegexPattern(pattern, opts), true
+	}
+	return "", false
+}
+
+func extractRegexPattern(pattern string, opts matchOptions) string {
+	return applyRegexOptions(pattern[1:len(pattern)-1], opts)
+}
+
+func applyRegexOptions(extracted string, opts matchOptions) string {
+	if opts.wholeWord {
+		extracted = `(?:^|[^\pL\pN_])(?:` + extracted + `)(?:$|[^\pL\pN_])`
+	}
 
-	if !strings.HasPrefix(extracted, "(?i)") {
+	if !opts.caseSensitive && !strings.HasPrefix(extracted, "(?i)") {
 		extracted = "(?i)" + extracted
 	}
 
@@ -17,7 +17,7 @@ func TestFilterer_ApplyFilters_NoFilters(t *testing.T) {
 		Filters: []types.Filter{}, // No filters
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	if len(result) != 2 {
 		t.Errorf("Expected 2 items, got %d", len(result))
@@ -47,7 +47,7 @@ func TestFilterer_ApplyFilters_TitleIncludeFilter(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	if len(result) != 3 {
 		t.Errorf("Expected 3 items, got %d", len(result))
@@ -85,7 +85,7 @@ func TestFilterer_ApplyFilters_TitleExcludeFilter(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	if len(result) != 3 {
 		t.Errorf("Expected 3 items, got %d", len(result))
@@ -123,7 +123,7 @@ func TestFilterer_ApplyFilters_CombinedIncludeExclude(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	// First item: contains "tech" and "news" (included) and doesn't contain excludes -> pass
 	if result[0].IsFiltered {
@@ -166,7 +166,7 @@ func TestFilterer_ApplyFilters_MultipleFields(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	// First item: title contains "news" and author doesn't contain "spam" -> pass
 	if result[0].IsFiltered {
@@ -199,7 +199,7 @@ func TestFilterer_ApplyFilters_AuthorsField(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	// First item: authors contain "john" and "jane" -> pass
 	if result[0].IsFiltered {
@@ -227,7 +227,7 @@ func TestFilterer_ApplyFilters_CategoriesField(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	// First item: categories contain "technology" and "news" -> pass
 	if result[0].IsFiltered {
@@ -256,7 +256,7 @@ func TestFilterer_ApplyFilters_CaseInsensitive(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	// First item: title contains "NEWS" (case insensitive match with "News") -> pass
 	if result[0].IsFiltered {
@@ -288,7 +288,7 @@ func TestFilterer_ApplyFilters_UnknownField(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	// Item should be filtered because unknown field returns empty string
 	if !result[0].IsFiltered {
@@ -311,7 +311,7 @@ func TestFilterer_ApplyFilters_EmptyValues(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	// First item: empty title doesn't contain "test" -> filtered
 	if !result[0].IsFiltered {
@@ -350,7 +350,7 @@ func TestFilterer_ApplyFilters_PreservesOriginalData(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	if len(result) != 1 {
 		t.Fatalf("Expected 1 item, got %d", len(result))
@@ -421,30 +421,30 @@ func TestMatchesFieldFilter(t *testing.T) {
 	}
 
 	for _, test := range stringTests {
-		result := matchesFieldFilter(item, test.field, test.pattern)
+		result := matchesFieldFilter(item, test.field, test.pattern, matchOptions{})
 		if result != test.expected {
 			t.Errorf("matchesFieldFilter(%s, %s): expected %v, got %v", test.field, test.pattern, test.expected, result)
 		}
 	}
 
 	// Test array fields
-	if !matchesFieldFilter(item, "authors", "author1") {
+	if !matchesFieldFilter(item, "authors", "author1", matchOptions{}) {
 		t.Errorf("Should match first author")
 	}
-	if !matchesFieldFilter(item, "authors", "author2") {
+	if !matchesFieldFilter(item, "authors", "author2", matchOptions{}) {
 		t.Errorf("Should match second author")
 	}
-	if matchesFieldFilter(item, "authors", "nonexistent") {
+	if matchesFieldFilter(item, "authors", "nonexistent", matchOptions{}) {
 		t.Errorf("Should not match nonexistent author")
 	}
 
-	if !matchesFieldFilter(item, "categories", "cat1") {
+	if !matchesFieldFilter(item, "categories", "cat1", matchOptions{}) {
 		t.Errorf("Should match first category")
 	}
-	if !matchesFieldFilter(item, "categories", "cat2") {
+	if !matchesFieldFilter(item, "categories", "cat2", matchOptions{}) {
 		t.Errorf("Should match second category")
 	}
-	if matchesFieldFilter(item, "categories", "nonexistent") {
+	if matchesFieldFilter(item, "categories", "nonexistent", matchOptions{}) {
 		t.Errorf("Should not match nonexistent category")
 	}
 }
@@ -468,7 +468,7 @@ func TestFilterer_ArrayFilterBugFix(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	// Item should NOT be filtered because "C Category" exists as exact match
 	if result[0].IsFiltered {
@@ -483,7 +483,7 @@ func TestFilterer_ArrayFilterBugFix(t *testing.T) {
 		},
 	}
 
-	result2 := Filter(items2, feedConfig.Filters)
+	result2 := Filter(items2, feedConfig.Filters, 0, time.Now())
 
 	// This item should be filtered because "C Category" doesn't exist as exact match
 	if !result2[0].IsFiltered {
@@ -507,7 +507,7 @@ func TestFilterer_ArrayFilterBugFix(t *testing.T) {
 		},
 	}
 
-	result3 := Filter(items3, feedConfig3.Filters)
+	result3 := Filter(items3, feedConfig3.Filters, 0, time.Now())
 
 	// Should NOT be filtered because "jo@example.com" exists as substring in the third author
 	if result3[0].IsFiltered {
@@ -535,7 +535,7 @@ func TestFilterer_ArrayFilterExactMatch(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, feedConfig.Filters)
+	result := Filter(items, feedConfig.Filters, 0, time.Now())
 
 	// Should be filtered because "News Breaking" doesn't exist as exact element
 	if !result[0].IsFiltered {
@@ -552,7 +552,7 @@ func TestFilterer_ArrayFilterExactMatch(t *testing.T) {
 		},
 	}
 
-	result2 := Filter(items, feedConfig2.Filters)
+	result2 := Filter(items, feedConfig2.Filters, 0, time.Now())
 
 	// Should NOT be filtered because "Tech News" exists as exact element
 	if result2[0].IsFiltered {
@@ -576,7 +576,7 @@ func TestFilterer_MatchesPattern(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		result := matchesPattern(test.value, test.pattern)
+		result := matchesPattern(test.value, test.pattern, matchOptions{})
 		if result != test.expected {
 			t.Errorf("matchesPattern('%s', '%s'): expected %v, got %v", test.value, test.pattern, test.expected, result)
 		}
@@ -610,7 +610,7 @@ func TestFilterer_WhitespaceNormalization(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// All items should pass because after normalization they all contain "test with"
 	for i, item := range result {
@@ -635,7 +635,7 @@ func TestFilterer_CyrillicWithNBSP(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// Item should be filtered even though the title has NBSP instead of regular space
 	if !result[0].IsFiltered {
@@ -657,7 +657,7 @@ func TestFilterer_MultipleConsecutiveSpaces(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// Should match after collapsing multiple spaces
 	if result[0].IsFiltered {
@@ -679,7 +679,7 @@ func TestFilterer_LeadingTrailingWhitespace(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// Should match after trimming whitespace
 	if result[0].IsFiltered {
@@ -728,7 +728,7 @@ func TestFilterer_UnicodeNormalization(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// Both items should be filtered despite different Unicode representations
 	for i, item := range result {
@@ -765,7 +765,7 @@ func TestFilterer_UnicodeNormalizationReverse(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// Should be filtered after normalization
 	if !result[0].IsFiltered {
@@ -788,7 +788,7 @@ func TestFilterer_RegexPattern_Basic(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// First two items should pass (start with "tech")
 	if result[0].IsFiltered {
@@ -822,7 +822,7 @@ func TestFilterer_RegexPattern_Exclude(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// First three items should be filtered (match regex)
 	if !result[0].IsFiltered {
@@ -856,7 +856,7 @@ func TestFilterer_RegexPattern_CaseInsensitive(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// First three should pass (case insensitive)
 	for i := 0; i < 3; i++ {
@@ -885,7 +885,7 @@ func TestFilterer_RegexPattern_Categories(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// First item should be filtered (has Angular, Vue, React)
 	if !result[0].IsFiltered {
@@ -920,7 +920,7 @@ func TestFilterer_RegexPattern_MixedWithSubstring(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// First item: has "tech" but also has "weekly" -> filtered
 	if !result[0].IsFiltered {
@@ -957,7 +957,7 @@ func TestFilterer_RegexPattern_Invalid(t *testing.T) {
 		},
 	}
 
-	result := Filter(items, filters)
+	result := Filter(items, filters, 0, time.Now())
 
 	// First item should pass (contains literal "/[invalid/" substring)
 	// Invalid regex falls back to substring match of "/[invalid/"
@@ -987,7 +987,7 @@ func TestFilterer_RegexCache(t *testing.T) {
 
 	// Process items multiple times
 	for i := 0; i < 3; i++ {
-		result := Filter(items, filters)
+		result := Filter(items, filters, 0, time.Now())
 		for j, item := range result {
 			if item.IsFiltered {
 				t.Errorf("Iteration %d, Item %d should not be filtered", i, j)
@@ -1009,7 +1009,7 @@ func TestFilterer_ClearRegexCache(t *testing.T) {
 	}
 
 	// First processing - populates cache
-	result1 := Filter(items, filters)
+	result1 := Filter(items, filters, 0, time.Now())
 	if result1[0].IsFiltered {
 		t.Errorf("Item should not be filtered before cache clear")
 	}
@@ -1018,7 +1018,7 @@ func TestFilterer_ClearRegexCache(t *testing.T) {
 	ClearRegexCache()
 
 	// Second processing - should work the same after cache clear
-	result2 := Filter(items, filters)
+	result2 := Filter(items, filters, 0, time.Now())
 	if result2[0].IsFiltered {
 		t.Errorf("Item should not be filtered after cache clear")
 	}
@@ -1061,9 +1061,388 @@ func TestExtractRegexPattern(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		result := extractRegexPattern(test.input)
+		result := extractRegexPattern(test.input, matchOptions{})
 		if result != test.expected {
 			t.Errorf("extractRegexPattern(%q): expected %q, got %q", test.input, test.expected, result)
 		}
 	}
 }
+
+func TestFilter_Scoring(t *testing.T) {
+	items := []types.Item{
+		{Title: "Go generics deep dive"},
+		{Title: "Sponsored: Go hosting deal"},
+		{Title: "Cooking pasta"},
+	}
+
+	filters := []types.Filter{
+		{Field: "title", Weight: 5, Includes: []string{"go"}},
+		{Field: "title", Weight: 10, Excludes: []string{"sponsored"}},
+	}
+
+	result := Filter(items, filters, 1, time.Now())
+
+	expected := []struct {
+		score    int
+		filtered bool
+	}{
+		{5, false},
+		{-5, true},
+		{0, true},
+	}
+	for i, want := range expected {
+		if result[i].Score != want.score {
+			t.Errorf("item %d: expected score %d, got %d", i, want.score, result[i].Score)
+		}
+		if result[i].IsFiltered != want.filtered {
+			t.Errorf("item %d: expected filtered=%v, got %v", i, want.filtered, result[i].IsFiltered)
+		}
+	}
+}
+
+func TestFilter_ScoringWithHardFilters(t *testing.T) {
+	items := []types.Item{
+		{Title: "Go release notes", Description: "advertisement"},
+		{Title: "Go release notes", Description: "changelog"},
+	}
+
+	filters := []types.Filter{
+		{Field: "description", Excludes: []string{"advertisement"}},
+		{Field: "title", Weight: 2, Includes: []string{"release"}},
+	}
+
+	result := Filter(items, filters, 0, time.Now())
+
+	if !result[0].IsFiltered {
+		t.Error("hard exclude should filter the item regardless of score")
+	}
+	if result[1].IsFiltered || result[1].Score != 2 {
+		t.Errorf("expected second item visible with score 2, got filtered=%v score=%d", result[1].IsFiltered, result[1].Score)
+	}
+}
+
+func TestFilter_TimedExcludes(t *testing.T) {
+	now := time.Date(2024, 7, 26, 12, 0, 0, 0, time.UTC)
+	items := []types.Item{
+		{Title: "Olympics day 3 results"},
+		{Title: "World Cup preview"},
+	}
+
+	filters := []types.Filter{
+		{
+			Field: "title",
+			TimedExcludes: []types.TimedPattern{
+				{Pattern: "Olympics", Until: now.Add(24 * time.Hour)},
+				{Pattern: "World Cup", Until: now.Add(-24 * time.Hour)},
+			},
+		},
+	}
+
+	result := Filter(items, filters, 0, now)
+
+	if !result[0].IsFiltered {
+		t.Error("expected item matching an active timed exclude to be filtered")
+	}
+	if result[1].IsFiltered {
+		t.Error("expected item matching an expired timed exclude to be visible")
+	}
+
+	// Rules expire relative to the given time, not the wall clock.
+	if result := Filter(items, filters, 0, now.Add(48*time.Hour)); result[0].IsFiltered {
+		t.Error("expected the Olympics exclude to have expired two days later")
+	}
+}
+
+func TestNextFilterExpiry(t *testing.T) {
+	now := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	soon := now.Add(48 * time.Hour)
+
+	filters := []types.Filter{
+		{Field: "title", Excludes: []string{"ad"}},
+		{Field: "title", TimedExcludes: []types.TimedPattern{
+			{Pattern: "past", Until: now.Add(-time.Hour)},
+			{Pattern: "later", Until: now.Add(96 * time.Hour)},
+		}},
+		{Field: "description", TimedExcludes: []types.TimedPattern{
+			{Pattern: "soon", Until: soon},
+		}},
+	}
+
+	next := NextFilterExpiry(filters, now)
+	if next == nil || !next.Equal(soon) {
+		t.Errorf("expected next expiry %v, got %v", soon, next)
+	}
+
+	if next := NextFilterExpiry(filters[:1], now); next != nil {
+		t.Errorf("expected no expiry without timed excludes, got %v", next)
+	}
+}
+
+func TestFilter_LinkDomain(t *testing.T) {
+	items := []types.Item{
+		{Title: "exact", Link: "https://example.com/a"},
+		{Title: "www", Link: "https://www.example.com/b"},
+		{Title: "subdomain", Link: "https://blog.example.com/c"},
+		{Title: "lookalike", Link: "https://notexample.com/d"},
+		{Title: "path only", Link: "https://other.org/example.com"},
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []bool
+	}{
+		{"exact host", "example.com", []bool{true, true, false, false, false}},
+		{"suffix", "*.example.com", []bool{true, true, true, false, false}},
+		{"leading dot", ".example.com", []bool{true, true, true, false, false}},
+		{"regex on host", "/^blog\\./", []bool{false, false, true, false, false}},
+		{"case insensitive", "EXAMPLE.COM", []bool{true, true, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters := []types.Filter{{Field: "link_domain", Excludes: []string{tt.pattern}}}
+			for i, item := range Filter(items, filters, 0, time.Now()) {
+				if item.IsFiltered != tt.want[i] {
+					t.Errorf("%s: expected filtered=%v, got %v", item.Title, tt.want[i], item.IsFiltered)
+				}
+			}
+		})
+	}
+}
+
+func TestFilter_Enclosure(t *testing.T) {
+	items := []types.Item{
+		{Title: "episode", EnclosureType: "audio/mpeg", EnclosureLength: 30 << 20},
+		{Title: "teaser", EnclosureType: "audio/mpeg", EnclosureLength: 200 << 10},
+		{Title: "video", EnclosureType: "video/mp4; codecs=avc1", EnclosureLength: 50 << 20},
+		{Title: "unknown size", EnclosureType: "audio/x-m4a"},
+		{Title: "no enclosure"},
+	}
+
+	// Only audio over 1MB
+	filters := []types.Filter{
+		{Field: "enclosure_type", Includes: []string{"audio/*"}},
+		{Field: "enclosure_size", Includes: []string{">1MB"}},
+	}
+	want := []bool{false, true, true, true, true}
+	for i, item := range Filter(items, filters, 0, time.Now()) {
+		if item.IsFiltered != want[i] {
+			t.Errorf("%s: expected filtered=%v, got %v", item.Title, want[i], item.IsFiltered)
+		}
+	}
+
+	// Exclude video by exact type (parameters ignored) and small files
+	filters = []types.Filter{
+		{Field: "enclosure_type", Excludes: []string{"VIDEO/MP4"}},
+		{Field: "enclosure_size", Excludes: []string{"<1MB"}},
+	}
+	want = []bool{false, true, true, false, false}
+	for i, item := range Filter(items, filters, 0, time.Now()) {
+		if item.IsFiltered != want[i] {
+			t.Errorf("%s: expected filtered=%v, got %v", item.Title, want[i], item.IsFiltered)
+		}
+	}
+}
+
+func TestParseSizeCondition(t *testing.T) {
+	tests := []struct {
+		pattern string
+		op      string
+		size    int64
+		wantErr bool
+	}{
+		{">1MB", ">", 1 << 20, false},
+		{">= 500 kb", ">=", 500 << 10, false},
+		{"<1.5GB", "<", 3 << 29, false},
+		{"1048576", "=", 1048576, false},
+		{"<=10B", "<=", 10, false},
+		{">1TB", "", 0, true},
+		{"big", "", 0, true},
+		{">", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		op, size, err := ParseSizeCondition(tt.pattern)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected error", tt.pattern)
+			}
+			continue
+		}
+		if err != nil || op != tt.op || size != tt.size {
+			t.Errorf("%q: got (%q, %d, %v), want (%q, %d)", tt.pattern, op, size, err, tt.op, tt.size)
+		}
+	}
+}
+
+func TestFilter_Groups(t *testing.T) {
+	items := []types.Item{
+		{Title: "Go 1.24 released"},
+		{Title: "Rust in production"},
+		{Title: "Go jobs this week"},
+		{Title: "Python tips"},
+	}
+
+	// (go OR rust) AND NOT jobs
+	filters := []types.Filter{
+		{Any: []types.Filter{
+			{Field: "title", Includes: []string{"go "}},
+			{Field: "title", Includes: []string{"rust"}},
+		}},
+		{Not: &types.Filter{Field: "title", Includes: []string{"jobs"}}},
+	}
+
+	want := []bool{false, false, true, true}
+	for i, item := range Filter(items, filters, 0, time.Now()) {
+		if item.IsFiltered != want[i] {
+			t.Errorf("%q: expected filtered=%v, got %v", item.Title, want[i], item.IsFiltered)
+		}
+	}
+}
+
+func TestFilter_AllGroup(t *testing.T) {
+	items := []types.Item{
+		{Title: "Go release", Categories: []string{"news"}},
+		{Title: "Go release", Categories: []string{"opinion"}},
+		{Title: "Other", Categories: []string{"news"}},
+	}
+
+	// Drop items that are both about Go and opinion pieces
+	filters := []types.Filter{
+		{Not: &types.Filter{All: []types.Filter{
+			{Field: "title", Includes: []string{"go"}},
+			{Field: "categories", Includes: []string{"opinion"}},
+		}}},
+	}
+
+	want := []bool{false, true, false}
+	for i, item := range Filter(items, filters, 0, time.Now()) {
+		if item.IsFiltered != want[i] {
+			t.Errorf("item %d: expected filtered=%v, got %v", i, want[i], item.IsFiltered)
+		}
+	}
+}
+
+func TestNextFilterExpiry_Nested(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := now.Add(48 * time.Hour)
+
+	filters := []types.Filter{
+		{Any: []types.Filter{
+			{Field: "title", TimedExcludes: []types.TimedPattern{{Pattern: "x", Until: until}}},
+		}},
+	}
+
+	next := NextFilterExpiry(filters, now)
+	if next == nil || !next.Equal(until) {
+		t.Errorf("expected nested expiry %v, got %v", until, next)
+	}
+}
+
+func TestFilter_WholeWordAndCaseSensitive(t *testing.T) {
+	items := []types.Item{
+		{Title: "New AI model released"},
+		{Title: "How to maintain a garden"},
+		{Title: "AI: what's next"},
+		{Title: "Said the guide"},
+		{Title: "Go 1.24 is out"},
+		{Title: "Where to go this summer"},
+		{Title: "Как работает ИИ"},
+		{Title: "ИИсследование"},
+	}
+
+	tests := []struct {
+		name   string
+		filter types.Filter
+		want   []bool
+	}{
+		{
+			name:   "substring by default",
+			filter: types.Filter{Field: "title", Excludes: []string{"ai"}},
+			want:   []bool{true, true, true, true, false, false, false, false},
+		},
+		{
+			name:   "whole word",
+			filter: types.Filter{Field: "title", Excludes: []string{"ai"}, WholeWord: true},
+			want:   []bool{true, false, true, false, false, false, false, false},
+		},
+		{
+			name:   "case sensitive whole word",
+			filter: types.Filter{Field: "title", Excludes: []string{"Go"}, WholeWord: true, CaseSensitive: true},
+			want:   []bool{false, false, false, false, true, false, false, false},
+		},
+		{
+			name:   "whole word with unicode letters",
+			filter: types.Filter{Field: "title", Excludes: []string{"ИИ"}, WholeWord: true},
+			want:   []bool{false, false, false, false, false, false, true, false},
+		},
+		{
+			name:   "whole word regex",
+			filter: types.Filter{Field: "title", Excludes: []string{"/ai|go/"}, WholeWord: true},
+			want:   []bool{true, false, true, false, true, true, false, false},
+		},
+		{
+			name:   "case sensitive regex",
+			filter: types.Filter{Field: "title", Excludes: []string{"/^AI/"}, CaseSensitive: true},
+			want:   []bool{false, false, true, false, false, false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, item := range Filter(items, []types.Filter{tt.filter}, 0, time.Now()) {
+				if item.IsFiltered != tt.want[i] {
+					t.Errorf("%q: expected filtered=%v, got %v", item.Title, tt.want[i], item.IsFiltered)
+				}
+			}
+		})
+	}
+}
+
+func TestFilter_RegexMode(t *testing.T) {
+	items := []types.Item{
+		{Title: "[AD] Buy this", Link: "https://ads.example.com/1"},
+		{Title: "Crypto markets today", Link: "https://news.example.com/2"},
+		{Title: "Cryptocurrency explained", Link: "https://news.example.com/3"},
+		{Title: "Cryptography basics", Link: "https://blog.example.org/4"},
+		{Title: "Ads in [AD] brackets", Link: "https://blog.example.org/5"},
+	}
+
+	tests := []struct {
+		name   string
+		filter types.Filter
+		want   []bool
+	}{
+		{
+			name:   "anchored",
+			filter: types.Filter{Field: "title", Excludes: []string{`^\[AD\]`}, Mode: "regex"},
+			want:   []bool{true, false, false, false, false},
+		},
+		{
+			name:   "word boundaries",
+			filter: types.Filter{Field: "title", Excludes: []string{`\bcrypto(currency)?\b`}, Mode: "regex"},
+			want:   []bool{false, true, true, false, false},
+		},
+		{
+			name:   "substring mode keeps the text literal",
+			filter: types.Filter{Field: "title", Excludes: []string{`^\[AD\]`}},
+			want:   []bool{false, false, false, false, false},
+		},
+		{
+			name:   "link domain",
+			filter: types.Filter{Field: "link_domain", Excludes: []string{`^(ads|news)\.`}, Mode: "regex"},
+			want:   []bool{true, true, true, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, item := range Filter(items, []types.Filter{tt.filter}, 0, time.Now()) {
+				if item.IsFiltered != tt.want[i] {
+					t.Errorf("%q: expected filtered=%v, got %v", item.Title, tt.want[i], item.IsFiltered)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package feed
+
+import "fmt"
+
+// Format is an output document format. Every format renders the same
+// Document, so the formats only differ in how they print it.
+type Format string
+
+const (
+	FormatRSS  Format = "rss"
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json" // JSON Feed 1.1
+)
+
+// Formats lists the output formats, RSS first.
+var Formats = []Format{FormatRSS, FormatAtom, FormatJSON}
+
+// ParseFormat returns the format named s; "" is RSS.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatRSS:
+		return FormatRSS, nil
+	case FormatAtom:
+		return FormatAtom, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("unknown format %q (must be rss, atom or json)", s)
+}
+
+// ContentType is the media type documents in the format are served with.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatAtom:
+		return "application/atom+xml; charset=utf-8"
+	case FormatJSON:
+		return "application/feed+json; charset=utf-8"
+	}
+	return "application/xml; charset=utf-8"
+}
+
+// URL returns the address of the feed document in the format, given the
+// address of its RSS document.
+func (f Format) URL(rssURL string) string {
+	if f == FormatRSS {
+		return rssURL
+	}
+	return rssURL + "." + string(f)
+}
+
+func (f Format) write(w feedWriter, doc *Document) {
+	switch f {
+	case FormatAtom:
+		writeAtom(w, doc)
+	case FormatJSON:
+		writeJSONFeed(w, doc)
+	default:
+		writeRSS(w, doc)
+	}
+}
@@ -8,19 +8,33 @@ import (
 	"encoding/xml"
 	"fmt"
 	"html"
+	"io"
 	"net/url"
 	"strconv"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/lysyi3m/rss-comb/app/cfg"
-	"github.com/lysyi3m/rss-comb/app/database"
 	"github.com/lysyi3m/rss-comb/app/types"
 	"github.com/mmcdole/gofeed"
 )
 
+// parserPool reuses gofeed parsers across fetches. A parser is not safe for
+// concurrent use, so each worker takes its own for the duration of a parse.
+var parserPool = sync.Pool{
+	New: func() any {
+		parser := gofeed.NewParser()
+		parser.RSSTranslator = &gofeed.DefaultRSSTranslator{}
+		parser.AtomTranslator = &gofeed.DefaultAtomTranslator{}
+		parser.JSONTranslator = &gofeed.DefaultJSONTranslator{}
+		return parser
+	},
+}
+
 func parseWithGofeed(data []byte) (*gofeed.Feed, error) {
-	parser := gofeed.NewParser()
+	parser := parserPool.Get().(*gofeed.Parser)
+	defer parserPool.Put(parser)
+
 	feed, err := parser.Parse(bytes.NewReader(data))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse feed: %w", err)
@@ -28,13 +42,18 @@ func parseWithGofeed(data []byte) (*gofeed.Feed, error) {
 	return feed, nil
 }
 
-func extractBaseMetadata(feed *gofeed.Feed) *Metadata {
+func extractBaseMetadata(feed *gofeed.Feed, data []byte) *Metadata {
 	metadata := &Metadata{
 		Title:       html.UnescapeString(feed.Title),
 		Link:        feed.Link,
 		Description: html.UnescapeString(feed.Description),
 		Language:    feed.Language,
+		SelfURL:     feed.FeedLink,
 	}
+	// gofeed keeps only the alternate and self links of an Atom feed.
+	hub, self := sourceLinks(data)
+	metadata.HubURL = hub
+	metadata.SelfURL = cmp.Or(self, metadata.SelfURL)
 
 	if feed.Image != nil {
 		metadata.ImageURL = feed.Image.URL
@@ -51,6 +70,48 @@ func extractBaseMetadata(feed *gofeed.Feed) *Metadata {
 	return metadata
 }
 
+// sourceLinks reads the hub and self links a feed declares at its top
+// level: <link rel=...> in Atom, <atom:link rel=...> in an RSS channel.
+// Scanning stops at the first item or entry.
+func sourceLinks(data []byte) (hub, self string) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	// Link URLs are ASCII, so other charsets can be read as they are.
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return hub, self
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "item", "entry":
+			return hub, self
+		case "link":
+			var rel, href string
+			for _, attr := range start.Attr {
+				switch attr.Name.Local {
+				case "rel":
+					rel = strings.ToLower(strings.TrimSpace(attr.Value))
+				case "href":
+					href = strings.TrimSpace(attr.Value)
+				}
+			}
+			switch {
+			case rel == "hub" && hub == "":
+				hub = href
+			case rel == "self" && self == "":
+				self = href
+			}
+		}
+	}
+}
+
 func normalizeBaseItem(item *gofeed.Item) types.Item {
 	normalizedLink := normalizeURL(item.Link)
 
@@ -91,6 +152,17 @@ func normalizeBaseItem(item *gofeed.Item) types.Item {
 	return normalized
 }
 
+var trackingParams = []string{
+	"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content",
+	"fbclid", "fb_action_ids", "fb_action_types", "fb_ref", "fb_source",
+	"gclid", "gclsrc", "dclid",
+	"twclid",
+	"msclkid",
+	"ref", "referrer", "source", "campaign", "medium",
+	"mc_cid", "mc_eid",
+	"_ga", "_gl", "igshid", "hsCtaTracking", "hsa_acc", "hsa_ad", "hsa_cam", "hsa_grp", "hsa_kw", "hsa_mt", "hsa_net", "hsa_src", "hsa_tgt", "hsa_ver",
+}
+
 func normalizeURL(rawURL string) string {
 	if rawURL == "" {
 		return rawURL
@@ -101,15 +173,8 @@ func normalizeURL(rawURL string) string {
 		return rawURL
 	}
 
-	trackingParams := []string{
-		"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content",
-		"fbclid", "fb_action_ids", "fb_action_types", "fb_ref", "fb_source",
-		"gclid", "gclsrc", "dclid",
-		"twclid",
-		"msclkid",
-		"ref", "referrer", "source", "campaign", "medium",
-		"mc_cid", "mc_eid",
-		"_ga", "_gl", "igshid", "hsCtaTracking", "hsa_acc", "hsa_ad", "hsa_cam", "hsa_grp", "hsa_kw", "hsa_mt", "hsa_net", "hsa_src", "hsa_tgt", "hsa_ver",
+	if parsedURL.RawQuery == "" {
+		return parsedURL.String()
 	}
 
 	query := parsedURL.Query()
@@ -124,11 +189,7 @@ func normalizeURL(rawURL string) string {
 }
 
 func generateContentHash(item types.Item) string {
-	content := fmt.Sprintf("%s|%s",
-		item.Title,
-		item.Link)
-
-	hash := sha256.Sum256([]byte(content))
+	hash := sha256.Sum256([]byte(item.Title + "|" + item.Link))
 	return hex.EncodeToString(hash[:])
 }
 
@@ -170,136 +231,13 @@ func formatAuthor(name, email string) string {
 }
 
 
-func writeChannelHeader(buf *bytes.Buffer, feed database.Feed, items []database.Item, cfg *cfg.Cfg) {
-	writeElement(buf, "title", feed.DisplayTitle(), 4)
-	writeElement(buf, "link", feed.Link, 4)
-	description := feed.Description
-	if description == "" {
-		description = fmt.Sprintf("Processed feed from %s", feed.FeedURL)
-	}
-	writeElement(buf, "description", description, 4)
-
-	var selfLink string
-	if cfg.BaseUrl != "" {
-		selfLink = fmt.Sprintf("%s/feeds/%s", cfg.BaseUrl, feed.Name)
-	} else {
-		selfLink = fmt.Sprintf("http://localhost:%s/feeds/%s", cfg.Port, feed.Name)
-	}
-	buf.WriteString(fmt.Sprintf("    <atom:link href=\"%s\" rel=\"self\" type=\"application/rss+xml\" />\n",
-		html.EscapeString(selfLink)))
-
-	if feed.FeedPublishedAt != nil {
-		writeElement(buf, "pubDate", feed.FeedPublishedAt.In(cfg.Location).Format(time.RFC1123Z), 4)
-	}
-
-	lastBuildDate := time.Now().In(cfg.Location)
-	if len(items) > 0 {
-		lastBuildDate = cmp.Or(items[0].PublishedAt, items[0].CreatedAt, lastBuildDate).In(cfg.Location)
-	}
-
-	writeElement(buf, "lastBuildDate", lastBuildDate.Format(time.RFC1123Z), 4)
-	writeElement(buf, "generator", fmt.Sprintf("RSS-Comb/%s", cfg.Version), 4)
-	if feed.Language != "" {
-		writeElement(buf, "language", feed.Language, 4)
-	}
-
-	if feed.ImageURL != "" {
-		buf.WriteString("    <image>\n")
-		writeElement(buf, "url", feed.ImageURL, 6)
-		writeElement(buf, "title", feed.DisplayTitle(), 6)
-		writeElement(buf, "link", feed.Link, 6)
-		buf.WriteString("    </image>\n")
-	}
-}
-
-func writeBaseItem(buf *bytes.Buffer, item database.Item, cfg *cfg.Cfg) {
-	buf.WriteString("    <item>\n")
-
-	if item.GUID != "" {
-		buf.WriteString(fmt.Sprintf("      <guid isPermaLink=\"%t\">", strings.HasPrefix(item.GUID, "http://") || strings.HasPrefix(item.GUID, "https://")))
-		xml.EscapeText(buf, []byte(item.GUID))
-		buf.WriteString("</guid>\n")
-	}
-
-	if item.Title != "" {
-		writeElement(buf, "title", item.Title, 6)
-	}
-
-	if item.Link != "" {
-		writeElement(buf, "link", item.Link, 6)
-	}
-
-	writeElement(buf, "description", cmp.Or(item.Description, "No description available"), 6)
-
-	if item.Content != "" && item.Content != item.Description {
-		buf.WriteString("      <content:encoded><![CDATA[")
-		buf.WriteString(item.Content)
-		buf.WriteString("]]></content:encoded>\n")
-	}
-
-	writeElement(buf, "pubDate", item.PublishedAt.In(cfg.Location).Format(time.RFC1123Z), 6)
-
-	if len(item.Authors) > 0 && item.Authors[0] != "" {
-		writeElement(buf, "author", item.Authors[0], 6)
-	}
-
-	for _, category := range item.Categories {
-		if category != "" {
-			writeElement(buf, "category", category, 6)
-		}
-	}
-}
-
-func writeITunesFeedElements(buf *bytes.Buffer, feed database.Feed) {
-	if feed.ITunesAuthor != "" {
-		writeElement(buf, "itunes:author", feed.ITunesAuthor, 4)
-	}
-
-	if feed.ITunesImage != "" {
-		buf.WriteString(fmt.Sprintf("    <itunes:image href=\"%s\" />\n",
-			html.EscapeString(feed.ITunesImage)))
-	}
-
-	if feed.ITunesExplicit != "" {
-		writeElement(buf, "itunes:explicit", feed.ITunesExplicit, 4)
-	}
-
-	if feed.ITunesOwnerName != "" || feed.ITunesOwnerEmail != "" {
-		buf.WriteString("    <itunes:owner>\n")
-		if feed.ITunesOwnerName != "" {
-			writeElement(buf, "itunes:name", feed.ITunesOwnerName, 6)
-		}
-		if feed.ITunesOwnerEmail != "" {
-			writeElement(buf, "itunes:email", feed.ITunesOwnerEmail, 6)
-		}
-		buf.WriteString("    </itunes:owner>\n")
-	}
-}
-
-func writeITunesItemElements(buf *bytes.Buffer, item database.Item) {
-	if item.ITunesDuration > 0 {
-		writeElement(buf, "itunes:duration", formatDuration(item.ITunesDuration), 6)
-	}
-
-	if item.ITunesEpisode > 0 {
-		writeElement(buf, "itunes:episode", fmt.Sprintf("%d", item.ITunesEpisode), 6)
-	}
-
-	if item.ITunesSeason > 0 {
-		writeElement(buf, "itunes:season", fmt.Sprintf("%d", item.ITunesSeason), 6)
-	}
-
-	if item.ITunesEpisodeType != "" {
-		writeElement(buf, "itunes:episodeType", item.ITunesEpisodeType, 6)
-	}
-
-	if item.ITunesImage != "" {
-		buf.WriteString(fmt.Sprintf("      <itunes:image href=\"%s\" />\n",
-			html.EscapeString(item.ITunesImage)))
-	}
+// serviceURL is the public base URL of this service, falling back to
+// localhost when BASE_URL isn't set.
+func serviceURL(cfg *cfg.Cfg) string {
+	return cmp.Or(cfg.BaseUrl, fmt.Sprintf("http://localhost:%s", cfg.Port))
 }
 
-func writeElement(buf *bytes.Buffer, tag, content string, indent int) {
+func writeElement(buf feedWriter, tag, content string, indent int) {
 	if content == "" {
 		return
 	}
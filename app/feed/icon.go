@@ -0,0 +1,101 @@
+package feed
+
+import (
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// FindIcon returns the absolute URL of the best icon a page links to, or ""
+// if it links none. Larger icons win; apple-touch-icon without sizes counts
+// as 180px (its usual size) and a plain favicon without sizes as 16px.
+func FindIcon(page []byte, pageURL string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	var best string
+	bestSize := 0
+
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return best
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			if tag == "body" {
+				return best
+			}
+			if !hasAttr || (tag != "link" && tag != "base") {
+				continue
+			}
+
+			attrs := make(map[string]string)
+			for {
+				key, val, more := z.TagAttr()
+				attrs[string(key)] = string(val)
+				if !more {
+					break
+				}
+			}
+
+			if tag == "base" {
+				if href, err := base.Parse(attrs["href"]); err == nil && attrs["href"] != "" {
+					base = href
+				}
+				continue
+			}
+
+			size := iconSize(attrs["rel"], attrs["sizes"])
+			if size <= bestSize || attrs["href"] == "" {
+				continue
+			}
+			href, err := base.Parse(strings.TrimSpace(attrs["href"]))
+			if err != nil || (href.Scheme != "http" && href.Scheme != "https") {
+				continue
+			}
+			best, bestSize = href.String(), size
+		}
+	}
+}
+
+// iconSize ranks a <link> as an icon by its largest declared size; 0 means
+// it isn't an icon.
+func iconSize(rel, sizes string) int {
+	var touch, icon bool
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		switch r {
+		case "apple-touch-icon", "apple-touch-icon-precomposed":
+			touch = true
+		case "icon":
+			icon = true
+		}
+	}
+	if !touch && !icon {
+		return 0
+	}
+
+	size := 0
+	for _, s := range strings.Fields(strings.ToLower(sizes)) {
+		if s == "any" {
+			return 1024 // Scalable
+		}
+		width, _, _ := strings.Cut(s, "x")
+		if n, err := strconv.Atoi(width); err == nil && n > size {
+			size = n
+		}
+	}
+	if size > 0 {
+		return size
+	}
+	if touch {
+		return 180
+	}
+	return 16
+}
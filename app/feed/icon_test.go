@@ -0,0 +1,50 @@
+package feed
+
+import "testing"
+
+func TestFindIcon(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{
+			name: "relative favicon",
+			page: `<html><head><link rel="icon" href="/static/favicon.png"></head></html>`,
+			want: "https://example.com/static/favicon.png",
+		},
+		{
+			name: "touch icon beats favicon",
+			page: `<head><link rel="shortcut icon" href="/favicon.ico"><link rel="apple-touch-icon" href="/touch.png"></head>`,
+			want: "https://example.com/touch.png",
+		},
+		{
+			name: "largest declared size",
+			page: `<head><link rel="icon" sizes="32x32" href="/32.png"><link rel="icon" sizes="192x192" href="/192.png"><link rel="apple-touch-icon" href="/touch.png"></head>`,
+			want: "https://example.com/192.png",
+		},
+		{
+			name: "base href",
+			page: `<head><base href="https://cdn.example.net/assets/"><link rel="icon" href="icon.svg" sizes="any"></head>`,
+			want: "https://cdn.example.net/assets/icon.svg",
+		},
+		{
+			name: "links in body ignored",
+			page: `<head><title>x</title></head><body><link rel="icon" href="/late.png"></body>`,
+			want: "",
+		},
+		{
+			name: "stylesheet is not an icon",
+			page: `<head><link rel="stylesheet" href="/style.css"></head>`,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindIcon([]byte(tt.page), "https://example.com/blog/"); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package feed
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// icsType turns an iCalendar (RFC 5545) document into one item per event:
+// the summary as title and the start as published date, so calendars can
+// be filtered and combined like any other feed. Recurring events are not
+// expanded; they appear once, at their first start.
+type icsType struct{}
+
+func (icsType) Parse(data []byte, _ time.Time) (*Metadata, []types.Item, error) {
+	lines := unfoldICS(data)
+	if len(lines) == 0 || !strings.EqualFold(lines[0].value, "VCALENDAR") || !strings.EqualFold(lines[0].name, "BEGIN") {
+		return nil, nil, fmt.Errorf("not an iCalendar document")
+	}
+
+	metadata := &Metadata{}
+	defaultLocation := time.UTC
+	var items []types.Item
+	var event *icsEvent
+	var stack []string
+
+	for _, line := range lines {
+		switch line.name {
+		case "BEGIN":
+			stack = append(stack, strings.ToUpper(line.value))
+			if len(stack) == 2 && stack[1] == "VEVENT" {
+				event = &icsEvent{}
+			}
+			continue
+		case "END":
+			if len(stack) == 2 && event != nil {
+				if item, ok := event.item(defaultLocation); ok {
+					items = append(items, item)
+				}
+				event = nil
+			}
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		switch {
+		case len(stack) == 1:
+			switch line.name {
+			case "X-WR-CALNAME":
+				metadata.Title = unescapeICSText(line.value)
+			case "X-WR-CALDESC":
+				metadata.Description = unescapeICSText(line.value)
+			case "X-WR-TIMEZONE":
+				if loc, err := time.LoadLocation(line.value); err == nil {
+					defaultLocation = loc
+				}
+			}
+		case len(stack) == 2 && event != nil:
+			// Properties of nested components such as VALARM are skipped by
+			// only reading at the event's own depth.
+			event.lines = append(event.lines, line)
+		}
+	}
+
+	return metadata, items, nil
+}
+
+func (t icsType) Build(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
+	return build(t, FormatRSS, feed, items, cfg)
+}
+
+func (t icsType) Write(w io.Writer, feed database.Feed, items []database.Item, cfg *cfg.Cfg) error {
+	return stream(t, FormatRSS, w, feed, items, cfg)
+}
+
+func (icsType) itunes() bool { return false }
+
+func (icsType) enclosure(database.Item, *cfg.Cfg) *Enclosure { return nil }
+
+// icsLine is an unfolded content line: NAME;PARAM=value:VALUE.
+type icsLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// unfoldICS joins continuation lines (starting with a space or tab) and
+// splits each line into name, parameters and value.
+func unfoldICS(data []byte) []icsLine {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var unfolded []string
+	for _, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t")) && len(unfolded) > 0 {
+			unfolded[len(unfolded)-1] += raw[1:]
+			continue
+		}
+		if strings.TrimSpace(raw) != "" {
+			unfolded = append(unfolded, raw)
+		}
+	}
+
+	lines := make([]icsLine, 0, len(unfolded))
+	for _, raw := range unfolded {
+		// The value starts at the first colon outside a quoted parameter.
+		quoted, colon := false, -1
+		for i, c := range raw {
+			if c == '"' {
+				quoted = !quoted
+			} else if c == ':' && !quoted {
+				colon = i
+				break
+			}
+		}
+		if colon < 0 {
+			continue
+		}
+
+		parts := strings.Split(raw[:colon], ";")
+		line := icsLine{name: strings.ToUpper(parts[0]), value: raw[colon+1:]}
+		for _, param := range parts[1:] {
+			if key, value, ok := strings.Cut(param, "="); ok {
+				if line.params == nil {
+					line.params = make(map[string]string)
+				}
+				line.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+type icsEvent struct {
+	lines []icsLine
+}
+
+func (e *icsEvent) get(name string) (icsLine, bool) {
+	for _, line := range e.lines {
+		if line.name == name {
+			return line, true
+		}
+	}
+	return icsLine{}, false
+}
+
+func (e *icsEvent) text(name string) string {
+	line, _ := e.get(name)
+	return strings.TrimSpace(unescapeICSText(line.value))
+}
+
+// item converts the event; events without a parseable start are skipped.
+func (e *icsEvent) item(defaultLocation *time.Location) (types.Item, bool) {
+	startLine, ok := e.get("DTSTART")
+	if !ok {
+		return types.Item{}, false
+	}
+	start, dateOnly, err := parseICSTime(startLine, defaultLocation)
+	if err != nil {
+		return types.Item{}, false
+	}
+
+	item := types.Item{
+		Title:       cmp.Or(e.text("SUMMARY"), "(untitled event)"),
+		Link:        e.text("URL"),
+		PublishedAt: start,
+	}
+	if strings.EqualFold(e.text("STATUS"), "CANCELLED") {
+		item.Title = "Cancelled: " + item.Title
+	}
+
+	if organizer, ok := e.get("ORGANIZER"); ok {
+		email := strings.TrimPrefix(strings.TrimPrefix(organizer.value, "mailto:"), "MAILTO:")
+		if author := formatAuthor(organizer.params["CN"], email); author != "" {
+			item.Authors = []string{author}
+		}
+	}
+	if categories, ok := e.get("CATEGORIES"); ok {
+		for _, category := range splitICSList(categories.value) {
+			if category = strings.TrimSpace(unescapeICSText(category)); category != "" {
+				item.Categories = append(item.Categories, category)
+			}
+		}
+	}
+	if modified, ok := e.get("LAST-MODIFIED"); ok {
+		if t, _, err := parseICSTime(modified, time.UTC); err == nil {
+			item.UpdatedAt = &t
+		}
+	}
+
+	// When and where come first, as readers show the description rather
+	// than the published date prominently.
+	var b strings.Builder
+	when := start.Format("Mon, 2 Jan 2006 15:04 MST")
+	if dateOnly {
+		when = start.Format("Mon, 2 Jan 2006")
+	}
+	if endLine, ok := e.get("DTEND"); ok {
+		if end, _, err := parseICSTime(endLine, start.Location()); err == nil && !dateOnly {
+			if end.Format(time.DateOnly) == start.Format(time.DateOnly) {
+				when += " – " + end.Format("15:04 MST")
+			} else {
+				when += " – " + end.Format("Mon, 2 Jan 2006 15:04 MST")
+			}
+		}
+	}
+	fmt.Fprintf(&b, "<p><strong>When:</strong> %s", html.EscapeString(when))
+	if location := e.text("LOCATION"); location != "" {
+		fmt.Fprintf(&b, "<br><strong>Where:</strong> %s", html.EscapeString(location))
+	}
+	b.WriteString("</p>")
+	if description := e.text("DESCRIPTION"); description != "" {
+		b.WriteString(textHTML(description))
+	}
+	item.Description = b.String()
+
+	uid := e.text("UID")
+	if recurrence, ok := e.get("RECURRENCE-ID"); ok {
+		uid += "#" + strings.TrimSpace(recurrence.value)
+	}
+	item.GUID = uid
+	if item.GUID == "" {
+		item.GUID = "ics:" + icsHash(item.Title, startLine.value)[:32]
+	}
+	// A changed time, place or text yields a new hash, so the stored event
+	// is updated through its GUID.
+	item.ContentHash = icsHash(item.GUID, startLine.value, item.Title, item.Link, item.Description)
+
+	return item, true
+}
+
+// unescapeICSText undoes the TEXT escapes: \n, \, \; and \\.
+func unescapeICSText(value string) string {
+	var b strings.Builder
+	escaped := false
+	for _, c := range value {
+		switch {
+		case escaped:
+			if c == 'n' || c == 'N' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteRune(c)
+			}
+			escaped = false
+		case c == '\\':
+			escaped = true
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// splitICSList splits a comma-separated TEXT list, keeping escaped commas.
+func splitICSList(value string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// parseICSTime reads a DATE-TIME (UTC with a Z suffix, local to TZID, or
+// floating in the calendar's time zone) or a DATE value. dateOnly reports
+// the latter.
+func parseICSTime(line icsLine, defaultLocation *time.Location) (t time.Time, dateOnly bool, err error) {
+	value := strings.TrimSpace(line.value)
+	if line.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err = time.ParseInLocation("20060102", value, defaultLocation)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	loc := defaultLocation
+	if tzid := line.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = l
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+func icsHash(parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,87 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"X-WR-CALNAME:Meetups\r\n" +
+	"X-WR-TIMEZONE:Europe/Berlin\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:go-meetup-1@example.com\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20240905T190000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20240905T210000\r\n" +
+	"SUMMARY:Go meetup\\, September\r\n" +
+	"LOCATION:Room 1\\; Main St.\r\n" +
+	"DESCRIPTION:Talks about generics.\\n\\nPizza <included>.\r\n" +
+	"  Bring a friend.\r\n" +
+	"CATEGORIES:go,talks\\,misc\r\n" +
+	"ORGANIZER;CN=\"Doe, Jane\":mailto:jane@example.com\r\n" +
+	"URL:https://example.com/events/1\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"DESCRIPTION:Reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20241003\r\n" +
+	"SUMMARY:Holiday\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:broken@example.com\r\n" +
+	"SUMMARY:No start\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestICSParse(t *testing.T) {
+	metadata, items, err := ForType("ics").Parse([]byte(testCalendar), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if metadata.Title != "Meetups" {
+		t.Errorf("unexpected title %q", metadata.Title)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items (the event without a start skipped), got %d", len(items))
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	meetup := items[0]
+	if meetup.Title != "Go meetup, September" || meetup.GUID != "go-meetup-1@example.com" || meetup.Link != "https://example.com/events/1" {
+		t.Errorf("unexpected item %q / %q / %q", meetup.Title, meetup.GUID, meetup.Link)
+	}
+	if !meetup.PublishedAt.Equal(time.Date(2024, 9, 5, 19, 0, 0, 0, berlin)) {
+		t.Errorf("expected the start as published date, got %v", meetup.PublishedAt)
+	}
+	for _, want := range []string{"Thu, 5 Sep 2024 19:00 CEST – 21:00 CEST", "Room 1; Main St.", "<p>Pizza &lt;included&gt;. Bring a friend.</p>"} {
+		if !strings.Contains(meetup.Description, want) {
+			t.Errorf("expected %q in description %q", want, meetup.Description)
+		}
+	}
+	if strings.Contains(meetup.Description, "Reminder") {
+		t.Error("expected alarm properties to be ignored")
+	}
+	if len(meetup.Categories) != 2 || meetup.Categories[1] != "talks,misc" {
+		t.Errorf("unexpected categories %v", meetup.Categories)
+	}
+	if len(meetup.Authors) != 1 || meetup.Authors[0] != "jane@example.com (Doe, Jane)" {
+		t.Errorf("unexpected authors %v", meetup.Authors)
+	}
+
+	holiday := items[1]
+	if holiday.Title != "Cancelled: Holiday" || !holiday.PublishedAt.Equal(time.Date(2024, 10, 3, 0, 0, 0, 0, berlin)) {
+		t.Errorf("unexpected all-day event %q at %v", holiday.Title, holiday.PublishedAt)
+	}
+	if holiday.ContentHash == meetup.ContentHash {
+		t.Error("expected distinct content hashes")
+	}
+
+	if _, _, err := ForType("ics").Parse([]byte("<rss></rss>"), time.Now()); err == nil {
+		t.Error("expected an error for a document that isn't a calendar")
+	}
+}
@@ -0,0 +1,141 @@
+package feed
+
+import (
+	"cmp"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// jsonFeedVersion identifies the JSON Feed version documents follow.
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type jsonFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url,omitempty"`
+	FeedURL     string           `json:"feed_url"`
+	Description string           `json:"description,omitempty"`
+	Icon        string           `json:"icon,omitempty"`
+	Authors     []jsonFeedAuthor `json:"authors,omitempty"`
+	Language    string           `json:"language,omitempty"`
+	Provenance  *jsonProvenance  `json:"_rss_comb,omitempty"`
+	Items       []jsonFeedItem   `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	Title         string               `json:"title,omitempty"`
+	ContentHTML   string               `json:"content_html,omitempty"`
+	Summary       string               `json:"summary,omitempty"`
+	Image         string               `json:"image,omitempty"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified,omitempty"`
+	Authors       []jsonFeedAuthor     `json:"authors,omitempty"`
+	Tags          []string             `json:"tags,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+type jsonFeedAttachment struct {
+	URL               string `json:"url"`
+	MimeType          string `json:"mime_type"`
+	SizeInBytes       int64  `json:"size_in_bytes,omitempty"`
+	DurationInSeconds int    `json:"duration_in_seconds,omitempty"`
+}
+
+// jsonProvenance is the provenance block as a JSON Feed extension object.
+type jsonProvenance struct {
+	GeneratedAt string `json:"generated_at"`
+	FetchedAt   string `json:"fetched_at,omitempty"`
+	SourceURL   string `json:"source_url"`
+	SourceTitle string `json:"source_title,omitempty"`
+	Version     string `json:"version"`
+}
+
+// writeJSONFeed renders a document as JSON Feed 1.1. Items carry the
+// stored HTML as content_html and, when there is separate content, the
+// description as a plain-text summary; date_modified is only set when an
+// item changed upstream after it was published. Like Atom, JSON Feed has no
+// counterpart for ttl, skip hours and days or channel elements.
+func writeJSONFeed(w feedWriter, doc *Document) {
+	out := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       doc.Title,
+		HomePageURL: doc.Link,
+		FeedURL:     FormatJSON.URL(doc.SelfURL),
+		Description: doc.Description,
+		Icon:        doc.ImageURL,
+		Language:    doc.Language,
+		Items:       make([]jsonFeedItem, 0, len(doc.Items)),
+	}
+	if doc.ITunes != nil && doc.ITunes.Author != "" {
+		out.Authors = jsonFeedAuthors([]string{doc.ITunes.Author})
+	}
+	if p := doc.Provenance; p != nil {
+		out.Provenance = &jsonProvenance{
+			GeneratedAt: doc.GeneratedAt.Format(time.RFC3339),
+			SourceURL:   p.SourceURL,
+			SourceTitle: p.SourceTitle,
+			Version:     p.Version,
+		}
+		if p.FetchedAt != nil {
+			out.Provenance.FetchedAt = p.FetchedAt.Format(time.RFC3339)
+		}
+	}
+
+	for _, item := range doc.Items {
+		entry := jsonFeedItem{
+			ID:            cmp.Or(item.GUID, item.Link, item.ID),
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   cmp.Or(item.Content, item.Description),
+			DatePublished: item.PublishedAt.Format(time.RFC3339),
+			Authors:       jsonFeedAuthors(item.Authors),
+			Tags:          item.Categories,
+		}
+		if item.Content != "" {
+			entry.Summary = strings.Join(plainText(item.Description), " ")
+		}
+		if item.UpdatedAt.After(item.PublishedAt) {
+			entry.DateModified = item.UpdatedAt.Format(time.RFC3339)
+		}
+		if enc := item.Enclosure; enc != nil {
+			attachment := jsonFeedAttachment{URL: enc.URL, MimeType: cmp.Or(enc.Type, "application/octet-stream"), SizeInBytes: enc.Length}
+			if item.ITunes != nil {
+				attachment.DurationInSeconds = item.ITunes.Duration
+				entry.Image = item.ITunes.Image
+			}
+			entry.Attachments = []jsonFeedAttachment{attachment}
+		}
+		out.Items = append(out.Items, entry)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(out)
+}
+
+// jsonFeedAuthors turns RSS-style authors into JSON Feed authors, with the
+// email address, if any, as a mailto: URL.
+func jsonFeedAuthors(authors []string) []jsonFeedAuthor {
+	var out []jsonFeedAuthor
+	for _, author := range authors {
+		name, email := splitAuthor(author)
+		if name == "" {
+			continue
+		}
+		a := jsonFeedAuthor{Name: name}
+		if email != "" {
+			a.URL = "mailto:" + email
+		}
+		out = append(out, a)
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package feed
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestBuildFormat_JSON(t *testing.T) {
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC, Version: "test"}
+	published := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	changed := published.Add(3 * time.Hour)
+	items := []database.Item{
+		{ID: "a", ChangedAt: &changed, Item: types.Item{GUID: "guid-a", Title: "A & B", Link: "https://example.com/a",
+			Description: "<p>Intro &amp; more</p>", Content: "<p>Full text</p>", PublishedAt: published,
+			Authors: []string{"jane@example.com (Jane Doe)"}, Categories: []string{"go"},
+			EnclosureURL: "https://example.com/a.mp3", EnclosureType: "audio/mpeg", EnclosureLength: 5, ITunesDuration: 90}},
+		{ID: "b", Item: types.Item{Title: "B", Link: "https://example.com/b", Description: "<p>Plain</p>", PublishedAt: published}},
+	}
+	f := database.Feed{Name: "test", Title: "Test", Link: "https://example.com", FeedURL: "https://example.com/feed.xml", FeedType: "podcast"}
+
+	out, err := BuildFormat(ForType(f.FeedType), FormatJSON, f, items, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var doc jsonFeed
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	if doc.Version != jsonFeedVersion || doc.FeedURL != "https://feeds.example.com/feeds/test.json" || doc.HomePageURL != "https://example.com" {
+		t.Errorf("unexpected feed %+v", doc)
+	}
+	if len(doc.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(doc.Items))
+	}
+
+	a := doc.Items[0]
+	if a.ID != "guid-a" || a.ContentHTML != "<p>Full text</p>" || a.Summary != "Intro & more" {
+		t.Errorf("unexpected item %+v", a)
+	}
+	if a.DatePublished != "2024-01-01T09:00:00Z" || a.DateModified != "2024-01-01T12:00:00Z" {
+		t.Errorf("unexpected dates %q and %q", a.DatePublished, a.DateModified)
+	}
+	if len(a.Authors) != 1 || a.Authors[0].Name != "Jane Doe" || a.Authors[0].URL != "mailto:jane@example.com" {
+		t.Errorf("unexpected authors %+v", a.Authors)
+	}
+	if len(a.Attachments) != 1 || a.Attachments[0].MimeType != "audio/mpeg" || a.Attachments[0].DurationInSeconds != 90 {
+		t.Errorf("unexpected attachments %+v", a.Attachments)
+	}
+
+	b := doc.Items[1]
+	if b.ID != "https://example.com/b" || b.ContentHTML != "<p>Plain</p>" || b.Summary != "" || b.DateModified != "" {
+		t.Errorf("unexpected item %+v", b)
+	}
+
+	// Readers parse it like any other source.
+	_, parsed, err := ForType("").Parse([]byte(out), time.Now())
+	if err != nil || len(parsed) != 2 || parsed[0].Title != "A & B" {
+		t.Errorf("failed to parse the output back: %v %+v", err, parsed)
+	}
+}
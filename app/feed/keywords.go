@@ -0,0 +1,113 @@
+package feed
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// TermCount is how many items a keyword or category appeared in.
+type TermCount struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+// KeywordStats summarizes what a set of items is about: the most frequent
+// title words and categories, each counted once per item.
+type KeywordStats struct {
+	Items      int         `json:"items"`
+	Keywords   []TermCount `json:"keywords"`
+	Categories []TermCount `json:"categories"`
+}
+
+// stopWords are left out of keyword counts; they are frequent in every feed
+// and say nothing about it.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "that": true,
+	"this": true, "are": true, "was": true, "were": true, "has": true, "have": true,
+	"had": true, "not": true, "but": true, "its": true, "into": true, "about": true,
+	"how": true, "why": true, "what": true, "when": true, "who": true, "which": true,
+	"your": true, "you": true, "our": true, "their": true, "they": true, "his": true,
+	"her": true, "will": true, "can": true, "all": true, "new": true, "more": true,
+	"over": true, "after": true, "than": true, "out": true, "now": true, "just": true,
+	"been": true, "also": true, "one": true, "two": true, "get": true, "via": true,
+}
+
+// Keywords counts title words and categories of items, returning the top
+// of each. Words are lowercased; numbers, stop words and words shorter
+// than three letters are skipped.
+func Keywords(items []types.Item, top int) KeywordStats {
+	keywords := make(map[string]int)
+	categories := make(map[string]int)
+	// Categories are compared case-insensitively and shown as first seen.
+	spelling := make(map[string]string)
+
+	for _, item := range items {
+		seen := make(map[string]bool)
+		for _, word := range titleWords(item.Title) {
+			if !seen[word] {
+				seen[word] = true
+				keywords[word]++
+			}
+		}
+
+		seen = make(map[string]bool)
+		for _, category := range item.Categories {
+			category = strings.TrimSpace(category)
+			key := strings.ToLower(category)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			categories[key]++
+			if _, ok := spelling[key]; !ok {
+				spelling[key] = category
+			}
+		}
+	}
+
+	return KeywordStats{
+		Items:      len(items),
+		Keywords:   topTerms(keywords, nil, top),
+		Categories: topTerms(categories, spelling, top),
+	}
+}
+
+func titleWords(title string) []string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\''
+	})
+
+	result := words[:0]
+	for _, word := range words {
+		word = strings.Trim(word, "-'")
+		if utf8.RuneCountInString(word) < 3 || stopWords[word] || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+		result = append(result, word)
+	}
+	return result
+}
+
+// topTerms returns the top most counted terms, most frequent first and
+// alphabetically among equal counts.
+func topTerms(counts map[string]int, spelling map[string]string, top int) []TermCount {
+	terms := make([]TermCount, 0, len(counts))
+	for term, count := range counts {
+		if display, ok := spelling[term]; ok {
+			term = display
+		}
+		terms = append(terms, TermCount{Term: term, Count: count})
+	}
+	slices.SortFunc(terms, func(a, b TermCount) int {
+		return cmp.Or(b.Count-a.Count, strings.Compare(a.Term, b.Term))
+	})
+	if len(terms) > top {
+		terms = terms[:top]
+	}
+	return terms
+}
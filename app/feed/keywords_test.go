@@ -0,0 +1,38 @@
+package feed
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestKeywords(t *testing.T) {
+	items := []types.Item{
+		{Title: "Rust 2.0 released: what's new in Rust", Categories: []string{"Rust", "Releases"}},
+		{Title: "Why the Go team loves Rust", Categories: []string{"rust"}},
+		{Title: "Go 1.24 released", Categories: []string{"Go"}},
+	}
+
+	stats := Keywords(items, 3)
+
+	if stats.Items != 3 {
+		t.Errorf("expected 3 items, got %d", stats.Items)
+	}
+	want := []TermCount{{"released", 2}, {"rust", 2}, {"loves", 1}}
+	if !reflect.DeepEqual(stats.Keywords, want) {
+		t.Errorf("expected keywords %v, got %v", want, stats.Keywords)
+	}
+	wantCategories := []TermCount{{"Rust", 2}, {"Go", 1}, {"Releases", 1}}
+	if !reflect.DeepEqual(stats.Categories, wantCategories) {
+		t.Errorf("expected categories %v, got %v", wantCategories, stats.Categories)
+	}
+}
+
+func TestTitleWords(t *testing.T) {
+	got := titleWords("The State of AI in 2025 — year-end review, part 3")
+	want := []string{"state", "year-end", "review", "part"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,31 @@
+package feed
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// NormalizeLanguage returns tag as a well-formed BCP 47 language tag
+// ("en_US" -> "en-US", "EN-us" -> "en-US"), or "" if it can't be parsed.
+func NormalizeLanguage(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return ""
+	}
+	parsed, err := language.Parse(tag)
+	if err != nil || parsed == language.Und {
+		return ""
+	}
+	return parsed.String()
+}
+
+// outputLanguage is the <language> written to the channel: the configured
+// language if set, otherwise the normalized source language. Source values
+// that aren't valid tags are left out, since strict validators reject them.
+func outputLanguage(sourceLanguage string, override string) string {
+	if override != "" {
+		return NormalizeLanguage(override)
+	}
+	return NormalizeLanguage(sourceLanguage)
+}
@@ -0,0 +1,64 @@
+package feed
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := map[string]string{
+		"en_US":      "en-US",
+		"EN-us":      "en-US",
+		" ru ":       "ru",
+		"zh_hant_tw": "zh-Hant-TW",
+		"english":    "",
+		"x":          "",
+		"":           "",
+	}
+
+	for input, want := range tests {
+		if got := NormalizeLanguage(input); got != want {
+			t.Errorf("NormalizeLanguage(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestBuild_Language(t *testing.T) {
+	c := &cfg.Cfg{Location: time.UTC}
+
+	tests := []struct {
+		name     string
+		source   string
+		override string
+		want     string
+	}{
+		{"normalized source", "en_US", "", "<language>en-US</language>"},
+		{"override wins", "en_US", "de", "<language>de</language>"},
+		{"invalid source dropped", "English", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, _ := json.Marshal(types.Settings{Language: tt.override})
+			rss, err := basicType{}.Build(database.Feed{Name: "test", Language: tt.source, Settings: settings}, nil, c)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want == "" {
+				if strings.Contains(rss, "<language>") {
+					t.Errorf("expected no language element, got:\n%s", rss)
+				}
+				return
+			}
+			if !strings.Contains(rss, tt.want) {
+				t.Errorf("expected %s, got:\n%s", tt.want, rss)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package feed
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// newsletterType is a feed without a source to fetch: its items are emails
+// posted to /inbound/<name>. The output is that of a basic feed.
+type newsletterType struct{}
+
+func (newsletterType) Parse([]byte, time.Time) (*Metadata, []types.Item, error) {
+	return nil, nil, fmt.Errorf("newsletter feeds receive items by email and aren't fetched")
+}
+
+func (t newsletterType) Build(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
+	return build(t, FormatRSS, feed, items, cfg)
+}
+
+func (t newsletterType) Write(w io.Writer, feed database.Feed, items []database.Item, cfg *cfg.Cfg) error {
+	return stream(t, FormatRSS, w, feed, items, cfg)
+}
+
+func (newsletterType) itunes() bool { return false }
+
+func (newsletterType) enclosure(database.Item, *cfg.Cfg) *Enclosure { return nil }
@@ -0,0 +1,44 @@
+package feed
+
+import (
+	"context"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// OutputItems returns the items a feed's output document contains, in
+// order: the visible items after title collapsing and change marking, with
+// drip-feed release times as publish dates and a diagnostic notice first
+// while the source is failing. The API and the publisher both render these.
+func OutputItems(ctx context.Context, itemRepo *database.ItemRepository, dbFeed *database.Feed, settings *types.Settings, location *time.Location, now time.Time) ([]database.Item, error) {
+	items, err := itemRepo.GetVisibleItems(ctx, dbFeed.ID, settings, now)
+	if err != nil {
+		return nil, err
+	}
+	return PrepareOutputItems(items, dbFeed, settings, location, now), nil
+}
+
+// PrepareOutputItems turns the visible items of a feed into the items of
+// its output document, for callers that query them on their own.
+func PrepareOutputItems(items []database.Item, dbFeed *database.Feed, settings *types.Settings, location *time.Location, now time.Time) []database.Item {
+	items = CollapseTitles(items, time.Duration(settings.CollapseTitles))
+	items = MarkChanges(items, settings.MarkChanges, location)
+
+	// Drip-feed items carry their release time as the publish date, so
+	// readers see them as new when they are released.
+	if settings.DripInterval > 0 {
+		for i := range items {
+			if items[i].ReleasedAt != nil {
+				items[i].PublishedAt = *items[i].ReleasedAt
+			}
+		}
+	}
+
+	if notice := DiagnosticItem(*dbFeed, time.Duration(settings.DiagnosticAfter), now); notice != nil {
+		items = append([]database.Item{*notice}, items...)
+	}
+
+	return items
+}
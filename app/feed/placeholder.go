@@ -0,0 +1,55 @@
+package feed
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+)
+
+// BuildPlaceholder renders an item-less document for a feed that hasn't
+// been fetched yet, so readers subscribing to a new config get a valid
+// feed instead of an error.
+func BuildPlaceholder(feed database.Feed, format Format, cfg *cfg.Cfg) string {
+	doc := standInDocument(feed.Name, cmp.Or(feed.DisplayTitle(), feed.Name), cmp.Or(feed.Link, feed.FeedURL),
+		"This feed is being processed. Please check back in a few minutes.", cfg)
+
+	var buf bytes.Buffer
+	format.write(&buf, doc)
+	return buf.String()
+}
+
+// BuildError renders a valid document with a single explanatory item,
+// served instead of a 500 when ERROR_FEEDS is set; some readers drop
+// subscriptions that keep failing. The item GUID changes daily so a long
+// outage doesn't flood readers with notices.
+func BuildError(feedName string, format Format, cfg *cfg.Cfg) string {
+	doc := standInDocument(feedName, feedName, feedURL(cfg, feedName), "This feed is temporarily unavailable.", cfg)
+	doc.Items = []DocumentItem{{
+		GUID:        fmt.Sprintf("rss-comb:error:%s:%s", feedName, doc.GeneratedAt.Format(time.DateOnly)),
+		Title:       "rss-comb: feed temporarily unavailable",
+		Description: "The feed could not be generated because of a server error. It will be back once the problem is resolved; no action is needed.",
+		PublishedAt: doc.GeneratedAt,
+	}}
+
+	var buf bytes.Buffer
+	format.write(&buf, doc)
+	return buf.String()
+}
+
+func standInDocument(feedName, title, link, description string, cfg *cfg.Cfg) *Document {
+	now := cfg.Now().In(cfg.Location)
+	return &Document{
+		Name:          feedName,
+		Title:         title,
+		Link:          link,
+		Description:   description,
+		SelfURL:       feedURL(cfg, feedName),
+		Generator:     generator(cfg),
+		LastBuildDate: now,
+		GeneratedAt:   now,
+	}
+}
@@ -0,0 +1,68 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+)
+
+func TestBuildPlaceholder(t *testing.T) {
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC, Version: "test"}
+	rss := BuildPlaceholder(database.Feed{Name: "new-feed", FeedURL: "https://example.com/feed.xml"}, FormatRSS, c)
+
+	var doc struct {
+		Channel struct {
+			Title       string     `xml:"title"`
+			Description string     `xml:"description"`
+			Items       []struct{} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal([]byte(rss), &doc); err != nil {
+		t.Fatalf("placeholder is not valid XML: %v\n%s", err, rss)
+	}
+
+	if doc.Channel.Title != "new-feed" {
+		t.Errorf("expected feed name as title, got %q", doc.Channel.Title)
+	}
+	if !strings.Contains(rss, "<link>https://example.com/feed.xml</link>") {
+		t.Errorf("expected source URL as link, got:\n%s", rss)
+	}
+	if !strings.Contains(doc.Channel.Description, "being processed") {
+		t.Errorf("unexpected description %q", doc.Channel.Description)
+	}
+	if len(doc.Channel.Items) != 0 {
+		t.Errorf("expected no items, got %d", len(doc.Channel.Items))
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	c := &cfg.Cfg{Location: time.UTC, Port: "8080"}
+	rss := BuildError("broken", FormatRSS, c)
+
+	var doc struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				GUID  string `xml:"guid"`
+				Title string `xml:"title"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal([]byte(rss), &doc); err != nil {
+		t.Fatalf("error feed is not valid XML: %v\n%s", err, rss)
+	}
+
+	if doc.Channel.Title != "broken" {
+		t.Errorf("expected feed name as title, got %q", doc.Channel.Title)
+	}
+	if len(doc.Channel.Items) != 1 {
+		t.Fatalf("expected 1 explanatory item, got %d", len(doc.Channel.Items))
+	}
+	if !strings.HasPrefix(doc.Channel.Items[0].GUID, "rss-comb:error:broken:") {
+		t.Errorf("unexpected GUID %q", doc.Channel.Items[0].GUID)
+	}
+}
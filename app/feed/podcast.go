@@ -1,10 +1,9 @@
 package feed
 
 import (
-	"bytes"
-	"fmt"
-	"html"
+	"io"
 	"strconv"
+	"time"
 
 	"github.com/lysyi3m/rss-comb/app/cfg"
 	"github.com/lysyi3m/rss-comb/app/database"
@@ -14,13 +13,13 @@ import (
 
 type podcastType struct{}
 
-func (podcastType) Parse(data []byte) (*Metadata, []types.Item, error) {
+func (podcastType) Parse(data []byte, _ time.Time) (*Metadata, []types.Item, error) {
 	feed, err := parseWithGofeed(data)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	metadata := extractBaseMetadata(feed)
+	metadata := extractBaseMetadata(feed, data)
 
 	if feed.ITunesExt != nil {
 		metadata.ITunesAuthor = feed.ITunesExt.Author
@@ -68,32 +67,20 @@ func normalizePodcastItem(normalized *types.Item, item *gofeed.Item) {
 	}
 }
 
-func (podcastType) Build(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
-	var buf bytes.Buffer
-
-	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
-	buf.WriteString("\n")
-	buf.WriteString(`<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">`)
-	buf.WriteString("\n  <channel>\n")
+func (t podcastType) Build(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
+	return build(t, FormatRSS, feed, items, cfg)
+}
 
-	writeChannelHeader(&buf, feed, items, cfg)
-	writeITunesFeedElements(&buf, feed)
+func (t podcastType) Write(w io.Writer, feed database.Feed, items []database.Item, cfg *cfg.Cfg) error {
+	return stream(t, FormatRSS, w, feed, items, cfg)
+}
 
-	for _, item := range items {
-		writeBaseItem(&buf, item, cfg)
+func (podcastType) itunes() bool { return true }
 
-		if item.EnclosureURL != "" && item.EnclosureType != "" {
-			buf.WriteString(fmt.Sprintf("      <enclosure url=\"%s\" length=\"%d\" type=\"%s\" />\n",
-				html.EscapeString(item.EnclosureURL),
-				item.EnclosureLength,
-				html.EscapeString(item.EnclosureType)))
-		}
-
-		writeITunesItemElements(&buf, item)
-		buf.WriteString("    </item>\n")
+// enclosure passes the source enclosure through.
+func (podcastType) enclosure(item database.Item, _ *cfg.Cfg) *Enclosure {
+	if item.EnclosureURL == "" || item.EnclosureType == "" {
+		return nil
 	}
-
-	buf.WriteString("  </channel>\n</rss>")
-
-	return buf.String(), nil
+	return &Enclosure{URL: item.EnclosureURL, Length: item.EnclosureLength, Type: item.EnclosureType}
 }
@@ -1,6 +1,9 @@
 package feed
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestPodcastParse_ITunesMetadata(t *testing.T) {
 	podcastRSS := `<?xml version="1.0"?>
@@ -31,7 +34,7 @@ func TestPodcastParse_ITunesMetadata(t *testing.T) {
 </rss>`
 
 	pt := podcastType{}
-	metadata, items, err := pt.Parse([]byte(podcastRSS))
+	metadata, items, err := pt.Parse([]byte(podcastRSS), time.Now())
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -79,7 +82,7 @@ func TestPodcastParse_ITunesMetadata(t *testing.T) {
 
 func TestPodcastParse_InvalidFeed(t *testing.T) {
 	pt := podcastType{}
-	_, _, err := pt.Parse([]byte("invalid xml"))
+	_, _, err := pt.Parse([]byte("invalid xml"), time.Now())
 
 	if err == nil {
 		t.Error("Expected error for invalid XML")
@@ -0,0 +1,79 @@
+package feed
+
+import (
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// previewSummaryLength caps the description text shown per item.
+const previewSummaryLength = 300
+
+// previewTemplate is deliberately plain: no scripts and no source markup.
+// Descriptions are shown as text, so nothing from the source runs on this
+// origin.
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html{{with .Language}} lang="{{.}}"{{end}}>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>{{.Title}} – preview</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 46rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; color: #222; }
+header p, .meta { color: #666; font-size: 0.9rem; }
+article { border-top: 1px solid #ddd; padding: 0.75rem 0; }
+article h2 { font-size: 1.1rem; margin: 0 0 0.25rem; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+{{with .Description}}<p>{{.}}</p>{{end}}
+<p>{{with .Link}}<a href="{{.}}" rel="noopener noreferrer">Website</a> · {{end}}<a href="{{.SelfURL}}">RSS</a> · {{len .Items}} items · generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</p>
+</header>
+<main>
+{{range .Items}}<article>
+<h2>{{if .Link}}<a href="{{.Link}}" rel="noopener noreferrer">{{.Title}}</a>{{else}}{{.Title}}{{end}}</h2>
+<div class="meta">{{.PublishedAt.Format "2006-01-02 15:04"}}{{with .Author}} · {{.}}{{end}}{{with .Enclosure}} · <a href="{{.URL}}">{{.Type}}</a>{{end}}</div>
+{{with .Summary}}<p>{{.}}</p>{{end}}
+</article>
+{{else}}<p>No items yet.</p>
+{{end}}</main>
+</body>
+</html>
+`))
+
+var scriptOrStyle = regexp.MustCompile(`(?is)<script\b.*?</script>|<style\b.*?</style>`)
+
+type previewItem struct {
+	DocumentItem
+	Summary string
+}
+
+// WritePreview renders a document as a minimal HTML page for checking a
+// feed in a browser.
+func WritePreview(w io.Writer, doc *Document) error {
+	items := make([]previewItem, len(doc.Items))
+	for i, item := range doc.Items {
+		items[i] = previewItem{DocumentItem: item, Summary: previewSummary(item.Description)}
+	}
+
+	return previewTemplate.Execute(w, struct {
+		*Document
+		Items []previewItem
+	}{doc, items})
+}
+
+// previewSummary returns the text of an HTML description, shortened to
+// previewSummaryLength characters.
+func previewSummary(description string) string {
+	text := strings.Join(plainText(scriptOrStyle.ReplaceAllString(description, " ")), " ")
+	if utf8.RuneCountInString(text) <= previewSummaryLength {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:previewSummaryLength])) + "…"
+}
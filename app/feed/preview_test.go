@@ -0,0 +1,57 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePreview(t *testing.T) {
+	published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	doc := &Document{
+		Name:        "news",
+		Title:       "News <b>Daily</b>",
+		Link:        "https://example.com",
+		SelfURL:     "https://feeds.example.com/feeds/news",
+		GeneratedAt: published,
+		Items: []DocumentItem{{
+			Title:       "First & foremost",
+			Link:        "https://example.com/1",
+			Description: `<p>Hello <a href="/x">world</a></p><script>alert(1)</script>`,
+			PublishedAt: published,
+			Enclosure:   &Enclosure{URL: "https://example.com/1.mp3", Type: "audio/mpeg"},
+		}},
+	}
+
+	var b strings.Builder
+	if err := WritePreview(&b, doc); err != nil {
+		t.Fatalf("WritePreview failed: %v", err)
+	}
+	page := b.String()
+
+	for _, want := range []string{
+		"News &lt;b&gt;Daily&lt;/b&gt;",
+		`<a href="https://example.com/1" rel="noopener noreferrer">First &amp; foremost</a>`,
+		"<p>Hello world</p>",
+		"2024-03-01 12:00",
+		`<a href="https://example.com/1.mp3">audio/mpeg</a>`,
+		"1 items",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected page to contain %q\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "alert") {
+		t.Error("source markup must not be rendered")
+	}
+}
+
+func TestPreviewSummary_Truncates(t *testing.T) {
+	summary := previewSummary("<p>" + strings.Repeat("word ", 100) + "</p>")
+	if n := len([]rune(summary)); n > previewSummaryLength+1 {
+		t.Errorf("expected at most %d characters, got %d", previewSummaryLength+1, n)
+	}
+	if !strings.HasSuffix(summary, "…") {
+		t.Errorf("expected an ellipsis, got %q", summary)
+	}
+}
@@ -10,11 +10,24 @@ import (
 	"github.com/lysyi3m/rss-comb/app/types"
 )
 
+// FeedFilters returns the filters applied to a feed's items: the global
+// blocklist followed by the feed's own filters. Ingest and Refilter both use
+// it, so an item is filtered the same way whichever path evaluates it.
+func FeedFilters(dbFeed *database.Feed, blocklist *Blocklist) ([]types.Filter, error) {
+	filters, err := dbFeed.GetFilters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed filters: %w", err)
+	}
+	return WithBlocklist(blocklist, filters), nil
+}
+
 func Refilter(
 	ctx context.Context,
 	feedName string,
+	blocklist *Blocklist,
 	feedRepo *database.FeedRepository,
 	itemRepo *database.ItemRepository,
+	now time.Time,
 ) error {
 	start := time.Now()
 
@@ -24,20 +37,25 @@ func Refilter(
 	default:
 	}
 
-	dbFeed, err := feedRepo.GetFeed(feedName)
+	dbFeed, err := feedRepo.GetFeed(ctx, feedName)
 	if err != nil {
 		return fmt.Errorf("failed to get feed from database: %w", err)
 	}
 	if dbFeed == nil {
-		return fmt.Errorf("feed not found in database")
+		return types.ErrFeedNotFound
 	}
 
-	filters, err := dbFeed.GetFilters()
+	filters, err := FeedFilters(dbFeed, blocklist)
 	if err != nil {
-		return fmt.Errorf("failed to get feed filters: %w", err)
+		return err
 	}
 
-	items, err := itemRepo.GetAllItems(feedName)
+	settings, err := dbFeed.GetSettings()
+	if err != nil {
+		return fmt.Errorf("failed to get feed settings: %w", err)
+	}
+
+	items, err := itemRepo.GetAllItems(ctx, dbFeed.ID)
 	if err != nil {
 		return fmt.Errorf("failed to get feed items: %w", err)
 	}
@@ -47,7 +65,11 @@ func Refilter(
 		feedItems[i] = item.Item
 	}
 
-	filteredItems := Filter(feedItems, filters)
+	filteredItems := Filter(feedItems, filters, settings.MinScore, now)
+
+	if err := feedRepo.SetRefilterAt(ctx, feedName, NextFilterExpiry(filters, now)); err != nil {
+		return err
+	}
 
 	updatedCount := 0
 	errorCount := 0
@@ -55,8 +77,22 @@ func Refilter(
 	for i, filteredItem := range filteredItems {
 		originalItem := items[i]
 
-		if originalItem.IsFiltered != filteredItem.IsFiltered {
-			err := itemRepo.UpdateItemFilterStatus(originalItem.ID, filteredItem.IsFiltered)
+		// Backfills items stored before series detection existed.
+		if seriesID := SeriesID(originalItem.Title); seriesID != originalItem.SeriesID {
+			if err := itemRepo.UpdateItemSeriesID(ctx, originalItem.ID, seriesID); err != nil {
+				slog.Error("Failed to update item series", "item_id", originalItem.ID, "error", err)
+			}
+		}
+
+		if originalItem.Score != filteredItem.Score {
+			if err := itemRepo.UpdateItemScore(ctx, originalItem.ID, filteredItem.Score); err != nil {
+				slog.Error("Failed to update item score", "item_id", originalItem.ID, "error", err)
+			}
+		}
+
+		// Moderated items keep the status set through the API.
+		if originalItem.ManualFilter == nil && originalItem.IsFiltered != filteredItem.IsFiltered {
+			err := itemRepo.UpdateItemFilterStatus(ctx, originalItem.ID, filteredItem.IsFiltered)
 			if err != nil {
 				slog.Error("Failed to update item filter status", "item_id", originalItem.ID, "error", err)
 				errorCount++
@@ -66,8 +102,13 @@ func Refilter(
 		}
 	}
 
+	if err := feedRepo.MarkRefiltered(ctx, feedName, dbFeed.ConfigVersion); err != nil {
+		return err
+	}
+
 	slog.Info("Feed refiltered",
 		"feed", feedName,
+		"config_version", dbFeed.ConfigVersion,
 		"duration", time.Since(start),
 		"success", updatedCount,
 		"errors", errorCount)
@@ -0,0 +1,65 @@
+package feed
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// Ingest filters items one at a time as they arrive; Refilter filters all
+// stored items at once. Both must reach the same result.
+func TestFeedFilters_IngestMatchesRefilter(t *testing.T) {
+	filters := []types.Filter{
+		{Field: "authors", Excludes: []string{"bot"}},
+		{Any: []types.Filter{
+			{Field: "title", Includes: []string{"go"}, WholeWord: true},
+			{Field: "categories", Includes: []string{"rust"}},
+		}},
+		{Field: "title", Includes: []string{"release"}, Weight: 2},
+	}
+	filtersJSON, err := json.Marshal(filters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbFeed := &database.Feed{Filters: filtersJSON}
+
+	blocklist := NewBlocklist(filepath.Join(t.TempDir(), "blocklist.yml"), nil, nil)
+	if err := blocklist.Save(types.Blocklist{Domains: []string{"spam.example"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	items := []types.Item{
+		{Title: "Go release notes", Link: "https://blog.example/go"},
+		{Title: "Go release notes", Link: "https://www.spam.example/go"},
+		{Title: "Go tips", Authors: []string{"Bot"}},
+		{Title: "Good morning", Categories: []string{"rust"}},
+		{Title: "Good morning"},
+		{Title: "Go tips"},
+	}
+
+	feedFilters, err := FeedFilters(dbFeed, blocklist)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	const minScore = 1
+
+	batch := Filter(items, feedFilters, minScore, time.Now())
+	for i, item := range items {
+		single := Filter([]types.Item{item}, feedFilters, minScore, time.Now())[0]
+		if single.IsFiltered != batch[i].IsFiltered || single.Score != batch[i].Score {
+			t.Errorf("item %d: ingest (%v, %d) and refilter (%v, %d) disagree",
+				i, single.IsFiltered, single.Score, batch[i].IsFiltered, batch[i].Score)
+		}
+	}
+
+	want := []bool{false, true, true, true, true, true}
+	for i, item := range batch {
+		if item.IsFiltered != want[i] {
+			t.Errorf("item %d (%q): expected filtered=%v, got %v", i, item.Title, want[i], item.IsFiltered)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package feed
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// maxCommitFiles caps the changed files listed for a commit.
+const maxCommitFiles = 50
+
+// ForgeRef is a release or commit on GitHub or GitLab, as linked from the
+// Atom feeds those sites provide.
+type ForgeRef struct {
+	Forge   string // "github" or "gitlab"
+	BaseURL string // Scheme and host, e.g. https://gitlab.example.com
+	Project string // owner/repo, or the full group path on GitLab
+	Kind    string // "release" or "commit"
+	Ref     string // Tag name or commit SHA
+}
+
+// ParseForgeLink recognizes release, tag and commit links on github.com and
+// on gitlab.com or the given self-hosted GitLab hosts.
+func ParseForgeLink(link string, gitlabHosts []string) (ForgeRef, bool) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return ForgeRef{}, false
+	}
+	host := strings.ToLower(u.Host)
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segments[i] = unescaped
+		}
+	}
+	base := u.Scheme + "://" + u.Host
+
+	if host == "github.com" || host == "www.github.com" {
+		// /owner/repo/releases/tag/v1.0 and /owner/repo/commit/sha
+		switch {
+		case len(segments) >= 5 && segments[2] == "releases" && segments[3] == "tag":
+			return ForgeRef{"github", base, segments[0] + "/" + segments[1], "release", strings.Join(segments[4:], "/")}, true
+		case len(segments) == 4 && segments[2] == "commit":
+			return ForgeRef{"github", base, segments[0] + "/" + segments[1], "commit", segments[3]}, true
+		}
+		return ForgeRef{}, false
+	}
+
+	isGitLab := host == "gitlab.com"
+	for _, h := range gitlabHosts {
+		isGitLab = isGitLab || strings.EqualFold(h, host)
+	}
+	if !isGitLab {
+		return ForgeRef{}, false
+	}
+
+	// /group/sub/project/-/releases/v1.0, /-/tags/v1.0 and /-/commit/sha
+	for i, segment := range segments {
+		if segment != "-" || i == 0 || len(segments) < i+3 {
+			continue
+		}
+		project := strings.Join(segments[:i], "/")
+		ref := strings.Join(segments[i+2:], "/")
+		switch segments[i+1] {
+		case "releases", "tags":
+			return ForgeRef{"gitlab", base, project, "release", ref}, true
+		case "commit":
+			return ForgeRef{"gitlab", base, project, "commit", ref}, true
+		}
+		break
+	}
+	return ForgeRef{}, false
+}
+
+// CommitFile is a file changed by a commit.
+type CommitFile struct {
+	Name      string
+	Additions int
+	Deletions int
+}
+
+// CommitHTML renders a commit message as paragraphs followed by the change
+// statistics and, when known, the changed files.
+func CommitHTML(message string, additions, deletions int, files []CommitFile) string {
+	var b strings.Builder
+	b.WriteString(textHTML(message))
+
+	if len(files) > 0 {
+		fmt.Fprintf(&b, "<p>%d files changed, +%d −%d</p><ul>", len(files), additions, deletions)
+		for i, file := range files {
+			if i == maxCommitFiles {
+				fmt.Fprintf(&b, "<li>and %d more</li>", len(files)-maxCommitFiles)
+				break
+			}
+			fmt.Fprintf(&b, "<li><code>%s</code> +%d −%d</li>", html.EscapeString(file.Name), file.Additions, file.Deletions)
+		}
+		b.WriteString("</ul>")
+	} else if additions > 0 || deletions > 0 {
+		fmt.Fprintf(&b, "<p>+%d −%d</p>", additions, deletions)
+	}
+	return b.String()
+}
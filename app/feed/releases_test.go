@@ -0,0 +1,51 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseForgeLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want ForgeRef
+		ok   bool
+	}{
+		{"https://github.com/golang/go/releases/tag/go1.22.0", ForgeRef{"github", "https://github.com", "golang/go", "release", "go1.22.0"}, true},
+		{"https://github.com/o/r/releases/tag/release/v2", ForgeRef{"github", "https://github.com", "o/r", "release", "release/v2"}, true},
+		{"https://github.com/o/r/commit/abc123", ForgeRef{"github", "https://github.com", "o/r", "commit", "abc123"}, true},
+		{"https://gitlab.com/group/sub/project/-/releases/v1.0", ForgeRef{"gitlab", "https://gitlab.com", "group/sub/project", "release", "v1.0"}, true},
+		{"https://gitlab.com/group/project/-/tags/v1.0", ForgeRef{"gitlab", "https://gitlab.com", "group/project", "release", "v1.0"}, true},
+		{"https://git.example.com/team/app/-/commit/def456", ForgeRef{"gitlab", "https://git.example.com", "team/app", "commit", "def456"}, true},
+		{"https://github.com/o/r/issues/1", ForgeRef{}, false},
+		{"https://gitlab.com/group/project/-/issues/1", ForgeRef{}, false},
+		{"https://other.example.com/team/app/-/commit/def456", ForgeRef{}, false},
+		{"https://example.com/blog/post", ForgeRef{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseForgeLink(tt.link, []string{"git.example.com"})
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseForgeLink(%q) = %+v, %v; want %+v, %v", tt.link, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCommitHTML(t *testing.T) {
+	files := make([]CommitFile, maxCommitFiles+2)
+	for i := range files {
+		files[i] = CommitFile{Name: "file.go", Additions: 1}
+	}
+	files[0].Name = "<main>.go"
+
+	got := CommitHTML("Fix parser\n\nHandles <empty> input.", 52, 0, files)
+	for _, want := range []string{"<p>Fix parser</p>", "<p>Handles &lt;empty&gt; input.</p>", "52 files changed, +52 −0", "<code>&lt;main&gt;.go</code>", "<li>and 2 more</li>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+
+	if got := CommitHTML("Bump version", 1, 1, nil); !strings.HasSuffix(got, "<p>+1 −1</p>") {
+		t.Errorf("expected the stats without files, got %q", got)
+	}
+}
@@ -0,0 +1,37 @@
+package feed
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// managedHeaders are set by rss-comb for the document it serves, or belong
+// to the connection, so response_headers can't replace them.
+var managedHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Encoding":  true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Etag":              true,
+	"Last-Modified":     true,
+	"Transfer-Encoding": true,
+}
+
+func validateResponseHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("response_headers: invalid header name %q", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("response_headers: invalid value for %s", name)
+		}
+		canonical := http.CanonicalHeaderKey(name)
+		if managedHeaders[canonical] || strings.HasPrefix(canonical, "X-Feed-") {
+			return fmt.Errorf("response_headers: %s is set by rss-comb and can't be overridden", canonical)
+		}
+	}
+	return nil
+}
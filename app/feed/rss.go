@@ -0,0 +1,184 @@
+package feed
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// writeRSS renders a document as RSS 2.0.
+func writeRSS(w feedWriter, doc *Document) {
+	w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	w.WriteString("\n")
+	w.WriteString(`<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:atom="http://www.w3.org/2005/Atom"`)
+	if doc.ITunes != nil {
+		w.WriteString(` xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`)
+	}
+	writeNamespaces(w, doc.Namespaces)
+	w.WriteString(">\n  <channel>\n")
+
+	writeRSSChannel(w, doc)
+
+	for _, item := range doc.Items {
+		writeRSSItem(w, doc, item)
+	}
+
+	w.WriteString("  </channel>\n</rss>")
+}
+
+func writeRSSChannel(w feedWriter, doc *Document) {
+	writeElement(w, "title", doc.Title, 4)
+	writeElement(w, "link", doc.Link, 4)
+	writeElement(w, "description", doc.Description, 4)
+	w.WriteString(fmt.Sprintf("    <atom:link href=\"%s\" rel=\"self\" type=\"application/rss+xml\" />\n",
+		html.EscapeString(doc.SelfURL)))
+
+	if doc.Provenance != nil {
+		writeProvenance(w, doc, 4)
+	}
+
+	// Same hint as X-Robots-Tag, for crawlers that only see the document.
+	if doc.Robots != "" {
+		w.WriteString(fmt.Sprintf("    <xhtml:meta xmlns:xhtml=\"http://www.w3.org/1999/xhtml\" name=\"robots\" content=\"%s\" />\n",
+			html.EscapeString(doc.Robots)))
+	}
+
+	if doc.PublishedAt != nil {
+		writeElement(w, "pubDate", doc.PublishedAt.Format(time.RFC1123Z), 4)
+	}
+	writeElement(w, "lastBuildDate", doc.LastBuildDate.Format(time.RFC1123Z), 4)
+	writeElement(w, "generator", doc.Generator, 4)
+	writeElement(w, "language", doc.Language, 4)
+	if doc.TTL > 0 {
+		writeElement(w, "ttl", strconv.Itoa(doc.TTL), 4)
+	}
+	if len(doc.SkipHours) > 0 {
+		w.WriteString("    <skipHours>\n")
+		for _, hour := range doc.SkipHours {
+			writeElement(w, "hour", strconv.Itoa(hour), 6)
+		}
+		w.WriteString("    </skipHours>\n")
+	}
+	if len(doc.SkipDays) > 0 {
+		w.WriteString("    <skipDays>\n")
+		for _, day := range doc.SkipDays {
+			writeElement(w, "day", day, 6)
+		}
+		w.WriteString("    </skipDays>\n")
+	}
+
+	if doc.ImageURL != "" {
+		w.WriteString("    <image>\n")
+		writeElement(w, "url", doc.ImageURL, 6)
+		writeElement(w, "title", doc.Title, 6)
+		writeElement(w, "link", doc.Link, 6)
+		w.WriteString("    </image>\n")
+	}
+
+	if doc.ITunes != nil {
+		writeITunesChannel(w, doc.ITunes)
+	}
+
+	writeChannelElements(w, doc.Elements, 4)
+}
+
+// provenanceNamespace qualifies rss-comb's own channel elements.
+const provenanceNamespace = "https://github.com/lysyi3m/rss-comb/ns/provenance"
+
+// writeProvenance records when the document was generated, when the source
+// was last fetched and by which version, to help audit caching layers.
+func writeProvenance(w feedWriter, doc *Document, indent int) {
+	pad := strings.Repeat(" ", indent)
+	w.WriteString(fmt.Sprintf("%s<comb:provenance xmlns:comb=\"%s\">\n", pad, provenanceNamespace))
+	writeElement(w, "comb:generatedAt", doc.GeneratedAt.Format(time.RFC3339), indent+2)
+	if doc.Provenance.FetchedAt != nil {
+		writeElement(w, "comb:fetchedAt", doc.Provenance.FetchedAt.Format(time.RFC3339), indent+2)
+	}
+	writeElement(w, "comb:sourceUrl", doc.Provenance.SourceURL, indent+2)
+	writeElement(w, "comb:version", doc.Provenance.Version, indent+2)
+	w.WriteString(pad + "</comb:provenance>\n")
+}
+
+func writeRSSItem(w feedWriter, doc *Document, item DocumentItem) {
+	w.WriteString("    <item>\n")
+
+	if item.GUID != "" {
+		w.WriteString(fmt.Sprintf("      <guid isPermaLink=\"%t\">", strings.HasPrefix(item.GUID, "http://") || strings.HasPrefix(item.GUID, "https://")))
+		xml.EscapeText(w, []byte(item.GUID))
+		w.WriteString("</guid>\n")
+	}
+
+	writeElement(w, "title", item.Title, 6)
+	writeElement(w, "link", item.Link, 6)
+	writeElement(w, "description", cmp.Or(item.Description, "No description available"), 6)
+
+	if item.Content != "" {
+		w.WriteString("      <content:encoded><![CDATA[")
+		w.WriteString(item.Content)
+		w.WriteString("]]></content:encoded>\n")
+	}
+
+	writeElement(w, "pubDate", item.PublishedAt.Format(time.RFC1123Z), 6)
+	writeElement(w, "author", item.Author, 6)
+
+	for _, category := range item.Categories {
+		writeElement(w, "category", category, 6)
+	}
+
+	if doc.Provenance != nil {
+		w.WriteString(fmt.Sprintf("      <source url=\"%s\">", html.EscapeString(doc.Provenance.SourceURL)))
+		xml.EscapeText(w, []byte(doc.Provenance.SourceTitle))
+		w.WriteString("</source>\n")
+	}
+
+	if enc := item.Enclosure; enc != nil {
+		w.WriteString(fmt.Sprintf("      <enclosure url=\"%s\" length=\"%d\" type=\"%s\" />\n",
+			html.EscapeString(enc.URL), enc.Length, html.EscapeString(enc.Type)))
+	}
+
+	if item.ITunes != nil {
+		writeITunesItem(w, item.ITunes)
+	}
+
+	w.WriteString("    </item>\n")
+}
+
+func writeITunesChannel(w feedWriter, it *ITunesChannel) {
+	writeElement(w, "itunes:author", it.Author, 4)
+
+	if it.Image != "" {
+		w.WriteString(fmt.Sprintf("    <itunes:image href=\"%s\" />\n",
+			html.EscapeString(it.Image)))
+	}
+
+	writeElement(w, "itunes:explicit", it.Explicit, 4)
+
+	if it.OwnerName != "" || it.OwnerEmail != "" {
+		w.WriteString("    <itunes:owner>\n")
+		writeElement(w, "itunes:name", it.OwnerName, 6)
+		writeElement(w, "itunes:email", it.OwnerEmail, 6)
+		w.WriteString("    </itunes:owner>\n")
+	}
+}
+
+func writeITunesItem(w feedWriter, it *ITunesItem) {
+	if it.Duration > 0 {
+		writeElement(w, "itunes:duration", formatDuration(it.Duration), 6)
+	}
+	if it.Episode > 0 {
+		writeElement(w, "itunes:episode", fmt.Sprintf("%d", it.Episode), 6)
+	}
+	if it.Season > 0 {
+		writeElement(w, "itunes:season", fmt.Sprintf("%d", it.Season), 6)
+	}
+	writeElement(w, "itunes:episodeType", it.EpisodeType, 6)
+
+	if it.Image != "" {
+		w.WriteString(fmt.Sprintf("      <itunes:image href=\"%s\" />\n",
+			html.EscapeString(it.Image)))
+	}
+}
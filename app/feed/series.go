@@ -0,0 +1,45 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// seriesMarker matches the part of a title that numbers an installment:
+// "Part 3", "Pt. 3", "Episode 12", "Ep 12", "#45", "No. 45", "Issue 45",
+// "Vol. 2", "Chapter 4", "(3/5)" and bare trailing numbers.
+var seriesMarker = regexp.MustCompile(`(?i)(\b(part|pt|episode|ep|issue|no|vol|volume|chapter|ch)\.?\s*\d+\b|#\s*\d+|\(\s*\d+\s*/\s*\d+\s*\)|\b\d+\s*$)`)
+
+// seriesNoise removes remaining dates, numbers and punctuation so that
+// "Weekly Digest – Jan 5" and "Weekly Digest – Feb 2" share a stem.
+var (
+	seriesDate  = regexp.MustCompile(`(?i)\b(jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?\s+\d{1,2}(st|nd|rd|th)?\b|\b\d{4}-\d{2}-\d{2}\b|\b\d{1,2}/\d{1,2}(/\d{2,4})?\b`)
+	seriesNoise = regexp.MustCompile(`[^\p{L}\s]+`)
+)
+
+// SeriesID returns a fingerprint shared by installments of the same series,
+// or "" if the title doesn't look like part of one. Only the title stem left
+// after removing installment markers and dates is fingerprinted.
+func SeriesID(title string) string {
+	if !seriesMarker.MatchString(title) && !seriesDate.MatchString(title) {
+		return ""
+	}
+
+	stem := seriesMarker.ReplaceAllString(title, " ")
+	stem = seriesDate.ReplaceAllString(stem, " ")
+	// Subtitles after a colon or dash differ per installment ("Part 3: The End").
+	if i := strings.IndexAny(stem, ":–—|"); i > 0 {
+		stem = stem[:i]
+	}
+	stem = seriesNoise.ReplaceAllString(strings.ToLower(stem), " ")
+	stem = strings.Join(strings.Fields(stem), " ")
+
+	// A stem of one short word ("ep 4") is too generic to group on.
+	if len(stem) < 4 {
+		return ""
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(stem)))[:16]
+}
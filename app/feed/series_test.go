@@ -0,0 +1,50 @@
+package feed
+
+import "testing"
+
+func TestSeriesID_GroupsInstallments(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"part numbers", "Building a Compiler, Part 2", "Building a Compiler, Part 3"},
+		{"part with subtitle", "Building a Compiler Part 2: Lexing", "Building a Compiler Part 3: Parsing"},
+		{"issue hash", "This Week in Rust #512", "This Week in Rust #513"},
+		{"trailing number", "Weekly Digest 45", "Weekly Digest 46"},
+		{"episode", "The Changelog Episode 12", "The Changelog Ep. 13"},
+		{"fraction", "Deep dive into Postgres (1/3)", "Deep dive into Postgres (2/3)"},
+		{"dates", "Morning Briefing – Jan 5", "Morning Briefing – Feb 12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := SeriesID(tt.a), SeriesID(tt.b)
+			if a == "" {
+				t.Fatalf("expected series ID for %q", tt.a)
+			}
+			if a != b {
+				t.Errorf("expected %q and %q in the same series, got %s and %s", tt.a, tt.b, a, b)
+			}
+		})
+	}
+}
+
+func TestSeriesID_DifferentSeries(t *testing.T) {
+	a := SeriesID("Building a Compiler, Part 2")
+	b := SeriesID("Building a Database, Part 2")
+	if a == b {
+		t.Errorf("expected different series, both got %s", a)
+	}
+}
+
+func TestSeriesID_NoMarker(t *testing.T) {
+	for _, title := range []string{
+		"Why we moved to Go",
+		"Ep 4",
+		"",
+	} {
+		if id := SeriesID(title); id != "" {
+			t.Errorf("expected no series ID for %q, got %s", title, id)
+		}
+	}
+}
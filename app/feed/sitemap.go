@@ -0,0 +1,167 @@
+package feed
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// maxSitemapSize caps a decompressed sitemap; the protocol allows 50MB.
+const maxSitemapSize = 50 << 20
+
+// sitemapType turns a sitemap.xml into one item per URL, for sites without
+// a feed. Sitemaps list only URLs, so titles start out derived from the URL
+// and are replaced by the page title when the item's content is extracted
+// (sitemap feeds always extract). A changed lastmod updates the item.
+type sitemapType struct{}
+
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapURL `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	Lastmod string `xml:"lastmod"`
+	News    struct {
+		Title           string `xml:"title"`
+		PublicationDate string `xml:"publication_date"`
+	} `xml:"news"`
+}
+
+func (sitemapType) Parse(data []byte, now time.Time) (*Metadata, []types.Item, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decompress sitemap: %w", err)
+		}
+		if data, err = io.ReadAll(io.LimitReader(reader, maxSitemapSize)); err != nil {
+			return nil, nil, fmt.Errorf("failed to decompress sitemap: %w", err)
+		}
+	}
+
+	var doc sitemapDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse sitemap: %w", err)
+	}
+	switch doc.XMLName.Local {
+	case "urlset":
+	case "sitemapindex":
+		if len(doc.Sitemaps) == 0 {
+			return nil, nil, fmt.Errorf("empty sitemap index")
+		}
+		return nil, nil, fmt.Errorf("sitemap index of %d sitemaps; use one of them as url, e.g. %s",
+			len(doc.Sitemaps), strings.TrimSpace(doc.Sitemaps[0].Loc))
+	default:
+		return nil, nil, fmt.Errorf("not a sitemap: root element <%s>", doc.XMLName.Local)
+	}
+
+	metadata := &Metadata{}
+	now = now.UTC()
+	items := make([]types.Item, 0, len(doc.URLs))
+	for _, entry := range doc.URLs {
+		loc := strings.TrimSpace(entry.Loc)
+		u, err := url.Parse(loc)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			continue
+		}
+		if metadata.Link == "" {
+			metadata.Link = u.Scheme + "://" + u.Host + "/"
+			metadata.Title = u.Host
+		}
+
+		item := types.Item{
+			GUID:        loc,
+			Link:        loc,
+			Title:       cmp.Or(strings.TrimSpace(entry.News.Title), titleFromURL(u)),
+			PublishedAt: now,
+		}
+		lastmod, hasLastmod := parseW3CDate(entry.Lastmod)
+		if hasLastmod {
+			item.PublishedAt = lastmod
+			item.UpdatedAt = &lastmod
+		}
+		if published, ok := parseW3CDate(entry.News.PublicationDate); ok {
+			item.PublishedAt = published
+		}
+		// URLs without lastmod get the time they were first seen, as the
+		// hash (and so the stored item) stays the same on later fetches.
+		hash := sha256.Sum256([]byte(loc + "|" + strings.TrimSpace(entry.Lastmod)))
+		item.ContentHash = hex.EncodeToString(hash[:])
+		items = append(items, item)
+	}
+
+	slices.SortStableFunc(items, func(a, b types.Item) int {
+		return b.PublishedAt.Compare(a.PublishedAt)
+	})
+
+	return metadata, items, nil
+}
+
+func (t sitemapType) Build(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
+	return build(t, FormatRSS, feed, items, cfg)
+}
+
+func (t sitemapType) Write(w io.Writer, feed database.Feed, items []database.Item, cfg *cfg.Cfg) error {
+	return stream(t, FormatRSS, w, feed, items, cfg)
+}
+
+func (sitemapType) itunes() bool { return false }
+
+func (sitemapType) enclosure(database.Item, *cfg.Cfg) *Enclosure { return nil }
+
+// parseW3CDate reads the W3C Datetime formats sitemaps use, from a bare
+// date to a timestamp with fractional seconds.
+func parseW3CDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04Z07:00", time.DateOnly, "2006-01", "2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// titleFromURL turns the last path segment into a readable stand-in title,
+// e.g. /blog/hello-world.html → "Hello world".
+func titleFromURL(u *url.URL) string {
+	segment := path.Base(strings.TrimSuffix(u.Path, "/"))
+	if segment == "." || segment == "/" || segment == "" {
+		return u.Host
+	}
+	if unescaped, err := url.PathUnescape(segment); err == nil {
+		segment = unescaped
+	}
+	switch ext := strings.ToLower(path.Ext(segment)); ext {
+	case ".html", ".htm", ".shtml", ".php", ".asp", ".aspx":
+		segment = segment[:len(segment)-len(ext)]
+	}
+	title := strings.Join(strings.FieldsFunc(segment, func(r rune) bool {
+		return r == '-' || r == '_' || r == '+' || unicode.IsSpace(r)
+	}), " ")
+	if title == "" {
+		return u.Host
+	}
+
+	first, size := utf8.DecodeRuneInString(title)
+	return string(unicode.ToUpper(first)) + title[size:]
+}
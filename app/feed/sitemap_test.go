@@ -0,0 +1,90 @@
+package feed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSitemapParse(t *testing.T) {
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:news="http://www.google.com/schemas/sitemap-news/0.9">
+  <url><loc>https://example.com/blog/hello-world.html</loc><lastmod>2024-09-01</lastmod></url>
+  <url><loc>https://example.com/blog/release-3.5</loc><lastmod>2024-09-03T10:15:00+02:00</lastmod></url>
+  <url>
+    <loc>https://example.com/news/42</loc>
+    <news:news><news:title>Big news</news:title><news:publication_date>2024-09-02T08:00:00Z</news:publication_date></news:news>
+  </url>
+  <url><loc>https://example.com/</loc></url>
+  <url><loc>mailto:someone@example.com</loc></url>
+</urlset>`
+
+	var gzipped bytes.Buffer
+	w := gzip.NewWriter(&gzipped)
+	w.Write([]byte(sitemap))
+	w.Close()
+
+	now := time.Date(2024, 9, 5, 12, 0, 0, 0, time.UTC)
+	for name, data := range map[string][]byte{"plain": []byte(sitemap), "gzip": gzipped.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			metadata, items, err := ForType("sitemap").Parse(data, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if metadata.Title != "example.com" || metadata.Link != "https://example.com/" {
+				t.Errorf("unexpected metadata %q / %q", metadata.Title, metadata.Link)
+			}
+			if len(items) != 4 {
+				t.Fatalf("expected 4 items (non-http URLs skipped), got %d", len(items))
+			}
+
+			// Newest first; the URL without lastmod was just seen.
+			want := []string{"example.com", "Release 3.5", "Big news", "Hello world"}
+			for i, title := range want {
+				if items[i].Title != title {
+					t.Errorf("item %d: expected title %q, got %q", i, title, items[i].Title)
+				}
+			}
+			if !items[0].PublishedAt.Equal(now) {
+				t.Errorf("expected the URL without lastmod published at the parse time, got %v", items[0].PublishedAt)
+			}
+			if !items[1].PublishedAt.Equal(time.Date(2024, 9, 3, 8, 15, 0, 0, time.UTC)) || items[1].UpdatedAt == nil {
+				t.Errorf("expected lastmod as published date, got %v", items[1].PublishedAt)
+			}
+			if items[3].GUID != "https://example.com/blog/hello-world.html" || items[3].Link != items[3].GUID {
+				t.Errorf("expected the URL as GUID and link, got %q / %q", items[3].GUID, items[3].Link)
+			}
+		})
+	}
+
+	// A changed lastmod updates the stored item.
+	_, before, _ := ForType("sitemap").Parse([]byte(sitemap), time.Now())
+	_, after, _ := ForType("sitemap").Parse([]byte(strings.Replace(sitemap, "2024-09-01", "2024-09-04", 1)), time.Now())
+	if before[3].ContentHash == after[0].ContentHash {
+		t.Error("expected a new content hash for a changed lastmod")
+	}
+
+	_, _, err := ForType("sitemap").Parse([]byte(`<sitemapindex><sitemap><loc>https://example.com/posts.xml</loc></sitemap></sitemapindex>`), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "https://example.com/posts.xml") {
+		t.Errorf("expected an error pointing at the index's sitemaps, got %v", err)
+	}
+}
+
+func TestPageTitle(t *testing.T) {
+	tests := []struct {
+		page string
+		want string
+	}{
+		{`<html><head><title> Hello &amp;
+ world </title></head><body><h1>Other</h1></body></html>`, "Hello & world"},
+		{`<head><title>Site</title><meta property="og:title" content="Article &quot;A&quot;"></head>`, `Article "A"`},
+		{`<body><title>Too late</title></body>`, ""},
+	}
+	for _, tt := range tests {
+		if got := PageTitle([]byte(tt.page)); got != tt.want {
+			t.Errorf("PageTitle(%q) = %q, want %q", tt.page, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package feed
+
+import (
+	"cmp"
+	"regexp"
+	"strings"
+)
+
+// stripFields are the output fields the strip setting can omit.
+var stripFields = map[string]bool{
+	"author_emails": true, // Email addresses in item authors and the iTunes owner
+	"authors":       true,
+	"categories":    true,
+}
+
+// stripSet parses the strip setting, a comma-separated list of fields.
+func stripSet(setting string) map[string]bool {
+	set := make(map[string]bool)
+	for _, field := range strings.Split(setting, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			set[field] = true
+		}
+	}
+	return set
+}
+
+var emailAddress = regexp.MustCompile(`(?i)(mailto:)?[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`)
+
+// withoutEmail removes email addresses from an author, keeping the name of
+// "jane@example.com (Jane Doe)" or "Jane Doe <jane@example.com>".
+func withoutEmail(author string) string {
+	author = emailAddress.ReplaceAllString(author, "")
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(author), "()<>[]"))
+}
+
+// splitAuthor splits an RSS-style author ("jane@example.com (Jane Doe)",
+// "Jane Doe <jane@example.com>" or a bare name or address) into a name and
+// an email address. Atom and JSON Feed require a name, so a bare address
+// is used as both.
+func splitAuthor(author string) (name, email string) {
+	email = emailAddress.FindString(author)
+	if len(email) > 7 && strings.EqualFold(email[:7], "mailto:") {
+		email = email[7:]
+	}
+	return cmp.Or(withoutEmail(author), email), email
+}
@@ -0,0 +1,106 @@
+package feed
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// subscribeTemplate shares the look of the preview page. The QR code is an
+// inline SVG generated here, so the page loads nothing from elsewhere.
+var subscribeTemplate = template.Must(template.New("subscribe").Parse(`<!DOCTYPE html>
+<html{{with .Language}} lang="{{.}}"{{end}}>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>Subscribe to {{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 32rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; color: #222; text-align: center; }
+input { width: 100%; box-sizing: border-box; padding: 0.5rem; font: inherit; text-align: center; }
+svg { width: 16rem; height: 16rem; margin: 1rem auto; display: block; }
+ul { list-style: none; padding: 0; }
+li { margin: 0.4rem 0; }
+p.note { color: #666; font-size: 0.9rem; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Description}}<p>{{.}}</p>{{end}}
+<p><input type="text" value="{{.FeedURL}}" readonly aria-label="Feed URL"></p>
+{{.QRCode}}
+<p class="note">Scan with a phone, or copy the address into any feed reader.</p>
+<ul>
+{{range .Readers}}<li><a href="{{.URL}}" rel="noopener noreferrer">{{.Name}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// SubscribeLink is a one-click subscription link for a feed reader.
+type SubscribeLink struct {
+	Name string
+	URL  template.URL
+}
+
+// SubscribeLinks returns links that add feedURL to popular readers. Podcast
+// feeds also get the podcast:// scheme, which podcast apps register for.
+func SubscribeLinks(feedURL string, podcast bool) []SubscribeLink {
+	escaped := url.QueryEscape(feedURL)
+	links := []SubscribeLink{
+		{"Feedly", template.URL("https://feedly.com/i/subscription/feed/" + escaped)},
+		{"Inoreader", template.URL("https://www.inoreader.com/?add_feed=" + escaped)},
+		{"NewsBlur", template.URL("https://www.newsblur.com/?url=" + escaped)},
+		{"The Old Reader", template.URL("https://theoldreader.com/feeds/subscribe?url=" + escaped)},
+		{"Desktop feed reader", template.URL("feed:" + feedURL)},
+	}
+	if podcast {
+		withoutScheme := strings.TrimPrefix(strings.TrimPrefix(feedURL, "https://"), "http://")
+		links = append(links, SubscribeLink{"Podcast app", template.URL("podcast://" + withoutScheme)})
+	}
+	return links
+}
+
+// WriteSubscribePage renders a page for subscribing to feedURL: the URL to
+// copy, a QR code of it and links for popular readers.
+func WriteSubscribePage(w io.Writer, doc *Document, feedURL string) error {
+	code, err := qrSVG(feedURL)
+	if err != nil {
+		return err
+	}
+
+	return subscribeTemplate.Execute(w, struct {
+		*Document
+		FeedURL string
+		QRCode  template.HTML
+		Readers []SubscribeLink
+	}{doc, feedURL, code, SubscribeLinks(feedURL, doc.ITunes != nil)})
+}
+
+// qrSVG encodes text as a QR code drawn as a single SVG path, one unit per
+// module, with the quiet zone the format requires around it.
+func qrSVG(text string) (template.HTML, error) {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	const quiet = 4
+	var path strings.Builder
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x+quiet, y+quiet)
+			}
+		}
+	}
+
+	size := code.Size + 2*quiet
+	return template.HTML(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges" role="img" aria-label="QR code of the feed URL"><rect width="%d" height="%d" fill="#fff"/><path d="%s" fill="#000"/></svg>`,
+		size, size, size, size, path.String())), nil
+}
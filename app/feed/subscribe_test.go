@@ -0,0 +1,36 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteSubscribePage(t *testing.T) {
+	doc := &Document{Title: "Podcast", ITunes: &ITunesChannel{}}
+
+	var b strings.Builder
+	if err := WriteSubscribePage(&b, doc, "https://feeds.example.com/feeds/show?x=1&y=2"); err != nil {
+		t.Fatalf("WriteSubscribePage failed: %v", err)
+	}
+	page := b.String()
+
+	for _, want := range []string{
+		`value="https://feeds.example.com/feeds/show?x=1&amp;y=2"`,
+		"<svg",
+		`href="https://feedly.com/i/subscription/feed/https%3A%2F%2Ffeeds.example.com%2Ffeeds%2Fshow%3Fx%3D1%26y%3D2"`,
+		`href="feed:https://feeds.example.com/feeds/show?x=1&amp;y=2"`,
+		`href="podcast://feeds.example.com/feeds/show?x=1&amp;y=2"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected page to contain %q\n%s", want, page)
+		}
+	}
+}
+
+func TestSubscribeLinks_PodcastOnlyForPodcasts(t *testing.T) {
+	for _, link := range SubscribeLinks("https://example.com/feeds/news", false) {
+		if strings.HasPrefix(string(link.URL), "podcast:") {
+			t.Errorf("unexpected podcast link for a basic feed: %s", link.URL)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package feed
+
+import (
+	"cmp"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+// bittorrentType is the MIME type of .torrent files.
+const bittorrentType = "application/x-bittorrent"
+
+// torrentType normalizes tracker feeds (nyaa, ezRSS, Jackett/Prowlarr and
+// the like) for download tools such as Sonarr: the .torrent file becomes an
+// enclosure typed application/x-bittorrent with the size from the tracker's
+// namespace, and the magnet link is kept for the magnet setting.
+type torrentType struct{}
+
+func (torrentType) Parse(data []byte, _ time.Time) (*Metadata, []types.Item, error) {
+	feed, err := parseWithGofeed(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadata := extractBaseMetadata(feed, data)
+
+	items := make([]types.Item, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		normalized := normalizeBaseItem(item)
+		normalizeTorrentItem(&normalized, item)
+		normalized.ContentHash = generateContentHash(normalized)
+		items = append(items, normalized)
+	}
+
+	return metadata, items, nil
+}
+
+// normalizeTorrentItem finds the .torrent file and magnet link among the
+// enclosures, link and GUID, and the size and info hash among tracker
+// extension elements (nyaa:size, torrent:contentLength, infoHash, ...).
+func normalizeTorrentItem(normalized *types.Item, item *gofeed.Item) {
+	var torrentURL, magnet string
+	var length int64
+	candidates := []string{item.Link, item.GUID}
+	for _, enclosure := range item.Enclosures {
+		if enclosure == nil {
+			continue
+		}
+		if isMagnet(enclosure.URL) || strings.EqualFold(enclosure.Type, bittorrentType) || isTorrentFile(enclosure.URL) {
+			candidates = append([]string{enclosure.URL}, candidates...)
+			if n, err := strconv.ParseInt(enclosure.Length, 10, 64); err == nil && n > 0 {
+				length = n
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		switch {
+		case magnet == "" && isMagnet(candidate):
+			magnet = candidate
+		case torrentURL == "" && isTorrentFile(candidate):
+			torrentURL = candidate
+		}
+	}
+
+	fields := torrentFields(item.Extensions)
+	if magnet == "" {
+		magnet = fields["magneturi"]
+	}
+	if hash := fields["infohash"]; magnet == "" && hash != "" {
+		magnet = "magnet:?xt=urn:btih:" + hash + "&dn=" + url.QueryEscape(normalized.Title)
+	}
+	if length == 0 {
+		length = cmp.Or(parseByteSize(fields["contentlength"]), parseByteSize(fields["size"]))
+	}
+
+	normalized.MagnetURI = magnet
+	normalized.EnclosureURL = cmp.Or(torrentURL, magnet)
+	if normalized.EnclosureURL != "" {
+		normalized.EnclosureType = bittorrentType
+		normalized.EnclosureLength = length
+	}
+
+	// Trackers such as nyaa put the download in <link> and the page in
+	// <guid>; readers should open the page.
+	if isTorrentFile(normalized.Link) || isMagnet(item.Link) {
+		normalized.Link = ""
+		if isAbsoluteURL(item.GUID) && !isTorrentFile(item.GUID) {
+			normalized.Link = normalizeURL(item.GUID)
+		}
+	}
+}
+
+// torrentFields collects the values of extension elements by lowercased
+// local name, whatever prefix the feed declares them under.
+func torrentFields(extensions ext.Extensions) map[string]string {
+	fields := make(map[string]string)
+	var walk func(map[string][]ext.Extension)
+	walk = func(elements map[string][]ext.Extension) {
+		for name, values := range elements {
+			for _, value := range values {
+				key := strings.ToLower(name)
+				if v := strings.TrimSpace(value.Value); v != "" && fields[key] == "" {
+					fields[key] = v
+				}
+				walk(value.Children)
+			}
+		}
+	}
+	for _, elements := range extensions {
+		walk(elements)
+	}
+	return fields
+}
+
+// isTorrentFile reports whether a URL points at a .torrent file, by its
+// extension or the /download/ path that nyaa-style trackers use.
+func isTorrentFile(link string) bool {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(u.Path), ".torrent") || strings.Contains(u.Path, "/download/")
+}
+
+// parseByteSize reads a byte count or a size such as "1.4 GiB" or "700 MB".
+func parseByteSize(value string) int64 {
+	value = strings.TrimSpace(value)
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n
+	}
+
+	i := strings.IndexFunc(value, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i <= 0 {
+		return 0
+	}
+	number, err := strconv.ParseFloat(value[:i], 64)
+	if err != nil {
+		return 0
+	}
+	unit := strings.ToUpper(strings.TrimSpace(value[i:]))
+	multipliers := map[string]float64{
+		"B":  1,
+		"KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12,
+		"KIB": 1 << 10, "MIB": 1 << 20, "GIB": 1 << 30, "TIB": 1 << 40,
+	}
+	multiplier, ok := multipliers[unit]
+	if !ok {
+		return 0
+	}
+	return int64(number * multiplier)
+}
+
+func (t torrentType) Build(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
+	return build(t, FormatRSS, feed, items, cfg)
+}
+
+func (t torrentType) Write(w io.Writer, feed database.Feed, items []database.Item, cfg *cfg.Cfg) error {
+	return stream(t, FormatRSS, w, feed, items, cfg)
+}
+
+func (torrentType) itunes() bool { return false }
+
+// enclosure publishes the .torrent file, or the magnet link for trackers
+// that offer no file. The magnet setting can move the magnet link into the
+// enclosure or the item link (see assemble).
+func (torrentType) enclosure(item database.Item, _ *cfg.Cfg) *Enclosure {
+	if item.EnclosureURL == "" {
+		return nil
+	}
+	return &Enclosure{URL: item.EnclosureURL, Length: item.EnclosureLength, Type: bittorrentType}
+}
@@ -0,0 +1,97 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+const testNyaaFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss xmlns:nyaa="https://nyaa.si/xmlns/nyaa" version="2.0">
+  <channel>
+    <title>Nyaa - Home - Torrent File RSS</title>
+    <link>https://nyaa.si/</link>
+    <description>RSS Feed for Home</description>
+    <item>
+      <title>[Group] Show - 01 (1080p).mkv</title>
+      <link>https://nyaa.si/download/1234567.torrent</link>
+      <guid isPermaLink="true">https://nyaa.si/view/1234567</guid>
+      <pubDate>Mon, 01 Jul 2024 12:00:00 -0000</pubDate>
+      <nyaa:infoHash>0123456789abcdef0123456789abcdef01234567</nyaa:infoHash>
+      <nyaa:size>1.5 GiB</nyaa:size>
+    </item>
+    <item>
+      <title>Magnet only</title>
+      <link>magnet:?xt=urn:btih:fedcba9876543210fedcba9876543210fedcba98&amp;dn=Magnet+only</link>
+      <guid isPermaLink="false">fedcba98</guid>
+      <pubDate>Mon, 01 Jul 2024 11:00:00 -0000</pubDate>
+      <enclosure url="magnet:?xt=urn:btih:fedcba9876543210fedcba9876543210fedcba98&amp;dn=Magnet+only" length="734003200" type="application/x-bittorrent"/>
+    </item>
+  </channel>
+</rss>`
+
+func TestTorrentParse(t *testing.T) {
+	_, items, err := ForType("torrent").Parse([]byte(testNyaaFeed), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+
+	nyaa := items[0]
+	if nyaa.EnclosureURL != "https://nyaa.si/download/1234567.torrent" || nyaa.EnclosureType != "application/x-bittorrent" {
+		t.Errorf("unexpected enclosure %q (%q)", nyaa.EnclosureURL, nyaa.EnclosureType)
+	}
+	if nyaa.EnclosureLength != 1610612736 {
+		t.Errorf("expected nyaa:size as length, got %d", nyaa.EnclosureLength)
+	}
+	if nyaa.Link != "https://nyaa.si/view/1234567" {
+		t.Errorf("expected the page as link, got %q", nyaa.Link)
+	}
+	if !strings.HasPrefix(nyaa.MagnetURI, "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=") {
+		t.Errorf("expected a magnet link from the info hash, got %q", nyaa.MagnetURI)
+	}
+
+	magnet := items[1]
+	if magnet.EnclosureURL != magnet.MagnetURI || !strings.HasPrefix(magnet.MagnetURI, "magnet:?xt=urn:btih:fedcba") {
+		t.Errorf("expected the magnet link as enclosure, got %q / %q", magnet.EnclosureURL, magnet.MagnetURI)
+	}
+	if magnet.Link != "" || magnet.EnclosureLength != 734003200 {
+		t.Errorf("unexpected link %q or length %d", magnet.Link, magnet.EnclosureLength)
+	}
+}
+
+func TestTorrentMagnetSetting(t *testing.T) {
+	item := database.Item{ID: "1", Item: types.Item{
+		GUID:            "https://nyaa.si/view/1",
+		Title:           "Episode",
+		Link:            "https://nyaa.si/view/1",
+		PublishedAt:     time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC),
+		EnclosureURL:    "https://nyaa.si/download/1.torrent",
+		EnclosureLength: 100,
+		EnclosureType:   "application/x-bittorrent",
+		MagnetURI:       "magnet:?xt=urn:btih:abc",
+	}}
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC}
+
+	for magnet, want := range map[string][2]string{
+		"":          {"https://nyaa.si/view/1", "https://nyaa.si/download/1.torrent"},
+		"enclosure": {"https://nyaa.si/view/1", "magnet:?xt=urn:btih:abc"},
+		"link":      {"magnet:?xt=urn:btih:abc", "https://nyaa.si/download/1.torrent"},
+	} {
+		feed := database.Feed{Name: "tracker", FeedType: "torrent", Settings: []byte(`{"magnet":"` + magnet + `"}`)}
+		doc, err := assemble(torrentType{}, feed, []database.Item{item}, c, time.Now())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := doc.Items[0]
+		if got.Link != want[0] || got.Enclosure == nil || got.Enclosure.URL != want[1] {
+			t.Errorf("magnet %q: got link %q and enclosure %+v", magnet, got.Link, got.Enclosure)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Violation is a problem Validate found in a generated document.
+type Violation struct {
+	Path    string `json:"path"` // Element, e.g. "channel/item[3]/pubDate"
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+type validationDoc struct {
+	XMLName xml.Name
+	Version string `xml:"version,attr"`
+	Channel *struct {
+		Title         *string          `xml:"title"`
+		Links         []validationLink `xml:"link"` // Both <link> and <atom:link>
+		Description   *string          `xml:"description"`
+		Language      string           `xml:"language"`
+		PubDate       string           `xml:"pubDate"`
+		LastBuildDate string           `xml:"lastBuildDate"`
+		Image         *struct {
+			URL   string `xml:"url"`
+			Title string `xml:"title"`
+			Link  string `xml:"link"`
+		} `xml:"image"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+			GUID        *struct {
+				Value       string `xml:",chardata"`
+				IsPermaLink string `xml:"isPermaLink,attr"`
+			} `xml:"guid"`
+			Enclosures []struct {
+				URL    string `xml:"url,attr"`
+				Length string `xml:"length,attr"`
+				Type   string `xml:"type,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// validationLink is a channel <link> or <atom:link>. encoding/xml matches
+// an unqualified tag in any namespace, so both are decoded together and
+// told apart by XMLName.
+type validationLink struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+	Href    string `xml:"href,attr"`
+	Rel     string `xml:"rel,attr"`
+}
+
+// Validate checks an RSS 2.0 document against the rules feed validators
+// commonly enforce: required channel and item elements, RFC 822 dates,
+// absolute http(s) URLs, well-formed enclosures and language tags, and
+// unique GUIDs. It returns nil for a valid document.
+func Validate(document []byte) []Violation {
+	var doc validationDoc
+	if err := xml.Unmarshal(document, &doc); err != nil {
+		return []Violation{{Path: "/", Message: fmt.Sprintf("not well-formed XML: %v", err)}}
+	}
+
+	var violations []Violation
+	add := func(path, format string, args ...any) {
+		violations = append(violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if doc.XMLName.Local != "rss" {
+		add("/", "root element is <%s>, expected <rss>", doc.XMLName.Local)
+		return violations
+	}
+	if doc.Version != "2.0" {
+		add("rss", "version is %q, expected \"2.0\"", doc.Version)
+	}
+	ch := doc.Channel
+	if ch == nil {
+		add("rss", "missing <channel>")
+		return violations
+	}
+
+	if ch.Title == nil || strings.TrimSpace(*ch.Title) == "" {
+		add("channel/title", "required element is missing or empty")
+	}
+	if ch.Description == nil {
+		add("channel/description", "required element is missing")
+	}
+	var channelLink string
+	for _, link := range ch.Links {
+		switch {
+		case link.XMLName.Space == atomNamespace:
+			if link.Rel == "self" && !isAbsoluteURL(link.Href) {
+				add("channel/atom:link", "self link %q is not an absolute http(s) URL", link.Href)
+			}
+		case channelLink == "":
+			channelLink = strings.TrimSpace(link.Value)
+		}
+	}
+	if channelLink == "" {
+		add("channel/link", "required element is missing or empty")
+	} else if !isAbsoluteURL(channelLink) {
+		add("channel/link", "%q is not an absolute http(s) URL", channelLink)
+	}
+	if ch.Language != "" && NormalizeLanguage(ch.Language) != ch.Language {
+		add("channel/language", "%q is not a well-formed language tag", ch.Language)
+	}
+	checkDate(ch.PubDate, "channel/pubDate", add)
+	checkDate(ch.LastBuildDate, "channel/lastBuildDate", add)
+
+	if img := ch.Image; img != nil {
+		if img.URL == "" || img.Title == "" || img.Link == "" {
+			add("channel/image", "url, title and link are required")
+		}
+		if img.URL != "" && !isAbsoluteURL(img.URL) {
+			add("channel/image/url", "%q is not an absolute http(s) URL", img.URL)
+		}
+	}
+
+	guids := make(map[string]int)
+	for i, item := range ch.Items {
+		path := fmt.Sprintf("channel/item[%d]", i+1)
+
+		if strings.TrimSpace(item.Title) == "" && strings.TrimSpace(item.Description) == "" {
+			add(path, "either title or description is required")
+		}
+		if item.Link != "" && !isAbsoluteURL(item.Link) && !isMagnet(item.Link) {
+			add(path+"/link", "%q is not an absolute http(s) URL", item.Link)
+		}
+		checkDate(item.PubDate, path+"/pubDate", add)
+
+		if guid := item.GUID; guid != nil {
+			value := strings.TrimSpace(guid.Value)
+			switch {
+			case value == "":
+				add(path+"/guid", "empty guid")
+			case guid.IsPermaLink != "false" && !isAbsoluteURL(value):
+				add(path+"/guid", "%q is a permalink guid but not an absolute URL (add isPermaLink=\"false\")", value)
+			}
+			if first, seen := guids[value]; seen && value != "" {
+				add(path+"/guid", "duplicates the guid of item[%d]", first)
+			} else {
+				guids[value] = i + 1
+			}
+		}
+
+		if len(item.Enclosures) > 1 {
+			add(path, "has %d enclosures; most readers only use the first", len(item.Enclosures))
+		}
+		for _, enc := range item.Enclosures {
+			if !isAbsoluteURL(enc.URL) && !isMagnet(enc.URL) {
+				add(path+"/enclosure", "url %q is not an absolute http(s) URL", enc.URL)
+			}
+			if n, err := strconv.ParseInt(enc.Length, 10, 64); err != nil || n < 0 {
+				add(path+"/enclosure", "length %q is not a byte count", enc.Length)
+			}
+			if !strings.Contains(enc.Type, "/") {
+				add(path+"/enclosure", "type %q is not a MIME type", enc.Type)
+			}
+		}
+	}
+
+	return violations
+}
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+var rfc822Layouts = []string{time.RFC1123Z, time.RFC1123, time.RFC822Z, time.RFC822}
+
+func checkDate(value, path string, add func(path, format string, args ...any)) {
+	if value == "" {
+		return
+	}
+	for _, layout := range rfc822Layouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return
+		}
+	}
+	add(path, "%q is not an RFC 822 date", value)
+}
+
+func isAbsoluteURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isMagnet reports whether s is a magnet link, which torrent feeds publish
+// in place of a URL.
+func isMagnet(s string) bool {
+	return strings.HasPrefix(strings.TrimSpace(s), "magnet:?")
+}
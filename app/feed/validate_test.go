@@ -0,0 +1,136 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestValidate_BuiltFeeds(t *testing.T) {
+	c := &cfg.Cfg{Location: time.UTC}
+	items := []database.Item{
+		{
+			ID: "item-1",
+			Item: types.Item{
+				GUID: "https://example.com/a", Title: "A & B", Link: "https://example.com/a",
+				Description:  "<p>Body</p>",
+				EnclosureURL: "https://example.com/a.mp3", EnclosureType: "audio/mpeg", EnclosureLength: 10,
+				PublishedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			ID: "item-2",
+			Item: types.Item{
+				GUID: "tag:example.com,2024:b", Title: "B", Link: "https://example.com/b",
+				PublishedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	f := database.Feed{
+		Name: "test", Title: "Test", Link: "https://example.com", FeedURL: "https://example.com/feed.xml",
+		Language: "en-US", ImageURL: "https://example.com/logo.png",
+	}
+
+	for _, typ := range []string{"basic", "podcast", "youtube"} {
+		built, err := ForType(typ).Build(f, items, c)
+		if err != nil {
+			t.Fatalf("%s: build failed: %v", typ, err)
+		}
+		if violations := Validate([]byte(built)); len(violations) > 0 {
+			t.Errorf("%s: expected a valid document, got %v", typ, violations)
+		}
+	}
+}
+
+func TestValidate_Violations(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		path string
+		want string
+	}{
+		{
+			name: "malformed XML",
+			doc:  `<rss version="2.0"><channel>`,
+			path: "/",
+			want: "not well-formed",
+		},
+		{
+			name: "not RSS",
+			doc:  `<feed xmlns="http://www.w3.org/2005/Atom"/>`,
+			path: "/",
+			want: "expected <rss>",
+		},
+		{
+			name: "missing channel title",
+			doc:  `<rss version="2.0"><channel><link>https://example.com</link><description/></channel></rss>`,
+			path: "channel/title",
+			want: "missing",
+		},
+		{
+			name: "relative channel link",
+			doc:  `<rss version="2.0"><channel><title>T</title><link>/home</link><description/></channel></rss>`,
+			path: "channel/link",
+			want: "absolute",
+		},
+		{
+			name: "bad item date",
+			doc: `<rss version="2.0"><channel><title>T</title><link>https://example.com</link><description/>
+				<item><title>A</title><pubDate>2024-01-01T00:00:00Z</pubDate></item></channel></rss>`,
+			path: "channel/item[1]/pubDate",
+			want: "RFC 822",
+		},
+		{
+			name: "empty item",
+			doc: `<rss version="2.0"><channel><title>T</title><link>https://example.com</link><description/>
+				<item><link>https://example.com/a</link></item></channel></rss>`,
+			path: "channel/item[1]",
+			want: "title or description",
+		},
+		{
+			name: "permalink guid that is not a URL",
+			doc: `<rss version="2.0"><channel><title>T</title><link>https://example.com</link><description/>
+				<item><title>A</title><guid>abc-123</guid></item></channel></rss>`,
+			path: "channel/item[1]/guid",
+			want: "permalink",
+		},
+		{
+			name: "duplicate guid",
+			doc: `<rss version="2.0"><channel><title>T</title><link>https://example.com</link><description/>
+				<item><title>A</title><guid isPermaLink="false">x</guid></item>
+				<item><title>B</title><guid isPermaLink="false">x</guid></item></channel></rss>`,
+			path: "channel/item[2]/guid",
+			want: "item[1]",
+		},
+		{
+			name: "bad enclosure length",
+			doc: `<rss version="2.0"><channel><title>T</title><link>https://example.com</link><description/>
+				<item><title>A</title><enclosure url="https://example.com/a.mp3" length="" type="audio/mpeg"/></item></channel></rss>`,
+			path: "channel/item[1]/enclosure",
+			want: "length",
+		},
+		{
+			name: "bad language",
+			doc: `<rss version="2.0"><channel><title>T</title><link>https://example.com</link><description/>
+				<language>english please</language></channel></rss>`,
+			path: "channel/language",
+			want: "language tag",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := Validate([]byte(tt.doc))
+			for _, v := range violations {
+				if v.Path == tt.path && strings.Contains(v.Message, tt.want) {
+					return
+				}
+			}
+			t.Errorf("expected a violation at %s containing %q, got %v", tt.path, tt.want, violations)
+		})
+	}
+}
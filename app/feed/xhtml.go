@@ -0,0 +1,79 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// writeXHTML re-serializes an HTML fragment as well-formed XHTML for Atom's
+// xhtml content type. Stored descriptions are whatever the source sent, so
+// they are parsed the way a browser would and printed back with every
+// element closed. Comments, scripts and attributes that aren't valid XML
+// names are dropped; such elements are replaced by their children.
+func writeXHTML(w feedWriter, fragment string) {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		xml.EscapeText(w, []byte(fragment))
+		return
+	}
+	for _, node := range nodes {
+		writeXHTMLNode(w, node)
+	}
+}
+
+func writeXHTMLNode(w feedWriter, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		xml.EscapeText(w, []byte(node.Data))
+	case html.ElementNode:
+		if node.DataAtom == atom.Script {
+			return
+		}
+		if !isXMLName(node.Data) {
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				writeXHTMLNode(w, child)
+			}
+			return
+		}
+		w.WriteString("<" + node.Data)
+		for _, attr := range node.Attr {
+			if attr.Namespace != "" || !isXMLName(attr.Key) {
+				continue
+			}
+			w.WriteString(" " + attr.Key + "=\"")
+			xml.EscapeText(w, []byte(attr.Val))
+			w.WriteString("\"")
+		}
+		if node.FirstChild == nil {
+			w.WriteString("/>")
+			return
+		}
+		w.WriteString(">")
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			writeXHTMLNode(w, child)
+		}
+		w.WriteString("</" + node.Data + ">")
+	}
+}
+
+// isXMLName is a conservative check: an ASCII letter, then letters, digits,
+// '-', '_' or '.'. Prefixed names are rejected since the fragment declares
+// no namespaces.
+func isXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
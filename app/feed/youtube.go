@@ -1,9 +1,10 @@
 package feed
 
 import (
-	"bytes"
 	"fmt"
 	"html"
+	"io"
+	"time"
 
 	"github.com/lysyi3m/rss-comb/app/cfg"
 	"github.com/lysyi3m/rss-comb/app/database"
@@ -13,13 +14,13 @@ import (
 
 type youtubeType struct{}
 
-func (youtubeType) Parse(data []byte) (*Metadata, []types.Item, error) {
+func (youtubeType) Parse(data []byte, _ time.Time) (*Metadata, []types.Item, error) {
 	feed, err := parseWithGofeed(data)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	metadata := extractBaseMetadata(feed)
+	metadata := extractBaseMetadata(feed, data)
 
 	items := make([]types.Item, 0, len(feed.Items))
 	for _, item := range feed.Items {
@@ -73,33 +74,24 @@ func extractMediaThumbnail(item *gofeed.Item) string {
 	return ""
 }
 
-func (youtubeType) Build(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
-	var buf bytes.Buffer
-
-	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
-	buf.WriteString("\n")
-	buf.WriteString(`<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">`)
-	buf.WriteString("\n  <channel>\n")
-
-	writeChannelHeader(&buf, feed, items, cfg)
-	writeITunesFeedElements(&buf, feed)
+func (t youtubeType) Build(feed database.Feed, items []database.Item, cfg *cfg.Cfg) (string, error) {
+	return build(t, FormatRSS, feed, items, cfg)
+}
 
-	for _, item := range items {
-		writeBaseItem(&buf, item, cfg)
+func (t youtubeType) Write(w io.Writer, feed database.Feed, items []database.Item, cfg *cfg.Cfg) error {
+	return stream(t, FormatRSS, w, feed, items, cfg)
+}
 
-		if item.MediaPath != "" && item.MediaSize > 0 {
-			mediaURL := fmt.Sprintf("%s/media/%s", cfg.BaseUrl, item.MediaPath)
-			buf.WriteString(fmt.Sprintf("      <enclosure url=\"%s\" length=\"%d\" type=\"%s\" />\n",
-				html.EscapeString(mediaURL),
-				item.MediaSize,
-				"audio/mpeg"))
-		}
+func (youtubeType) itunes() bool { return true }
 
-		writeITunesItemElements(&buf, item)
-		buf.WriteString("    </item>\n")
+// enclosure points at the audio downloaded for the video.
+func (youtubeType) enclosure(item database.Item, cfg *cfg.Cfg) *Enclosure {
+	if item.MediaPath == "" || item.MediaSize <= 0 {
+		return nil
+	}
+	return &Enclosure{
+		URL:    fmt.Sprintf("%s/media/%s", cfg.BaseUrl, item.MediaPath),
+		Length: item.MediaSize,
+		Type:   "audio/mpeg",
 	}
-
-	buf.WriteString("  </channel>\n</rss>")
-
-	return buf.String(), nil
 }
@@ -1,6 +1,9 @@
 package feed
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestYouTubeParse_AtomFeed(t *testing.T) {
 	youtubeAtom := `<?xml version="1.0" encoding="UTF-8"?>
@@ -38,7 +41,7 @@ func TestYouTubeParse_AtomFeed(t *testing.T) {
 </feed>`
 
 	yt := youtubeType{}
-	metadata, items, err := yt.Parse([]byte(youtubeAtom))
+	metadata, items, err := yt.Parse([]byte(youtubeAtom), time.Now())
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -91,7 +94,7 @@ func TestYouTubeParse_StandardDescriptionTakesPrecedence(t *testing.T) {
 </feed>`
 
 	yt := youtubeType{}
-	_, items, err := yt.Parse([]byte(atomWithSummary))
+	_, items, err := yt.Parse([]byte(atomWithSummary), time.Now())
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -119,7 +122,7 @@ func TestYouTubeParse_FeedImageNotOverriddenWhenPresent(t *testing.T) {
 </feed>`
 
 	yt := youtubeType{}
-	metadata, _, err := yt.Parse([]byte(atomWithImage))
+	metadata, _, err := yt.Parse([]byte(atomWithImage), time.Now())
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -0,0 +1,112 @@
+// Package feedgen produces synthetic RSS feeds for benchmarks and load
+// tests. Output is deterministic: the same options always yield the same
+// document, and each item depends only on the seed and its index, so a
+// feed whose newest index advances looks like a real feed gaining items.
+package feedgen
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Epoch is the publish time of item index 0.
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type Options struct {
+	Items    int           // number of items in the document
+	Newest   int64         // index of the first (newest) item; items count down from it
+	Seed     uint64        // varies titles and text between feeds
+	Interval time.Duration // publish time step between consecutive indexes
+	Words    int           // approximate description length in words
+	Podcast  bool          // add enclosures and iTunes elements
+}
+
+func (o Options) withDefaults() Options {
+	if o.Newest == 0 {
+		o.Newest = int64(o.Items)
+	}
+	if o.Interval <= 0 {
+		o.Interval = time.Hour
+	}
+	if o.Words <= 0 {
+		o.Words = 120
+	}
+	return o
+}
+
+var vocabulary = strings.Fields(`
+	release update security patch kernel browser compiler database cluster
+	network storage latency throughput outage incident report review guide
+	tutorial benchmark migration upgrade feature preview beta stable version
+	open source community conference keynote interview podcast episode video
+	analysis deep dive roadmap changelog announcement sponsored weekly digest
+	rust go python javascript linux windows android cloud edge cache queue`)
+
+var categories = []string{"News", "Tech", "Security", "Programming", "Ops", "Science", "Culture"}
+
+// Generate renders an RSS 2.0 document for o.
+func Generate(o Options) []byte {
+	o = o.withDefaults()
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	buf.WriteString(`<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">` + "\n<channel>\n")
+	fmt.Fprintf(&buf, "<title>Synthetic feed %d</title>\n", o.Seed)
+	fmt.Fprintf(&buf, "<link>https://synthetic.example/%d</link>\n", o.Seed)
+	buf.WriteString("<description>Generated for benchmarking</description>\n<language>en</language>\n")
+	if o.Podcast {
+		buf.WriteString("<itunes:author>Synthetic</itunes:author>\n<itunes:explicit>false</itunes:explicit>\n")
+	}
+
+	for i := range o.Items {
+		writeItem(&buf, o, o.Newest-int64(i))
+	}
+
+	buf.WriteString("</channel>\n</rss>\n")
+	return buf.Bytes()
+}
+
+func writeItem(buf *bytes.Buffer, o Options, index int64) {
+	r := rand.New(rand.NewPCG(o.Seed, uint64(index)))
+	link := fmt.Sprintf("https://synthetic.example/%d/items/%d", o.Seed, index)
+
+	buf.WriteString("<item>\n")
+	buf.WriteString("<title>")
+	xml.EscapeText(buf, []byte(sentence(r, 4+r.IntN(8))))
+	buf.WriteString("</title>\n")
+	fmt.Fprintf(buf, "<link>%s</link>\n<guid isPermaLink=\"true\">%s</guid>\n", link, link)
+	fmt.Fprintf(buf, "<pubDate>%s</pubDate>\n", Epoch.Add(time.Duration(index)*o.Interval).Format(time.RFC1123Z))
+	for range 1 + r.IntN(3) {
+		fmt.Fprintf(buf, "<category>%s</category>\n", categories[r.IntN(len(categories))])
+	}
+
+	var desc strings.Builder
+	for remaining := o.Words; remaining > 0; remaining -= 40 {
+		desc.WriteString("<p>")
+		desc.WriteString(sentence(r, min(remaining, 40)))
+		desc.WriteString("</p>")
+	}
+	buf.WriteString("<description>")
+	xml.EscapeText(buf, []byte(desc.String()))
+	buf.WriteString("</description>\n")
+
+	if o.Podcast {
+		fmt.Fprintf(buf, "<enclosure url=\"https://synthetic.example/%d/audio/%d.mp3\" length=\"%d\" type=\"audio/mpeg\"/>\n",
+			o.Seed, index, 1_000_000+r.IntN(50_000_000))
+		fmt.Fprintf(buf, "<itunes:duration>%d</itunes:duration>\n<itunes:episode>%d</itunes:episode>\n", 600+r.IntN(5400), index)
+	}
+	buf.WriteString("</item>\n")
+}
+
+func sentence(r *rand.Rand, words int) string {
+	parts := make([]string, words)
+	for i := range parts {
+		parts[i] = vocabulary[r.IntN(len(vocabulary))]
+	}
+	parts[0] = strings.ToUpper(parts[0][:1]) + parts[0][1:]
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,35 @@
+package feedgen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGenerate_Deterministic(t *testing.T) {
+	opts := Options{Items: 20, Seed: 7}
+	if !bytes.Equal(Generate(opts), Generate(opts)) {
+		t.Error("expected identical output for identical options")
+	}
+
+	other := Generate(Options{Items: 20, Seed: 8})
+	if bytes.Equal(Generate(opts), other) {
+		t.Error("expected different output for a different seed")
+	}
+}
+
+func TestGenerate_AdvancingNewest(t *testing.T) {
+	before := string(Generate(Options{Items: 5, Newest: 100, Seed: 1}))
+	after := string(Generate(Options{Items: 5, Newest: 101, Seed: 1}))
+
+	// Advancing by one adds item 101 on top and drops item 96; the
+	// overlapping items render identically.
+	if strings.Contains(before, "/items/101<") || !strings.Contains(after, "/items/101<") {
+		t.Error("expected item 101 only in the advanced feed")
+	}
+	start := strings.Index(before, "<item>")
+	end := strings.LastIndex(before, "<item>")
+	if !strings.Contains(after, before[start:end]) {
+		t.Error("expected shared items to be unchanged")
+	}
+}
@@ -0,0 +1,216 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/storage"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// Archiver periodically renders the stored items of feeds with the archive
+// setting into a static HTML site: index.html, <feed>/index.html and
+// <feed>/<item-id>.html.
+type Archiver struct {
+	dir      string
+	target   *storage.DirTarget
+	feedRepo *database.FeedRepository
+	itemRepo *database.ItemRepository
+	location *time.Location
+	clock    types.Clock
+}
+
+func NewArchiver(dir string, feedRepo *database.FeedRepository, itemRepo *database.ItemRepository, location *time.Location, clock types.Clock) (*Archiver, error) {
+	target, err := storage.NewDirTarget(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Archiver{
+		dir:      dir,
+		target:   target,
+		feedRepo: feedRepo,
+		itemRepo: itemRepo,
+		location: location,
+		clock:    clock,
+	}, nil
+}
+
+// Run exports the archive right away and then every interval until ctx is
+// cancelled.
+func (a *Archiver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.Export(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Archive export failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Export renders the whole archive and writes the pages that changed.
+// Pages of items that are no longer stored or visible are removed, as are
+// the directories of feeds that are no longer archived.
+func (a *Archiver) Export(ctx context.Context) error {
+	start := time.Now()
+
+	feeds, err := a.feedRepo.GetAllFeeds(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get feeds: %w", err)
+	}
+
+	var archived []feed.ArchiveFeed
+	keep := map[string]bool{}
+	pages := 0
+	for _, dbFeed := range feeds {
+		settings, err := dbFeed.GetSettings()
+		if err != nil || !settings.Archive || !dbFeed.IsEnabled {
+			continue
+		}
+
+		archiveFeed, written, err := a.exportFeed(ctx, &dbFeed, settings)
+		if err != nil {
+			return fmt.Errorf("[%s] %w", dbFeed.Name, err)
+		}
+		archived = append(archived, archiveFeed)
+		keep[dbFeed.Name] = true
+		pages += written
+	}
+
+	var buf bytes.Buffer
+	if err := feed.WriteArchiveIndex(&buf, archived); err != nil {
+		return fmt.Errorf("failed to render archive index: %w", err)
+	}
+	written, err := a.publish(ctx, "index.html", buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if written {
+		pages++
+	}
+	a.pruneFeeds(keep)
+
+	slog.Info("Archive exported", "feeds", len(archived), "pages", pages, "duration", time.Since(start))
+	return nil
+}
+
+func (a *Archiver) exportFeed(ctx context.Context, dbFeed *database.Feed, settings *types.Settings) (feed.ArchiveFeed, int, error) {
+	// The archive keeps every item the feed serves, not only the newest
+	// max_items, and each item of a series.
+	visible := *settings
+	visible.MaxItems = math.MaxInt32
+	visible.CollapseSeries = false
+	stored, err := a.itemRepo.GetVisibleItems(ctx, dbFeed.ID, &visible, a.clock.Now())
+	if err != nil {
+		return feed.ArchiveFeed{}, 0, err
+	}
+
+	items := make([]feed.ArchiveItem, 0, len(stored))
+	keep := map[string]bool{"index.html": true}
+	for _, item := range stored {
+		items = append(items, feed.NewArchiveItem(item, a.location))
+		keep[item.ID+".html"] = true
+	}
+
+	archiveFeed := feed.ArchiveFeed{
+		Name:        dbFeed.Name,
+		Title:       dbFeed.DisplayTitle(),
+		Description: dbFeed.DisplayDescription(),
+		Link:        dbFeed.Link,
+		ItemCount:   len(items),
+	}
+
+	pages := 0
+	var buf bytes.Buffer
+	for _, item := range items {
+		buf.Reset()
+		if err := feed.WriteArchiveItem(&buf, archiveFeed, item); err != nil {
+			return archiveFeed, 0, fmt.Errorf("failed to render item %s: %w", item.ID, err)
+		}
+		written, err := a.publish(ctx, dbFeed.Name+"/"+item.ID+".html", buf.Bytes())
+		if err != nil {
+			return archiveFeed, 0, err
+		}
+		if written {
+			pages++
+		}
+	}
+
+	buf.Reset()
+	if err := feed.WriteArchiveFeed(&buf, archiveFeed, items); err != nil {
+		return archiveFeed, 0, fmt.Errorf("failed to render feed page: %w", err)
+	}
+	written, err := a.publish(ctx, dbFeed.Name+"/index.html", buf.Bytes())
+	if err != nil {
+		return archiveFeed, 0, err
+	}
+	if written {
+		pages++
+	}
+
+	a.prune(filepath.Join(a.dir, dbFeed.Name), keep)
+	return archiveFeed, pages, nil
+}
+
+// publish writes a page unless the archive already holds the same bytes,
+// so unchanged pages keep their modification time and aren't re-synced by
+// tools mirroring the directory. It reports whether the page was written.
+func (a *Archiver) publish(ctx context.Context, key string, body []byte) (bool, error) {
+	if current, err := os.ReadFile(filepath.Join(a.dir, filepath.FromSlash(key))); err == nil && bytes.Equal(current, body) {
+		return false, nil
+	}
+	if err := a.target.Publish(ctx, key, body, "text/html; charset=utf-8"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// pruneFeeds removes the directories of feeds that are no longer archived:
+// removed, disabled or without the archive setting.
+func (a *Archiver) pruneFeeds(keep map[string]bool) {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || keep[entry.Name()] || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(a.dir, entry.Name())); err != nil {
+			slog.Warn("Failed to remove archived feed", "path", filepath.Join(a.dir, entry.Name()), "error", err)
+		}
+	}
+}
+
+// prune removes pages of items deleted by cleanup or filtered since the
+// last export.
+func (a *Archiver) prune(dir string, keep map[string]bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".html") || keep[name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			slog.Warn("Failed to remove archived page", "path", filepath.Join(dir, name), "error", err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchiver_PublishUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewArchiver(dir, nil, nil, time.UTC, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if written, err := a.publish(ctx, "news/a.html", []byte("<p>A</p>")); err != nil || !written {
+		t.Fatalf("expected a new page to be written, got %v, %v", written, err)
+	}
+	path := filepath.Join(dir, "news", "a.html")
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if written, err := a.publish(ctx, "news/a.html", []byte("<p>A</p>")); err != nil || written {
+		t.Errorf("expected an unchanged page to be skipped, got %v, %v", written, err)
+	}
+	if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(old) {
+		t.Errorf("expected the page to be left alone, got %v", err)
+	}
+
+	if written, err := a.publish(ctx, "news/a.html", []byte("<p>A, edited</p>")); err != nil || !written {
+		t.Errorf("expected a changed page to be written, got %v, %v", written, err)
+	}
+}
+
+func TestArchiver_Prune(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewArchiver(dir, nil, nil, time.UTC, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"index.html", "news/index.html", "news/a.html", "news/gone.html", "dropped/index.html"} {
+		if _, err := a.publish(context.Background(), key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a.prune(filepath.Join(dir, "news"), map[string]bool{"index.html": true, "a.html": true})
+	a.pruneFeeds(map[string]bool{"news": true})
+
+	for path, want := range map[string]bool{
+		"index.html":      true,
+		"news/index.html": true,
+		"news/a.html":     true,
+		"news/gone.html":  false,
+		"dropped":         false,
+	} {
+		if _, err := os.Stat(filepath.Join(dir, path)); (err == nil) != want {
+			t.Errorf("%s: expected exists = %v, got %v", path, want, err)
+		}
+	}
+}
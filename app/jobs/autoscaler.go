@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+)
+
+const (
+	autoscaleInterval  = 10 * time.Second
+	backlogPerWorker   = 2 // ready jobs per worker before scaling up
+	idleChecksToShrink = 3 // consecutive empty-queue checks before removing a worker
+)
+
+// Autoscaler resizes a WorkerPool between min and max workers based on the
+// number of ready jobs. It is disabled while max <= min.
+type Autoscaler struct {
+	pool    *WorkerPool
+	jobRepo *database.JobRepository
+
+	mu         sync.Mutex
+	min, max   int
+	idleChecks int
+
+	queueDepth atomic.Int64
+	scaleUps   atomic.Int64
+	scaleDowns atomic.Int64
+}
+
+type AutoscalerStats struct {
+	Enabled    bool
+	Min        int
+	Max        int
+	QueueDepth int
+	ScaleUps   int
+	ScaleDowns int
+}
+
+func NewAutoscaler(pool *WorkerPool, jobRepo *database.JobRepository, minWorkers, maxWorkers int) *Autoscaler {
+	return &Autoscaler{
+		pool:    pool,
+		jobRepo: jobRepo,
+		min:     minWorkers,
+		max:     maxWorkers,
+	}
+}
+
+// SetBounds changes the worker range; the pool is brought within it on the
+// next check.
+func (a *Autoscaler) SetBounds(minWorkers, maxWorkers int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.min, a.max = minWorkers, maxWorkers
+	a.idleChecks = 0
+}
+
+func (a *Autoscaler) Stats() AutoscalerStats {
+	a.mu.Lock()
+	minWorkers, maxWorkers := a.min, a.max
+	a.mu.Unlock()
+
+	return AutoscalerStats{
+		Enabled:    maxWorkers > minWorkers,
+		Min:        minWorkers,
+		Max:        maxWorkers,
+		QueueDepth: int(a.queueDepth.Load()),
+		ScaleUps:   int(a.scaleUps.Load()),
+		ScaleDowns: int(a.scaleDowns.Load()),
+	}
+}
+
+// Run checks the queue periodically until ctx is cancelled.
+func (a *Autoscaler) Run(ctx context.Context) {
+	ticker := time.NewTicker(autoscaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.check(ctx)
+		}
+	}
+}
+
+func (a *Autoscaler) check(ctx context.Context) {
+	depth, err := a.jobRepo.CountReadyJobs(ctx, a.pool.clock.Now())
+	if err != nil {
+		slog.Error("Autoscaler failed to count ready jobs", "error", err)
+		return
+	}
+	a.queueDepth.Store(int64(depth))
+	a.scale(depth)
+}
+
+// scale resizes the pool for depth ready jobs.
+func (a *Autoscaler) scale(depth int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Nothing is claimed during maintenance, so the backlog says nothing about load.
+	if a.max <= a.min || a.pool.maintenance.Enabled() {
+		return
+	}
+
+	size := a.pool.Size()
+	target := size
+
+	// Enough workers to keep the busy ones going plus drain the backlog.
+	wanted := a.pool.Busy() + (depth+backlogPerWorker-1)/backlogPerWorker
+	switch {
+	case wanted > size:
+		target = wanted
+		a.idleChecks = 0
+	case depth == 0:
+		a.idleChecks++
+		if a.idleChecks >= idleChecksToShrink {
+			target = size - 1
+			a.idleChecks = 0
+		}
+	default:
+		a.idleChecks = 0
+	}
+	target = max(a.min, min(a.max, target))
+
+	if target == size {
+		return
+	}
+	if target > size {
+		a.scaleUps.Add(1)
+	} else {
+		a.scaleDowns.Add(1)
+	}
+	slog.Info("Autoscaling worker pool", "from", size, "to", target, "queue_depth", depth)
+	a.pool.Resize(target)
+}
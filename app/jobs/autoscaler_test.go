@@ -0,0 +1,46 @@
+package jobs
+
+import "testing"
+
+func TestAutoscalerScale(t *testing.T) {
+	pool := NewWorkerPool(nil, &Maintenance{}, 2, 0, nil)
+	a := NewAutoscaler(pool, nil, 2, 6)
+
+	// Two busy workers and five ready jobs want 2 + ceil(5/2) workers.
+	pool.busy.Store(2)
+	a.scale(5)
+	if pool.Size() != 5 {
+		t.Errorf("expected 5 workers, got %d", pool.Size())
+	}
+
+	a.scale(20)
+	if pool.Size() != 6 {
+		t.Errorf("expected the maximum of 6 workers, got %d", pool.Size())
+	}
+
+	// An empty queue shrinks the pool by one after idleChecksToShrink checks.
+	pool.busy.Store(0)
+	for range idleChecksToShrink - 1 {
+		a.scale(0)
+	}
+	if pool.Size() != 6 {
+		t.Errorf("expected no shrinking before %d idle checks, got %d workers", idleChecksToShrink, pool.Size())
+	}
+	a.scale(0)
+	if pool.Size() != 5 {
+		t.Errorf("expected one worker fewer, got %d", pool.Size())
+	}
+
+	a.SetBounds(3, 3)
+	a.scale(20)
+	if pool.Size() != 5 || a.Stats().Enabled {
+		t.Errorf("expected a disabled autoscaler to leave the pool alone, got %d workers", pool.Size())
+	}
+
+	a.SetBounds(2, 6)
+	a.pool.maintenance.enabled.Store(true)
+	a.scale(20)
+	if pool.Size() != 5 {
+		t.Errorf("expected no scaling during maintenance, got %d workers", pool.Size())
+	}
+}
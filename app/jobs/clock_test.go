@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestNilClockDefaultsToSystem(t *testing.T) {
+	scheduler := NewScheduler(time.Minute, nil, 0, nil, nil, nil, nil, nil)
+	pool := NewWorkerPool(nil, nil, 1, 0, nil)
+	for name, clock := range map[string]types.Clock{"scheduler": scheduler.clock, "worker pool": pool.clock} {
+		if _, ok := clock.(types.SystemClock); !ok {
+			t.Errorf("%s: expected the system clock, got %T", name, clock)
+		}
+	}
+
+	fixed := types.FixedClock(time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC))
+	if got := NewScheduler(time.Minute, nil, 0, nil, nil, nil, nil, fixed).clock; got != fixed {
+		t.Errorf("expected the given clock to be kept, got %v", got)
+	}
+}
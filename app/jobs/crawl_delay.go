@@ -0,0 +1,124 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+	"golang.org/x/net/publicsuffix"
+)
+
+// CrawlLimiter spaces out article fetches to the same site across all
+// workers, so a feed full of links to one publisher doesn't hit it with a
+// burst of parallel requests. Subdomains count as the same site.
+type CrawlLimiter struct {
+	delay     time.Duration
+	overrides map[string]time.Duration
+	clock     types.Clock
+
+	mu   sync.Mutex
+	next map[string]time.Time // Earliest time the next fetch may start, per site
+}
+
+// NewCrawlLimiter creates a limiter with a default delay and per-domain
+// overrides written as "example.com=5s,other.org=0s". Slots are spaced in
+// real time, as they pace real requests; a rescheduled job's run_after is
+// set on clock, the one jobs are claimed by (nil = system clock).
+func NewCrawlLimiter(delay time.Duration, overrides string, clock types.Clock) (*CrawlLimiter, error) {
+	l := &CrawlLimiter{
+		delay:     delay,
+		overrides: make(map[string]time.Duration),
+		clock:     types.ClockOrSystem(clock),
+		next:      make(map[string]time.Time),
+	}
+
+	for _, entry := range strings.Split(overrides, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		domain, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid crawl delay %q (expected domain=duration)", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid crawl delay for %s: %q", domain, value)
+		}
+		l.overrides[siteKey(domain)] = d
+	}
+
+	return l, nil
+}
+
+// maxCrawlWait is the longest a job waits for its slot; with a longer queue
+// the job is rescheduled instead of holding a worker.
+const maxCrawlWait = 30 * time.Second
+
+// Wait blocks until a fetch of rawURL may start, reserving the slot so that
+// concurrent callers for the same site queue up behind each other. When the
+// slot is further away than maxCrawlWait it returns a *RescheduleError
+// without reserving it.
+func (l *CrawlLimiter) Wait(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+	site := siteKey(u.Hostname())
+
+	delay, ok := l.overrides[site]
+	if !ok {
+		delay = l.delay
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	start := now
+	if next := l.next[site]; next.After(start) {
+		start = next
+	}
+	if start.Sub(now) > maxCrawlWait {
+		l.mu.Unlock()
+		return &RescheduleError{RunAfter: l.clock.Now().Add(start.Sub(now)), Reason: "crawl delay for " + site}
+	}
+	l.next[site] = start.Add(delay)
+	// Forget sites whose slots have passed, so the map doesn't grow forever.
+	if len(l.next) > 1000 {
+		for key, next := range l.next {
+			if next.Before(now) {
+				delete(l.next, key)
+			}
+		}
+	}
+	l.mu.Unlock()
+
+	wait := start.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// siteKey groups hosts by registrable domain: news.example.co.uk and
+// www.example.co.uk are both example.co.uk.
+func siteKey(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if site, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return site
+	}
+	return host
+}
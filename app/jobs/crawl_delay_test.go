@@ -0,0 +1,125 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestNewCrawlLimiter_Overrides(t *testing.T) {
+	l, err := NewCrawlLimiter(time.Second, " example.com=5s, news.other.org = 0s ,", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l.overrides["example.com"] != 5*time.Second {
+		t.Errorf("expected 5s for example.com, got %v", l.overrides["example.com"])
+	}
+	if d, ok := l.overrides["other.org"]; !ok || d != 0 {
+		t.Errorf("expected the override to apply to the site other.org, got %v (%v)", d, ok)
+	}
+
+	for _, overrides := range []string{"example.com", "example.com=soon", "example.com=-1s"} {
+		if _, err := NewCrawlLimiter(time.Second, overrides, nil); err == nil {
+			t.Errorf("expected an error for %q", overrides)
+		}
+	}
+}
+
+func TestSiteKey(t *testing.T) {
+	for host, want := range map[string]string{
+		"news.example.co.uk": "example.co.uk",
+		"WWW.Example.com.":   "example.com",
+		"example.com":        "example.com",
+		"localhost":          "localhost",
+		"192.168.1.10":       "192.168.1.10",
+	} {
+		if got := siteKey(host); got != want {
+			t.Errorf("siteKey(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestCrawlLimiter_SpacesFetchesPerSite(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	l, err := NewCrawlLimiter(delay, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	start := time.Now()
+	if err := l.Wait(ctx, "https://a.example.com/1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Wait(ctx, "https://other.org/1"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= delay {
+		t.Errorf("expected different sites not to wait, took %v", elapsed)
+	}
+
+	if err := l.Wait(ctx, "https://b.example.com/2"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("expected subdomains of one site to be spaced by %v, took %v", delay, elapsed)
+	}
+}
+
+func TestCrawlLimiter_ReschedulesPastMaxWait(t *testing.T) {
+	// Reschedules are set on the injected clock, not the wall clock.
+	clock := types.FixedClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	l, err := NewCrawlLimiter(time.Millisecond, "slow.example=20s,fast.example=0s", clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// 20s slots: the first fetch starts now and the second reserves the slot
+	// in 20s (its worker gives up waiting here), so a third would wait 40s,
+	// past maxCrawlWait.
+	if err := l.Wait(ctx, "https://slow.example/1"); err != nil {
+		t.Fatal(err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.Wait(cancelled, "https://news.slow.example/2"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the second fetch to wait for its slot, got %v", err)
+	}
+
+	var reschedule *RescheduleError
+	err = l.Wait(ctx, "https://www.slow.example/3")
+	if !errors.As(err, &reschedule) || reschedule.RunAfter.Sub(clock.Now()) < maxCrawlWait || reschedule.RunAfter.Sub(clock.Now()) > 40*time.Second {
+		t.Fatalf("expected a reschedule past %v on the clock, got %v", maxCrawlWait, err)
+	}
+	runAfter := reschedule.RunAfter
+	if err := l.Wait(ctx, "https://slow.example/4"); !errors.As(err, &reschedule) || reschedule.RunAfter.Sub(runAfter) > time.Second {
+		t.Errorf("expected the rescheduled job not to reserve a slot, got %v", err)
+	}
+
+	// A zero override disables the delay for its site.
+	for range 3 {
+		if err := l.Wait(ctx, "https://fast.example/"); err != nil {
+			t.Errorf("expected no delay, got %v", err)
+		}
+	}
+}
+
+func TestCrawlLimiter_WaitHonoursContext(t *testing.T) {
+	l, err := NewCrawlLimiter(20*time.Second, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Wait(context.Background(), "https://example.com/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "https://example.com/2"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to end with the context, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// DNSCache resolves hostnames for the HTTP client and keeps the answers for
+// a fixed TTL, so refreshing hundreds of feeds per interval doesn't query
+// the resolver for the same hosts over and over. Failed lookups aren't
+// cached, and concurrent lookups of a host share one query.
+type DNSCache struct {
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	dialer     *net.Dialer
+	ttl        time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]dnsEntry
+	inflight map[string]*dnsLookup
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// dnsLookup is a query in progress; dials of the host arriving meanwhile
+// wait for its answer instead of sending their own.
+type dnsLookup struct {
+	done  chan struct{}
+	addrs []string
+	err   error
+}
+
+// dnsLookupTimeout bounds a shared lookup, which doesn't end with the dial
+// that started it.
+const dnsLookupTimeout = 15 * time.Second
+
+// fallbackDelay is how long a dial tries the addresses of the first
+// address's family before racing those of the other family, as
+// net.Dialer's Happy Eyeballs (RFC 6555) does.
+const fallbackDelay = 300 * time.Millisecond
+
+// NewDNSCache creates a cache with the given TTL. server ("host:port") sends
+// all lookups to that DNS server instead of the system resolver.
+func NewDNSCache(ttl time.Duration, server string) (*DNSCache, error) {
+	resolver := net.DefaultResolver
+	if server != "" {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			return nil, fmt.Errorf("invalid DNS server %q (expected host:port): %w", server, err)
+		}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	return &DNSCache{
+		lookupHost: resolver.LookupHost,
+		dialer:     &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		ttl:        ttl,
+		entries:    make(map[string]dnsEntry),
+		inflight:   make(map[string]*dnsLookup),
+	}, nil
+}
+
+// DialContext implements http.Transport.DialContext with the host's cached
+// addresses. Like net.Dialer, it tries the addresses of one family in
+// order and races the other family after fallbackDelay, so a host with a
+// broken IPv6 route doesn't wait out the dial timeout on every request.
+func (c *DNSCache) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return c.dialer.DialContext(ctx, network, address)
+	}
+
+	addrs, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	primaries, fallbacks := partitionAddrs(network, addrs)
+	if len(primaries) == 0 {
+		return nil, &net.DNSError{Err: "no suitable address found", Name: host, IsNotFound: true}
+	}
+	return c.dialParallel(ctx, network, port, primaries, fallbacks)
+}
+
+// partitionAddrs splits addrs into those of the first address's family and
+// the others, keeping only the families network allows.
+func partitionAddrs(network string, addrs []string) (primaries, fallbacks []string) {
+	var primaryIPv4 bool
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+		isIPv4 := ip.To4() != nil
+		if (network == "tcp4" && !isIPv4) || (network == "tcp6" && isIPv4) {
+			continue
+		}
+		if len(primaries) == 0 {
+			primaryIPv4 = isIPv4
+		}
+		if isIPv4 == primaryIPv4 {
+			primaries = append(primaries, addr)
+		} else {
+			fallbacks = append(fallbacks, addr)
+		}
+	}
+	return primaries, fallbacks
+}
+
+// dialParallel dials primaries and, once they failed or fallbackDelay
+// passed, fallbacks alongside, returning the first connection made.
+func (c *DNSCache) dialParallel(ctx context.Context, network, port string, primaries, fallbacks []string) (net.Conn, error) {
+	if len(fallbacks) == 0 {
+		return c.dialSerial(ctx, network, port, primaries)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type dialResult struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan dialResult, 2)
+	dial := func(addrs []string) {
+		conn, err := c.dialSerial(ctx, network, port, addrs)
+		results <- dialResult{conn, err}
+	}
+
+	go dial(primaries)
+	timer := time.NewTimer(fallbackDelay)
+	defer timer.Stop()
+
+	pending, fallbackStarted := 1, false
+	startFallback := func() {
+		if !fallbackStarted {
+			fallbackStarted = true
+			pending++
+			go dial(fallbacks)
+		}
+	}
+
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			startFallback()
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				// The losing dial is cancelled; close its connection should
+				// it have been made anyway.
+				if pending > 0 {
+					go func() {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}()
+				}
+				return res.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			startFallback()
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
+
+// dialSerial tries addrs in order and returns the first connection made.
+func (c *DNSCache) dialSerial(ctx context.Context, network, port string, addrs []string) (net.Conn, error) {
+	var firstErr error
+	for _, addr := range addrs {
+		conn, err := c.dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+func (c *DNSCache) lookup(ctx context.Context, host string) ([]string, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	if entry, ok := c.entries[host]; ok && now.Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.addrs, nil
+	}
+	call, ok := c.inflight[host]
+	if !ok {
+		call = &dnsLookup{done: make(chan struct{})}
+		c.inflight[host] = call
+		go c.resolve(context.WithoutCancel(ctx), host, call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.addrs, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resolve runs a shared lookup of host and caches a successful answer.
+func (c *DNSCache) resolve(ctx context.Context, host string, call *dnsLookup) {
+	ctx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+	defer cancel()
+
+	addrs, err := c.lookupHost(ctx, host)
+
+	c.mu.Lock()
+	delete(c.inflight, host)
+	call.addrs, call.err = addrs, err
+	if err == nil {
+		now := time.Now()
+		c.entries[host] = dnsEntry{addrs: addrs, expires: now.Add(c.ttl)}
+		// Forget expired hosts, so the map doesn't grow forever.
+		if len(c.entries) > 1000 {
+			for key, e := range c.entries {
+				if e.expires.Before(now) {
+					delete(c.entries, key)
+				}
+			}
+		}
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubDNSCache returns a cache answering lookups of any host with addrs,
+// counting them in lookups; release, when not nil, holds each lookup until
+// it is closed.
+func stubDNSCache(t *testing.T, addrs []string, lookups *atomic.Int32, release chan struct{}) *DNSCache {
+	t.Helper()
+
+	c, err := NewDNSCache(time.Minute, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		lookups.Add(1)
+		if release != nil {
+			<-release
+		}
+		if addrs == nil {
+			return nil, errors.New("no such host")
+		}
+		return addrs, nil
+	}
+	return c
+}
+
+// listen accepts connections on 127.0.0.1 until the test ends and returns
+// the port.
+func listen(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	return port
+}
+
+func TestDNSCache_CachesLookups(t *testing.T) {
+	port := listen(t)
+	var lookups atomic.Int32
+	c := stubDNSCache(t, []string{"127.0.0.1"}, &lookups, nil)
+
+	for range 3 {
+		conn, err := c.DialContext(context.Background(), "tcp", net.JoinHostPort("feeds.example", port))
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("expected one lookup for three dials, got %d", n)
+	}
+}
+
+func TestDNSCache_SharesConcurrentLookups(t *testing.T) {
+	var lookups atomic.Int32
+	release := make(chan struct{})
+	c := stubDNSCache(t, []string{"127.0.0.1"}, &lookups, release)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.lookup(context.Background(), "feeds.example"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("expected concurrent misses to share one lookup, got %d", n)
+	}
+}
+
+func TestDNSCache_FailuresNotCached(t *testing.T) {
+	var lookups atomic.Int32
+	c := stubDNSCache(t, nil, &lookups, nil)
+
+	for range 2 {
+		if _, err := c.lookup(context.Background(), "feeds.example"); err == nil {
+			t.Fatal("expected the lookup to fail")
+		}
+	}
+	if n := lookups.Load(); n != 2 {
+		t.Errorf("expected a failed lookup to be retried, got %d lookups", n)
+	}
+}
+
+func TestDNSCache_CancelledWaiter(t *testing.T) {
+	var lookups atomic.Int32
+	release := make(chan struct{})
+	defer close(release)
+	c := stubDNSCache(t, []string{"127.0.0.1"}, &lookups, release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.lookup(ctx, "feeds.example"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the dial's deadline to end its wait, got %v", err)
+	}
+}
+
+func TestDNSCache_FallsBack(t *testing.T) {
+	port := listen(t)
+	var lookups atomic.Int32
+
+	// Nothing listens on 127.0.0.2 or on ::1, so the dial moves on to the
+	// next address and to the other family.
+	for _, addrs := range [][]string{{"127.0.0.2", "127.0.0.1"}, {"::1", "127.0.0.1"}} {
+		c := stubDNSCache(t, addrs, &lookups, nil)
+		conn, err := c.DialContext(context.Background(), "tcp", net.JoinHostPort("feeds.example", port))
+		if err != nil {
+			t.Errorf("%v: expected a connection to 127.0.0.1, got %v", addrs, err)
+			continue
+		}
+		conn.Close()
+	}
+}
+
+func TestPartitionAddrs(t *testing.T) {
+	addrs := []string{"2001:db8::1", "192.0.2.1", "2001:db8::2", "192.0.2.2"}
+	tests := []struct {
+		network              string
+		primaries, fallbacks []string
+	}{
+		{"tcp", []string{"2001:db8::1", "2001:db8::2"}, []string{"192.0.2.1", "192.0.2.2"}},
+		{"tcp4", []string{"192.0.2.1", "192.0.2.2"}, nil},
+		{"tcp6", []string{"2001:db8::1", "2001:db8::2"}, nil},
+	}
+	for _, tt := range tests {
+		primaries, fallbacks := partitionAddrs(tt.network, addrs)
+		if !slices.Equal(primaries, tt.primaries) || !slices.Equal(fallbacks, tt.fallbacks) {
+			t.Errorf("%s: got %v and %v", tt.network, primaries, fallbacks)
+		}
+	}
+}
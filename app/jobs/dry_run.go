@@ -0,0 +1,123 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// dryRunSampleSize is how many of the items a dry run would store are
+// returned with its counts.
+const dryRunSampleSize = 10
+
+// DryRunner fetches, parses and filters a feed the way a fetch_feed job
+// does, without writing anything, to test configuration changes against
+// the live source.
+type DryRunner struct {
+	blocklist  *feed.Blocklist
+	itemRepo   *database.ItemRepository
+	httpClient *http.Client
+	userAgent  string
+	clock      types.Clock
+}
+
+// NewDryRunner creates a dry runner; clock (nil = the system clock) stands
+// in for the fetch time, as in processFeed.
+func NewDryRunner(blocklist *feed.Blocklist, itemRepo *database.ItemRepository, httpClient *http.Client, userAgent string, clock types.Clock) *DryRunner {
+	return &DryRunner{
+		blocklist:  blocklist,
+		itemRepo:   itemRepo,
+		httpClient: httpClient,
+		userAgent:  userAgent,
+		clock:      types.ClockOrSystem(clock),
+	}
+}
+
+// DryRunResult counts what a fetch would do with the source's items.
+type DryRunResult struct {
+	Title             string // Source feed title
+	Total             int
+	Limited           int // Dropped by initial_max_items on a first fetch
+	TooOld            int
+	Duplicates        int
+	Filtered          int
+	New               int
+	ExtractionQueued  int
+	ExtractionSkipped int
+	Samples           []types.Item // The first items that would be stored, filtered ones included
+}
+
+// Run performs the dry run for a stored feed. Existing items are read to
+// detect duplicates; nothing is stored and no jobs are enqueued.
+func (d *DryRunner) Run(ctx context.Context, dbFeed *database.Feed) (*DryRunResult, error) {
+	settings, err := dbFeed.GetSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed settings: %w", err)
+	}
+
+	if dbFeed.FeedType == "newsletter" {
+		return nil, fmt.Errorf("%w: newsletter feeds receive emails", types.ErrNotFetched)
+	}
+
+	// Broken filters fail the dry run before the source is fetched, as
+	// they fail a fetch.
+	if _, err := feed.FeedFilters(dbFeed, d.blocklist); err != nil {
+		return nil, err
+	}
+
+	now := d.clock.Now().UTC()
+	metadata, items, _, err := fetchAndParseFeed(ctx, dbFeed.FeedURL, dbFeed.FeedType, settings, d.httpClient, d.userAgent, validators{}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DryRunResult{Title: metadata.Title, Total: len(items)}
+	items, result.Limited = limitItems(items, dbFeed, settings)
+
+	planner, err := newIngestPlanner(ctx, dbFeed, settings, d.blocklist, d.itemRepo, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := result.add(ctx, planner, items); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// add plans items and counts the outcomes.
+func (r *DryRunResult) add(ctx context.Context, planner *ingestPlanner, items []types.Item) error {
+	for _, item := range items {
+		planned, err := planner.plan(ctx, item)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case planned.Outcome == ingestTooOld:
+			r.TooOld++
+			continue
+		case planned.Outcome == ingestDuplicate:
+			r.Duplicates++
+			continue
+		case planned.Item.IsFiltered:
+			r.Filtered++
+		default:
+			r.New++
+		}
+		if planned.Extract {
+			r.ExtractionQueued++
+		}
+		if planned.ExtractionSkipped {
+			r.ExtractionSkipped++
+		}
+
+		if len(r.Samples) < dryRunSampleSize {
+			r.Samples = append(r.Samples, planned.Item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// Extractor fetches an item's article and extracts its content. It is
+// shared by extract_content jobs and on-demand extraction via the API, so
+// both respect the same crawl delay.
+type Extractor struct {
+	httpClient   *http.Client
+	userAgent    string
+	redirects    RedirectPolicy
+	crawlLimiter *CrawlLimiter
+	releaseNotes *ReleaseNotes
+}
+
+func NewExtractor(httpClient *http.Client, userAgent string, redirects RedirectPolicy, crawlLimiter *CrawlLimiter, releaseNotes *ReleaseNotes) *Extractor {
+	return &Extractor{
+		httpClient:   httpClient,
+		userAgent:    userAgent,
+		redirects:    redirects,
+		crawlLimiter: crawlLimiter,
+		releaseNotes: releaseNotes,
+	}
+}
+
+// Extract returns the content extracted from the item's link and its
+// quality. It returns a *RescheduleError when the site's crawl delay is
+// too far away.
+func (e *Extractor) Extract(ctx context.Context, item *database.Item, settings *types.Settings) (string, feed.ExtractionQuality, error) {
+	content, _, quality, err := e.ExtractPage(ctx, item, settings)
+	return content, quality, err
+}
+
+// ExtractPage is Extract that also returns the page's title, which items of
+// sitemap feeds take over.
+func (e *Extractor) ExtractPage(ctx context.Context, item *database.Item, settings *types.Settings) (string, string, feed.ExtractionQuality, error) {
+	if item.Link == "" {
+		return "", "", feed.ExtractionQuality{}, fmt.Errorf("item has no link")
+	}
+
+	// Releases and commits come from the GitHub/GitLab API instead, which
+	// isn't subject to the sites' crawl delay.
+	if settings.ReleaseNotes {
+		content, ok, err := e.releaseNotes.Fetch(ctx, item.Link)
+		if ok {
+			if err != nil {
+				return "", "", feed.ExtractionQuality{}, err
+			}
+			return content, "", feed.ScoreSourceContent(content, item.Description), nil
+		}
+	}
+
+	if err := e.crawlLimiter.Wait(ctx, item.Link); err != nil {
+		return "", "", feed.ExtractionQuality{}, err
+	}
+
+	data, err := fetchArticle(ctx, item.Link, time.Duration(settings.Timeout), e.httpClient, e.userAgent, e.redirects, settings.ExtractCharset)
+	if err != nil {
+		return "", "", feed.ExtractionQuality{}, err
+	}
+
+	content, err := feed.Extract(data)
+	if err != nil {
+		return "", "", feed.ExtractionQuality{}, err
+	}
+
+	return content, feed.PageTitle(data), feed.ScoreExtraction(content, item.Description), nil
+}
+
+// SaveExtraction stores an extraction result: the content when its quality
+// is acceptable, otherwise the "fallback" status keeping the original.
+func SaveExtraction(ctx context.Context, itemRepo *database.ItemRepository, itemID, content string, quality feed.ExtractionQuality) (string, error) {
+	status := "ready"
+	if !quality.Accepted() {
+		status, content = "fallback", ""
+	}
+	if err := itemRepo.UpdateContentExtractionStatus(ctx, itemID, status, content, quality.String()); err != nil {
+		return "", fmt.Errorf("failed to update extraction status: %w", err)
+	}
+	return status, nil
+}
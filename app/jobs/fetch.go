@@ -2,45 +2,167 @@ package jobs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
 )
 
-func fetchURL(ctx context.Context, url string, timeout int, httpClient *http.Client, userAgent string, requireHTML bool) ([]byte, error) {
-	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+func fetchURL(ctx context.Context, url string, timeout time.Duration, httpClient *http.Client, userAgent string, requireHTML bool) ([]byte, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(timeoutCtx, "GET", url, nil)
+	data, _, err := fetchBody(timeoutCtx, url, httpClient, userAgent, requireHTML)
+	return data, err
+}
+
+// fetchBody GETs url and returns the body with the response Content-Type.
+func fetchBody(ctx context.Context, url string, httpClient *http.Client, userAgent string, requireHTML bool) ([]byte, string, error) {
+	data, contentType, _, err := fetchConditional(ctx, url, httpClient, userAgent, requireHTML, validators{})
+	return data, contentType, err
+}
+
+// validators are the ETag and Last-Modified of a response, sent back as
+// If-None-Match and If-Modified-Since to fetch a document only when it
+// changed.
+type validators struct {
+	ETag         string
+	LastModified string
+}
+
+// errNotModified is returned by fetchConditional when the server answered
+// 304 Not Modified.
+var errNotModified = errors.New("not modified")
+
+// fetchConditional is fetchBody for a document fetched before: prev is sent
+// as request validators, and the validators of the response are returned.
+func fetchConditional(ctx context.Context, url string, httpClient *http.Client, userAgent string, requireHTML bool, prev validators) ([]byte, string, validators, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, "", validators{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", userAgent)
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
 
 	resp, err := httpClient.Do(req)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, "", validators{}, fmt.Errorf("failed to fetch URL: %w: %w", types.ErrUpstreamTimeout, err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+		return nil, "", validators{}, fmt.Errorf("failed to fetch URL: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && (prev.ETag != "" || prev.LastModified != "") {
+		return nil, "", prev, errNotModified
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+		return nil, "", validators{}, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
 	}
 
+	contentType := resp.Header.Get("Content-Type")
 	if requireHTML {
-		contentType := resp.Header.Get("Content-Type")
 		if !strings.Contains(strings.ToLower(contentType), "text/html") {
-			return nil, fmt.Errorf("content type is not HTML: %s", contentType)
+			return nil, "", validators{}, fmt.Errorf("content type is not HTML: %s", contentType)
 		}
 	}
 
 	data, err := io.ReadAll(resp.Body)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, "", validators{}, fmt.Errorf("failed to read response body: %w: %w", types.ErrUpstreamTimeout, err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, "", validators{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	next := validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	return data, contentType, next, nil
+}
+
+var (
+	errTooManyRedirects = errors.New("too many redirects")
+	errInsecureRedirect = errors.New("refused redirect from https to http")
+)
+
+// RedirectPolicy limits the redirects the HTTP clients follow. By default a
+// redirect may not downgrade https to plain http, which would expose the
+// request (and whatever the page is then trusted with) on the wire.
+type RedirectPolicy struct {
+	MaxRedirects   int
+	AllowDowngrade bool
+}
+
+// CheckRedirect implements http.Client.CheckRedirect for feed fetches.
+func (p RedirectPolicy) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > p.MaxRedirects {
+		return errTooManyRedirects
+	}
+	return p.checkScheme(via[len(via)-1].URL.Scheme, req.URL.Scheme)
+}
+
+func (p RedirectPolicy) checkScheme(from, to string) error {
+	if from == "https" && to == "http" && !p.AllowDowngrade {
+		return errInsecureRedirect
+	}
+	return nil
+}
+
+// fetchArticle fetches an article page for extraction. It follows HTTP and
+// <meta http-equiv="refresh"> redirects, at most policy.MaxRedirects in
+// total, and returns the page decoded to UTF-8 using charsetName when set,
+// or else the charset the page declares.
+func fetchArticle(ctx context.Context, pageURL string, timeout time.Duration, httpClient *http.Client, userAgent string, policy RedirectPolicy, charsetName string) ([]byte, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	redirects := 0
+	client := *httpClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		redirects++
+		if redirects > policy.MaxRedirects {
+			return errTooManyRedirects
+		}
+		return policy.checkScheme(via[len(via)-1].URL.Scheme, req.URL.Scheme)
 	}
 
-	return data, nil
+	for {
+		data, contentType, err := fetchBody(timeoutCtx, pageURL, &client, userAgent, true)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := feed.DecodeHTML(data, contentType, charsetName)
+		if err != nil {
+			return nil, err
+		}
+
+		target := feed.MetaRefreshURL(page, pageURL)
+		if target == "" {
+			return page, nil
+		}
+		redirects++
+		if redirects > policy.MaxRedirects {
+			return nil, fmt.Errorf("failed to fetch URL: %w", errTooManyRedirects)
+		}
+		if err := policy.checkScheme(schemeOf(pageURL), schemeOf(target)); err != nil {
+			return nil, fmt.Errorf("failed to fetch URL: %w", err)
+		}
+		pageURL = target
+	}
+}
+
+func schemeOf(rawURL string) string {
+	scheme, _, _ := strings.Cut(rawURL, ":")
+	return strings.ToLower(scheme)
 }
@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// redirectChain serves /0 .. /n-1, each redirecting to the next, and /n
+// as the final page.
+func redirectChain(n int) *httptest.Server {
+	mux := http.NewServeMux()
+	for i := range n {
+		mux.HandleFunc("/"+strconv.Itoa(i), func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/"+strconv.Itoa(i+1), http.StatusFound)
+		})
+	}
+	mux.HandleFunc("/"+strconv.Itoa(n), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<p>final</p>")
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestRedirectPolicy_Limit(t *testing.T) {
+	server := redirectChain(3)
+	defer server.Close()
+
+	client := server.Client()
+	for _, tt := range []struct {
+		max  int
+		want error
+	}{{3, nil}, {2, errTooManyRedirects}} {
+		client.CheckRedirect = RedirectPolicy{MaxRedirects: tt.max}.CheckRedirect
+		_, _, err := fetchBody(context.Background(), server.URL+"/0", client, "test", false)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("max %d: expected %v, got %v", tt.max, tt.want, err)
+		}
+	}
+}
+
+func TestRedirectPolicy_CrossHost(t *testing.T) {
+	target := redirectChain(0)
+	defer target.Close()
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+"/0", http.StatusMovedPermanently)
+	}))
+	defer source.Close()
+
+	client := source.Client()
+	client.CheckRedirect = RedirectPolicy{MaxRedirects: 1}.CheckRedirect
+	data, _, err := fetchBody(context.Background(), source.URL, client, "test", false)
+	if err != nil || string(data) != "<p>final</p>" {
+		t.Errorf("expected a redirect to another host to be followed, got %q, %v", data, err)
+	}
+}
+
+func TestRedirectPolicy_Downgrade(t *testing.T) {
+	plain := redirectChain(0)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+"/0", http.StatusFound)
+	}))
+	defer secure.Close()
+
+	client := secure.Client()
+	client.CheckRedirect = RedirectPolicy{MaxRedirects: 5}.CheckRedirect
+	if _, _, err := fetchBody(context.Background(), secure.URL, client, "test", false); !errors.Is(err, errInsecureRedirect) {
+		t.Errorf("expected the https to http redirect to be refused, got %v", err)
+	}
+
+	client.CheckRedirect = RedirectPolicy{MaxRedirects: 5, AllowDowngrade: true}.CheckRedirect
+	if _, _, err := fetchBody(context.Background(), secure.URL, client, "test", false); err != nil {
+		t.Errorf("expected AllowDowngrade to follow the redirect, got %v", err)
+	}
+}
+
+func TestFetchArticle_MetaRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/meta":
+			io.WriteString(w, `<meta http-equiv="refresh" content="0; url=/http">`)
+		case "/http":
+			http.Redirect(w, r, "/article", http.StatusFound)
+		case "/ping":
+			io.WriteString(w, `<meta http-equiv="refresh" content="0; url=/pong">`)
+		case "/pong":
+			io.WriteString(w, `<meta http-equiv="refresh" content="0; url=/ping">`)
+		default:
+			io.WriteString(w, "<p>article</p>")
+		}
+	}))
+	defer server.Close()
+
+	page, err := fetchArticle(context.Background(), server.URL+"/meta", time.Second, server.Client(), "test", RedirectPolicy{MaxRedirects: 2}, "")
+	if err != nil || string(page) != "<p>article</p>" {
+		t.Errorf("expected meta refresh and HTTP redirects to be followed, got %q, %v", page, err)
+	}
+
+	// The meta refresh and the HTTP redirect share the limit.
+	if _, err := fetchArticle(context.Background(), server.URL+"/meta", time.Second, server.Client(), "test", RedirectPolicy{MaxRedirects: 1}, ""); !errors.Is(err, errTooManyRedirects) {
+		t.Errorf("expected the limit to count both kinds of redirect, got %v", err)
+	}
+	if _, err := fetchArticle(context.Background(), server.URL+"/ping", time.Second, server.Client(), "test", RedirectPolicy{MaxRedirects: 5}, ""); !errors.Is(err, errTooManyRedirects) {
+		t.Errorf("expected a meta refresh loop to stop at the limit, got %v", err)
+	}
+}
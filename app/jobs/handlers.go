@@ -2,6 +2,7 @@ package jobs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"github.com/lysyi3m/rss-comb/app/database"
 	"github.com/lysyi3m/rss-comb/app/feed"
 	"github.com/lysyi3m/rss-comb/app/media"
+	"github.com/lysyi3m/rss-comb/app/types"
 )
 
 // RescheduleError signals that a job should be rescheduled to a later time
@@ -27,31 +29,46 @@ func (e *RescheduleError) Error() string {
 }
 
 // FetchFeedHandler returns a HandlerFunc that processes a feed by resolving
-// the feed name from the job's FeedID. After processing youtube feeds, it
-// runs global media cleanup.
+// the feed name from the job's FeedID. After processing it publishes the
+// feed when a publisher is configured (nil = off), and after youtube feeds
+// it runs global media cleanup. Fetch and next fetch times come from clock,
+// the system clock when nil.
 func FetchFeedHandler(
+	blocklist *feed.Blocklist,
 	feedRepo *database.FeedRepository,
 	itemRepo *database.ItemRepository,
 	jobRepo *database.JobRepository,
 	httpClient *http.Client,
 	userAgent string,
 	mediaDir string,
+	publisher *Publisher,
+	readLater bool,
+	location *time.Location,
+	clock types.Clock,
 ) HandlerFunc {
+	clock = types.ClockOrSystem(clock)
 	return func(ctx context.Context, job *database.Job) error {
-		dbFeed, err := feedRepo.GetFeedByID(job.FeedID)
+		dbFeed, err := feedRepo.GetFeedByID(ctx, job.FeedID)
 		if err != nil {
 			return fmt.Errorf("failed to get feed by ID: %w", err)
 		}
 		if dbFeed == nil {
-			return fmt.Errorf("feed not found for ID: %s", job.FeedID)
+			return fmt.Errorf("%w: %s", types.ErrFeedNotFound, job.FeedID)
 		}
 
-		if err := processFeed(ctx, dbFeed.Name, feedRepo, itemRepo, jobRepo, httpClient, userAgent); err != nil {
+		if err := processFeed(ctx, dbFeed.Name, blocklist, feedRepo, itemRepo, jobRepo, httpClient, userAgent, readLater, location, clock); err != nil {
 			return fmt.Errorf("[%s] %w", dbFeed.Name, err)
 		}
 
+		// The fetch succeeded; a failed upload is retried with the next one.
+		if publisher != nil {
+			if err := publisher.Publish(ctx, dbFeed.Name); err != nil {
+				slog.Error("Failed to publish feed", "feed", dbFeed.Name, "error", err)
+			}
+		}
+
 		if dbFeed.FeedType == "youtube" {
-			keepPaths, err := itemRepo.GetAllActiveMediaPaths()
+			keepPaths, err := itemRepo.GetAllActiveMediaPaths(ctx)
 			if err != nil {
 				slog.Error("Failed to get active media paths for cleanup", "error", err)
 				return nil
@@ -68,33 +85,55 @@ func FetchFeedHandler(
 	}
 }
 
+// RefilterFeedHandler returns a HandlerFunc that re-applies a feed's filters
+// to its stored items, used when timed filter rules expire or the config or
+// blocklist changed. Timed rules are decided at clock's time (nil = system
+// clock).
+func RefilterFeedHandler(blocklist *feed.Blocklist, feedRepo *database.FeedRepository, itemRepo *database.ItemRepository, clock types.Clock) HandlerFunc {
+	clock = types.ClockOrSystem(clock)
+	return func(ctx context.Context, job *database.Job) error {
+		dbFeed, err := feedRepo.GetFeedByID(ctx, job.FeedID)
+		if err != nil {
+			return fmt.Errorf("failed to get feed by ID: %w", err)
+		}
+		if dbFeed == nil {
+			return fmt.Errorf("%w: %s", types.ErrFeedNotFound, job.FeedID)
+		}
+
+		return feed.Refilter(ctx, dbFeed.Name, blocklist, feedRepo, itemRepo, clock.Now())
+	}
+}
+
 // ExtractContentHandler returns a HandlerFunc that fetches HTML content
 // from an item's link and extracts clean text using go-readability.
+// Retitled sitemap items are filtered at clock's time (nil = system clock).
 func ExtractContentHandler(
+	blocklist *feed.Blocklist,
 	feedRepo *database.FeedRepository,
 	itemRepo *database.ItemRepository,
-	httpClient *http.Client,
-	userAgent string,
+	extractor *Extractor,
+	clock types.Clock,
 ) HandlerFunc {
+	clock = types.ClockOrSystem(clock)
 	return func(ctx context.Context, job *database.Job) error {
 		if job.ItemID == nil {
 			return fmt.Errorf("extract_content job has no item_id")
 		}
 
-		item, err := itemRepo.GetItemByID(*job.ItemID)
+		item, err := itemRepo.GetItemByID(ctx, *job.ItemID)
 		if err != nil {
 			return fmt.Errorf("failed to get item: %w", err)
 		}
 		if item == nil {
-			return fmt.Errorf("item not found for ID: %s", *job.ItemID)
+			return fmt.Errorf("%w: %s", types.ErrItemNotFound, *job.ItemID)
 		}
 
-		dbFeed, err := feedRepo.GetFeedByID(job.FeedID)
+		dbFeed, err := feedRepo.GetFeedByID(ctx, job.FeedID)
 		if err != nil {
 			return fmt.Errorf("failed to get feed: %w", err)
 		}
 		if dbFeed == nil {
-			return fmt.Errorf("feed not found for ID: %s", job.FeedID)
+			return fmt.Errorf("%w: %s", types.ErrFeedNotFound, job.FeedID)
 		}
 
 		settings, err := dbFeed.GetSettings()
@@ -102,69 +141,130 @@ func ExtractContentHandler(
 			return fmt.Errorf("failed to get feed settings: %w", err)
 		}
 
-		if item.Link == "" {
-			return handleExtractionFailure(itemRepo, *job.ItemID, job, fmt.Errorf("item has no link"))
+		content, title, quality, err := extractor.ExtractPage(ctx, item, settings)
+		var rescheduleErr *RescheduleError
+		if errors.As(err, &rescheduleErr) {
+			return err
 		}
-
-		data, err := fetchURL(ctx, item.Link, settings.Timeout, httpClient, userAgent, true)
 		if err != nil {
-			return handleExtractionFailure(itemRepo, *job.ItemID, job, err)
+			return handleExtractionFailure(ctx, itemRepo, *job.ItemID, job, err)
 		}
 
-		extractedContent, err := feed.Extract(data)
-		if err != nil {
-			return handleExtractionFailure(itemRepo, *job.ItemID, job, err)
+		// Boilerplate or a navigation page is worse than the source's own
+		// description, so SaveExtraction keeps that instead.
+		if !quality.Accepted() {
+			slog.Info("Extracted content rejected, keeping original content",
+				"item_id", *job.ItemID, "link", item.Link, "quality", quality.String())
 		}
-
-		if err := itemRepo.UpdateContentExtractionStatus(*job.ItemID, "ready", extractedContent); err != nil {
-			return fmt.Errorf("failed to update extraction status: %w", err)
+		if _, err := SaveExtraction(ctx, itemRepo, *job.ItemID, content, quality); err != nil {
+			return err
 		}
 
+		// Also when the title didn't change: a lastmod update stored the
+		// item again, filtered and scored by the URL-derived stand-in.
+		if dbFeed.FeedType == "sitemap" && title != "" {
+			return retitleItem(ctx, item, title, dbFeed, settings, blocklist, itemRepo, clock.Now())
+		}
 		return nil
 	}
 }
 
+// retitleItem replaces the URL-derived title of a sitemap item with the
+// page's and applies the feed's filters again, as title rules could only
+// see the stand-in so far.
+func retitleItem(
+	ctx context.Context,
+	item *database.Item,
+	title string,
+	dbFeed *database.Feed,
+	settings *types.Settings,
+	blocklist *feed.Blocklist,
+	itemRepo *database.ItemRepository,
+	now time.Time,
+) error {
+	filters, err := feed.FeedFilters(dbFeed, blocklist)
+	if err != nil {
+		return err
+	}
+	updated := retitled(item, title, filters, settings.MinScore, now)
+
+	if err := itemRepo.UpdateItemTitle(ctx, item.ID, title); err != nil {
+		return err
+	}
+	if updated.SeriesID != item.SeriesID {
+		if err := itemRepo.UpdateItemSeriesID(ctx, item.ID, updated.SeriesID); err != nil {
+			return err
+		}
+	}
+	if updated.Score != item.Score {
+		if err := itemRepo.UpdateItemScore(ctx, item.ID, updated.Score); err != nil {
+			return err
+		}
+	}
+	if updated.IsFiltered != item.IsFiltered {
+		return itemRepo.UpdateItemFilterStatus(ctx, item.ID, updated.IsFiltered)
+	}
+	return nil
+}
+
+// retitled returns the item as the filters see it under title: its series,
+// score and filter status at now, which a moderated item keeps.
+func retitled(item *database.Item, title string, filters []types.Filter, minScore int, now time.Time) types.Item {
+	updated := item.Item
+	updated.Title = title
+	updated.SeriesID = feed.SeriesID(title)
+	updated = feed.Filter([]types.Item{updated}, filters, minScore, now)[0]
+	if item.ManualFilter != nil {
+		updated.IsFiltered = item.IsFiltered
+	}
+	return updated
+}
+
 // DownloadMediaHandler returns a HandlerFunc that downloads audio from
 // a video URL using yt-dlp. Uses three-layer dedup: DB → filesystem → download.
+// Videos that aren't ready yet are retried at clock's time plus a wait (nil
+// = system clock).
 func DownloadMediaHandler(
 	feedRepo *database.FeedRepository,
 	itemRepo *database.ItemRepository,
 	ytdlpCmd string,
 	ytdlpArgs string,
 	mediaDir string,
+	clock types.Clock,
 ) HandlerFunc {
+	clock = types.ClockOrSystem(clock)
 	return func(ctx context.Context, job *database.Job) error {
 		if job.ItemID == nil {
 			return fmt.Errorf("download_media job has no item_id")
 		}
 
-		item, err := itemRepo.GetItemByID(*job.ItemID)
+		item, err := itemRepo.GetItemByID(ctx, *job.ItemID)
 		if err != nil {
 			return fmt.Errorf("failed to get item: %w", err)
 		}
 		if item == nil {
-			return fmt.Errorf("item not found for ID: %s", *job.ItemID)
+			return fmt.Errorf("%w: %s", types.ErrItemNotFound, *job.ItemID)
 		}
 
 		fileID := media.MediaFileID(item.GUID)
 		mediaPath := fileID + ".mp3"
 
 		// Load min_duration setting for filtering short videos
-		dbFeed, err := feedRepo.GetFeedByID(job.FeedID)
+		dbFeed, err := feedRepo.GetFeedByID(ctx, job.FeedID)
 		if err != nil {
 			return fmt.Errorf("failed to get feed: %w", err)
 		}
 		if dbFeed == nil {
-			return fmt.Errorf("feed not found for ID: %s", job.FeedID)
+			return fmt.Errorf("%w: %s", types.ErrFeedNotFound, job.FeedID)
 		}
 		settings, err := dbFeed.GetSettings()
 		if err != nil {
 			return fmt.Errorf("failed to get feed settings: %w", err)
 		}
-		minDuration := settings.MinDuration
+		minDuration := int(time.Duration(settings.MinDuration) / time.Second)
 
 		// Layer 1: DB check — does any item already have this file ready?
-		if existing, _ := itemRepo.GetReadyMediaByPath(mediaPath); existing != nil {
+		if existing, _ := itemRepo.GetReadyMediaByPath(ctx, mediaPath); existing != nil {
 			existingDuration := existing.ITunesDuration
 			// If duration wasn't recorded (e.g. downloaded before duration tracking),
 			// probe the file so min_duration can be enforced.
@@ -174,9 +274,9 @@ func DownloadMediaHandler(
 				}
 			}
 			if minDuration > 0 && existingDuration > 0 && existingDuration < minDuration {
-				return filterShortVideo(itemRepo, *job.ItemID, existingDuration, minDuration)
+				return filterShortVideo(ctx, itemRepo, *job.ItemID, existingDuration, minDuration)
 			}
-			if err := itemRepo.UpdateMediaStatus(*job.ItemID, "ready", existing.MediaPath, existing.MediaSize, existingDuration); err != nil {
+			if err := itemRepo.UpdateMediaStatus(ctx, *job.ItemID, "ready", existing.MediaPath, existing.MediaSize, existingDuration); err != nil {
 				return fmt.Errorf("failed to update media status (reuse): %w", err)
 			}
 			return nil
@@ -189,9 +289,9 @@ func DownloadMediaHandler(
 				slog.Warn("Could not probe duration for min_duration check, proceeding without filtering", "item_id", *job.ItemID, "error", err)
 			}
 			if minDuration > 0 && duration > 0 && duration < minDuration {
-				return filterShortVideo(itemRepo, *job.ItemID, duration, minDuration)
+				return filterShortVideo(ctx, itemRepo, *job.ItemID, duration, minDuration)
 			}
-			if err := itemRepo.UpdateMediaStatus(*job.ItemID, "ready", mediaPath, size, duration); err != nil {
+			if err := itemRepo.UpdateMediaStatus(ctx, *job.ItemID, "ready", mediaPath, size, duration); err != nil {
 				return fmt.Errorf("failed to update media status (filesystem): %w", err)
 			}
 			return nil
@@ -199,7 +299,7 @@ func DownloadMediaHandler(
 
 		// Layer 3: Check video info before downloading
 		if item.Link == "" {
-			return handleMediaFailure(itemRepo, *job.ItemID, job, fmt.Errorf("item has no link"))
+			return handleMediaFailure(ctx, itemRepo, *job.ItemID, job, fmt.Errorf("item has no link"))
 		}
 
 		var duration int
@@ -208,7 +308,7 @@ func DownloadMediaHandler(
 			// Metadata failure is not fatal — proceed and rely on post-download
 			// ffprobe for the authoritative duration check.
 			slog.Warn("Video info check failed, proceeding with download", "item_id", *job.ItemID, "error", err)
-		} else if reschedule := videoReschedule(videoInfo); reschedule != nil {
+		} else if reschedule := videoReschedule(videoInfo, clock.Now()); reschedule != nil {
 			slog.Info("Video not ready for download",
 				"item_id", *job.ItemID, "live_status", videoInfo.LiveStatus, "reschedule_at", reschedule.RunAfter)
 			return reschedule
@@ -217,13 +317,13 @@ func DownloadMediaHandler(
 		}
 
 		if minDuration > 0 && duration > 0 && duration < minDuration {
-			return filterShortVideo(itemRepo, *job.ItemID, duration, minDuration)
+			return filterShortVideo(ctx, itemRepo, *job.ItemID, duration, minDuration)
 		}
 
 		// Layer 4: Actually download
 		path, size, err := media.Download(ctx, ytdlpCmd, ytdlpArgs, mediaDir, item.Link, fileID)
 		if err != nil {
-			return handleMediaFailure(itemRepo, *job.ItemID, job, err)
+			return handleMediaFailure(ctx, itemRepo, *job.ItemID, job, err)
 		}
 
 		// Use ffprobe for authoritative duration (yt-dlp metadata can be null for live VODs)
@@ -232,7 +332,7 @@ func DownloadMediaHandler(
 			// than the actual file, YouTube hasn't finished processing the full recording.
 			if duration > 0 && probeDuration < duration*80/100 {
 				os.Remove(filepath.Join(mediaDir, path))
-				return handleMediaFailure(itemRepo, *job.ItemID, job,
+				return handleMediaFailure(ctx, itemRepo, *job.ItemID, job,
 					fmt.Errorf("downloaded file is truncated: got %ds, expected ~%ds (VOD likely still processing)", probeDuration, duration))
 			}
 			duration = probeDuration
@@ -242,10 +342,10 @@ func DownloadMediaHandler(
 		// Catches cases where pre-download metadata was unavailable or inaccurate.
 		if minDuration > 0 && duration > 0 && duration < minDuration {
 			os.Remove(filepath.Join(mediaDir, path))
-			return filterShortVideo(itemRepo, *job.ItemID, duration, minDuration)
+			return filterShortVideo(ctx, itemRepo, *job.ItemID, duration, minDuration)
 		}
 
-		if err := itemRepo.UpdateMediaStatus(*job.ItemID, "ready", path, size, duration); err != nil {
+		if err := itemRepo.UpdateMediaStatus(ctx, *job.ItemID, "ready", path, size, duration); err != nil {
 			return fmt.Errorf("failed to update media status: %w", err)
 		}
 
@@ -254,7 +354,7 @@ func DownloadMediaHandler(
 		// the VOD became available.
 		if videoInfo.UploadTimestamp > 0 {
 			publishedAt := time.Unix(videoInfo.UploadTimestamp, 0)
-			if err := itemRepo.UpdateItemPublishedAt(*job.ItemID, publishedAt); err != nil {
+			if err := itemRepo.UpdateItemPublishedAt(ctx, *job.ItemID, publishedAt); err != nil {
 				slog.Warn("Failed to update published_at from yt-dlp metadata", "item_id", *job.ItemID, "error", err)
 			}
 		}
@@ -265,44 +365,44 @@ func DownloadMediaHandler(
 }
 
 // handleExtractionFailure checks if this is the last retry attempt.
-// On final failure, marks the item as 'failed' and returns nil (job completes).
-// Otherwise returns the error so the job will be retried.
-func handleExtractionFailure(itemRepo *database.ItemRepository, itemID string, job *database.Job, extractionErr error) error {
-	if job.Retries >= job.MaxRetries-1 {
+// On final failure, marks the item as 'failed' so it falls back to the original
+// content. The error is always returned: the job is retried or, once retries
+// are exhausted or the error is permanent, moved to the dead letter queue.
+func handleExtractionFailure(ctx context.Context, itemRepo *database.ItemRepository, itemID string, job *database.Job, extractionErr error) error {
+	if job.Retries >= job.MaxRetries-1 || types.Permanent(extractionErr) {
 		slog.Warn("Content extraction permanently failed, item will use original content",
 			"item_id", itemID, "error", extractionErr, "retries", job.Retries+1)
-		if err := itemRepo.UpdateContentExtractionStatus(itemID, "failed", ""); err != nil {
+		if err := itemRepo.UpdateContentExtractionStatus(context.WithoutCancel(ctx), itemID, "failed", "", extractionErr.Error()); err != nil {
 			slog.Error("Failed to mark item extraction as failed", "item_id", itemID, "error", err)
 		}
-		return nil
 	}
 	return fmt.Errorf("content extraction failed: %w", extractionErr)
 }
 
 // handleMediaFailure checks if this is the last retry attempt.
-// On final failure, marks the item as 'failed' and returns nil (item stays hidden forever).
-// Otherwise returns the error so the job will be retried.
-func handleMediaFailure(itemRepo *database.ItemRepository, itemID string, job *database.Job, mediaErr error) error {
-	if job.Retries >= job.MaxRetries-1 {
+// On final failure, marks the item as 'failed' (it stays hidden unless the
+// job is re-driven from the dead letter queue). The error is always returned
+// so the job is retried or dead-lettered.
+func handleMediaFailure(ctx context.Context, itemRepo *database.ItemRepository, itemID string, job *database.Job, mediaErr error) error {
+	if job.Retries >= job.MaxRetries-1 || types.Permanent(mediaErr) {
 		slog.Warn("Media download permanently failed, item will stay hidden",
 			"item_id", itemID, "error", mediaErr, "retries", job.Retries+1)
-		if err := itemRepo.UpdateMediaStatus(itemID, "failed", "", 0, 0); err != nil {
+		if err := itemRepo.UpdateMediaStatus(context.WithoutCancel(ctx), itemID, "failed", "", 0, 0); err != nil {
 			slog.Error("Failed to mark item media as failed", "item_id", itemID, "error", err)
 		}
-		return nil
 	}
 	return fmt.Errorf("media download failed: %w", mediaErr)
 }
 
-func filterShortVideo(itemRepo *database.ItemRepository, itemID string, duration, minDuration int) error {
+func filterShortVideo(ctx context.Context, itemRepo *database.ItemRepository, itemID string, duration, minDuration int) error {
 	slog.Info("Video below min_duration, filtering",
 		"item_id", itemID, "duration", duration, "min_duration", minDuration)
-	if err := itemRepo.UpdateItemFilterStatus(itemID, true); err != nil {
+	if err := itemRepo.UpdateItemFilterStatus(ctx, itemID, true); err != nil {
 		return fmt.Errorf("failed to filter short video: %w", err)
 	}
 	// Also mark media as skipped so the item stays hidden even if Refilter()
 	// clears is_filtered (refilter only knows about pattern-based filters).
-	if err := itemRepo.UpdateMediaStatus(itemID, "skipped", "", 0, duration); err != nil {
+	if err := itemRepo.UpdateMediaStatus(ctx, itemID, "skipped", "", 0, duration); err != nil {
 		return fmt.Errorf("failed to update media status for short video: %w", err)
 	}
 	return nil
@@ -312,7 +412,7 @@ func filterShortVideo(itemRepo *database.ItemRepository, itemID string, duration
 // signals: upcoming, currently live, or post-live processing. All other statuses
 // (including empty, "was_live", "not_live") proceed to download — the format
 // filter in Download() prevents premature HLS-only downloads of unprocessed VODs.
-func videoReschedule(info media.VideoInfo) *RescheduleError {
+func videoReschedule(info media.VideoInfo, now time.Time) *RescheduleError {
 	switch info.LiveStatus {
 	case "is_upcoming":
 		if info.ReleaseTimestamp > 0 {
@@ -322,20 +422,50 @@ func videoReschedule(info media.VideoInfo) *RescheduleError {
 			}
 		}
 		return &RescheduleError{
-			RunAfter: time.Now().Add(1 * time.Hour),
+			RunAfter: now.Add(1 * time.Hour),
 			Reason:   "video is upcoming, no scheduled time available",
 		}
 	case "is_live":
 		return &RescheduleError{
-			RunAfter: time.Now().Add(15 * time.Minute),
+			RunAfter: now.Add(15 * time.Minute),
 			Reason:   "video is currently live",
 		}
 	case "post_live":
 		return &RescheduleError{
-			RunAfter: time.Now().Add(15 * time.Minute),
+			RunAfter: now.Add(15 * time.Minute),
 			Reason:   "video VOD is being processed",
 		}
 	default:
 		return nil
 	}
 }
+
+// SaveItemHandler returns a HandlerFunc that saves an item's link to the
+// read-later service. Items are saved once; readLater is nil when no
+// service is configured.
+func SaveItemHandler(itemRepo *database.ItemRepository, readLater *ReadLater) HandlerFunc {
+	return func(ctx context.Context, job *database.Job) error {
+		if job.ItemID == nil {
+			return fmt.Errorf("save_item job has no item_id")
+		}
+		if readLater == nil {
+			return fmt.Errorf("%w: read_later is set but READ_LATER_SERVICE isn't", types.ErrFilteredConfigInvalid)
+		}
+
+		item, err := itemRepo.GetItemByID(ctx, *job.ItemID)
+		if err != nil {
+			return fmt.Errorf("failed to get item: %w", err)
+		}
+		if item == nil {
+			return fmt.Errorf("%w: %s", types.ErrItemNotFound, *job.ItemID)
+		}
+		if item.ReadLaterSavedAt != nil || item.Link == "" {
+			return nil
+		}
+
+		if err := readLater.Save(ctx, item.Link, item.Title); err != nil {
+			return err
+		}
+		return itemRepo.MarkReadLaterSaved(ctx, *job.ItemID)
+	}
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestRetitled(t *testing.T) {
+	filters := []types.Filter{
+		{Field: "title", Includes: []string{"release"}, Weight: 5},
+		{Field: "title", Excludes: []string{"sponsored"}},
+	}
+	standIn := types.Item{Title: "Post 42", SeriesID: "post", IsFiltered: true}
+
+	updated := retitled(&database.Item{Item: standIn}, "Release notes 2.0", filters, 1, time.Now())
+	if updated.Title != "Release notes 2.0" || updated.Score != 5 || updated.IsFiltered {
+		t.Errorf("expected the page title to be scored and pass, got %+v", updated)
+	}
+	if updated.SeriesID == standIn.SeriesID {
+		t.Errorf("expected the series to follow the new title")
+	}
+
+	updated = retitled(&database.Item{Item: standIn}, "Sponsored release", filters, 1, time.Now())
+	if !updated.IsFiltered || updated.Score != 5 {
+		t.Errorf("expected an exclude match to filter the item, got %+v", updated)
+	}
+
+	pinned := true
+	updated = retitled(&database.Item{Item: types.Item{Title: "Post 42"}, ManualFilter: &pinned}, "Release notes 2.0", filters, 1, time.Now())
+	if updated.IsFiltered || updated.Score != 5 {
+		t.Errorf("expected a moderated item to keep its status but get the new score, got %+v", updated)
+	}
+}
@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// iconTTL is how long a discovered (or missing) site icon is trusted before
+// the site is checked again.
+const iconTTL = 30 * 24 * time.Hour
+
+// refreshIcon looks up the site's icon for feeds whose source has no image.
+// Failures are only logged: a feed without an image is still a valid feed.
+func refreshIcon(
+	ctx context.Context,
+	dbFeed *database.Feed,
+	metadata *types.Metadata,
+	feedRepo *database.FeedRepository,
+	httpClient *http.Client,
+	userAgent string,
+	timeout time.Duration,
+) {
+	if metadata.ImageURL != "" || metadata.Link == "" {
+		return
+	}
+	if dbFeed.IconCheckedAt != nil && time.Since(*dbFeed.IconCheckedAt) < iconTTL {
+		return
+	}
+
+	icon := discoverIcon(ctx, metadata.Link, httpClient, userAgent, timeout)
+	if err := feedRepo.SetIcon(ctx, dbFeed.Name, icon); err != nil {
+		slog.Error("Failed to store feed icon", "feed", dbFeed.Name, "error", err)
+		return
+	}
+	slog.Debug("Feed icon discovery finished", "feed", dbFeed.Name, "icon", icon)
+}
+
+// discoverIcon returns the icon linked from the site's page, falling back to
+// /favicon.ico when that exists, or "" when the site has neither.
+func discoverIcon(ctx context.Context, siteURL string, httpClient *http.Client, userAgent string, timeout time.Duration) string {
+	site, err := url.Parse(siteURL)
+	if err != nil || site.Host == "" {
+		return ""
+	}
+
+	if page, err := fetchURL(ctx, siteURL, timeout, httpClient, userAgent, true); err == nil {
+		if icon := feed.FindIcon(page, siteURL); icon != "" {
+			return icon
+		}
+	} else {
+		slog.Debug("Failed to fetch site page for icon", "url", siteURL, "error", err)
+	}
+
+	favicon := (&url.URL{Scheme: site.Scheme, Host: site.Host, Path: "/favicon.ico"}).String()
+	if imageExists(ctx, favicon, httpClient, userAgent, timeout) {
+		return favicon
+	}
+	return ""
+}
+
+func imageExists(ctx context.Context, imageURL string, httpClient *http.Client, userAgent string, timeout time.Duration) bool {
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(timeoutCtx, http.MethodHead, imageURL, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK && strings.HasPrefix(resp.Header.Get("Content-Type"), "image/")
+}
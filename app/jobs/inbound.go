@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// IngestItem stores an item that didn't come from a fetch, such as an
+// email posted to a newsletter feed, as processFeed stores a fetched one:
+// it is planned by the same ingestPlanner (deduplicated by content hash,
+// filtered with moderation overrides, queued for extraction or media) and
+// gets the same jobs, saving to the read-later service included when
+// readLater says one is configured. The item is stored as received at now.
+// It returns the item's ID, the stored duplicate's for a redelivery, and
+// whether it was new. An item older than the feed's ingest cutoff isn't
+// stored, and its ID is empty.
+func IngestItem(
+	ctx context.Context,
+	dbFeed *database.Feed,
+	item types.Item,
+	blocklist *feed.Blocklist,
+	itemRepo *database.ItemRepository,
+	jobRepo *database.JobRepository,
+	readLater bool,
+	now time.Time,
+) (string, bool, error) {
+	settings, err := dbFeed.GetSettings()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get feed settings: %w", err)
+	}
+
+	planner, err := newIngestPlanner(ctx, dbFeed, settings, blocklist, itemRepo, now)
+	if err != nil {
+		return "", false, err
+	}
+	planned, err := planner.plan(ctx, item)
+	if err != nil {
+		return "", false, err
+	}
+	switch planned.Outcome {
+	case ingestTooOld:
+		slog.Info("Item too old, skipped", "feed", dbFeed.Name, "title", item.Title)
+		return "", false, nil
+	case ingestDuplicate:
+		return planned.DuplicateID, false, nil
+	}
+
+	itemID, err := itemRepo.UpsertItem(ctx, dbFeed.ID, planned.Item, now)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to upsert item: %w", err)
+	}
+	enqueueItemJobs(ctx, jobRepo, dbFeed, settings, itemID, planned, readLater)
+
+	slog.Info("Item ingested", "feed", dbFeed.Name, "item_id", itemID, "title", planned.Item.Title, "filtered", planned.Item.IsFiltered)
+	return itemID, true, nil
+}
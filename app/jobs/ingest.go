@@ -0,0 +1,229 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// ingestOutcome is what becomes of an item a source lists.
+type ingestOutcome int
+
+const (
+	ingestStored    ingestOutcome = iota // New or changed, stored (possibly filtered)
+	ingestTooOld                         // Published before the ingest cutoff
+	ingestDuplicate                      // Already stored with the same content
+)
+
+// plannedItem is the decision about one item: the item as it is to be
+// stored, its filter and statuses set, and the jobs that follow from it.
+type plannedItem struct {
+	Item              types.Item
+	Outcome           ingestOutcome
+	Extract           bool   // Queue an extract_content job
+	ExtractionSkipped bool   // Visible and extract_content is on, but extraction limits skip it
+	Media             bool   // Queue a download_media job
+	DuplicateID       string // The stored item an ingestDuplicate item repeats
+}
+
+// ingestPlanner decides what ingesting a source's items does, item by
+// item: the age cutoff, deduplication, filters with moderation overrides,
+// and which visible items get extraction and media jobs. processFeed,
+// DryRunner and IngestItem all plan with it, so a dry run or an inbound
+// email reaches the decisions a fetch would. It writes nothing.
+type ingestPlanner struct {
+	feedType  string
+	settings  *types.Settings
+	filters   []types.Filter
+	overrides map[string]bool // Moderation overrides by GUID
+	cutoff    time.Time
+	now       time.Time
+	duplicate func(ctx context.Context, contentHash string) (string, error) // ID of the stored item with the content, or ""
+
+	visible   int // Unfiltered items planned so far
+	extracted int // Items queued for extraction so far
+}
+
+// newIngestPlanner loads what planning a feed's items needs: its filters,
+// blocklist included, and its moderation overrides.
+func newIngestPlanner(
+	ctx context.Context,
+	dbFeed *database.Feed,
+	settings *types.Settings,
+	blocklist *feed.Blocklist,
+	itemRepo *database.ItemRepository,
+	now time.Time,
+) (*ingestPlanner, error) {
+	filters, err := feed.FeedFilters(dbFeed, blocklist)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := itemRepo.GetManualFilters(ctx, dbFeed.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ingestPlanner{
+		feedType:  dbFeed.FeedType,
+		settings:  settings,
+		filters:   filters,
+		overrides: overrides,
+		cutoff:    ingestCutoff(dbFeed, settings, now),
+		now:       now,
+		duplicate: func(ctx context.Context, contentHash string) (string, error) {
+			isDuplicate, duplicateID, err := itemRepo.CheckDuplicate(ctx, dbFeed.ID, contentHash)
+			if err != nil || !isDuplicate || duplicateID == nil {
+				return "", err
+			}
+			return *duplicateID, nil
+		},
+	}, nil
+}
+
+// plan decides the fate of the next item. Items must be planned in the
+// order they are ingested, as max_items and the extraction limits count
+// the items planned before.
+func (p *ingestPlanner) plan(ctx context.Context, item types.Item) (plannedItem, error) {
+	if tooOld(item, p.cutoff) {
+		return plannedItem{Item: item, Outcome: ingestTooOld}, nil
+	}
+
+	duplicateID, err := p.duplicate(ctx, item.ContentHash)
+	if err != nil {
+		return plannedItem{}, fmt.Errorf("failed to check for duplicates: %w", err)
+	}
+	if duplicateID != "" {
+		return plannedItem{Item: item, Outcome: ingestDuplicate, DuplicateID: duplicateID}, nil
+	}
+
+	item.SeriesID = feed.SeriesID(item.Title)
+	planned := plannedItem{Item: feed.Filter([]types.Item{item}, p.filters, p.settings.MinScore, p.now)[0]}
+	// A moderated item keeps its pinned status (see UpsertItem), and its
+	// jobs follow that rather than the filters.
+	if filtered, ok := p.overrides[item.GUID]; ok {
+		planned.Item.IsFiltered = filtered
+	}
+	if planned.Item.IsFiltered {
+		return planned, nil
+	}
+
+	p.visible++
+	if p.visible > p.settings.MaxItems {
+		return planned, nil
+	}
+
+	if p.settings.ExtractContent {
+		if extractionWanted(planned.Item, p.settings, p.extracted, p.now) {
+			planned.Item.ContentExtractionStatus = stringPtr("pending")
+			planned.Extract = true
+			p.extracted++
+		} else {
+			// Served with the source's own content right away.
+			planned.ExtractionSkipped = true
+		}
+	}
+	if p.feedType == "youtube" {
+		planned.Item.MediaStatus = stringPtr("pending")
+		planned.Media = true
+	}
+	return planned, nil
+}
+
+// enqueueItemJobs creates the jobs a stored item's plan calls for:
+// extraction, media download, and saving to the read-later service for
+// read_later feeds when readLater says one is configured. Failures are
+// logged rather than returned, as the item is stored either way. It
+// reports which of the extraction and media jobs were created.
+func enqueueItemJobs(
+	ctx context.Context,
+	jobRepo *database.JobRepository,
+	dbFeed *database.Feed,
+	settings *types.Settings,
+	itemID string,
+	planned plannedItem,
+	readLater bool,
+) (extract, media bool) {
+	if planned.Extract {
+		if _, err := jobRepo.CreateJob(ctx, "extract_content", dbFeed.ID, &itemID, 3); err != nil {
+			slog.Error("Failed to create extract_content job", "feed", dbFeed.Name, "item_id", itemID, "error", err)
+		} else {
+			extract = true
+		}
+	}
+
+	if readLater && settings.ReadLater && !planned.Item.IsFiltered {
+		if _, err := jobRepo.CreateJob(ctx, "save_item", dbFeed.ID, &itemID, 3); err != nil {
+			slog.Error("Failed to create save_item job", "feed", dbFeed.Name, "item_id", itemID, "error", err)
+		}
+	}
+
+	if planned.Media {
+		if _, err := jobRepo.CreateJob(ctx, "download_media", dbFeed.ID, &itemID, 30); err != nil {
+			slog.Error("Failed to create download_media job", "feed", dbFeed.Name, "item_id", itemID, "error", err)
+		} else {
+			media = true
+		}
+	}
+	return extract, media
+}
+
+// limitItems orders and trims a source's items before planning: on a
+// feed's first fetch only the newest initial_max_items are kept, so a new
+// subscription doesn't flood readers with the source's history, and
+// extract_content_max_items picks the newest items, so the source isn't
+// relied on to list them newest first. It returns the items to plan and
+// how many the first-fetch limit dropped.
+func limitItems(items []types.Item, dbFeed *database.Feed, settings *types.Settings) ([]types.Item, int) {
+	newestFirst := func(a, b types.Item) int {
+		return b.PublishedAt.Compare(a.PublishedAt)
+	}
+
+	limited := 0
+	if dbFeed.LastSuccessAt == nil && settings.InitialMaxItems > 0 && len(items) > settings.InitialMaxItems {
+		slices.SortStableFunc(items, newestFirst)
+		limited = len(items) - settings.InitialMaxItems
+		items = items[:settings.InitialMaxItems]
+	}
+	if settings.ExtractContent && settings.ExtractContentMaxItems > 0 {
+		slices.SortStableFunc(items, newestFirst)
+	}
+	return items, limited
+}
+
+// ingestCutoff returns the publish date new items must not be older than:
+// ignore_items_older_than before now, or where a limited first fetch
+// stopped, whichever is later. It is zero when neither applies.
+func ingestCutoff(dbFeed *database.Feed, settings *types.Settings, now time.Time) time.Time {
+	var cutoff time.Time
+	if settings.IgnoreItemsOlderThan > 0 {
+		cutoff = now.Add(-time.Duration(settings.IgnoreItemsOlderThan))
+	}
+	if dbFeed.IngestCutoffAt != nil && dbFeed.IngestCutoffAt.After(cutoff) {
+		cutoff = *dbFeed.IngestCutoffAt
+	}
+	return cutoff
+}
+
+// tooOld reports whether an item was published before the cutoff. Undated
+// items can't be placed relative to it and are kept.
+func tooOld(item types.Item, cutoff time.Time) bool {
+	return !cutoff.IsZero() && !item.PublishedAt.IsZero() && item.PublishedAt.Before(cutoff)
+}
+
+// extractionWanted reports whether a new visible item should be queued for
+// content extraction, given how many already were during this fetch.
+func extractionWanted(item types.Item, settings *types.Settings, queued int, now time.Time) bool {
+	if settings.ExtractContentMaxItems > 0 && queued >= settings.ExtractContentMaxItems {
+		return false
+	}
+	if settings.ExtractContentMaxAge > 0 && item.PublishedAt.Before(now.Add(-time.Duration(settings.ExtractContentMaxAge))) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestIngestCutoff(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	firstFetch := now.Add(-time.Hour)
+
+	if got := ingestCutoff(&database.Feed{}, &types.Settings{}, now); !got.IsZero() {
+		t.Errorf("expected no cutoff, got %s", got)
+	}
+	settings := &types.Settings{IgnoreItemsOlderThan: types.Duration(24 * time.Hour)}
+	if got := ingestCutoff(&database.Feed{}, settings, now); !got.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("expected the age cutoff, got %s", got)
+	}
+	if got := ingestCutoff(&database.Feed{IngestCutoffAt: &firstFetch}, settings, now); !got.Equal(firstFetch) {
+		t.Errorf("expected the later first-fetch cutoff, got %s", got)
+	}
+}
+
+func TestTooOld(t *testing.T) {
+	cutoff := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		published time.Time
+		cutoff    time.Time
+		want      bool
+	}{
+		{"before the cutoff", cutoff.Add(-time.Hour), cutoff, true},
+		{"after the cutoff", cutoff.Add(time.Hour), cutoff, false},
+		{"undated", time.Time{}, cutoff, false},
+		{"no cutoff", cutoff.Add(-time.Hour), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		if got := tooOld(types.Item{PublishedAt: tt.published}, tt.cutoff); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIngestPlanner(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	settings := &types.Settings{
+		MaxItems:               3,
+		ExtractContent:         true,
+		ExtractContentMaxItems: 1,
+		IgnoreItemsOlderThan:   types.Duration(48 * time.Hour),
+	}
+	p := &ingestPlanner{
+		feedType:  "youtube",
+		settings:  settings,
+		filters:   []types.Filter{{Field: "title", Excludes: []string{"sponsored"}}},
+		overrides: map[string]bool{"pinned": false},
+		cutoff:    ingestCutoff(&database.Feed{}, settings, now),
+		now:       now,
+		duplicate: func(_ context.Context, contentHash string) (string, error) {
+			if contentHash == "seen" {
+				return "stored", nil
+			}
+			return "", nil
+		},
+	}
+
+	tests := []struct {
+		item                     types.Item
+		outcome                  ingestOutcome
+		filtered, extract, media bool
+	}{
+		{types.Item{GUID: "a", Title: "First", PublishedAt: now}, ingestStored, false, true, true},
+		{types.Item{GUID: "old", Title: "Old", PublishedAt: now.Add(-72 * time.Hour)}, ingestTooOld, false, false, false},
+		{types.Item{GUID: "dup", Title: "Dup", ContentHash: "seen", PublishedAt: now}, ingestDuplicate, false, false, false},
+		{types.Item{GUID: "ad", Title: "Sponsored post", PublishedAt: now}, ingestStored, true, false, false},
+		{types.Item{GUID: "pinned", Title: "Sponsored, but wanted", PublishedAt: now}, ingestStored, false, false, true},
+		{types.Item{GUID: "b", Title: "Third visible", PublishedAt: now}, ingestStored, false, false, true},
+		{types.Item{GUID: "c", Title: "Beyond max_items", PublishedAt: now}, ingestStored, false, false, false},
+	}
+	for _, tt := range tests {
+		planned, err := p.plan(context.Background(), tt.item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if planned.Outcome != tt.outcome || planned.Item.IsFiltered != tt.filtered || planned.Extract != tt.extract || planned.Media != tt.media {
+			t.Errorf("%s: got outcome %d, filtered %v, extract %v, media %v", tt.item.GUID,
+				planned.Outcome, planned.Item.IsFiltered, planned.Extract, planned.Media)
+		}
+		if planned.Extract != (planned.Item.ContentExtractionStatus != nil) {
+			t.Errorf("%s: extraction status doesn't match the decision", tt.item.GUID)
+		}
+		if (planned.Outcome == ingestDuplicate) != (planned.DuplicateID == "stored") {
+			t.Errorf("%s: got duplicate ID %q", tt.item.GUID, planned.DuplicateID)
+		}
+	}
+}
+
+func TestLimitItems(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	items := []types.Item{
+		{GUID: "old", PublishedAt: now.Add(-2 * time.Hour)},
+		{GUID: "new", PublishedAt: now},
+		{GUID: "mid", PublishedAt: now.Add(-time.Hour)},
+	}
+	settings := &types.Settings{InitialMaxItems: 2}
+
+	kept, limited := limitItems(slices.Clone(items), &database.Feed{}, settings)
+	if limited != 1 || len(kept) != 2 || kept[0].GUID != "new" || kept[1].GUID != "mid" {
+		t.Errorf("expected the two newest items on a first fetch, got %v (%d dropped)", kept, limited)
+	}
+
+	fetched := now
+	kept, limited = limitItems(slices.Clone(items), &database.Feed{LastSuccessAt: &fetched}, settings)
+	if limited != 0 || len(kept) != 3 || kept[0].GUID != "old" {
+		t.Errorf("expected later fetches to keep the source's order, got %v (%d dropped)", kept, limited)
+	}
+}
+
+func TestDryRunResult_Add(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	settings := &types.Settings{MaxItems: 10, ExtractContent: true, ExtractContentMaxAge: types.Duration(24 * time.Hour)}
+	p := &ingestPlanner{
+		settings: settings,
+		filters:  []types.Filter{{Field: "title", Excludes: []string{"sponsored"}}},
+		now:      now,
+		duplicate: func(_ context.Context, contentHash string) (string, error) {
+			if contentHash == "seen" {
+				return "stored", nil
+			}
+			return "", nil
+		},
+	}
+
+	var result DryRunResult
+	err := result.add(context.Background(), p, []types.Item{
+		{GUID: "a", Title: "Fresh", PublishedAt: now},
+		{GUID: "b", Title: "Last week", PublishedAt: now.Add(-7 * 24 * time.Hour)},
+		{GUID: "c", Title: "Sponsored", PublishedAt: now},
+		{GUID: "d", Title: "Seen", ContentHash: "seen", PublishedAt: now},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.New != 2 || result.Filtered != 1 || result.Duplicates != 1 || result.ExtractionQueued != 1 || result.ExtractionSkipped != 1 {
+		t.Errorf("unexpected counts %+v", result)
+	}
+	if len(result.Samples) != 3 || result.Samples[0].ContentExtractionStatus == nil {
+		t.Errorf("expected the stored items as samples, with their statuses, got %+v", result.Samples)
+	}
+}
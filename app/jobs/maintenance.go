@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+)
+
+const maintenanceStateKey = "maintenance"
+
+// Maintenance is the global switch that pauses scheduling and job
+// processing. The flag is persisted in app_state and cached in memory so
+// checks don't hit the database while it is being maintained.
+type Maintenance struct {
+	stateRepo *database.StateRepository
+	enabled   atomic.Bool
+}
+
+// NewMaintenance loads the persisted maintenance flag.
+func NewMaintenance(ctx context.Context, stateRepo *database.StateRepository) (*Maintenance, error) {
+	m := &Maintenance{stateRepo: stateRepo}
+
+	value, err := stateRepo.GetValue(ctx, maintenanceStateKey)
+	if err != nil {
+		return nil, err
+	}
+	if value != nil {
+		enabled, err := strconv.ParseBool(*value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance state %q: %w", *value, err)
+		}
+		m.enabled.Store(enabled)
+	}
+
+	return m, nil
+}
+
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Set persists and applies the maintenance flag.
+func (m *Maintenance) Set(ctx context.Context, enabled bool) error {
+	if err := m.stateRepo.SetValue(ctx, maintenanceStateKey, strconv.FormatBool(enabled)); err != nil {
+		return err
+	}
+	m.enabled.Store(enabled)
+	return nil
+}
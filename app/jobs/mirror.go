@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// mirrorFeed stores the source document of a feed in mirror mode as it was
+// fetched, without parsing it: no items are stored and the document is
+// served unchanged.
+func mirrorFeed(
+	ctx context.Context,
+	dbFeed *database.Feed,
+	settings *types.Settings,
+	feedRepo *database.FeedRepository,
+	httpClient *http.Client,
+	userAgent string,
+	location *time.Location,
+	clock types.Clock,
+) error {
+	start := time.Now()
+
+	body, contentType, err := fetchMirror(ctx, dbFeed.FeedURL, time.Duration(settings.Timeout), httpClient, userAgent)
+	if err != nil {
+		if recordErr := feedRepo.RecordFetchError(context.WithoutCancel(ctx), dbFeed.Name, err.Error(), clock.Now()); recordErr != nil {
+			slog.Error("Failed to record fetch error", "feed", dbFeed.Name, "error", recordErr)
+		}
+		return err
+	}
+
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+	if err := feedRepo.SaveMirror(ctx, dbFeed.ID, body, contentType, etag); err != nil {
+		return err
+	}
+
+	now := clock.Now().UTC()
+	if err := feedRepo.MarkFetched(ctx, dbFeed.Name, feed.NextFetch(settings, now, location), now); err != nil {
+		return err
+	}
+
+	slog.Info("Feed mirrored", "feed", dbFeed.Name, "duration", time.Since(start), "bytes", len(body), "etag", etag)
+	return nil
+}
+
+func fetchMirror(ctx context.Context, feedURL string, timeout time.Duration, httpClient *http.Client, userAgent string) ([]byte, string, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, contentType, err := fetchBody(timeoutCtx, feedURL, httpClient, userAgent, false)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch feed: %w", err)
+	}
+
+	// Without parsing, at least don't replace a good copy with an error page
+	// or an empty response: feeds are XML or JSON documents.
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if len(trimmed) == 0 || (trimmed[0] != '<' && trimmed[0] != '{') || bytes.HasPrefix(bytes.ToLower(trimmed), []byte("<!doctype html")) || bytes.HasPrefix(bytes.ToLower(trimmed), []byte("<html")) {
+		return nil, "", fmt.Errorf("failed to mirror feed: %w: not an XML or JSON document", types.ErrParse)
+	}
+
+	return body, contentType, nil
+}
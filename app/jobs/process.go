@@ -2,6 +2,7 @@ package jobs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -15,12 +16,16 @@ import (
 func processFeed(
 	ctx context.Context,
 	feedName string,
+	blocklist *feed.Blocklist,
 	feedRepo *database.FeedRepository,
 	itemRepo *database.ItemRepository,
 	jobRepo *database.JobRepository,
 	httpClient *http.Client,
 	userAgent string,
-) error {
+	readLater bool,
+	location *time.Location,
+	clock types.Clock,
+) (err error) {
 	start := time.Now()
 
 	select {
@@ -29,15 +34,15 @@ func processFeed(
 	default:
 	}
 
-	dbFeed, err := feedRepo.GetFeed(feedName)
+	dbFeed, err := feedRepo.GetFeed(ctx, feedName)
 	if err != nil {
 		return fmt.Errorf("failed to get feed from database: %w", err)
 	}
 	if dbFeed == nil {
-		return fmt.Errorf("feed not found in database")
+		return types.ErrFeedNotFound
 	}
 
-	if !dbFeed.IsEnabled {
+	if !dbFeed.IsEnabled || dbFeed.IsPaused {
 		return nil
 	}
 
@@ -46,44 +51,101 @@ func processFeed(
 		return fmt.Errorf("failed to get feed settings: %w", err)
 	}
 
-	filters, err := dbFeed.GetFilters()
-	if err != nil {
-		return fmt.Errorf("failed to get feed filters: %w", err)
+	if settings.Mirror {
+		return mirrorFeed(ctx, dbFeed, settings, feedRepo, httpClient, userAgent, location, clock)
+	}
+
+	// Newsletters arrive through IngestItem; marking the feed fetched ends
+	// the placeholder and keeps the feed out of the failing list.
+	if dbFeed.FeedType == "newsletter" {
+		now := clock.Now().UTC()
+		return feedRepo.MarkFetched(ctx, feedName, feed.NextFetch(settings, now, location), now)
+	}
+
+	// Broken filters fail the job before the source is fetched.
+	if _, err := feed.FeedFilters(dbFeed, blocklist); err != nil {
+		return err
 	}
 
-	metadata, items, err := fetchAndParseFeed(ctx, dbFeed.FeedURL, dbFeed.FeedType, settings, httpClient, userAgent)
+	prev := validators{ETag: dbFeed.HTTPETag, LastModified: dbFeed.HTTPLastModified}
+	metadata, items, next, err := fetchAndParseFeed(ctx, dbFeed.FeedURL, dbFeed.FeedType, settings, httpClient, userAgent, prev, clock.Now())
+	if errors.Is(err, errNotModified) {
+		now := clock.Now().UTC()
+		if err := feedRepo.MarkNotModified(ctx, feedName, feed.NextFetch(settings, now, location), now); err != nil {
+			return err
+		}
+		slog.Info("Feed not modified", "feed", feedName, "duration", time.Since(start))
+		return nil
+	}
 	if err != nil {
+		// The fetch may have failed because ctx expired; record it anyway.
+		if recordErr := feedRepo.RecordFetchError(context.WithoutCancel(ctx), feedName, err.Error(), clock.Now()); recordErr != nil {
+			slog.Error("Failed to record fetch error", "feed", feedName, "error", recordErr)
+		}
 		return err
 	}
 
-	now := time.Now().UTC()
-	nextFetch := now.Add(time.Duration(settings.RefreshInterval) * time.Second)
-	if err := feedRepo.UpdateFeedMetadata(feedName, metadata, nextFetch); err != nil {
+	// The validators are kept only once the items are stored, so a run that
+	// fails halfway isn't answered with 304 next time.
+	defer func() {
+		if err == nil && next != prev {
+			err = feedRepo.SetHTTPValidators(ctx, feedName, next.ETag, next.LastModified)
+		}
+	}()
+
+	now := clock.Now().UTC()
+	nextFetch := feed.NextFetch(settings, now, location)
+	if err := feedRepo.UpdateFeedMetadata(ctx, feedName, metadata, nextFetch, now); err != nil {
 		return fmt.Errorf("failed to update feed metadata: %w", err)
 	}
 
+	refreshIcon(ctx, dbFeed, metadata, feedRepo, httpClient, userAgent, time.Duration(settings.Timeout))
+
 	if len(items) == 0 {
 		return nil
 	}
 
-	// Check if newest item already exists — if so, no new items to process
-	isDuplicate, _, err := itemRepo.CheckDuplicate(feedName, items[0].ContentHash)
-	if err != nil {
-		return fmt.Errorf("failed to check newest item: %w", err)
+	// Check if newest item already exists — if so, no new items to process.
+	// Sitemaps without lastmod don't list new URLs first, so they are always
+	// checked item by item.
+	if dbFeed.FeedType != "sitemap" {
+		isDuplicate, _, err := itemRepo.CheckDuplicate(ctx, dbFeed.ID, items[0].ContentHash)
+		if err != nil {
+			return fmt.Errorf("failed to check newest item: %w", err)
+		}
+		if isDuplicate {
+			slog.Info("Feed unchanged, skipping item processing",
+				"feed", feedName,
+				"duration", time.Since(start))
+			return nil
+		}
 	}
-	if isDuplicate {
-		slog.Info("Feed unchanged, skipping item processing",
-			"feed", feedName,
-			"duration", time.Since(start))
-		return nil
+
+	items, limited := limitItems(items, dbFeed, settings)
+	if limited > 0 {
+		slog.Info("Limiting first fetch", "feed", feedName, "total", len(items)+limited, "kept", len(items))
+
+		// Remember where the first fetch stopped so the skipped items aren't
+		// picked up by the next fetch instead.
+		ingestCutoff := items[len(items)-1].PublishedAt
+		if err := feedRepo.SetIngestCutoff(ctx, feedName, ingestCutoff); err != nil {
+			return err
+		}
+		dbFeed.IngestCutoffAt = &ingestCutoff
+	}
+
+	planner, err := newIngestPlanner(ctx, dbFeed, settings, blocklist, itemRepo, now)
+	if err != nil {
+		return err
 	}
 
 	duplicateCount := 0
+	tooOldCount := 0
 	filteredCount := 0
 	newCount := 0
 	extractionJobCount := 0
+	extractionSkippedCount := 0
 	mediaJobCount := 0
-	visibleCount := 0
 
 	for _, item := range items {
 		select {
@@ -92,55 +154,39 @@ func processFeed(
 		default:
 		}
 
-		isDuplicate, _, err := itemRepo.CheckDuplicate(feedName, item.ContentHash)
+		planned, err := planner.plan(ctx, item)
 		if err != nil {
-			return fmt.Errorf("failed to check for duplicates: %w", err)
+			return err
 		}
-
-		if isDuplicate {
+		switch planned.Outcome {
+		case ingestTooOld:
+			tooOldCount++
+			continue
+		case ingestDuplicate:
 			duplicateCount++
 			continue
 		}
 
-		filteredItems := feed.Filter([]types.Item{item}, filters)
-		processedItem := filteredItems[0]
-
-		if processedItem.IsFiltered {
+		if planned.Item.IsFiltered {
 			filteredCount++
 		} else {
 			newCount++
-			visibleCount++
 		}
-
-		withinMaxItems := visibleCount <= settings.MaxItems
-
-		if !processedItem.IsFiltered && settings.ExtractContent && withinMaxItems {
-			processedItem.ContentExtractionStatus = stringPtr("pending")
+		if planned.ExtractionSkipped {
+			extractionSkippedCount++
 		}
 
-		if !processedItem.IsFiltered && dbFeed.FeedType == "youtube" && withinMaxItems {
-			processedItem.MediaStatus = stringPtr("pending")
-		}
-
-		itemID, err := itemRepo.UpsertItem(feedName, processedItem)
+		itemID, err := itemRepo.UpsertItem(ctx, dbFeed.ID, planned.Item, now)
 		if err != nil {
 			return fmt.Errorf("failed to upsert item: %w", err)
 		}
 
-		if processedItem.ContentExtractionStatus != nil && *processedItem.ContentExtractionStatus == "pending" {
-			if _, err := jobRepo.CreateJob("extract_content", dbFeed.ID, &itemID, 3); err != nil {
-				slog.Error("Failed to create extract_content job", "feed", feedName, "item_id", itemID, "error", err)
-			} else {
-				extractionJobCount++
-			}
+		extract, media := enqueueItemJobs(ctx, jobRepo, dbFeed, settings, itemID, planned, readLater)
+		if extract {
+			extractionJobCount++
 		}
-
-		if processedItem.MediaStatus != nil && *processedItem.MediaStatus == "pending" {
-			if _, err := jobRepo.CreateJob("download_media", dbFeed.ID, &itemID, 30); err != nil {
-				slog.Error("Failed to create download_media job", "feed", feedName, "item_id", itemID, "error", err)
-			} else {
-				mediaJobCount++
-			}
+		if media {
+			mediaJobCount++
 		}
 	}
 
@@ -153,8 +199,15 @@ func processFeed(
 		"new", newCount,
 	}
 
+	if tooOldCount > 0 {
+		logData = append(logData, "too_old", tooOldCount)
+	}
+
 	if settings.ExtractContent {
 		logData = append(logData, "extraction_jobs", extractionJobCount)
+		if extractionSkippedCount > 0 {
+			logData = append(logData, "extraction_skipped", extractionSkippedCount)
+		}
 	}
 
 	if dbFeed.FeedType == "youtube" {
@@ -170,6 +223,10 @@ func stringPtr(s string) *string {
 	return &s
 }
 
+// fetchAndParseFeed fetches and parses a feed's source. With validators
+// from a previous fetch the request is conditional, and an unchanged
+// source returns errNotModified; the response's validators are returned
+// for the next fetch.
 func fetchAndParseFeed(
 	ctx context.Context,
 	feedURL string,
@@ -177,17 +234,25 @@ func fetchAndParseFeed(
 	settings *types.Settings,
 	httpClient *http.Client,
 	userAgent string,
-) (*feed.Metadata, []types.Item, error) {
-	data, err := fetchURL(ctx, feedURL, settings.Timeout, httpClient, userAgent, false)
+	prev validators,
+	now time.Time,
+) (*feed.Metadata, []types.Item, validators, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(settings.Timeout))
+	defer cancel()
+
+	data, _, next, err := fetchConditional(timeoutCtx, feedURL, httpClient, userAgent, false, prev)
+	if errors.Is(err, errNotModified) {
+		return nil, nil, next, err
+	}
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch feed: %w", err)
+		return nil, nil, validators{}, fmt.Errorf("failed to fetch feed: %w", err)
 	}
 
 	ft := feed.ForType(feedType)
-	metadata, items, err := ft.Parse(data)
+	metadata, items, err := ft.Parse(data, now)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to parse feed: %w", err)
+		return nil, nil, validators{}, fmt.Errorf("failed to parse feed: %w: %w", types.ErrParse, err)
 	}
 
-	return metadata, items, nil
+	return metadata, items, next, nil
 }
@@ -0,0 +1,154 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/storage"
+)
+
+// Publisher writes a feed's output documents to a publish target after each
+// successful fetch and whenever its items change, so a CDN or static web
+// server can serve feeds while rss-comb only processes them.
+type Publisher struct {
+	target   storage.PublishTarget
+	feedRepo *database.FeedRepository
+	itemRepo *database.ItemRepository
+	cfg      *cfg.Cfg
+
+	mu      sync.Mutex
+	changed map[string]bool // IDs of feeds to publish on the next flush
+}
+
+func NewPublisher(target storage.PublishTarget, feedRepo *database.FeedRepository, itemRepo *database.ItemRepository, cfg *cfg.Cfg) *Publisher {
+	return &Publisher{
+		target:   target,
+		feedRepo: feedRepo,
+		itemRepo: itemRepo,
+		cfg:      cfg,
+		changed:  make(map[string]bool),
+	}
+}
+
+// MarkChanged queues a feed for publishing. It is meant for
+// ItemRepository.OnChange, which fires on every item write, including
+// extraction, moderation and drip releases outside fetches; Run publishes
+// the queued feeds in batches rather than once per write.
+func (p *Publisher) MarkChanged(feedID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.changed[feedID] = true
+}
+
+// takeChanged returns the queued feed IDs and empties the queue.
+func (p *Publisher) takeChanged() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ids := slices.Sorted(maps.Keys(p.changed))
+	clear(p.changed)
+	return ids
+}
+
+// Run publishes the feeds queued by MarkChanged every interval until ctx
+// is done. Failures are logged; the feed is published again on its next
+// change or fetch.
+func (p *Publisher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, id := range p.takeChanged() {
+			dbFeed, err := p.feedRepo.GetFeedByID(ctx, id)
+			if err != nil {
+				slog.Error("Failed to get feed to publish", "feed_id", id, "error", err)
+				continue
+			}
+			if dbFeed == nil {
+				continue
+			}
+			if err := p.publishFeed(ctx, dbFeed); err != nil && ctx.Err() == nil {
+				slog.Error("Failed to publish feed", "feed", dbFeed.Name, "error", err)
+			}
+		}
+	}
+}
+
+// Publish renders the feed in every format as GET /feeds/<name> would serve
+// it and writes <name>.xml, <name>.atom and <name>.json. Mirrored feeds
+// publish the stored upstream document as <name>.xml only.
+func (p *Publisher) Publish(ctx context.Context, feedName string) error {
+	dbFeed, err := p.feedRepo.GetFeed(ctx, feedName)
+	if err != nil {
+		return fmt.Errorf("failed to get feed: %w", err)
+	}
+	if dbFeed == nil {
+		return nil
+	}
+	return p.publishFeed(ctx, dbFeed)
+}
+
+func (p *Publisher) publishFeed(ctx context.Context, dbFeed *database.Feed) error {
+	if dbFeed.LastSuccessAt == nil {
+		return nil
+	}
+
+	settings, err := dbFeed.GetSettings()
+	if err != nil {
+		return fmt.Errorf("failed to get feed settings: %w", err)
+	}
+
+	if settings.Mirror {
+		mirror, err := p.feedRepo.GetMirror(ctx, dbFeed.ID)
+		if err != nil {
+			return err
+		}
+		if mirror == nil {
+			return nil
+		}
+		return p.target.Publish(ctx, publishKey(dbFeed.Name, feed.FormatRSS), mirror.Body, mirror.ContentType)
+	}
+
+	items, err := feed.OutputItems(ctx, p.itemRepo, dbFeed, settings, p.cfg.Location, p.cfg.Now())
+	if err != nil {
+		return fmt.Errorf("failed to get feed items: %w", err)
+	}
+	return p.publishDocuments(ctx, dbFeed, items)
+}
+
+// publishDocuments builds the document of items in each format and writes
+// it to the target.
+func (p *Publisher) publishDocuments(ctx context.Context, dbFeed *database.Feed, items []database.Item) error {
+	for _, format := range feed.Formats {
+		doc, err := feed.BuildFormat(feed.ForType(dbFeed.FeedType), format, *dbFeed, items, p.cfg)
+		if err != nil {
+			return fmt.Errorf("failed to build %s feed: %w", format, err)
+		}
+		if err := p.target.Publish(ctx, publishKey(dbFeed.Name, format), []byte(doc), format.ContentType()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishKey names a feed's published document: <name>.xml for RSS, the
+// format as extension otherwise, as in the /feeds/<name>.<format> URLs.
+func publishKey(name string, format feed.Format) string {
+	if format == feed.FormatRSS {
+		return name + ".xml"
+	}
+	return name + "." + string(format)
+}
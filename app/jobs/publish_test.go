@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+type memoryTarget map[string]string
+
+func (m memoryTarget) Publish(_ context.Context, key string, body []byte, contentType string) error {
+	m[key] = contentType + "\n" + string(body)
+	return nil
+}
+
+func TestPublisher_AllFormats(t *testing.T) {
+	target := memoryTarget{}
+	c := &cfg.Cfg{BaseUrl: "https://feeds.example.com", Location: time.UTC, Clock: types.FixedClock(time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC))}
+	p := NewPublisher(target, nil, nil, c)
+
+	dbFeed := &database.Feed{ID: "feed-1", Name: "news", Title: "News", FeedURL: "https://example.com/feed"}
+	items := []database.Item{{ID: "a", Item: types.Item{GUID: "a", Title: "Hello", Link: "https://example.com/a"}}}
+	if err := p.publishDocuments(context.Background(), dbFeed, items); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, contentType := range map[string]string{
+		"news.xml":  "application/xml",
+		"news.atom": "application/atom+xml",
+		"news.json": "application/feed+json",
+	} {
+		doc, ok := target[key]
+		if !ok {
+			t.Errorf("%s was not published", key)
+			continue
+		}
+		if !strings.HasPrefix(doc, contentType) || !strings.Contains(doc, "Hello") {
+			t.Errorf("%s: unexpected document %q", key, doc)
+		}
+	}
+}
+
+func TestPublisher_MarkChanged(t *testing.T) {
+	p := NewPublisher(memoryTarget{}, nil, nil, &cfg.Cfg{})
+	for _, id := range []string{"b", "a", "b"} {
+		p.MarkChanged(id)
+	}
+
+	if got := p.takeChanged(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("expected each changed feed once, got %v", got)
+	}
+	if got := p.takeChanged(); len(got) != 0 {
+		t.Errorf("expected the queue to be emptied, got %v", got)
+	}
+}
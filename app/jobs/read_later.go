@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReadLaterConfig holds the credentials of a read-later service. Readeck
+// uses an API token; Wallabag an OAuth client and the user's login.
+type ReadLaterConfig struct {
+	Service      string // "wallabag" or "readeck"
+	URL          string // Base URL of the instance
+	Token        string
+	ClientID     string
+	ClientSecret string
+	Username     string
+	Password     string
+}
+
+// ReadLater saves item links to a Wallabag or Readeck instance.
+type ReadLater struct {
+	cfg    ReadLaterConfig
+	client *http.Client
+
+	mu           sync.Mutex // Guards the Wallabag access token
+	accessToken  string
+	tokenExpires time.Time
+}
+
+func NewReadLater(cfg ReadLaterConfig, client *http.Client) (*ReadLater, error) {
+	if !strings.HasPrefix(cfg.URL, "https://") && !strings.HasPrefix(cfg.URL, "http://") {
+		return nil, fmt.Errorf("invalid read-later URL %q (expected an http(s) URL)", cfg.URL)
+	}
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+	switch cfg.Service {
+	case "readeck":
+		if cfg.Token == "" {
+			return nil, fmt.Errorf("readeck needs an API token")
+		}
+	case "wallabag":
+		if cfg.ClientID == "" || cfg.ClientSecret == "" || cfg.Username == "" || cfg.Password == "" {
+			return nil, fmt.Errorf("wallabag needs a client ID, client secret, username and password")
+		}
+	default:
+		return nil, fmt.Errorf("unknown read-later service %q (must be one of: wallabag, readeck)", cfg.Service)
+	}
+
+	return &ReadLater{cfg: cfg, client: client}, nil
+}
+
+// Save adds a link to the service's reading list.
+func (r *ReadLater) Save(ctx context.Context, link, title string) error {
+	body, _ := json.Marshal(map[string]string{"url": link, "title": title})
+
+	endpoint := r.cfg.URL + "/api/bookmarks"
+	token := r.cfg.Token
+	if r.cfg.Service == "wallabag" {
+		endpoint = r.cfg.URL + "/api/entries.json"
+		var err error
+		if token, err = r.wallabagToken(ctx); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", r.cfg.Service, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return r.do(req)
+}
+
+// wallabagToken returns a cached access token, requesting a new one with
+// the password grant shortly before it expires.
+func (r *ReadLater) wallabagToken(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.accessToken != "" && time.Now().Before(r.tokenExpires) {
+		return r.accessToken, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {r.cfg.ClientID},
+		"client_secret": {r.cfg.ClientSecret},
+		"username":      {r.cfg.Username},
+		"password":      {r.cfg.Password},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.URL+"/oauth/v2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create wallabag token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallabag token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get wallabag token: HTTP %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to read wallabag token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("failed to read wallabag token: no access_token in the response")
+	}
+
+	r.accessToken = token.AccessToken
+	r.tokenExpires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return r.accessToken, nil
+}
+
+func (r *ReadLater) do(req *http.Request) error {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to save to %s: %w", r.cfg.Service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if resp.StatusCode == http.StatusUnauthorized {
+			// Let the next attempt log in again.
+			r.mu.Lock()
+			r.accessToken = ""
+			r.mu.Unlock()
+		}
+		return fmt.Errorf("failed to save to %s: HTTP %d: %s", r.cfg.Service, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
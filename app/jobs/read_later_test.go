@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadLater_Readeck(t *testing.T) {
+	var saved map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/bookmarks" || r.Header.Get("Authorization") != "Bearer rd-token" {
+			t.Errorf("unexpected request %s %s %v", r.Method, r.URL.Path, r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&saved)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	r, err := NewReadLater(ReadLaterConfig{Service: "readeck", URL: server.URL + "/", Token: "rd-token"}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Save(context.Background(), "https://example.com/a", "A"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved["url"] != "https://example.com/a" || saved["title"] != "A" {
+		t.Errorf("unexpected bookmark %v", saved)
+	}
+}
+
+func TestReadLater_Wallabag(t *testing.T) {
+	logins, saves := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/v2/token":
+			logins++
+			if r.FormValue("grant_type") != "password" || r.FormValue("username") != "jane" {
+				t.Errorf("unexpected token request %v", r.Form)
+			}
+			io.WriteString(w, `{"access_token": "wb-token", "expires_in": 3600}`)
+		case "/api/entries.json":
+			saves++
+			if r.Header.Get("Authorization") != "Bearer wb-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			io.WriteString(w, `{"id": 1}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	r, err := NewReadLater(ReadLaterConfig{Service: "wallabag", URL: server.URL, ClientID: "id", ClientSecret: "secret",
+		Username: "jane", Password: "pw"}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := r.Save(context.Background(), "https://example.com/a", "A"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if logins != 1 || saves != 2 {
+		t.Errorf("expected the token to be reused, got %d logins for %d saves", logins, saves)
+	}
+}
+
+func TestReadLater_WallabagTokenErrors(t *testing.T) {
+	for _, body := range []string{`{"expires_in": 3600}`, `not json`} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, body)
+		}))
+
+		r, err := NewReadLater(ReadLaterConfig{Service: "wallabag", URL: server.URL, ClientID: "id", ClientSecret: "secret",
+			Username: "jane", Password: "pw"}, server.Client())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = r.Save(context.Background(), "https://example.com/a", "A")
+		if err == nil || strings.Contains(err.Error(), "%!") || strings.Contains(err.Error(), "<nil>") {
+			t.Errorf("%s: expected a readable error, got %v", body, err)
+		}
+		server.Close()
+	}
+}
+
+func TestReadLater_SaveError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	r, err := NewReadLater(ReadLaterConfig{Service: "readeck", URL: server.URL, Token: "rd-token"}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Save(context.Background(), "https://example.com/a", "A")
+	if err == nil || !strings.Contains(err.Error(), "HTTP 403: quota exceeded") {
+		t.Errorf("expected the service's answer in the error, got %v", err)
+	}
+}
+
+func TestNewReadLater_Invalid(t *testing.T) {
+	for _, c := range []ReadLaterConfig{
+		{Service: "readeck", URL: "ftp://example.com", Token: "t"},
+		{Service: "readeck", URL: "https://example.com"},
+		{Service: "wallabag", URL: "https://example.com", ClientID: "id"},
+		{Service: "pocket", URL: "https://example.com"},
+	} {
+		if _, err := NewReadLater(c, http.DefaultClient); err == nil {
+			t.Errorf("expected an error for %+v", c)
+		}
+	}
+}
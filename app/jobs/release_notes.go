@@ -0,0 +1,235 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+// ReleaseNotes fetches release notes and commit details from the GitHub
+// and GitLab APIs for items of feeds with the release_notes setting, whose
+// Atom entries only carry a short summary.
+type ReleaseNotes struct {
+	httpClient  *http.Client
+	userAgent   string
+	githubAPI   string
+	githubToken string
+	gitlabToken string
+	gitlabHost  string // The host the GitLab token belongs to
+	gitlabHosts []string
+	clock       types.Clock
+}
+
+const githubAPI = "https://api.github.com"
+
+// NewReleaseNotes creates the client. gitlabURL adds a self-hosted GitLab
+// instance to gitlab.com and makes it the instance the GitLab token is for.
+// Tokens are optional but raise the APIs' rate limits and give access to
+// private projects. Rate-limited fetches are rescheduled on clock (nil =
+// system clock).
+func NewReleaseNotes(httpClient *http.Client, userAgent, githubToken, gitlabToken, gitlabURL string, clock types.Clock) (*ReleaseNotes, error) {
+	r := &ReleaseNotes{
+		httpClient:  withoutCredentialLeaks(httpClient),
+		userAgent:   userAgent,
+		githubAPI:   githubAPI,
+		githubToken: githubToken,
+		gitlabToken: gitlabToken,
+		gitlabHost:  "gitlab.com",
+		clock:       types.ClockOrSystem(clock),
+	}
+	if gitlabURL != "" {
+		u, err := url.Parse(gitlabURL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid GitLab URL %q", gitlabURL)
+		}
+		r.gitlabHost = u.Host
+		r.gitlabHosts = []string{u.Host}
+	}
+	return r, nil
+}
+
+// withoutCredentialLeaks returns a copy of the client that drops the API
+// tokens when a redirect leaves the host they were sent to, or leaves https.
+func withoutCredentialLeaks(httpClient *http.Client) *http.Client {
+	client := *httpClient
+	next := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !strings.EqualFold(req.URL.Host, via[0].URL.Host) || req.URL.Scheme != "https" {
+			req.Header.Del("Authorization")
+			req.Header.Del("PRIVATE-TOKEN")
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after %d redirects", len(via))
+		}
+		return nil
+	}
+	return &client
+}
+
+// Fetch returns the release notes or commit details a link points to as
+// HTML. ok is false for links that aren't GitHub or GitLab releases or
+// commits, which are extracted from the page as usual.
+func (r *ReleaseNotes) Fetch(ctx context.Context, link string) (content string, ok bool, err error) {
+	ref, ok := feed.ParseForgeLink(link, r.gitlabHosts)
+	if !ok {
+		return "", false, nil
+	}
+
+	switch {
+	case ref.Forge == "github" && ref.Kind == "release":
+		content, err = r.githubRelease(ctx, ref)
+	case ref.Forge == "github":
+		content, err = r.githubCommit(ctx, ref)
+	case ref.Kind == "release":
+		content, err = r.gitlabRelease(ctx, ref)
+	default:
+		content, err = r.gitlabCommit(ctx, ref)
+	}
+	return content, true, err
+}
+
+func (r *ReleaseNotes) githubRelease(ctx context.Context, ref feed.ForgeRef) (string, error) {
+	var release struct {
+		BodyHTML string `json:"body_html"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/releases/tags/%s", r.githubAPI, ref.Project, url.PathEscape(ref.Ref))
+	if err := r.get(ctx, endpoint, ref, &release); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(release.BodyHTML) == "" {
+		return "", fmt.Errorf("release %s of %s has no notes", ref.Ref, ref.Project)
+	}
+	return release.BodyHTML, nil
+}
+
+func (r *ReleaseNotes) githubCommit(ctx context.Context, ref feed.ForgeRef) (string, error) {
+	var commit struct {
+		Commit struct {
+			Message string `json:"message"`
+		} `json:"commit"`
+		Stats struct {
+			Additions int `json:"additions"`
+			Deletions int `json:"deletions"`
+		} `json:"stats"`
+		Files []struct {
+			Filename  string `json:"filename"`
+			Additions int    `json:"additions"`
+			Deletions int    `json:"deletions"`
+		} `json:"files"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/commits/%s", r.githubAPI, ref.Project, url.PathEscape(ref.Ref))
+	if err := r.get(ctx, endpoint, ref, &commit); err != nil {
+		return "", err
+	}
+
+	files := make([]feed.CommitFile, len(commit.Files))
+	for i, f := range commit.Files {
+		files[i] = feed.CommitFile{Name: f.Filename, Additions: f.Additions, Deletions: f.Deletions}
+	}
+	return feed.CommitHTML(commit.Commit.Message, commit.Stats.Additions, commit.Stats.Deletions, files), nil
+}
+
+func (r *ReleaseNotes) gitlabRelease(ctx context.Context, ref feed.ForgeRef) (string, error) {
+	var release struct {
+		DescriptionHTML string `json:"description_html"`
+	}
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/releases/%s?include_html_description=true",
+		gitlabAPI(ref), url.PathEscape(ref.Project), url.PathEscape(ref.Ref))
+	if err := r.get(ctx, endpoint, ref, &release); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(release.DescriptionHTML) == "" {
+		return "", fmt.Errorf("release %s of %s has no notes", ref.Ref, ref.Project)
+	}
+	return release.DescriptionHTML, nil
+}
+
+func (r *ReleaseNotes) gitlabCommit(ctx context.Context, ref feed.ForgeRef) (string, error) {
+	var commit struct {
+		Message string `json:"message"`
+		Stats   struct {
+			Additions int `json:"additions"`
+			Deletions int `json:"deletions"`
+		} `json:"stats"`
+	}
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/repository/commits/%s",
+		gitlabAPI(ref), url.PathEscape(ref.Project), url.PathEscape(ref.Ref))
+	if err := r.get(ctx, endpoint, ref, &commit); err != nil {
+		return "", err
+	}
+	return feed.CommitHTML(commit.Message, commit.Stats.Additions, commit.Stats.Deletions, nil), nil
+}
+
+// gitlabAPI returns the base URL of the GitLab instance a link points to.
+// The API is always called over https, whatever the link's scheme.
+func gitlabAPI(ref feed.ForgeRef) string {
+	_, host, _ := strings.Cut(ref.BaseURL, "://")
+	return "https://" + host
+}
+
+// get calls an API endpoint and decodes its JSON answer. Exhausted rate
+// limits become a *RescheduleError for when the limit resets.
+func (r *ReleaseNotes) get(ctx context.Context, endpoint string, ref feed.ForgeRef, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create %s API request: %w", ref.Forge, err)
+	}
+	req.Header.Set("User-Agent", r.userAgent)
+	if ref.Forge == "github" {
+		req.Header.Set("Accept", "application/vnd.github.html+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if r.githubToken != "" {
+			req.Header.Set("Authorization", "Bearer "+r.githubToken)
+		}
+	} else if r.gitlabToken != "" && req.URL.Scheme == "https" && strings.EqualFold(req.URL.Host, r.gitlabHost) {
+		// The host comes from the item link, so the token only goes to the
+		// instance it was configured for.
+		req.Header.Set("PRIVATE-TOKEN", r.gitlabToken)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s API: %w", ref.Forge, err)
+	}
+	defer resp.Body.Close()
+
+	rateLimited := resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0")
+	if rateLimited {
+		return &RescheduleError{RunAfter: r.clock.Now().Add(rateLimitWait(resp.Header)), Reason: ref.Forge + " API rate limit exceeded"}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s API error for %s %s of %s: HTTP %d", ref.Forge, ref.Kind, ref.Ref, ref.Project, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s API response: %w", ref.Forge, err)
+	}
+	return nil
+}
+
+// rateLimitWait reads how long until a rate limit resets from Retry-After
+// or the reset timestamp GitHub (X-RateLimit-Reset) and GitLab
+// (RateLimit-Reset) send, defaulting to an hour.
+func rateLimitWait(header http.Header) time.Duration {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	for _, name := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		if reset, err := strconv.ParseInt(header.Get(name), 10, 64); err == nil {
+			return time.Until(time.Unix(reset, 0))
+		}
+	}
+	return time.Hour
+}
@@ -0,0 +1,163 @@
+package jobs
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lysyi3m/rss-comb/app/types"
+)
+
+func TestReleaseNotes_FetchGitHub(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gh-token" || r.Header.Get("Accept") != "application/vnd.github.html+json" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		switch r.URL.Path {
+		case "/repos/o/r/releases/tags/v1.0":
+			io.WriteString(w, `{"body_html": "<p>Notes</p>"}`)
+		case "/repos/o/r/commits/abc123":
+			io.WriteString(w, `{"commit": {"message": "Fix it"}, "stats": {"additions": 2, "deletions": 1}, "files": [{"filename": "a.go", "additions": 2, "deletions": 1}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+
+	r, err := NewReleaseNotes(api.Client(), "test", "gh-token", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.githubAPI = api.URL
+
+	content, ok, err := r.Fetch(context.Background(), "https://github.com/o/r/releases/tag/v1.0")
+	if err != nil || !ok || content != "<p>Notes</p>" {
+		t.Errorf("release: got %q, %v, %v", content, ok, err)
+	}
+	content, ok, err = r.Fetch(context.Background(), "https://github.com/o/r/commit/abc123")
+	if err != nil || !ok || !strings.Contains(content, "Fix it") || !strings.Contains(content, "a.go") {
+		t.Errorf("commit: got %q, %v, %v", content, ok, err)
+	}
+	if _, ok, err := r.Fetch(context.Background(), "https://example.com/post"); ok || err != nil {
+		t.Errorf("expected other links to be left alone, got %v, %v", ok, err)
+	}
+	if _, _, err := r.Fetch(context.Background(), "https://github.com/o/r/releases/tag/missing"); err == nil {
+		t.Error("expected an error for a missing release")
+	}
+}
+
+func TestReleaseNotes_GitLabToken(t *testing.T) {
+	other := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.Header.Get("PRIVATE-TOKEN"); token != "" {
+			t.Errorf("token %q leaked to another host", token)
+		}
+		io.WriteString(w, `{"description_html": "<p>Moved</p>"}`)
+	}))
+	defer other.Close()
+
+	gitlab := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "gl-token" {
+			t.Errorf("expected the token on the configured host, got %v", r.Header)
+		}
+		if strings.Contains(r.URL.EscapedPath(), "moved") {
+			http.Redirect(w, r, other.URL+r.URL.EscapedPath(), http.StatusFound)
+			return
+		}
+		io.WriteString(w, `{"description_html": "<p>Notes</p>"}`)
+	}))
+	defer gitlab.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	r, err := NewReleaseNotes(client, "test", "", "gl-token", gitlab.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := strings.TrimPrefix(gitlab.URL, "https://")
+
+	// An http link still calls the API over https.
+	content, ok, err := r.Fetch(context.Background(), "http://"+host+"/team/app/-/releases/v1.0")
+	if err != nil || !ok || content != "<p>Notes</p>" {
+		t.Errorf("got %q, %v, %v", content, ok, err)
+	}
+	content, _, err = r.Fetch(context.Background(), "https://"+host+"/team/moved/-/releases/v1.0")
+	if err != nil || content != "<p>Moved</p>" {
+		t.Errorf("redirect: got %q, %v", content, err)
+	}
+}
+
+func TestReleaseNotes_GitLabTokenOtherHost(t *testing.T) {
+	var sent http.Header
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"description_html": "<p>Notes</p>"}`))}, nil
+	})}
+	r, err := NewReleaseNotes(client, "test", "", "gl-token", "https://git.example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := r.Fetch(context.Background(), "https://gitlab.com/group/project/-/releases/v1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Get("PRIVATE-TOKEN") != "" {
+		t.Error("expected the self-hosted token not to be sent to gitlab.com")
+	}
+}
+
+func TestReleaseNotes_RateLimit(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer api.Close()
+
+	clock := types.FixedClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	r, err := NewReleaseNotes(api.Client(), "test", "", "", "", clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.githubAPI = api.URL
+
+	_, _, err = r.Fetch(context.Background(), "https://github.com/o/r/releases/tag/v1.0")
+	var reschedule *RescheduleError
+	if !errors.As(err, &reschedule) {
+		t.Fatalf("expected a reschedule, got %v", err)
+	}
+	// The reset is 30 minutes away, counted from the injected clock.
+	if wait := reschedule.RunAfter.Sub(clock.Now()); wait < 29*time.Minute || wait > 30*time.Minute {
+		t.Errorf("expected a reschedule about 30m after the clock, got %s", wait)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "120")
+	if got := rateLimitWait(header); got != 120*time.Second {
+		t.Errorf("Retry-After: got %s", got)
+	}
+
+	header = http.Header{}
+	header.Set("RateLimit-Reset", strconv.FormatInt(time.Now().Add(10*time.Minute).Unix(), 10))
+	if got := rateLimitWait(header); got < 9*time.Minute || got > 10*time.Minute {
+		t.Errorf("RateLimit-Reset: got %s", got)
+	}
+
+	if got := rateLimitWait(http.Header{}); got != time.Hour {
+		t.Errorf("default: got %s", got)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -3,61 +3,144 @@ package jobs
 import (
 	"context"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
 )
 
 type Scheduler struct {
-	interval time.Duration
-	feedRepo *database.FeedRepository
-	jobRepo  *database.JobRepository
+	interval      atomic.Int64 // time.Duration
+	reset         chan struct{}
+	staleTimeouts map[string]time.Duration
+	warmup        time.Duration
+	maintenance   *Maintenance
+	feedRepo      *database.FeedRepository
+	itemRepo      *database.ItemRepository
+	jobRepo       *database.JobRepository
+	clock         types.Clock
 }
 
-func NewScheduler(interval time.Duration, feedRepo *database.FeedRepository, jobRepo *database.JobRepository) *Scheduler {
-	return &Scheduler{
-		interval: interval,
-		feedRepo: feedRepo,
-		jobRepo:  jobRepo,
+// NewScheduler creates a scheduler. Jobs stuck in processing longer than
+// the staleTimeouts entry of their type are returned to the queue; each must
+// exceed the type's job timeout so running jobs aren't picked up twice, and
+// types without an entry are never reset. Fetches due at startup are
+// spread evenly over warmup (0 enqueues them all at once). Due fetches,
+// staggered start times and drip-feed releases are timed by clock, which
+// defaults to the system clock when nil.
+func NewScheduler(
+	interval time.Duration,
+	staleTimeouts map[string]time.Duration,
+	warmup time.Duration,
+	maintenance *Maintenance,
+	feedRepo *database.FeedRepository,
+	itemRepo *database.ItemRepository,
+	jobRepo *database.JobRepository,
+	clock types.Clock,
+) *Scheduler {
+	s := &Scheduler{
+		reset:         make(chan struct{}, 1),
+		staleTimeouts: staleTimeouts,
+		warmup:        warmup,
+		maintenance:   maintenance,
+		feedRepo:      feedRepo,
+		itemRepo:      itemRepo,
+		jobRepo:       jobRepo,
+		clock:         types.ClockOrSystem(clock),
+	}
+	s.interval.Store(int64(interval))
+	return s
+}
+
+// Interval returns the current tick interval.
+func (s *Scheduler) Interval() time.Duration {
+	return time.Duration(s.interval.Load())
+}
+
+// SetInterval changes the tick interval of a running scheduler; the next
+// tick happens one new interval from now.
+func (s *Scheduler) SetInterval(interval time.Duration) {
+	if s.interval.Swap(int64(interval)) == int64(interval) {
+		return
+	}
+
+	// Run reads the new value; a pending signal already covers this change.
+	select {
+	case s.reset <- struct{}{}:
+	default:
 	}
 }
 
 // Run starts the scheduler loop. It creates fetch_feed jobs for due feeds
 // and resets stale jobs on each tick. Blocks until ctx is cancelled.
 func (s *Scheduler) Run(ctx context.Context) {
-	ticker := time.NewTicker(s.interval)
+	ticker := time.NewTicker(s.Interval())
 	defer ticker.Stop()
 
-	slog.Info("Scheduler started", "interval", s.interval)
+	slog.Info("Scheduler started", "interval", s.Interval())
 
 	// Immediate tick on startup — don't wait for the first interval
-	s.tick()
+	s.tick(ctx, s.warmup)
 
 	for {
 		select {
 		case <-ctx.Done():
 			slog.Info("Scheduler stopped")
 			return
+		case <-s.reset:
+			interval := s.Interval()
+			ticker.Reset(interval)
+			slog.Info("Scheduler interval changed", "interval", interval)
 		case <-ticker.C:
-			s.tick()
+			s.tick(ctx, 0)
 		}
 	}
 }
 
-func (s *Scheduler) tick() {
-	feeds, err := s.feedRepo.GetDueFeeds()
+// tick enqueues fetches for due feeds, most overdue first. With a non-zero
+// spread, their start times are staggered across that window.
+func (s *Scheduler) tick(ctx context.Context, spread time.Duration) {
+	if s.maintenance.Enabled() {
+		slog.Debug("Scheduler tick skipped, maintenance mode is on")
+		return
+	}
+
+	feeds, err := s.feedRepo.GetDueFeeds(ctx, s.clock.Now())
 	if err != nil {
 		slog.Error("Scheduler failed to get due feeds", "error", err)
 		return
 	}
 
-	for _, f := range feeds {
-		if _, err := s.jobRepo.CreateJob("fetch_feed", f.ID, nil, 0); err != nil {
+	if spread > 0 && len(feeds) > 1 {
+		slog.Info("Staggering initial feed fetches", "feeds", len(feeds), "window", spread)
+	}
+
+	now := s.clock.Now()
+	for i, f := range feeds {
+		var runAfter *time.Time
+		if spread > 0 && i > 0 {
+			at := now.Add(spread * time.Duration(i) / time.Duration(len(feeds)))
+			runAfter = &at
+		}
+		if _, err := s.jobRepo.CreateJobAfter(ctx, "fetch_feed", f.ID, nil, 0, runAfter); err != nil {
 			slog.Error("Scheduler failed to create fetch_feed job", "feed", f.Name, "error", err)
 		}
 	}
 
-	resetCount, err := s.jobRepo.ResetStaleJobs(10 * time.Minute)
+	refilterFeeds, err := s.feedRepo.GetFeedsDueRefilter(ctx, s.clock.Now())
+	if err != nil {
+		slog.Error("Scheduler failed to get feeds due refilter", "error", err)
+	}
+	for _, f := range refilterFeeds {
+		if _, err := s.jobRepo.CreateJob(ctx, "refilter_feed", f.ID, nil, 0); err != nil {
+			slog.Error("Scheduler failed to create refilter_feed job", "feed", f.Name, "error", err)
+		}
+	}
+
+	s.releaseDripItems(ctx)
+
+	resetCount, err := s.jobRepo.ResetStaleJobs(ctx, s.staleTimeouts, s.clock.Now())
 	if err != nil {
 		slog.Error("Scheduler failed to reset stale jobs", "error", err)
 		return
@@ -66,3 +149,32 @@ func (s *Scheduler) tick() {
 		slog.Warn("Reset stale jobs", "count", resetCount)
 	}
 }
+
+// releaseDripItems releases the next batch of items for drip-feeds whose
+// release interval has passed.
+func (s *Scheduler) releaseDripItems(ctx context.Context) {
+	feeds, err := s.feedRepo.GetAllFeeds(ctx)
+	if err != nil {
+		slog.Error("Scheduler failed to get feeds for drip release", "error", err)
+		return
+	}
+
+	for _, f := range feeds {
+		if !f.IsEnabled || f.IsPaused {
+			continue
+		}
+		settings, err := f.GetSettings()
+		if err != nil || settings.DripInterval <= 0 {
+			continue
+		}
+
+		released, err := s.itemRepo.ReleaseNextItems(ctx, f.ID, max(settings.DripCount, 1), time.Duration(settings.DripInterval), s.clock.Now())
+		if err != nil {
+			slog.Error("Scheduler failed to release drip items", "feed", f.Name, "error", err)
+			continue
+		}
+		if released > 0 {
+			slog.Info("Released drip items", "feed", f.Name, "count", released)
+		}
+	}
+}
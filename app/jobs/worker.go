@@ -3,95 +3,268 @@ package jobs
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/types"
 )
 
 type HandlerFunc func(ctx context.Context, job *database.Job) error
 
+type registeredHandler struct {
+	fn      HandlerFunc
+	timeout time.Duration // 0 means no limit
+}
+
 type WorkerPool struct {
-	jobRepo  *database.JobRepository
-	handlers map[string]HandlerFunc
-	count    int
-	wg       sync.WaitGroup
+	jobRepo     *database.JobRepository
+	maintenance *Maintenance
+	handlers    map[string]registeredHandler
+	count       int
+	wg          sync.WaitGroup
+
+	mu     sync.Mutex
+	ctx    context.Context
+	stops  []chan struct{} // one per running worker, closed to retire it
+	nextID int
+
+	busy atomic.Int32
+
+	slowJob time.Duration // jobs running longer are logged and counted; 0 disables
+
+	clock types.Clock // decides which jobs are due and when failed ones retry
+
+	statsMu  sync.Mutex
+	timeouts map[string]int // per job type
+	slowJobs map[string]int // per job type
 }
 
-func NewWorkerPool(jobRepo *database.JobRepository, count int) *WorkerPool {
+// NewWorkerPool creates a pool of count workers. A nil clock is the system
+// clock.
+func NewWorkerPool(jobRepo *database.JobRepository, maintenance *Maintenance, count int, slowJob time.Duration, clock types.Clock) *WorkerPool {
 	return &WorkerPool{
-		jobRepo:  jobRepo,
-		handlers: make(map[string]HandlerFunc),
-		count:    count,
+		jobRepo:     jobRepo,
+		maintenance: maintenance,
+		handlers:    make(map[string]registeredHandler),
+		count:       count,
+		slowJob:     slowJob,
+		clock:       types.ClockOrSystem(clock),
+		timeouts:    make(map[string]int),
+		slowJobs:    make(map[string]int),
 	}
 }
 
-func (wp *WorkerPool) RegisterHandler(jobType string, handler HandlerFunc) {
-	wp.handlers[jobType] = handler
+// RegisterHandler sets the handler for a job type. Each run gets at most
+// timeout to finish (0 disables the limit).
+func (wp *WorkerPool) RegisterHandler(jobType string, handler HandlerFunc, timeout time.Duration) {
+	wp.handlers[jobType] = registeredHandler{fn: handler, timeout: timeout}
+}
+
+// minStaleTimeout is the shortest time a job runs before it counts as stuck.
+const minStaleTimeout = 10 * time.Minute
+
+// StaleTimeouts returns, per job type, how long a job may stay processing
+// before it is presumed stuck: its timeout plus a minute, at least
+// minStaleTimeout. Types without a timeout are left out, since a long run
+// can't be told from a stuck one and resetting it would run it twice.
+func (wp *WorkerPool) StaleTimeouts() map[string]time.Duration {
+	stale := make(map[string]time.Duration)
+	for jobType, h := range wp.handlers {
+		if h.timeout > 0 {
+			stale[jobType] = max(minStaleTimeout, h.timeout+time.Minute)
+		}
+	}
+	return stale
+}
+
+// Timeouts returns how many jobs of each type ran out of time.
+func (wp *WorkerPool) Timeouts() map[string]int {
+	wp.statsMu.Lock()
+	defer wp.statsMu.Unlock()
+	return maps.Clone(wp.timeouts)
+}
+
+// SlowJobs returns how many jobs of each type ran longer than the slow job
+// threshold, including ones that then timed out.
+func (wp *WorkerPool) SlowJobs() map[string]int {
+	wp.statsMu.Lock()
+	defer wp.statsMu.Unlock()
+	return maps.Clone(wp.slowJobs)
 }
 
 // Start spawns worker goroutines that poll for and execute jobs.
 func (wp *WorkerPool) Start(ctx context.Context) {
-	for i := range wp.count {
-		wp.wg.Add(1)
-		go wp.runWorker(ctx, i)
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.ctx = ctx
+	for range wp.count {
+		wp.spawnWorker()
 	}
 	slog.Info("Worker pool started", "workers", wp.count)
 }
 
+// Resize changes the number of running workers. Retired workers finish
+// their current job before exiting; queued jobs stay in the database and
+// are picked up by the remaining workers.
+func (wp *WorkerPool) Resize(count int) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if count == wp.count {
+		return
+	}
+
+	previous := wp.count
+	wp.count = count
+
+	// Not started yet: Start will spawn the new count.
+	if wp.ctx == nil {
+		return
+	}
+
+	for len(wp.stops) < count {
+		wp.spawnWorker()
+	}
+	for len(wp.stops) > count {
+		last := len(wp.stops) - 1
+		close(wp.stops[last])
+		wp.stops = wp.stops[:last]
+	}
+
+	slog.Info("Worker pool resized", "from", previous, "to", count)
+}
+
+// Size returns the configured number of workers.
+func (wp *WorkerPool) Size() int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return wp.count
+}
+
+// Busy returns the number of workers currently executing a job.
+func (wp *WorkerPool) Busy() int {
+	return int(wp.busy.Load())
+}
+
+// spawnWorker must be called with wp.mu held.
+func (wp *WorkerPool) spawnWorker() {
+	stop := make(chan struct{})
+	wp.stops = append(wp.stops, stop)
+	wp.wg.Add(1)
+	go wp.runWorker(wp.ctx, wp.nextID, stop)
+	wp.nextID++
+}
+
 // Wait blocks until all workers have finished.
 func (wp *WorkerPool) Wait() {
 	wp.wg.Wait()
 }
 
-func (wp *WorkerPool) runWorker(ctx context.Context, id int) {
+func (wp *WorkerPool) runWorker(ctx context.Context, id int, stop <-chan struct{}) {
 	defer wp.wg.Done()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-stop:
+			return
 		default:
 		}
 
-		job, err := wp.jobRepo.ClaimJob()
+		// In maintenance mode running jobs finish, but no new ones are claimed.
+		if wp.maintenance.Enabled() {
+			sleepWithContext(ctx, stop, 1*time.Second)
+			continue
+		}
+
+		job, err := wp.jobRepo.ClaimJob(ctx, wp.clock.Now())
 		if err != nil {
 			slog.Error("Failed to claim job", "worker_id", id, "error", err)
-			sleepWithContext(ctx, 1*time.Second)
+			sleepWithContext(ctx, stop, 1*time.Second)
 			continue
 		}
 
 		if job == nil {
-			sleepWithContext(ctx, 1*time.Second)
+			sleepWithContext(ctx, stop, 1*time.Second)
 			continue
 		}
 
 		handler, ok := wp.handlers[job.JobType]
 		if !ok {
 			slog.Error("No handler registered for job type", "worker_id", id, "job_type", job.JobType, "job_id", job.ID)
-			_ = wp.jobRepo.FailJob(job.ID, "no handler registered for job type: "+job.JobType)
+			_ = wp.jobRepo.FailJob(ctx, job.ID, "no handler registered for job type: "+job.JobType, false, wp.clock.Now())
 			continue
 		}
 
-		if err := handler(ctx, job); err != nil {
+		wp.busy.Add(1)
+		started := time.Now()
+		err = wp.execute(ctx, handler, job)
+		wp.busy.Add(-1)
+		wp.recordDuration(job, time.Since(started))
+
+		// Record the outcome even if shutdown cancelled the job meanwhile,
+		// so it isn't left processing until the stale job reset.
+		doneCtx := context.WithoutCancel(ctx)
+
+		if err != nil {
 			var rescheduleErr *RescheduleError
 			if errors.As(err, &rescheduleErr) {
 				slog.Info("Job rescheduled", "worker_id", id, "job_type", job.JobType, "job_id", job.ID, "run_after", rescheduleErr.RunAfter, "reason", rescheduleErr.Reason)
-				_ = wp.jobRepo.DelayJob(job.ID, rescheduleErr.RunAfter)
+				_ = wp.jobRepo.DelayJob(doneCtx, job.ID, rescheduleErr.RunAfter, wp.clock.Now())
 			} else {
-				slog.Error("Job failed", "worker_id", id, "job_type", job.JobType, "job_id", job.ID, "error", err)
-				_ = wp.jobRepo.FailJob(job.ID, err.Error())
+				// Retrying can't fix a missing feed or a broken document.
+				permanent := types.Permanent(err)
+				slog.Error("Job failed", "worker_id", id, "job_type", job.JobType, "job_id", job.ID, "permanent", permanent, "error", err)
+				_ = wp.jobRepo.FailJob(doneCtx, job.ID, err.Error(), permanent, wp.clock.Now())
+				if job.MaxRetries > 0 && (permanent || job.Retries+1 >= job.MaxRetries) {
+					slog.Warn("Job moved to dead letter queue", "worker_id", id, "job_type", job.JobType, "job_id", job.ID, "retries", job.Retries+1)
+				}
 			}
 		} else {
-			_ = wp.jobRepo.CompleteJob(job.ID)
+			_ = wp.jobRepo.CompleteJob(doneCtx, job.ID)
 		}
 	}
 }
 
-func sleepWithContext(ctx context.Context, d time.Duration) {
+func (wp *WorkerPool) recordDuration(job *database.Job, elapsed time.Duration) {
+	if wp.slowJob <= 0 || elapsed < wp.slowJob {
+		return
+	}
+	slog.Info("Slow job", "job_type", job.JobType, "job_id", job.ID, "duration", elapsed)
+	wp.statsMu.Lock()
+	wp.slowJobs[job.JobType]++
+	wp.statsMu.Unlock()
+}
+
+func (wp *WorkerPool) execute(ctx context.Context, handler registeredHandler, job *database.Job) error {
+	if handler.timeout <= 0 {
+		return handler.fn(ctx, job)
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, handler.timeout)
+	defer cancel()
+
+	err := handler.fn(jobCtx, job)
+	if err != nil && errors.Is(jobCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		wp.statsMu.Lock()
+		wp.timeouts[job.JobType]++
+		wp.statsMu.Unlock()
+		return fmt.Errorf("timed out after %s: %w", handler.timeout, err)
+	}
+	return err
+}
+
+func sleepWithContext(ctx context.Context, stop <-chan struct{}, d time.Duration) {
 	select {
 	case <-ctx.Done():
+	case <-stop:
 	case <-time.After(d):
 	}
 }
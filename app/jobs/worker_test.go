@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleTimeouts(t *testing.T) {
+	pool := NewWorkerPool(nil, &Maintenance{}, 1, 0, nil)
+	pool.RegisterHandler("fetch_feed", nil, 2*time.Minute)
+	pool.RegisterHandler("extract_content", nil, 30*time.Minute)
+	pool.RegisterHandler("download_media", nil, 0)
+
+	stale := pool.StaleTimeouts()
+	if stale["fetch_feed"] != minStaleTimeout {
+		t.Errorf("expected short timeouts to be raised to %s, got %s", minStaleTimeout, stale["fetch_feed"])
+	}
+	if stale["extract_content"] != 31*time.Minute {
+		t.Errorf("expected the timeout plus a minute, got %s", stale["extract_content"])
+	}
+	if _, ok := stale["download_media"]; ok {
+		t.Error("expected jobs without a timeout never to be reset")
+	}
+}
@@ -14,13 +14,31 @@ import (
 
 	"github.com/lysyi3m/rss-comb/app/api"
 	"github.com/lysyi3m/rss-comb/app/cfg"
+	"github.com/lysyi3m/rss-comb/app/ctl"
 	"github.com/lysyi3m/rss-comb/app/database"
+	"github.com/lysyi3m/rss-comb/app/demo"
 	"github.com/lysyi3m/rss-comb/app/feed"
+	"github.com/lysyi3m/rss-comb/app/feedgen"
 	"github.com/lysyi3m/rss-comb/app/jobs"
 	"github.com/lysyi3m/rss-comb/app/media"
+	"github.com/lysyi3m/rss-comb/app/storage"
+	"github.com/lysyi3m/rss-comb/app/types"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "ctl" {
+		os.Exit(ctl.Run(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "feedgen" {
+		os.Exit(feedgen.Run(os.Args[2:]))
+	}
+	// `rss-comb demo` takes the server's configuration, seeds the demo
+	// feeds and exits.
+	demoMode := len(os.Args) > 1 && os.Args[1] == "demo"
+	if demoMode {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	cfg, err := cfg.Load()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
@@ -30,7 +48,9 @@ func main() {
 		return
 	}
 
-	initializeLogger()
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.Level)
+	initializeLogger(logLevel)
 
 	slog.Info("Starting RSS Comb server", "version", cfg.Version)
 
@@ -42,8 +62,27 @@ func main() {
 		os.Exit(1)
 	}
 	defer db.Close()
+	db.LogSlowQueries(time.Duration(cfg.SlowQueryMs) * time.Millisecond)
 	slog.Info("Database connected")
 
+	var readDB *database.DB
+	if cfg.DBReadHost != "" {
+		readPort := cfg.DBReadPort
+		if readPort == "" {
+			readPort = cfg.DBPort
+		}
+		readDB, err = database.NewConnection(
+			cfg.DBReadHost, readPort, cfg.DBUser,
+			cfg.DBPassword, cfg.DBName)
+		if err != nil {
+			slog.Error("Read replica connection failed", "host", cfg.DBReadHost, "error", err)
+			os.Exit(1)
+		}
+		defer readDB.Close()
+		readDB.ShareSlowQueryLog(db)
+		slog.Info("Read replica connected", "host", cfg.DBReadHost)
+	}
+
 	version, dirty, err := database.RunMigrations(db)
 	if err != nil {
 		slog.Error("Database migration failed", "error", err)
@@ -52,14 +91,54 @@ func main() {
 	slog.Info("Database migrations completed", "version", version, "dirty", dirty)
 
 	feedRepo := database.NewFeedRepository(db)
-	itemRepo := database.NewItemRepository(db)
+	var contentStore storage.ContentStore
+	if cfg.ContentDir != "" {
+		fileStore, err := storage.NewFileStore(cfg.ContentDir)
+		if err != nil {
+			slog.Error("Failed to initialize content store", "path", cfg.ContentDir, "error", err)
+			os.Exit(1)
+		}
+		contentStore = storage.NewCachedStore(fileStore, cfg.ContentCacheSize<<20)
+		slog.Info("Storing extracted content on disk", "path", cfg.ContentDir)
+	}
+	itemRepo := database.NewItemRepository(db, readDB, contentStore)
+	// Before the workers start, so no extraction is between storing content
+	// and referencing it.
+	if deleted, err := itemRepo.CleanupContent(context.Background()); err != nil {
+		slog.Error("Content cleanup failed", "error", err)
+	} else if deleted > 0 {
+		slog.Info("Content cleanup completed", "deleted", deleted)
+	}
+	feedCache := api.NewFeedCache(time.Duration(cfg.FeedCacheTTL)*time.Second, cfg.Clock)
+	itemRepo.OnChange(feedCache.Invalidate)
 
-	hasMediaFeeds, err := loadFeedConfigurations(cfg.FeedsDir, feedRepo)
+	hasMediaFeeds, err := loadFeedConfigurations(cfg.FeedsDir, &cfg.FeedDefaults, feedRepo, cfg.Location, cfg.Now())
 	if err != nil {
 		slog.Error("Configuration loading failed", "directory", cfg.FeedsDir, "error", err)
 		os.Exit(1)
 	}
 
+	stateRepo := database.NewStateRepository(db)
+	blocklist := feed.NewBlocklist(cfg.BlocklistFile, feedRepo, stateRepo)
+	if err := blocklist.Load(); err != nil {
+		slog.Error("Blocklist loading failed", "path", cfg.BlocklistFile, "error", err)
+		os.Exit(1)
+	}
+	if err := blocklist.Sync(context.Background(), cfg.Now()); err != nil {
+		slog.Error("Failed to apply blocklist changes", "error", err)
+	}
+
+	if demoMode {
+		demoClient := &http.Client{Transport: demo.Transport{Clock: cfg.Clock}}
+		fetch := jobs.FetchFeedHandler(blocklist, feedRepo, itemRepo, database.NewJobRepository(db), demoClient, cfg.UserAgent, cfg.MediaDir, nil, false, cfg.Location, cfg.Clock)
+		if err := demo.Seed(context.Background(), &cfg.FeedDefaults, feedRepo, fetch, cfg.Location, cfg.Now()); err != nil {
+			slog.Error("Demo seeding failed", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Demo feeds seeded; start the server to browse them", "feeds", demo.Names())
+		return
+	}
+
 	if err := os.MkdirAll(cfg.MediaDir, 0755); err != nil {
 		slog.Error("Failed to create media directory", "path", cfg.MediaDir, "error", err)
 		os.Exit(1)
@@ -79,32 +158,145 @@ func main() {
 		slog.Info("yt-dlp validated", "command", cfg.YTDLPCmd)
 	}
 
+	transport := &http.Transport{
+		MaxIdleConns:        10,
+		IdleConnTimeout:     30 * time.Second,
+		DisableCompression:  false,
+		DisableKeepAlives:   false,
+		MaxIdleConnsPerHost: 5,
+	}
+	if cfg.DNSCacheTTL > 0 || cfg.DNSServer != "" {
+		dnsCache, err := jobs.NewDNSCache(time.Duration(cfg.DNSCacheTTL)*time.Second, cfg.DNSServer)
+		if err != nil {
+			slog.Error("Invalid DNS configuration", "error", err)
+			os.Exit(1)
+		}
+		transport.DialContext = dnsCache.DialContext
+		slog.Info("Using DNS cache for fetches", "ttl_seconds", cfg.DNSCacheTTL, "server", cfg.DNSServer)
+	}
 	httpClient := &http.Client{
-		Transport: &http.Transport{
-			MaxIdleConns:        10,
-			IdleConnTimeout:     30 * time.Second,
-			DisableCompression:  false,
-			DisableKeepAlives:   false,
-			MaxIdleConnsPerHost: 5,
-		},
+		CheckRedirect: jobs.RedirectPolicy{MaxRedirects: cfg.MaxRedirects, AllowDowngrade: cfg.AllowInsecureRedirects}.CheckRedirect,
+		Transport:     transport,
 	}
 
 	jobRepo := database.NewJobRepository(db)
 
-	pool := jobs.NewWorkerPool(jobRepo, cfg.WorkerCount)
-	pool.RegisterHandler("fetch_feed", jobs.FetchFeedHandler(feedRepo, itemRepo, jobRepo, httpClient, cfg.UserAgent, cfg.MediaDir))
-	pool.RegisterHandler("extract_content", jobs.ExtractContentHandler(feedRepo, itemRepo, httpClient, cfg.UserAgent))
-	pool.RegisterHandler("download_media", jobs.DownloadMediaHandler(feedRepo, itemRepo, cfg.YTDLPCmd, cfg.YTDLPArgs, cfg.MediaDir))
+	maintenance, err := jobs.NewMaintenance(context.Background(), stateRepo)
+	if err != nil {
+		slog.Error("Failed to load maintenance state", "error", err)
+		os.Exit(1)
+	}
+	if maintenance.Enabled() {
+		slog.Warn("Maintenance mode is on: scheduling and job processing are paused")
+	}
 
-	scheduler := jobs.NewScheduler(time.Duration(cfg.SchedulerInterval)*time.Second, feedRepo, jobRepo)
+	crawlLimiter, err := jobs.NewCrawlLimiter(time.Duration(cfg.CrawlDelayMs)*time.Millisecond, cfg.CrawlDelays, cfg.Clock)
+	if err != nil {
+		slog.Error("Invalid crawl delay configuration", "error", err)
+		os.Exit(1)
+	}
+
+	releaseNotes, err := jobs.NewReleaseNotes(httpClient, cfg.UserAgent, cfg.GitHubToken, cfg.GitLabToken, cfg.GitLabURL, cfg.Clock)
+	if err != nil {
+		slog.Error("Invalid release notes configuration", "error", err)
+		os.Exit(1)
+	}
+	extractor := jobs.NewExtractor(httpClient, cfg.UserAgent,
+		jobs.RedirectPolicy{MaxRedirects: cfg.ArticleMaxRedirects, AllowDowngrade: cfg.AllowInsecureRedirects}, crawlLimiter, releaseNotes)
+	dryRunner := jobs.NewDryRunner(blocklist, itemRepo, httpClient, cfg.UserAgent, cfg.Clock)
+
+	pool := jobs.NewWorkerPool(jobRepo, maintenance, cfg.WorkerCount, time.Duration(cfg.SlowJobSeconds)*time.Second, cfg.Clock)
+	var publisher *jobs.Publisher
+	switch {
+	case cfg.PublishDir != "" && cfg.PublishS3Endpoint != "":
+		slog.Error("PUBLISH_DIR and PUBLISH_S3_ENDPOINT are mutually exclusive")
+		os.Exit(1)
+	case cfg.PublishDir != "":
+		target, err := storage.NewDirTarget(cfg.PublishDir)
+		if err != nil {
+			slog.Error("Failed to initialize publish directory", "path", cfg.PublishDir, "error", err)
+			os.Exit(1)
+		}
+		publisher = jobs.NewPublisher(target, feedRepo, itemRepo, cfg)
+		slog.Info("Publishing feeds to directory", "path", cfg.PublishDir)
+	case cfg.PublishS3Endpoint != "":
+		target, err := storage.NewS3Target(cfg.PublishS3Endpoint, cfg.PublishS3Bucket, cfg.PublishS3Region, cfg.PublishS3Prefix,
+			cfg.PublishS3AccessKey, cfg.PublishS3SecretKey, httpClient)
+		if err != nil {
+			slog.Error("Invalid S3 publish configuration", "error", err)
+			os.Exit(1)
+		}
+		publisher = jobs.NewPublisher(target, feedRepo, itemRepo, cfg)
+		slog.Info("Publishing feeds to S3", "endpoint", cfg.PublishS3Endpoint, "bucket", cfg.PublishS3Bucket)
+	}
+	if publisher != nil {
+		itemRepo.OnChange(publisher.MarkChanged)
+	}
+
+	var readLater *jobs.ReadLater
+	if cfg.ReadLaterService != "" {
+		readLater, err = jobs.NewReadLater(jobs.ReadLaterConfig{
+			Service: cfg.ReadLaterService, URL: cfg.ReadLaterURL, Token: cfg.ReadLaterToken,
+			ClientID: cfg.ReadLaterClientID, ClientSecret: cfg.ReadLaterClientSecret,
+			Username: cfg.ReadLaterUsername, Password: cfg.ReadLaterPassword,
+		}, httpClient)
+		if err != nil {
+			slog.Error("Invalid read-later configuration", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Saving items to read-later service", "service", cfg.ReadLaterService, "url", cfg.ReadLaterURL)
+	}
+
+	pool.RegisterHandler("fetch_feed", jobs.FetchFeedHandler(blocklist, feedRepo, itemRepo, jobRepo, httpClient, cfg.UserAgent, cfg.MediaDir, publisher, cfg.ReadLaterService != "", cfg.Location, cfg.Clock),
+		time.Duration(cfg.FetchJobTimeout)*time.Second)
+	pool.RegisterHandler("refilter_feed", jobs.RefilterFeedHandler(blocklist, feedRepo, itemRepo, cfg.Clock),
+		time.Duration(cfg.FetchJobTimeout)*time.Second)
+	pool.RegisterHandler("extract_content", jobs.ExtractContentHandler(blocklist, feedRepo, itemRepo, extractor, cfg.Clock),
+		time.Duration(cfg.ExtractJobTimeout)*time.Second)
+	pool.RegisterHandler("download_media", jobs.DownloadMediaHandler(feedRepo, itemRepo, cfg.YTDLPCmd, cfg.YTDLPArgs, cfg.MediaDir, cfg.Clock),
+		time.Duration(cfg.MediaJobTimeout)*time.Second)
+	pool.RegisterHandler("save_item", jobs.SaveItemHandler(itemRepo, readLater),
+		time.Duration(cfg.ExtractJobTimeout)*time.Second)
+
+	scheduler := jobs.NewScheduler(time.Duration(cfg.SchedulerInterval)*time.Second, pool.StaleTimeouts(),
+		time.Duration(cfg.StartupWarmup)*time.Second, maintenance, feedRepo, itemRepo, jobRepo, cfg.Clock)
+	autoscaler := jobs.NewAutoscaler(pool, jobRepo, cfg.WorkerCount, cfg.WorkerMax)
 
 	jobCtx, jobCancel := context.WithCancel(context.Background())
 	var jobWg sync.WaitGroup
-	jobWg.Add(1)
+	jobWg.Add(2)
 	go func() {
 		defer jobWg.Done()
 		scheduler.Run(jobCtx)
 	}()
+	go func() {
+		defer jobWg.Done()
+		autoscaler.Run(jobCtx)
+	}()
+	if publisher != nil {
+		jobWg.Add(1)
+		go func() {
+			defer jobWg.Done()
+			publisher.Run(jobCtx, 10*time.Second)
+		}()
+	}
+	if cfg.ArchiveDir != "" {
+		if cfg.ArchiveInterval <= 0 {
+			slog.Error("ARCHIVE_INTERVAL must be positive")
+			os.Exit(1)
+		}
+		archiver, err := jobs.NewArchiver(cfg.ArchiveDir, feedRepo, itemRepo, cfg.Location, cfg.Clock)
+		if err != nil {
+			slog.Error("Failed to initialize archive directory", "path", cfg.ArchiveDir, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Exporting static archive", "path", cfg.ArchiveDir, "interval", time.Duration(cfg.ArchiveInterval)*time.Second)
+		jobWg.Add(1)
+		go func() {
+			defer jobWg.Done()
+			archiver.Run(jobCtx, time.Duration(cfg.ArchiveInterval)*time.Second)
+		}()
+	}
 	pool.Start(jobCtx)
 	defer func() {
 		jobCancel()
@@ -112,7 +304,7 @@ func main() {
 		jobWg.Wait()
 	}()
 
-	apiHandler := api.NewHandler(cfg, feedRepo, itemRepo, jobRepo)
+	apiHandler := api.NewHandler(cfg, db, feedRepo, itemRepo, jobRepo, database.NewClickRepository(db), feedCache, blocklist, extractor, dryRunner, pool, scheduler, autoscaler, maintenance, logLevel)
 	server := api.NewServer(apiHandler, cfg)
 	httpServer := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -129,14 +321,24 @@ func main() {
 	}()
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
 
 	slog.Info("Server started successfully", "port", cfg.Port, "api_enabled", cfg.APIAccessKey != "")
 
-	select {
-	case sig := <-sigChan:
-		slog.Info("Shutdown signal received", "signal", sig)
-	case err := <-serverErrChan:
-		slog.Error("Server error occurred", "error", err)
+wait:
+	for {
+		select {
+		case <-hupChan:
+			reloadRuntimeSettings(pool, scheduler, autoscaler, logLevel)
+			reloadBlocklist(blocklist, cfg.Now())
+		case sig := <-sigChan:
+			slog.Info("Shutdown signal received", "signal", sig)
+			break wait
+		case err := <-serverErrChan:
+			slog.Error("Server error occurred", "error", err)
+			break wait
+		}
 	}
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -147,9 +349,49 @@ func main() {
 	}
 }
 
-func initializeLogger() {
+// reloadRuntimeSettings re-reads configuration (flags, env and config file)
+// and applies the settings that can change without a restart.
+func reloadRuntimeSettings(pool *jobs.WorkerPool, scheduler *jobs.Scheduler, autoscaler *jobs.Autoscaler, logLevel *slog.LevelVar) {
+	newCfg, err := cfg.Load()
+	if err != nil || newCfg == nil {
+		slog.Error("Failed to reload configuration, keeping current settings", "error", err)
+		return
+	}
+
+	logLevel.Set(newCfg.Level)
+	if newCfg.WorkerCount >= 1 {
+		if newCfg.WorkerMax > 0 && newCfg.WorkerMax < newCfg.WorkerCount {
+			slog.Warn("WORKER_MAX is below WORKER_COUNT, autoscaling is disabled", "worker_count", newCfg.WorkerCount, "worker_max", newCfg.WorkerMax)
+		}
+		pool.Resize(newCfg.WorkerCount)
+		autoscaler.SetBounds(newCfg.WorkerCount, newCfg.WorkerMax)
+	}
+	if newCfg.SchedulerInterval >= 1 {
+		scheduler.SetInterval(time.Duration(newCfg.SchedulerInterval) * time.Second)
+	}
+
+	slog.Info("Runtime settings reloaded",
+		"worker_count", pool.Size(),
+		"worker_max", newCfg.WorkerMax,
+		"scheduler_interval", scheduler.Interval(),
+		"log_level", newCfg.Level)
+}
+
+// reloadBlocklist re-reads the blocklist file and refilters all feeds if it
+// changed.
+func reloadBlocklist(blocklist *feed.Blocklist, now time.Time) {
+	if err := blocklist.Load(); err != nil {
+		slog.Error("Failed to reload blocklist, keeping current entries", "error", err)
+		return
+	}
+	if err := blocklist.Sync(context.Background(), now); err != nil {
+		slog.Error("Failed to apply blocklist changes", "error", err)
+	}
+}
+
+func initializeLogger(level *slog.LevelVar) {
 	opts := &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: level,
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 			if a.Key == slog.TimeKey {
 				return slog.String("time", a.Value.Time().Format("2006-01-02 15:04:05"))
@@ -163,7 +405,11 @@ func initializeLogger() {
 	slog.SetDefault(logger)
 }
 
-func loadFeedConfigurations(feedsDir string, feedRepo *database.FeedRepository) (bool, error) {
+func loadFeedConfigurations(feedsDir string, defaults *types.Settings, feedRepo *database.FeedRepository, loc *time.Location, now time.Time) (bool, error) {
+	if err := feed.ValidateDefaults(defaults); err != nil {
+		return false, err
+	}
+
 	if _, err := os.Stat(feedsDir); os.IsNotExist(err) {
 		slog.Info("Feeds directory does not exist, skipping config loading", "directory", feedsDir)
 		return false, nil
@@ -188,7 +434,7 @@ func loadFeedConfigurations(feedsDir string, feedRepo *database.FeedRepository)
 		fileName := filepath.Base(file)
 		feedName := fileName[:len(fileName)-4]
 
-		config, err := feed.ConfigSync(context.Background(), feedsDir, feedName, feedRepo)
+		config, err := feed.ConfigSync(context.Background(), feedsDir, feedName, defaults, feedRepo, loc, now)
 		if err != nil {
 			slog.Warn("Failed to sync feed config, skipping", "file", file, "error", err)
 			continue
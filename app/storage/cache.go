@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CachedStore keeps recently read content in memory, evicting the least
+// recently used entries once maxBytes is exceeded.
+type CachedStore struct {
+	store    ContentStore
+	maxBytes int
+
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front = most recently used
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	content []byte
+}
+
+func NewCachedStore(store ContentStore, maxBytes int) *CachedStore {
+	return &CachedStore{
+		store:    store,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *CachedStore) Put(key string, content []byte) error {
+	if err := c.store.Put(key, content); err != nil {
+		return err
+	}
+	c.add(key, content)
+	return nil
+}
+
+func (c *CachedStore) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		content := elem.Value.(*cacheEntry).content
+		c.mu.Unlock()
+		return content, nil
+	}
+	c.mu.Unlock()
+
+	content, err := c.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	c.add(key, content)
+	return content, nil
+}
+
+func (c *CachedStore) Delete(key string) error {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.size -= len(elem.Value.(*cacheEntry).content)
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	return c.store.Delete(key)
+}
+
+func (c *CachedStore) Keys() ([]string, error) {
+	return c.store.Keys()
+}
+
+func (c *CachedStore) add(key string, content []byte) {
+	if len(content) > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.size -= len(elem.Value.(*cacheEntry).content)
+		c.order.Remove(elem)
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, content: content})
+	c.size += len(content)
+
+	for c.size > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*cacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.content)
+	}
+}
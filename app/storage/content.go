@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ContentStore keeps large item bodies (extracted article content) outside
+// the database. Keys are item IDs. Another backend, such as S3, only needs
+// to implement this interface.
+type ContentStore interface {
+	Put(key string, content []byte) error
+	Get(key string) ([]byte, error)
+	// Delete removes the content of key; a missing key is not an error.
+	Delete(key string) error
+	// Keys lists the stored keys, for sweeping content of deleted items.
+	Keys() ([]string, error)
+}
+
+// FileStore stores content as gzip files under a directory, sharded by the
+// first two characters of the key to keep directories small.
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create content directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(key string) string {
+	shard := key
+	if len(shard) > 2 {
+		shard = shard[:2]
+	}
+	return filepath.Join(s.dir, shard, filepath.Base(key)+".html.gz")
+}
+
+// Put writes through a temporary file so readers never see partial content.
+func (s *FileStore) Put(key string, content []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create content directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create content file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	if _, err := zw.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write content file: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write content file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write content file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store content file: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Get(key string) ([]byte, error) {
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		// Written uncompressed before gzip was introduced.
+		content, plainErr := os.ReadFile(strings.TrimSuffix(s.path(key), ".gz"))
+		if plainErr == nil {
+			return content, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content %s: %w", key, err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content %s: %w", key, err)
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content %s: %w", key, err)
+	}
+	return content, nil
+}
+
+// Delete removes the gzip file and a legacy uncompressed one.
+func (s *FileStore) Delete(key string) error {
+	path := s.path(key)
+	for _, p := range []string{path, strings.TrimSuffix(path, ".gz")} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete content %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) Keys() ([]string, error) {
+	shards, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content directory: %w", err)
+	}
+
+	var keys []string
+	for _, shard := range shards {
+		if !shard.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(s.dir, shard.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read content directory: %w", err)
+		}
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), ".gz")
+			if key, ok := strings.CutSuffix(name, ".html"); ok && !entry.IsDir() {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys, nil
+}
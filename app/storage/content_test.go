@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sampleArticle approximates an extracted article body.
+var sampleArticle = []byte(strings.Repeat("<p>Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua.</p>\n", 200))
+
+func TestFileStore_RoundTrip(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	key := "0b7c7a0e-2f6d-4d1e-9a55-5c3a8e0f1b2c"
+	if err := store.Put(key, sampleArticle); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+
+	got, err := store.Get(key)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if !bytes.Equal(got, sampleArticle) {
+		t.Error("content changed in round trip")
+	}
+
+	info, err := os.Stat(store.path(key))
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+	if info.Size() >= int64(len(sampleArticle)) {
+		t.Errorf("expected compressed file, got %d bytes for %d bytes of content", info.Size(), len(sampleArticle))
+	}
+}
+
+func TestFileStore_ReadsUncompressedFiles(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	key := "abcdef"
+	plain := strings.TrimSuffix(store.path(key), ".gz")
+	os.MkdirAll(filepath.Dir(plain), 0755)
+	if err := os.WriteFile(plain, []byte("<p>old</p>"), 0644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	got, err := store.Get(key)
+	if err != nil || string(got) != "<p>old</p>" {
+		t.Errorf("expected legacy content, got %q (err %v)", got, err)
+	}
+}
+
+func TestFileStore_DeleteAndKeys(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, key := range []string{"aa1", "ab2", "cd3"} {
+		if err := store.Put(key, []byte("<p>x</p>")); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+	}
+	legacy := strings.TrimSuffix(store.path("ef4"), ".gz")
+	os.MkdirAll(filepath.Dir(legacy), 0755)
+	os.WriteFile(legacy, []byte("<p>old</p>"), 0644)
+
+	keys, err := store.Keys()
+	if err != nil {
+		t.Fatalf("keys failed: %v", err)
+	}
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"aa1", "ab2", "cd3", "ef4"}) {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	for _, key := range []string{"ab2", "ef4", "missing"} {
+		if err := store.Delete(key); err != nil {
+			t.Errorf("delete %s failed: %v", key, err)
+		}
+	}
+	if keys, _ := store.Keys(); len(keys) != 2 {
+		t.Errorf("expected 2 keys left, got %v", keys)
+	}
+	if _, err := store.Get("ab2"); err == nil {
+		t.Error("expected deleted content to be gone")
+	}
+}
+
+func TestCachedStore_Evicts(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache := NewCachedStore(store, 10)
+
+	cache.Put("aa1", []byte("123456"))
+	cache.Put("aa2", []byte("123456"))
+
+	if _, ok := cache.entries["aa1"]; ok {
+		t.Error("expected oldest entry to be evicted")
+	}
+	if got, err := cache.Get("aa1"); err != nil || string(got) != "123456" {
+		t.Errorf("expected evicted entry to be read from the store, got %q (err %v)", got, err)
+	}
+}
+
+func TestCachedStore_Delete(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache := NewCachedStore(store, 1<<10)
+
+	cache.Put("aa1", []byte("123456"))
+	if err := cache.Delete("aa1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := cache.Get("aa1"); err == nil {
+		t.Error("expected deleted content to be gone from the cache too")
+	}
+	if cache.size != 0 {
+		t.Errorf("expected an empty cache, got %d bytes", cache.size)
+	}
+}
+
+func BenchmarkFileStore_Put(b *testing.B) {
+	store, err := NewFileStore(b.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(sampleArticle)))
+	for b.Loop() {
+		if err := store.Put("bench", sampleArticle); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	info, err := os.Stat(store.path("bench"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportMetric(float64(len(sampleArticle))/float64(info.Size()), "ratio")
+}
+
+func BenchmarkFileStore_Get(b *testing.B) {
+	store, err := NewFileStore(b.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := store.Put("bench", sampleArticle); err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(sampleArticle)))
+	for b.Loop() {
+		if _, err := store.Get("bench"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCachedStore_Get(b *testing.B) {
+	store, err := NewFileStore(b.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+	cache := NewCachedStore(store, 1<<20)
+	if err := cache.Put("bench", sampleArticle); err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(sampleArticle)))
+	for b.Loop() {
+		if _, err := cache.Get("bench"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
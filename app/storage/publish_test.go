@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDirTarget_Publish(t *testing.T) {
+	dir := t.TempDir()
+	target, err := NewDirTarget(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, body := range []string{"<rss>1</rss>", "<rss>2</rss>"} {
+		if err := target.Publish(context.Background(), "news.xml", []byte(body), "application/rss+xml"); err != nil {
+			t.Fatalf("publish failed: %v", err)
+		}
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "news.xml"))
+	if err != nil || string(got) != "<rss>2</rss>" {
+		t.Errorf("expected the latest document, got %q (%v)", got, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected no leftover temporary files, got %d entries", len(entries))
+	}
+
+	// Keys can't escape the directory.
+	if err := target.Publish(context.Background(), "../outside.xml", []byte("x"), ""); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outside.xml")); err != nil {
+		t.Errorf("expected the key to be confined to the directory: %v", err)
+	}
+}
+
+func TestS3Target_Publish(t *testing.T) {
+	var gotPath, gotAuth, gotBody, gotHash string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotAuth, gotBody = r.URL.EscapedPath(), r.Header.Get("Authorization"), string(body)
+		gotHash = r.Header.Get("X-Amz-Content-Sha256")
+		if r.Method != http.MethodPut || r.Header.Get("Content-Type") != "application/rss+xml" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	target, err := NewS3Target(server.URL, "feeds", "eu-west-1", "/public/", "AKID", "secret", server.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := target.Publish(context.Background(), "my news.xml", []byte("<rss/>"), "application/rss+xml"); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	if gotPath != "/feeds/public/my%20news.xml" {
+		t.Errorf("unexpected path %q", gotPath)
+	}
+	if gotBody != "<rss/>" || gotHash != sha256Hex([]byte("<rss/>")) {
+		t.Errorf("unexpected body %q or payload hash %q", gotBody, gotHash)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/") ||
+		!strings.Contains(gotAuth, "/eu-west-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("unexpected Authorization header %q", gotAuth)
+	}
+}
+
+func TestS3Target_SignatureIsDeterministic(t *testing.T) {
+	target, _ := NewS3Target("https://s3.example.com", "b", "", "", "AKID", "secret", http.DefaultClient)
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	sign := func(body string) string {
+		req, _ := http.NewRequest(http.MethodPut, "https://s3.example.com/b/k.xml", nil)
+		req.Header.Set("Content-Type", "text/xml")
+		target.sign(req, "/b/k.xml", []byte(body), now)
+		return req.Header.Get("Authorization")
+	}
+
+	if sign("a") != sign("a") {
+		t.Error("expected the same signature for the same request")
+	}
+	if sign("a") == sign("b") {
+		t.Error("expected the signature to cover the payload")
+	}
+	if !strings.Contains(sign("a"), "Credential=AKID/20250102/us-east-1/s3/aws4_request") {
+		t.Errorf("expected the default region in the scope, got %q", sign("a"))
+	}
+}
+
+func TestS3Target_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	target, _ := NewS3Target(server.URL, "feeds", "", "", "AKID", "secret", server.Client())
+	err := target.Publish(context.Background(), "news.xml", []byte("x"), "application/rss+xml")
+	if err == nil || !strings.Contains(err.Error(), "HTTP 403") || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("expected an error with the status and response, got %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+package types
+
+// Blocklist holds mute lists applied to every feed before its own filters.
+// Entries use the same matching as filter patterns; domains also match their
+// subdomains.
+type Blocklist struct {
+	Authors  []string `yaml:"authors" json:"authors"`
+	Domains  []string `yaml:"domains" json:"domains"`
+	Keywords []string `yaml:"keywords" json:"keywords"` // Matched against title and description
+}
@@ -0,0 +1,28 @@
+package types
+
+import "time"
+
+// Clock tells the current time. Code deciding what a feed contains or when
+// it is fetched reads the time from a Clock, so tests can fix it.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock of the running service.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// FixedClock is a Clock stopped at one instant, for tests.
+type FixedClock time.Time
+
+func (c FixedClock) Now() time.Time { return time.Time(c) }
+
+// ClockOrSystem returns c, or SystemClock when c is nil, for constructors
+// whose callers may leave the clock out.
+func ClockOrSystem(c Clock) Clock {
+	if c == nil {
+		return SystemClock{}
+	}
+	return c
+}
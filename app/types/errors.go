@@ -0,0 +1,32 @@
+package types
+
+import "errors"
+
+// Errors shared by the repositories, jobs and API handlers. They are
+// wrapped with context where they occur and checked with errors.Is, so
+// HTTP status codes and job retries depend on the kind of failure rather
+// than on its message.
+var (
+	// ErrFeedNotFound: the feed isn't configured (or was removed meanwhile).
+	ErrFeedNotFound = errors.New("feed not found")
+	// ErrItemNotFound: the item was deleted, e.g. by cleanup.
+	ErrItemNotFound = errors.New("item not found")
+	// ErrUpstreamTimeout: the source didn't answer within the feed's timeout.
+	ErrUpstreamTimeout = errors.New("upstream timed out")
+	// ErrParse: the source answered with something that isn't a usable feed.
+	ErrParse = errors.New("unparseable document")
+	// ErrFilteredConfigInvalid: a feed's settings or filters fail validation,
+	// which no retry can fix until the config file is corrected.
+	ErrFilteredConfigInvalid = errors.New("invalid feed config")
+	// ErrNotFetched: the feed has no source to fetch, as newsletter feeds
+	// receive their items instead.
+	ErrNotFetched = errors.New("feed has no source to fetch")
+)
+
+// Permanent reports whether err can't go away by retrying the same work.
+func Permanent(err error) bool {
+	return errors.Is(err, ErrFeedNotFound) ||
+		errors.Is(err, ErrItemNotFound) ||
+		errors.Is(err, ErrParse) ||
+		errors.Is(err, ErrFilteredConfigInvalid)
+}
@@ -3,25 +3,89 @@ package types
 import "time"
 
 type Settings struct {
-	RefreshInterval int  `yaml:"refresh_interval" json:"refresh_interval"`
-	MaxItems        int  `yaml:"max_items" json:"max_items"`
-	Timeout         int  `yaml:"timeout" json:"timeout"`
-	ExtractContent bool `yaml:"extract_content" json:"extract_content"`
-	MinDuration    int  `yaml:"min_duration" json:"min_duration"`
+	RefreshInterval        Duration          `yaml:"refresh_interval" json:"refresh_interval"`
+	FetchHours             string            `yaml:"fetch_hours" json:"fetch_hours,omitempty"` // Only fetch in these hours (TIMEZONE), e.g. "7-22"
+	FetchDays              string            `yaml:"fetch_days" json:"fetch_days,omitempty"`   // Only fetch on these days, e.g. "mon-fri"
+	Priority               string            `yaml:"priority" json:"priority,omitempty"`       // "high" for time-sensitive feeds: shorter default refresh_interval, jobs claimed first
+	MaxItems               int               `yaml:"max_items" json:"max_items"`
+	Timeout                Duration          `yaml:"timeout" json:"timeout"`
+	ExtractContent         bool              `yaml:"extract_content" json:"extract_content"`
+	ExtractContentMaxItems int               `yaml:"extract_content_max_items" json:"extract_content_max_items"` // Newest new items per fetch queued for extraction (0 = up to max_items)
+	ExtractContentMaxAge   Duration          `yaml:"extract_content_max_age" json:"extract_content_max_age"`     // Only extract items published within this window (0 = any age)
+	ExtractCharset         string            `yaml:"extract_charset" json:"extract_charset,omitempty"`           // Decode article pages with this charset instead of the declared one
+	MinDuration            Duration          `yaml:"min_duration" json:"min_duration"`
+	CollapseSeries         bool              `yaml:"collapse_series" json:"collapse_series"`
+	MinScore               int               `yaml:"min_score" json:"min_score"`
+	OrderBy                string            `yaml:"order_by" json:"order_by"`                               // "" / "published" (default) or "score"
+	ShortLinks             bool              `yaml:"short_links" json:"short_links"`                         // Link items via /r/:id to count clicks
+	Robots                 string            `yaml:"robots" json:"robots"`                                   // Robots directives for the output, e.g. "noindex, nofollow"
+	Language               string            `yaml:"language" json:"language,omitempty"`                     // Output <language> instead of the source's, e.g. "en-US"
+	DiagnosticAfter        Duration          `yaml:"diagnostic_after" json:"diagnostic_after"`               // Upstream failure time before a notice item is shown (0 = never)
+	InitialMaxItems        int               `yaml:"initial_max_items" json:"initial_max_items"`             // Newest items ingested on the first fetch (0 = all)
+	Delay                  Duration          `yaml:"delay" json:"delay"`                                     // Withhold items from output until they are this old
+	Provenance             bool              `yaml:"provenance" json:"provenance"`                           // Add generation/fetch metadata and item <source> to output
+	MarkChanges            string            `yaml:"mark_changes" json:"mark_changes"`                       // "marker" or "diff" to flag items changed upstream
+	CollapseTitles         Duration          `yaml:"collapse_titles" json:"collapse_titles"`                 // Hide older items repeating a title within this window (0 = off)
+	DripInterval           Duration          `yaml:"drip_interval" json:"drip_interval"`                     // Release stored items gradually, one batch per interval (0 = off)
+	DripCount              int               `yaml:"drip_count" json:"drip_count"`                           // Items per drip release (default 1)
+	IgnoreItemsOlderThan   Duration          `yaml:"ignore_items_older_than" json:"ignore_items_older_than"` // Skip items published longer ago at ingest (0 = keep all)
+	Mirror                 bool              `yaml:"mirror" json:"mirror,omitempty"`                         // Store and serve the upstream document as is
+	Strip                  string            `yaml:"strip" json:"strip,omitempty"`                           // Output fields to omit: author_emails, authors, categories
+	Attribution            string            `yaml:"attribution" json:"attribution,omitempty"`               // HTML footer appended to each item's content, with {link}, {title}, ... placeholders
+	ReleaseNotes           bool              `yaml:"release_notes" json:"release_notes,omitempty"`           // Fill GitHub/GitLab release and commit items from their APIs
+	InboundToken           string            `yaml:"inbound_token" json:"inbound_token,omitempty"`           // Secret for posting emails to /inbound/<name> (newsletter feeds)
+	Magnet                 string            `yaml:"magnet" json:"magnet,omitempty"`                         // Torrent feeds: publish the magnet link as "enclosure" or "link" (default: enclosure only without a .torrent file)
+	ReadLater              bool              `yaml:"read_later" json:"read_later,omitempty"`                 // Save new visible items to the read-later service (READ_LATER_SERVICE)
+	Archive                bool              `yaml:"archive" json:"archive,omitempty"`                       // Include the feed's items in the static HTML archive (ARCHIVE_DIR)
+	ResponseHeaders        map[string]string `yaml:"response_headers" json:"response_headers,omitempty"`     // Extra HTTP headers on the feed's responses, e.g. Cache-Control for a CDN
+	Namespaces             map[string]string `yaml:"namespaces" json:"namespaces,omitempty"`                 // Extra XML namespaces by prefix, for channel_elements
+	ChannelElements        []ChannelElement  `yaml:"channel_elements" json:"channel_elements,omitempty"`     // Static elements added to the output channel
+}
+
+// ChannelElement is a static element written into a feed's output channel,
+// such as webMaster or podcast:locked. Prefixed names need their namespace
+// in Settings.Namespaces unless it's one rss-comb knows.
+type ChannelElement struct {
+	Name       string            `yaml:"name" json:"name"`
+	Value      string            `yaml:"value" json:"value,omitempty"`
+	Attributes map[string]string `yaml:"attributes" json:"attributes,omitempty"`
+	Children   []ChannelElement  `yaml:"children" json:"children,omitempty"`
 }
 
 type Filter struct {
 	Field    string   `yaml:"field" json:"field"`
 	Includes []string `yaml:"includes" json:"includes"`
 	Excludes []string `yaml:"excludes" json:"excludes"`
+	// Weight turns the filter into a scoring rule: a match in includes adds
+	// it to the item's score, a match in excludes subtracts it.
+	Weight int `yaml:"weight" json:"weight,omitempty"`
+	// CaseSensitive and WholeWord change how the filter's patterns match:
+	// exact case, and only whole words (not "ai" inside "maintain").
+	CaseSensitive bool `yaml:"case_sensitive" json:"case_sensitive,omitempty"`
+	WholeWord     bool `yaml:"whole_word" json:"whole_word,omitempty"`
+	// Mode "regex" reads every pattern as a regular expression, without
+	// /slashes/. The default ("substring") matches text and keeps /regex/
+	// patterns opt-in.
+	Mode string `yaml:"mode" json:"mode,omitempty"`
+	// TimedExcludes are excludes written as {pattern, until}; they stop
+	// applying once until has passed.
+	TimedExcludes []TimedPattern `yaml:"-" json:"timed_excludes,omitempty"`
+	// Any, All and Not make the filter a group of other filters instead of a
+	// field rule: any passes if one of its filters passes, all if every one
+	// does, not if its filter fails.
+	Any []Filter `yaml:"-" json:"any,omitempty"`
+	All []Filter `yaml:"-" json:"all,omitempty"`
+	Not *Filter  `yaml:"-" json:"not,omitempty"`
 }
 
 type Metadata struct {
 	Title           string
-	Link            string
+	Link            string // rel=alternate: the site
 	Description     string
 	ImageURL        string
 	Language        string
+	SelfURL         string // rel=self: the feed's canonical URL
+	HubURL          string // rel=hub: the WebSub hub the source publishes to
 	FeedPublishedAt *time.Time
 	FeedUpdatedAt   *time.Time
 	// iTunes podcast extension fields
@@ -0,0 +1,123 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TimedPattern is an exclude pattern that stops applying after Until.
+type TimedPattern struct {
+	Pattern string    `yaml:"pattern" json:"pattern"`
+	Until   time.Time `yaml:"until" json:"until"`
+	// allDay marks an until written as a date, which ResolveDates moves to
+	// the end of that day.
+	allDay bool
+}
+
+// ActiveExcludes returns the plain excludes plus timed excludes that have
+// not expired at now.
+func (f Filter) ActiveExcludes(now time.Time) []string {
+	if len(f.TimedExcludes) == 0 {
+		return f.Excludes
+	}
+
+	excludes := append([]string{}, f.Excludes...)
+	for _, timed := range f.TimedExcludes {
+		if now.Before(timed.Until) {
+			excludes = append(excludes, timed.Pattern)
+		}
+	}
+	return excludes
+}
+
+// ResolveDates moves until dates written without a time to the end of that
+// day in loc, so "until: 2025-08-30" mutes through the 30th in TIMEZONE
+// rather than up to midnight UTC. Grouped filters are resolved too. It
+// reports whether any date was resolved.
+func (f *Filter) ResolveDates(loc *time.Location) bool {
+	if loc == nil {
+		loc = time.UTC
+	}
+	resolved := false
+	for i := range f.TimedExcludes {
+		timed := &f.TimedExcludes[i]
+		if timed.allDay {
+			year, month, day := timed.Until.Date()
+			timed.Until = time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+			timed.allDay = false
+			resolved = true
+		}
+	}
+	for i := range f.Any {
+		resolved = f.Any[i].ResolveDates(loc) || resolved
+	}
+	for i := range f.All {
+		resolved = f.All[i].ResolveDates(loc) || resolved
+	}
+	if f.Not != nil {
+		resolved = f.Not.ResolveDates(loc) || resolved
+	}
+	return resolved
+}
+
+// IsGroup reports whether the filter combines other filters (any, all or
+// not) rather than matching a field.
+func (f Filter) IsGroup() bool {
+	return f.Any != nil || f.All != nil || f.Not != nil
+}
+
+// UnmarshalYAML accepts excludes as plain strings or as
+// {pattern, until} mappings, which are collected into TimedExcludes.
+func (f *Filter) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Field         string      `yaml:"field"`
+		Includes      []string    `yaml:"includes"`
+		Excludes      []yaml.Node `yaml:"excludes"`
+		Weight        int         `yaml:"weight"`
+		CaseSensitive bool        `yaml:"case_sensitive"`
+		WholeWord     bool        `yaml:"whole_word"`
+		Mode          string      `yaml:"mode"`
+		Any           []Filter    `yaml:"any"`
+		All           []Filter    `yaml:"all"`
+		Not           *Filter     `yaml:"not"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	*f = Filter{
+		Field: raw.Field, Includes: raw.Includes, Weight: raw.Weight,
+		CaseSensitive: raw.CaseSensitive, WholeWord: raw.WholeWord, Mode: raw.Mode,
+		Any: raw.Any, All: raw.All, Not: raw.Not,
+	}
+
+	for _, node := range raw.Excludes {
+		if node.Kind != yaml.MappingNode {
+			var pattern string
+			if err := node.Decode(&pattern); err != nil {
+				return err
+			}
+			f.Excludes = append(f.Excludes, pattern)
+			continue
+		}
+
+		var timed TimedPattern
+		if err := node.Decode(&timed); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		if timed.Pattern == "" || timed.Until.IsZero() {
+			return fmt.Errorf("line %d: exclude rules need both pattern and until", node.Line)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "until" {
+				_, err := time.Parse(time.DateOnly, node.Content[i+1].Value)
+				timed.allDay = err == nil
+			}
+		}
+		f.TimedExcludes = append(f.TimedExcludes, timed)
+	}
+
+	return nil
+}
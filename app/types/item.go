@@ -2,25 +2,33 @@ package types
 
 import "time"
 
+// Item is the one item model shared by parsing, filtering, storage and
+// generation. Fields that only the database knows about (IDs, timestamps,
+// change history) live in database.Item, which embeds Item, so a stored item
+// converts back with item.Item and new item fields need adding only here
+// and in the repository's column lists.
 type Item struct {
-	GUID            string
-	Title           string
-	Link            string
-	Description     string
-	Content         string
-	PublishedAt     time.Time
-	UpdatedAt       *time.Time
-	Authors         []string
-	Categories      []string
-	ContentHash     string
+	GUID                    string
+	Title                   string
+	Link                    string
+	Description             string
+	Content                 string
+	PublishedAt             time.Time
+	UpdatedAt               *time.Time
+	Authors                 []string
+	Categories              []string
+	ContentHash             string
+	SeriesID                string // Fingerprint shared by installments of a series, "" if none
 	IsFiltered              bool
+	Score                   int // Sum of matched weighted filter rules
 	ContentExtractionStatus *string
 	MediaStatus             *string
 	MediaPath               string
 	MediaSize               int64
 	EnclosureURL            string
-	EnclosureLength int64
-	EnclosureType   string
+	EnclosureLength         int64
+	EnclosureType           string
+	MagnetURI               string // Magnet link of torrent items, kept beside the .torrent enclosure
 	// iTunes podcast episode extension fields
 	ITunesDuration    int    // Duration in seconds
 	ITunesEpisode     int    // Episode number